@@ -0,0 +1,147 @@
+// Command contractgen generates the Go bindings in pkg/contracts from the
+// Hardhat build artifacts of smart-contract-service.
+//
+// Usage, after running `npx hardhat compile` in smart-contract-service:
+//
+//	go run ./cmd/contractgen            # regenerate the bindings
+//	go run ./cmd/contractgen -check     # fail if the bindings drifted from the artifacts
+//
+// The binding generator is the go-ethereum version pinned in go.mod, so the
+// output is reproducible for a given set of artifacts.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// contract describes one Solidity contract to bind
+type contract struct {
+	Name   string // contract name, also the Go type name
+	Source string // source file relative to the contracts directory
+	Output string // generated Go file relative to the output directory
+}
+
+var contracts = []contract{
+	{Name: "TwistToken", Source: "TwistToken.sol", Output: "twist_token.go"},
+	{Name: "BlockchainNodeRegistry", Source: "BlockchainNodeRegistry.sol", Output: "blockchain_node_registry.go"},
+}
+
+// artifact is the subset of a Hardhat artifact (hh-sol-artifact-1) used for binding
+type artifact struct {
+	ContractName string          `json:"contractName"`
+	ABI          json.RawMessage `json:"abi"`
+}
+
+func main() {
+	artifactsDir := flag.String("artifacts", "../smart-contract-service/artifacts", "Hardhat artifacts directory")
+	outDir := flag.String("out", "pkg/contracts", "output directory for the generated bindings")
+	pkg := flag.String("pkg", "contracts", "Go package name of the generated bindings")
+	check := flag.Bool("check", false, "verify the bindings are up to date instead of writing them")
+	flag.Parse()
+
+	var drifted []string
+	for _, c := range contracts {
+		code, err := generate(*artifactsDir, *pkg, c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "contractgen: %s: %v\n", c.Name, err)
+			os.Exit(1)
+		}
+
+		path := filepath.Join(*outDir, c.Output)
+		if *check {
+			existing, err := os.ReadFile(path)
+			if err != nil || !bytes.Equal(existing, code) {
+				drifted = append(drifted, path)
+			}
+			continue
+		}
+
+		if err := os.WriteFile(path, code, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "contractgen: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(drifted) > 0 {
+		for _, path := range drifted {
+			fmt.Fprintf(os.Stderr, "contractgen: %s is out of date with the compiled ABI\n", path)
+		}
+		fmt.Fprintln(os.Stderr, "contractgen: run `go generate ./pkg/contracts` to regenerate")
+		os.Exit(1)
+	}
+}
+
+// generate produces the Go binding for c from its Hardhat artifact
+func generate(artifactsDir, pkg string, c contract) ([]byte, error) {
+	path := filepath.Join(artifactsDir, "contracts", c.Source, c.Name+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact (run `npx hardhat compile` first): %w", err)
+	}
+
+	var a artifact
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("failed to parse artifact %s: %w", path, err)
+	}
+	if a.ContractName != c.Name {
+		return nil, fmt.Errorf("artifact %s contains contract %q", path, a.ContractName)
+	}
+
+	abiJSON, err := normalizeABI(a.ABI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ABI in %s: %w", path, err)
+	}
+
+	// Bytecode is deliberately not bound: it changes with every compiler
+	// setting and would make the drift check fail on changes that don't
+	// affect the interface
+	code, err := bind.Bind(
+		[]string{c.Name},
+		[]string{abiJSON},
+		[]string{""},
+		nil,
+		pkg,
+		bind.LangGo,
+		nil,
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate binding: %w", err)
+	}
+
+	return []byte(code), nil
+}
+
+// normalizeABI orders ABI entries by type and name and object keys
+// alphabetically, so equivalent ABIs produce byte-identical bindings
+func normalizeABI(raw json.RawMessage) (string, error) {
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		ti, _ := entries[i]["type"].(string)
+		tj, _ := entries[j]["type"].(string)
+		if ti != tj {
+			return ti < tj
+		}
+		ni, _ := entries[i]["name"].(string)
+		nj, _ := entries[j]["name"].(string)
+		return ni < nj
+	})
+
+	normalized, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return string(normalized), nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bindings "github.com/twist/api-gateway/pkg/contracts"
+)
+
+const bindingsDir = "../../pkg/contracts"
+
+// writeArtifacts writes Hardhat artifacts holding the ABIs embedded in the
+// checked-in bindings, so they can be regenerated without a Solidity build
+func writeArtifacts(t *testing.T) string {
+	t.Helper()
+
+	abis := map[string]string{
+		"TwistToken":             bindings.TwistTokenMetaData.ABI,
+		"BlockchainNodeRegistry": bindings.BlockchainNodeRegistryMetaData.ABI,
+	}
+	dir := t.TempDir()
+	for _, c := range contracts {
+		data, err := json.Marshal(map[string]interface{}{
+			"_format":      "hh-sol-artifact-1",
+			"contractName": c.Name,
+			"abi":          json.RawMessage(abis[c.Name]),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "contracts", c.Source, c.Name+".json")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// checkBindings regenerates every binding from artifactsDir and fails on any
+// difference from the checked-in output
+func checkBindings(t *testing.T, artifactsDir string) {
+	t.Helper()
+
+	for _, c := range contracts {
+		code, err := generate(artifactsDir, "contracts", c)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		existing, err := os.ReadFile(filepath.Join(bindingsDir, c.Output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(existing, code) {
+			t.Errorf("%s differs from the contractgen output; run `go generate ./pkg/contracts`", c.Output)
+		}
+	}
+}
+
+// TestBindingsAreGenerated catches hand edits to the bindings and changes in
+// the generator's output for the pinned go-ethereum version
+func TestBindingsAreGenerated(t *testing.T) {
+	checkBindings(t, writeArtifacts(t))
+}
+
+// TestBindingsMatchArtifacts compares the bindings with the compiled
+// contracts, when `npx hardhat compile` has been run
+func TestBindingsMatchArtifacts(t *testing.T) {
+	artifactsDir := "../../../smart-contract-service/artifacts"
+	if _, err := os.Stat(artifactsDir); err != nil {
+		t.Skip("no Hardhat artifacts; run `npx hardhat compile` in smart-contract-service")
+	}
+	checkBindings(t, artifactsDir)
+}
+
+func TestNormalizeABIIsOrderIndependent(t *testing.T) {
+	a := `[{"type":"function","name":"b","inputs":[]},{"type":"event","name":"A","inputs":[],"anonymous":false}]`
+	b := `[{"anonymous":false,"inputs":[],"name":"A","type":"event"},{"inputs":[],"name":"b","type":"function"}]`
+
+	na, err := normalizeABI(json.RawMessage(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nb, err := normalizeABI(json.RawMessage(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if na != nb {
+		t.Errorf("normalized ABIs differ:\n%s\n%s", na, nb)
+	}
+}
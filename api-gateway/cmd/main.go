@@ -3,22 +3,52 @@ package main
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/accounttokens"
+	"github.com/twist/api-gateway/internal/apikeys"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/broadcast"
 	"github.com/twist/api-gateway/internal/config"
 	"github.com/twist/api-gateway/internal/handlers"
+	"github.com/twist/api-gateway/internal/lockout"
+	"github.com/twist/api-gateway/internal/mailer"
+	"github.com/twist/api-gateway/internal/mfa"
 	"github.com/twist/api-gateway/internal/middleware"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/monitor"
+	"github.com/twist/api-gateway/internal/nodeevents"
+	"github.com/twist/api-gateway/internal/nodes"
+	"github.com/twist/api-gateway/internal/registry"
+	"github.com/twist/api-gateway/internal/sessions"
+	"github.com/twist/api-gateway/internal/sso"
+	"github.com/twist/api-gateway/internal/tiers"
+	"github.com/twist/api-gateway/internal/token"
+	"github.com/twist/api-gateway/internal/tokens"
+	"github.com/twist/api-gateway/migrations"
+	gatewayv1 "github.com/twist/api-gateway/pkg/api/gateway/v1"
+	"github.com/twist/api-gateway/pkg/contracts"
 	"github.com/twist/api-gateway/pkg/database"
 	"github.com/twist/api-gateway/pkg/logger"
 	"github.com/twist/api-gateway/pkg/metrics"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
@@ -44,6 +74,11 @@ func main() {
 	}
 	defer db.Close()
 
+	// Apply gateway-owned schema migrations
+	if err := database.Migrate(context.Background(), db, migrations.FS); err != nil {
+		log.Fatal("Failed to apply database migrations", zap.Error(err))
+	}
+
 	// Initialize Redis
 	redisClient, err := database.NewRedisClient(cfg.Redis)
 	if err != nil {
@@ -67,8 +102,245 @@ func main() {
 	router.Use(middleware.Logger(log))
 	router.Use(middleware.Metrics(metricsClient))
 
+	// Initialize access token signing
+	tokenManager, err := tokens.NewManager(context.Background(), db, log, tokens.Options{
+		Algorithm:        cfg.JWT.Algorithm,
+		Secret:           []byte(cfg.JWT.Secret),
+		Issuer:           cfg.JWT.Issuer,
+		Audience:         cfg.JWT.Audience,
+		ExtraAudiences:   cfg.JWT.ExtraAudiences,
+		TokenTTL:         time.Duration(cfg.JWT.ExpiryMinutes) * time.Minute,
+		RotationInterval: time.Duration(cfg.JWT.RotationHours) * time.Hour,
+		ReloadInterval:   time.Duration(cfg.JWT.KeyReloadSeconds) * time.Second,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize token signing", zap.Error(err))
+	}
+
 	// Initialize handlers
-	h := handlers.NewHandler(db, redisClient, log, cfg)
+	h := handlers.NewHandler(db, redisClient, log, cfg, tokenManager)
+
+	// Throttle password, SIWE and API key authentication
+	loginGuard := lockout.NewGuard(redisClient, log, metricsClient, audit.NewRecorder(db, log), lockout.Options{
+		Window:           time.Duration(cfg.Lockout.WindowSeconds) * time.Second,
+		BackoffAfter:     cfg.Lockout.BackoffAfter,
+		BaseDelay:        time.Duration(cfg.Lockout.BaseDelaySeconds) * time.Second,
+		MaxDelay:         time.Duration(cfg.Lockout.MaxDelaySeconds) * time.Second,
+		UserMaxFailures:  cfg.Lockout.UserMaxFailures,
+		IPMaxFailures:    cfg.Lockout.IPMaxFailures,
+		LockoutDuration:  time.Duration(cfg.Lockout.LockoutSeconds) * time.Second,
+		StuffingAccounts: cfg.Lockout.StuffingAccounts,
+	})
+	h.SetLoginGuard(loginGuard)
+
+	// Enable TOTP second factors
+	mfaService, err := mfa.NewService(db, redisClient, cfg.JWT.Secret, mfa.Options{
+		Issuer:       cfg.MFA.Issuer,
+		ChallengeTTL: time.Duration(cfg.MFA.ChallengeTTLSeconds) * time.Second,
+		MaxAttempts:  cfg.MFA.MaxAttempts,
+	})
+	if err != nil {
+		log.Fatal("Failed to create MFA service", zap.Error(err))
+	}
+	h.SetMFAService(mfaService)
+
+	// Send verification and password reset email
+	var accountMailer mailer.Mailer
+	switch cfg.Email.Driver {
+	case "smtp":
+		accountMailer = mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.Email.SMTP.Host,
+			Port:     cfg.Email.SMTP.Port,
+			Username: cfg.Email.SMTP.Username,
+			Password: cfg.Email.SMTP.Password,
+			From:     cfg.Email.From,
+		})
+	case "file":
+		if accountMailer, err = mailer.NewFileMailer(cfg.Email.FileDir, cfg.Email.From); err != nil {
+			log.Fatal("Failed to create file mailer", zap.Error(err))
+		}
+	case "log":
+		accountMailer = mailer.NewLogMailer(log)
+	default:
+		log.Fatal("Unknown email driver", zap.String("driver", cfg.Email.Driver))
+	}
+	h.SetMailer(accountMailer)
+
+	// Unverified users can still read but not change anything when required
+	var emailVerifier middleware.EmailVerifier
+	var verifiedGuards []gin.HandlerFunc
+	if cfg.Email.RequireVerified {
+		emailVerifier = accounttokens.NewVerifier(db)
+		verifiedGuards = append(verifiedGuards, middleware.RequireVerifiedEmail(emailVerifier))
+	}
+
+	// Enable single sign-on when an OpenID Connect provider is configured
+	if cfg.OIDC.IssuerURL != "" {
+		h.SetSSOProvider(sso.NewProvider(sso.Config{
+			IssuerURL:     cfg.OIDC.IssuerURL,
+			ClientID:      cfg.OIDC.ClientID,
+			ClientSecret:  cfg.OIDC.ClientSecret,
+			RedirectURL:   cfg.OIDC.RedirectURL,
+			Scopes:        cfg.OIDC.Scopes,
+			UsernameClaim: cfg.OIDC.UsernameClaim,
+			GroupsClaim:   cfg.OIDC.GroupsClaim,
+			AdminGroups:   cfg.OIDC.AdminGroups,
+			AllowedGroups: cfg.OIDC.AllowedGroups,
+		}))
+	}
+
+	// Start background monitors, stopped on shutdown
+	monitorCtx, stopMonitors := context.WithCancel(context.Background())
+	defer stopMonitors()
+
+	go tokenManager.Run(monitorCtx)
+
+	consensusMonitor := monitor.NewConsensusMonitor(
+		db,
+		log,
+		time.Duration(cfg.Monitoring.ConsensusPollSeconds)*time.Second,
+		time.Duration(cfg.Monitoring.RequestTimeoutSeconds)*time.Second,
+	)
+	go consensusMonitor.Run(monitorCtx)
+
+	gasMonitor := monitor.NewGasMonitor(
+		db,
+		log,
+		metricsClient,
+		time.Duration(cfg.Monitoring.GasPollSeconds)*time.Second,
+		time.Duration(cfg.Monitoring.RequestTimeoutSeconds)*time.Second,
+	)
+	go gasMonitor.Run(monitorCtx)
+
+	requestTimeout := time.Duration(cfg.Monitoring.RequestTimeoutSeconds) * time.Second
+	txTracker := broadcast.NewTracker(
+		db,
+		log,
+		broadcast.NewNotifier(
+			cfg.Broadcast.WebhookSecret,
+			broadcast.WebhookPolicy{AllowedHosts: cfg.Broadcast.WebhookAllowedHosts},
+			requestTimeout,
+			log,
+		),
+		time.Duration(cfg.Broadcast.PollSeconds)*time.Second,
+		requestTimeout,
+		time.Duration(cfg.Broadcast.DropTimeoutSeconds)*time.Second,
+		cfg.Broadcast.FinalityDepth,
+		cfg.Broadcast.DefaultFanout,
+	)
+	go txTracker.Run(monitorCtx)
+
+	// Publish node changes to streaming clients on every replica
+	nodeEvents := nodeevents.NewBroker(redisClient, nodeevents.NewHub(), log, cfg.NodeEvents.StreamLength)
+	go nodeEvents.Run(monitorCtx)
+	nodeWatcher := nodeevents.NewWatcher(
+		nodes.NewStore(db),
+		nodeEvents,
+		log,
+		time.Duration(cfg.NodeEvents.PollIntervalSeconds)*time.Second,
+	)
+	go nodeWatcher.Run(monitorCtx)
+	h.SetNodeEvents(nodeEvents)
+
+	// Compare running nodes with their stored config, alerting on critical
+	// drift when enabled
+	var driftAlerts *nodeevents.Broker
+	if cfg.Monitoring.DriftAlerts {
+		driftAlerts = nodeEvents
+	}
+	driftMonitor := monitor.NewDriftMonitor(
+		db,
+		log,
+		metricsClient,
+		driftAlerts,
+		time.Duration(cfg.Monitoring.DriftPollSeconds)*time.Second,
+		requestTimeout,
+	)
+	go driftMonitor.Run(monitorCtx)
+
+	// Mirror the on-chain node registry when it is configured
+	if cfg.Registry.RPCURL != "" && cfg.Registry.ContractAddress != "" {
+		if !common.IsHexAddress(cfg.Registry.ContractAddress) {
+			log.Fatal("Invalid registry contract address", zap.String("address", cfg.Registry.ContractAddress))
+		}
+
+		var trustedOwners []common.Address
+		for _, owner := range cfg.Registry.TrustedOwners {
+			if !common.IsHexAddress(owner) {
+				log.Fatal("Invalid trusted registry owner", zap.String("address", owner))
+			}
+			trustedOwners = append(trustedOwners, common.HexToAddress(owner))
+		}
+		if len(trustedOwners) == 0 {
+			log.Warn("No trusted registry owners configured, on-chain nodes won't be mirrored")
+		}
+
+		registryClient, err := ethclient.Dial(cfg.Registry.RPCURL)
+		if err != nil {
+			log.Fatal("Failed to connect to registry RPC endpoint", zap.Error(err))
+		}
+		defer registryClient.Close()
+
+		indexer, err := registry.NewIndexer(
+			db,
+			registryClient,
+			log,
+			common.HexToAddress(cfg.Registry.ContractAddress),
+			trustedOwners,
+			cfg.Registry.StartBlock,
+			cfg.Registry.Confirmations,
+			time.Duration(cfg.Registry.PollSeconds)*time.Second,
+		)
+		if err != nil {
+			log.Fatal("Failed to create registry indexer", zap.Error(err))
+		}
+		go indexer.Run(monitorCtx)
+
+		if cfg.Registry.Publisher.Enabled {
+			publisher, err := newRegistryPublisher(cfg.Registry, db, redisClient, registryClient, log)
+			if err != nil {
+				log.Fatal("Failed to create registry publisher", zap.Error(err))
+			}
+			go publisher.Run(monitorCtx)
+		}
+	}
+
+	// Serve TWIST token data and token-gated tiers when the token contract is configured
+	if cfg.Token.RPCURL != "" && cfg.Token.ContractAddress != "" {
+		if !common.IsHexAddress(cfg.Token.ContractAddress) {
+			log.Fatal("Invalid token contract address", zap.String("address", cfg.Token.ContractAddress))
+		}
+
+		tokenClient, err := ethclient.Dial(cfg.Token.RPCURL)
+		if err != nil {
+			log.Fatal("Failed to connect to token RPC endpoint", zap.Error(err))
+		}
+		defer tokenClient.Close()
+
+		tokenService, err := token.NewService(
+			common.HexToAddress(cfg.Token.ContractAddress),
+			tokenClient,
+			redisClient,
+			log,
+			requestTimeout,
+			time.Duration(cfg.Token.CacheTTLSeconds)*time.Second,
+			cfg.Token.CurvePoints,
+		)
+		if err != nil {
+			log.Fatal("Failed to create token service", zap.Error(err))
+		}
+		h.SetTokenService(tokenService)
+
+		tierRefresher, err := newTierRefresher(cfg, db, redisClient, tokenClient, log)
+		if err != nil {
+			log.Fatal("Failed to create tier refresher", zap.Error(err))
+		}
+		h.SetTierRefresher(tierRefresher)
+		go tierRefresher.Run(monitorCtx)
+	}
+
+	denylist := sessions.NewDenylist(redisClient)
+	apiKeyAuth := apikeys.NewAuthenticator(apikeys.NewStore(db), loginGuard)
 
 	// Set up API routes
 	api := router.Group("/api/v1")
@@ -76,44 +348,155 @@ func main() {
 		// Public routes
 		api.GET("/health", h.HealthCheck)
 
+		// TWIST token data, read from the public token contract
+		tokenRoutes := api.Group("/token")
+		{
+			tokenRoutes.GET("/vesting/:address", h.GetTokenVesting)
+			tokenRoutes.GET("/supply", h.GetTokenSupply)
+		}
+
 		// Auth routes
 		auth := api.Group("/auth")
 		{
 			auth.POST("/login", h.Login)
 			auth.POST("/register", h.Register)
+			auth.GET("/siwe/nonce", h.SIWENonce)
+			auth.POST("/siwe/verify", h.SIWEVerify)
+			auth.POST("/refresh", h.RefreshToken)
+			auth.GET("/oidc/login", h.OIDCLogin)
+			auth.GET("/oidc/callback", h.OIDCCallback)
+			auth.POST("/mfa/verify", h.VerifyMFALogin)
+			auth.POST("/verify-email", h.VerifyEmail)
+			auth.POST("/password/forgot", h.ForgotPassword)
+			auth.POST("/password/reset", h.ResetPassword)
 		}
 
 		// Protected routes
 		protected := api.Group("/")
-		protected.Use(middleware.Auth(cfg.JWT.Secret))
+		protected.Use(middleware.Auth(tokenManager, denylist, apiKeyAuth))
+		protected.Use(middleware.RateLimit(tiers.NewResolver(db, redisClient), redisClient, log))
+		{
+			// Session management
+			protected.POST("/auth/logout", h.Logout)
+			protected.POST("/auth/logout-all", middleware.ForbidImpersonation(), h.LogoutAll)
+
+			// MFA management stays reachable for users who still have to enrol
+			mfaRoutes := protected.Group("/users/me/mfa", middleware.ForbidImpersonation())
+			{
+				mfaRoutes.GET("", h.GetMFAStatus)
+				mfaRoutes.POST("/totp", h.EnrolTOTP)
+				mfaRoutes.POST("/totp/activate", h.ActivateTOTP)
+				mfaRoutes.DELETE("/totp", h.DisableTOTP)
+				mfaRoutes.POST("/recovery-codes", h.RegenerateRecoveryCodes)
+			}
+
+			// Unverified users need to be able to ask for another link
+			protected.POST("/users/me/email/verification", h.ResendVerificationEmail)
+		}
+
+		// Everything else needs a second factor when the user's role requires one
+		enforced := protected.Group("/")
+		enforced.Use(middleware.RequireMFA(mfaService))
 		{
 			// Node management
-			nodes := protected.Group("/nodes")
+			nodes := enforced.Group("/nodes", verifiedGuards...)
 			{
 				nodes.GET("", h.ListNodes)
+				nodes.GET("/events", h.StreamNodeEvents)
+				nodes.POST("/bulk", h.BulkNodes)
+				nodes.POST("/import", h.ImportNodes)
+				nodes.GET("/export", h.ExportNodes)
 				nodes.GET("/:id", h.GetNode)
+				nodes.GET("/:id/render", h.RenderNode)
+				nodes.GET("/:id/drift", h.GetNodeDrift)
 				nodes.POST("", h.CreateNode)
 				nodes.PUT("/:id", h.UpdateNode)
 				nodes.DELETE("/:id", h.DeleteNode)
 			}
 
+			// Named sets of nodes, by label selector or explicit membership
+			nodeGroups := enforced.Group("/node-groups", verifiedGuards...)
+			{
+				nodeGroups.GET("", h.ListNodeGroups)
+				nodeGroups.POST("", h.CreateNodeGroup)
+				nodeGroups.GET("/:group", h.GetNodeGroup)
+				nodeGroups.PATCH("/:group", h.UpdateNodeGroup)
+				nodeGroups.DELETE("/:group", h.DeleteNodeGroup)
+				nodeGroups.POST("/:group/members", h.AddNodeGroupMembers)
+				nodeGroups.DELETE("/:group/members", h.RemoveNodeGroupMembers)
+			}
+
+			// Config profiles each node's config is checked against
+			nodeConfig := enforced.Group("/node-config", verifiedGuards...)
+			{
+				nodeConfig.GET("/profiles", h.ListNodeConfigProfiles)
+				nodeConfig.GET("/profiles/:chain", h.GetNodeConfigProfile)
+				nodeConfig.POST("/validate", h.ValidateNodeConfig)
+			}
+
+			// Declarative node management from a fleet manifest. Applying can
+			// delete nodes outside the manifest, so only admins may.
+			fleet := enforced.Group("/fleet", verifiedGuards...)
+			{
+				fleet.POST("/plan", h.FleetPlan)
+				fleet.POST("/apply", middleware.RequireRole(models.RoleAdmin), h.FleetApply)
+			}
+
+			// Chain-wide data aggregated across nodes
+			chains := enforced.Group("/chains", verifiedGuards...)
+			{
+				chains.GET("/:chain/gas", middleware.RequireRPCMethods("eth_feeHistory", "eth_maxPriorityFeePerGas"), h.GetChainGas)
+				chains.POST("/:chain/transactions", middleware.RequireRPCMethods("eth_sendRawTransaction"), h.BroadcastTransaction)
+				chains.GET("/:chain/transactions/:hash", middleware.RequireRPCMethods("eth_getTransactionReceipt"), h.GetTransaction)
+			}
+
 			// User management
-			users := protected.Group("/users")
+			users := enforced.Group("/users")
 			{
 				users.GET("/me", h.GetCurrentUser)
-				users.PUT("/me", h.UpdateCurrentUser)
+				users.PUT("/me", middleware.ForbidImpersonation(), h.UpdateCurrentUser)
+				users.GET("/me/sessions", h.ListSessions)
+				users.DELETE("/me/sessions/:id", h.RevokeSession)
+				users.GET("/me/tier", h.GetCurrentUserTier)
+				users.GET("/me/wallets", h.ListWallets)
+				users.POST("/me/wallets/challenge", h.CreateWalletChallenge)
+				users.POST("/me/wallets", middleware.ForbidImpersonation(), h.LinkWallet)
+				users.DELETE("/me/wallets/:address", middleware.ForbidImpersonation(), h.UnlinkWallet)
 			}
 
 			// API key management
-			apiKeys := protected.Group("/api-keys")
+			// Impersonating admins can't mint credentials that outlive their token
+			apiKeys := enforced.Group("/api-keys", verifiedGuards...)
+			apiKeys.Use(middleware.ForbidImpersonation())
 			{
 				apiKeys.GET("", h.ListAPIKeys)
 				apiKeys.POST("", h.CreateAPIKey)
 				apiKeys.DELETE("/:id", h.DeleteAPIKey)
 			}
+
+			// Administration
+			admin := enforced.Group("/admin")
+			admin.Use(middleware.RequireRole(models.RoleAdmin))
+			{
+				admin.GET("/mfa/roles", h.ListMFARequirements)
+				admin.PUT("/mfa/roles/:role", h.SetMFARequirement)
+				admin.GET("/lockouts/:scope/:id", h.GetLockout)
+				admin.DELETE("/lockouts/:scope/:id", h.ClearLockout)
+
+				admin.GET("/users", h.ListUsers)
+				admin.POST("/users", h.CreateUser)
+				admin.GET("/users/:id", h.GetUser)
+				admin.PATCH("/users/:id", h.UpdateUser)
+				admin.DELETE("/users/:id", h.DeleteUser)
+				admin.POST("/users/:id/password-reset", h.ForcePasswordReset)
+				admin.POST("/users/:id/impersonate", h.ImpersonateUser)
+			}
 		}
 	}
 
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", h.JWKS)
+
 	// Metrics endpoint
 	router.GET("/metrics", h.Metrics)
 
@@ -131,12 +514,54 @@ func main() {
 		}
 	}()
 
+	// Serve the gRPC API alongside REST, with the same credentials and policies
+	var grpcServer *grpc.Server
+	if cfg.GRPC.Enabled {
+		authOptions := middleware.GRPCAuthOptions{
+			Parser:        tokenManager,
+			Denylist:      denylist,
+			APIKeys:       apiKeyAuth,
+			MFA:           mfaService,
+			EmailVerifier: emailVerifier,
+			Policies:      grpcPolicies(),
+			PublicServices: []string{
+				healthpb.Health_ServiceDesc.ServiceName,
+				"grpc.reflection.v1.ServerReflection",
+				"grpc.reflection.v1alpha.ServerReflection",
+			},
+		}
+		grpcServer = grpc.NewServer(
+			grpc.ChainUnaryInterceptor(middleware.UnaryAuth(authOptions)),
+			grpc.ChainStreamInterceptor(middleware.StreamAuth(authOptions)),
+		)
+		h.RegisterGRPC(grpcServer)
+
+		healthServer := health.NewServer()
+		healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+		healthpb.RegisterHealthServer(grpcServer, healthServer)
+		if cfg.GRPC.Reflection {
+			reflection.Register(grpcServer)
+		}
+
+		listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.GRPC.Port))
+		if err != nil {
+			log.Fatal("Failed to listen for gRPC", zap.Error(err))
+		}
+		go func() {
+			log.Info("Starting gRPC server", zap.String("address", listener.Addr().String()))
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatal("Failed to start gRPC server", zap.Error(err))
+			}
+		}()
+	}
+
 	// Wait for interrupt signal to gracefully shut down the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Info("Shutting down server...")
+	stopMonitors()
 
 	// Create a deadline for server shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -145,6 +570,75 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server forced to shutdown", zap.Error(err))
 	}
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
 
 	log.Info("Server exiting")
 }
+
+// grpcPolicies mirrors the route middleware of the REST API for the gRPC methods
+func grpcPolicies() map[string]middleware.GRPCPolicy {
+	admin := middleware.GRPCPolicy{Roles: []models.UserRole{models.RoleAdmin}}
+	verified := middleware.GRPCPolicy{RequireVerifiedEmail: true}
+
+	return map[string]middleware.GRPCPolicy{
+		gatewayv1.NodeService_CreateNode_FullMethodName:     verified,
+		gatewayv1.NodeService_UpdateNode_FullMethodName:     verified,
+		gatewayv1.NodeService_DeleteNode_FullMethodName:     verified,
+		gatewayv1.UserService_ListUsers_FullMethodName:      admin,
+		gatewayv1.UserService_GetUser_FullMethodName:        admin,
+		gatewayv1.APIKeyService_ListAPIKeys_FullMethodName:  {ForbidImpersonation: true},
+		gatewayv1.APIKeyService_CreateAPIKey_FullMethodName: {RequireVerifiedEmail: true, ForbidImpersonation: true},
+		gatewayv1.APIKeyService_DeleteAPIKey_FullMethodName: {RequireVerifiedEmail: true, ForbidImpersonation: true},
+	}
+}
+
+// newRegistryPublisher creates the publisher that writes opted-in nodes to the registry contract
+func newRegistryPublisher(cfg config.RegistryConfig, db *pgxpool.Pool, redisClient *redis.Client, client *ethclient.Client, log *zap.Logger) (*registry.Publisher, error) {
+	key, err := registry.LoadKeystoreKey(cfg.Publisher.KeystorePath, cfg.Publisher.KeystorePassword)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain ID: %w", err)
+	}
+
+	var maxFee *big.Int
+	if cfg.Publisher.MaxFeeGwei > 0 {
+		maxFee, _ = new(big.Float).Mul(big.NewFloat(cfg.Publisher.MaxFeeGwei), big.NewFloat(1e9)).Int(nil)
+	}
+
+	return registry.NewPublisher(db, redisClient, client, log, common.HexToAddress(cfg.ContractAddress), key, chainID, registry.PublisherOptions{
+		Interval:          time.Duration(cfg.Publisher.PollSeconds) * time.Second,
+		MaxTxPerCycle:     cfg.Publisher.MaxTxPerCycle,
+		MinUpdateInterval: time.Duration(cfg.Publisher.MinUpdateSeconds) * time.Second,
+		BlockDelta:        cfg.Publisher.BlockDelta,
+		ReceiptTimeout:    time.Duration(cfg.Publisher.ReceiptTimeoutSeconds) * time.Second,
+		MaxFeeBumps:       cfg.Publisher.MaxFeeBumps,
+		MaxFeePerGas:      maxFee,
+	})
+}
+
+// newTierRefresher creates the refresher that maps linked wallets' TWIST holdings to API tiers
+func newTierRefresher(cfg *config.Config, db *pgxpool.Pool, redisClient *redis.Client, client *ethclient.Client, log *zap.Logger) (*tiers.Refresher, error) {
+	timeout := time.Duration(cfg.Monitoring.RequestTimeoutSeconds) * time.Second
+	tokenContract, err := contracts.NewTokenService(common.HexToAddress(cfg.Token.ContractAddress), client, timeout, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return tiers.NewRefresher(
+		db,
+		redisClient,
+		tokenContract,
+		client,
+		log,
+		tiers.NewThresholds(cfg.Tiers.BasicMinTokens, cfg.Tiers.ProMinTokens, cfg.Tiers.EnterpriseMinTokens),
+		time.Duration(cfg.Tiers.RefreshSeconds)*time.Second,
+		timeout,
+		time.Duration(cfg.Tiers.DowngradeGraceSeconds)*time.Second,
+	), nil
+}
@@ -0,0 +1,165 @@
+// Package accounttokens issues the signed, expiring, single-use tokens that
+// are mailed to users to verify their email address or reset their password.
+package accounttokens
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Purpose is what a token may be used for
+type Purpose string
+
+const (
+	PurposeVerifyEmail   Purpose = "verify_email"
+	PurposePasswordReset Purpose = "password_reset"
+)
+
+// ErrInvalidToken is returned for forged, expired, already used or
+// superseded tokens, and for tokens presented for another purpose
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// payloadSize is the length of a token's signed payload: ID and expiry
+const payloadSize = 16 + 8
+
+// Claim is what a consumed token was issued for
+type Claim struct {
+	UserID uuid.UUID
+	Email  string
+}
+
+// Tokens issues and consumes account tokens
+type Tokens struct {
+	db  *pgxpool.Pool
+	key []byte
+}
+
+// NewTokens creates a new Tokens that signs with a key derived from secret
+func NewTokens(db *pgxpool.Pool, secret string) *Tokens {
+	key := sha256.Sum256([]byte("twist-account-token:" + secret))
+	return &Tokens{db: db, key: key[:]}
+}
+
+func (t *Tokens) sign(purpose Purpose, payload []byte) []byte {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Issue creates a token for userID that expires after ttl. email is the
+// address the token is mailed to. Earlier unused tokens of userID for the
+// same purpose stop working.
+func (t *Tokens) Issue(ctx context.Context, userID uuid.UUID, purpose Purpose, email string, ttl time.Duration) (string, error) {
+	id := uuid.New()
+	now := time.Now().UTC()
+	expiresAt := now.Add(ttl)
+
+	err := pgx.BeginFunc(ctx, t.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			UPDATE account_tokens SET used_at = $1
+			WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL`,
+			now, userID, purpose); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO account_tokens (id, user_id, purpose, email, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			id, userID, purpose, email, now, expiresAt)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	payload := make([]byte, payloadSize)
+	copy(payload, id[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(t.sign(purpose, payload)), nil
+}
+
+// Consume checks token for purpose and marks it used
+func (t *Tokens) Consume(ctx context.Context, token string, purpose Purpose) (*Claim, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != payloadSize {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, t.sign(purpose, payload)) {
+		return nil, ErrInvalidToken
+	}
+
+	// Expired tokens are rejected before touching the database
+	now := time.Now().UTC()
+	if int64(binary.BigEndian.Uint64(payload[16:])) <= now.Unix() {
+		return nil, ErrInvalidToken
+	}
+	id, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claim Claim
+	err = t.db.QueryRow(ctx, `
+		UPDATE account_tokens SET used_at = $1
+		WHERE id = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING user_id, email`,
+		now, id, purpose,
+	).Scan(&claim.UserID, &claim.Email)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// Revoke invalidates userID's unused tokens for purpose
+func (t *Tokens) Revoke(ctx context.Context, userID uuid.UUID, purpose Purpose) error {
+	_, err := t.db.Exec(ctx, `
+		UPDATE account_tokens SET used_at = $1
+		WHERE user_id = $2 AND purpose = $3 AND used_at IS NULL`,
+		time.Now().UTC(), userID, purpose)
+	return err
+}
+
+// Verifier reports whether users have verified their email address
+type Verifier struct {
+	db *pgxpool.Pool
+}
+
+// NewVerifier creates a new Verifier
+func NewVerifier(db *pgxpool.Pool) *Verifier {
+	return &Verifier{db: db}
+}
+
+// EmailVerified reports whether userID's email address is verified.
+// Accounts without an email address, such as wallet-only accounts, have
+// nothing to verify and count as verified.
+func (v *Verifier) EmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var verified bool
+	err := v.db.QueryRow(ctx, `
+		SELECT email IS NULL OR email_verified_at IS NOT NULL FROM users WHERE id = $1`, userID,
+	).Scan(&verified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return verified, err
+}
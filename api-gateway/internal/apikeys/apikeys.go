@@ -0,0 +1,191 @@
+// Package apikeys manages API keys, which authenticate requests through the
+// X-API-Key header as an alternative to access tokens.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/lockout"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+var (
+	// ErrInvalidKey is returned for unknown, disabled or expired API keys
+	ErrInvalidKey = errors.New("invalid API key")
+
+	// ErrUserDisabled is returned for valid keys whose owner has been disabled
+	ErrUserDisabled = errors.New("the account this API key belongs to has been disabled")
+
+	// ErrNotFound is returned when a key does not exist or belongs to another user
+	ErrNotFound = errors.New("API key not found")
+)
+
+const (
+	keyPrefix = "twk_"
+
+	// displayPrefixLength is how much of a key is kept in clear for display
+	displayPrefixLength = len(keyPrefix) + 8
+
+	// lastUsedResolution limits how often last_used is written for busy keys
+	lastUsedResolution = time.Minute
+)
+
+// Store persists API keys. Only a hash of each key is stored.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a new Store
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Principal is who a valid API key authenticates as
+type Principal struct {
+	User models.User
+
+	// MFA reports whether the key was created from a session that passed MFA
+	MFA bool
+}
+
+// Create issues a new key for userID. mfa records whether the creating
+// session passed MFA. The returned response holds the full key, which can't
+// be retrieved again.
+func (s *Store) Create(ctx context.Context, userID uuid.UUID, name string, expiresAt *time.Time, mfa bool) (*models.APIKeyResponse, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	response := &models.APIKeyResponse{
+		ID:        uuid.New(),
+		Name:      name,
+		Key:       key,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO api_keys (id, user_id, name, key_hash, key_prefix, created_at, expires_at, enabled, mfa)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE, $8)`,
+		response.ID, userID, name, hashKey(key), key[:displayPrefixLength], response.CreatedAt, expiresAt, mfa)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// List returns userID's keys, newest first. Key holds only the key's prefix.
+func (s *Store) List(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_id, key_prefix, name, created_at, expires_at, last_used, enabled, mfa
+		FROM api_keys WHERE user_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Key, &key.Name,
+			&key.CreatedAt, &key.ExpiresAt, &key.LastUsed, &key.Enabled, &key.MFA); err != nil {
+			return nil, err
+		}
+		key.Key += "..."
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Delete removes one of userID's keys
+func (s *Store) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Authenticate returns who key authenticates as. Keys of disabled users
+// return ErrUserDisabled.
+func (s *Store) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	var principal Principal
+	user := &principal.User
+	var keyID uuid.UUID
+	var expiresAt *time.Time
+	var enabled bool
+	err := s.db.QueryRow(ctx, `
+		SELECT k.id, k.expires_at, k.enabled, k.mfa, u.id, u.username, u.role, u.disabled_at
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1`, hashKey(key),
+	).Scan(&keyID, &expiresAt, &enabled, &principal.MFA, &user.ID, &user.Username, &user.Role, &user.DisabledAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if !enabled || (expiresAt != nil && !now.Before(*expiresAt)) {
+		return nil, ErrInvalidKey
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrUserDisabled
+	}
+
+	if _, err := s.db.Exec(ctx, `
+		UPDATE api_keys SET last_used = $1
+		WHERE id = $2 AND (last_used IS NULL OR last_used < $3)`,
+		now, keyID, now.Add(-lastUsedResolution)); err != nil {
+		return nil, err
+	}
+	return &principal, nil
+}
+
+// Authenticator authenticates API keys behind a lockout.Guard, so clients
+// guessing keys are throttled like password guessers
+type Authenticator struct {
+	store *Store
+	guard *lockout.Guard
+}
+
+// NewAuthenticator creates a new Authenticator
+func NewAuthenticator(store *Store, guard *lockout.Guard) *Authenticator {
+	return &Authenticator{store: store, guard: guard}
+}
+
+// AuthenticateAPIKey returns who key authenticates as. It returns a
+// *lockout.LockedError while ip is locked out and ErrInvalidKey for bad keys.
+func (a *Authenticator) AuthenticateAPIKey(ctx context.Context, key, ip string) (*Principal, error) {
+	attempt := lockout.Attempt{Method: lockout.MethodAPIKey, IP: ip}
+	if err := a.guard.Check(ctx, attempt); err != nil {
+		return nil, err
+	}
+
+	principal, err := a.store.Authenticate(ctx, key)
+	if errors.Is(err, ErrInvalidKey) {
+		a.guard.Failure(ctx, attempt, "invalid_key")
+	}
+	return principal, err
+}
@@ -0,0 +1,65 @@
+// Package audit records security-relevant events to the audit_events table.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+// Recorder writes audit events
+type Recorder struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+}
+
+// NewRecorder creates a new Recorder
+func NewRecorder(db *pgxpool.Pool, logger *zap.Logger) *Recorder {
+	return &Recorder{db: db, logger: logger}
+}
+
+// Record stores event and mirrors it to the log. Recording is best effort:
+// a failure to store the event is logged rather than failing the request
+// that caused it.
+func (r *Recorder) Record(ctx context.Context, event models.AuditEvent) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC()
+	}
+	if event.Metadata == nil {
+		event.Metadata = map[string]interface{}{}
+	}
+
+	fields := []zap.Field{
+		zap.String("action", event.Action),
+		zap.String("actor", event.Actor),
+		zap.String("target_type", event.TargetType),
+		zap.String("target_id", event.TargetID),
+		zap.String("ip", event.IPAddress),
+		zap.Any("metadata", event.Metadata),
+	}
+	if event.ActorID != nil {
+		fields = append(fields, zap.String("actor_id", event.ActorID.String()))
+	}
+	r.logger.Info("Audit event", fields...)
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO audit_events (action, actor_id, actor, target_type, target_id, ip_address, user_agent, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		event.Action, event.ActorID, event.Actor, event.TargetType, event.TargetID,
+		event.IPAddress, event.UserAgent, event.Metadata, event.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to record audit event", zap.String("action", event.Action), zap.Error(err))
+	}
+}
+
+// UserID returns a pointer to id for AuditEvent.ActorID, or nil for uuid.Nil
+func UserID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,290 @@
+package broadcast
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/ethrpc"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrNoHealthyNodes is returned when a chain has no running nodes to broadcast to
+	ErrNoHealthyNodes = errors.New("no healthy nodes available for chain")
+
+	// ErrRejected is returned when every node rejected the transaction
+	ErrRejected = errors.New("transaction rejected by all nodes")
+
+	// ErrInvalidTransaction is returned when the raw transaction is not valid hex
+	ErrInvalidTransaction = errors.New("raw transaction must be 0x-prefixed hex")
+
+	// ErrNotFound is returned when a tracked transaction doesn't exist
+	ErrNotFound = errors.New("transaction not found")
+)
+
+// Service fans signed transactions out to multiple healthy nodes of a chain
+type Service struct {
+	db            *pgxpool.Pool
+	logger        *zap.Logger
+	timeout       time.Duration
+	defaultFanout int
+	webhooks      WebhookPolicy
+}
+
+// NewService creates a new broadcast Service
+func NewService(db *pgxpool.Pool, logger *zap.Logger, timeout time.Duration, defaultFanout int, webhooks WebhookPolicy) *Service {
+	return &Service{
+		db:            db,
+		logger:        logger,
+		timeout:       timeout,
+		defaultFanout: defaultFanout,
+		webhooks:      webhooks,
+	}
+}
+
+type target struct {
+	id       uuid.UUID
+	endpoint string
+}
+
+// Broadcast submits req.RawTransaction to up to req.Fanout running nodes of
+// chain in parallel and starts tracking it. Node responses are deduplicated;
+// nodes that already know the transaction count as accepting it.
+func (s *Service) Broadcast(ctx context.Context, userID uuid.UUID, chain models.ChainType, req models.BroadcastTransactionRequest) (*models.BroadcastTransactionResponse, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(req.RawTransaction, "0x"))
+	if err != nil || len(raw) == 0 {
+		return nil, ErrInvalidTransaction
+	}
+	if req.WebhookURL != "" {
+		if err := s.webhooks.Validate(ctx, req.WebhookURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// The transaction hash is the keccak256 of the signed envelope, for both
+	// legacy and typed transactions
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(raw)
+	txHash := "0x" + hex.EncodeToString(hasher.Sum(nil))
+
+	fanout := req.Fanout
+	if fanout == 0 {
+		fanout = s.defaultFanout
+	}
+
+	targets, err := healthyTargets(ctx, s.db, chain, fanout)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, ErrNoHealthyNodes
+	}
+
+	results := s.submit(ctx, targets, req.RawTransaction)
+
+	response := &models.BroadcastTransactionResponse{
+		TxHash:  txHash,
+		Results: results,
+	}
+	for _, r := range results {
+		if !r.Accepted {
+			continue
+		}
+		response.Accepted++
+		if r.TxHash != "" && !strings.EqualFold(r.TxHash, txHash) {
+			s.logger.Warn("Node returned unexpected transaction hash",
+				zap.String("node_id", r.NodeID.String()),
+				zap.String("expected", txHash),
+				zap.String("returned", r.TxHash),
+			)
+		}
+	}
+	if response.Accepted == 0 {
+		return response, ErrRejected
+	}
+
+	now := time.Now().UTC()
+	tx := models.BroadcastTransaction{
+		TxHash:         txHash,
+		ChainType:      chain,
+		UserID:         userID,
+		RawTransaction: req.RawTransaction,
+		Status:         models.TxStatusPending,
+		WebhookURL:     req.WebhookURL,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.lookupSender(ctx, targets, &tx)
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO broadcast_transactions (tx_hash, chain_type, user_id, raw_transaction, from_address,
+			nonce, status, webhook_url, created_at, updated_at, last_seen_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9, $10)
+		ON CONFLICT (tx_hash) DO NOTHING`,
+		tx.TxHash, tx.ChainType, tx.UserID, tx.RawTransaction, tx.FromAddress,
+		nullableInt64(tx.Nonce), tx.Status, tx.WebhookURL, tx.CreatedAt, tx.LastSeenAt,
+	)
+	if err != nil {
+		return response, fmt.Errorf("failed to record transaction: %w", err)
+	}
+
+	return response, nil
+}
+
+// Get returns a tracked transaction
+func (s *Service) Get(ctx context.Context, chain models.ChainType, txHash string) (*models.BroadcastTransaction, error) {
+	tx, err := scanTransaction(s.db.QueryRow(ctx,
+		`SELECT `+transactionColumns+` FROM broadcast_transactions WHERE chain_type = $1 AND tx_hash = $2`,
+		chain, strings.ToLower(txHash),
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return tx, err
+}
+
+// submit sends rawTx to every target concurrently
+func (s *Service) submit(ctx context.Context, targets []target, rawTx string) []models.BroadcastNodeResult {
+	results := make([]models.BroadcastNodeResult, len(targets))
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t target) {
+			defer wg.Done()
+
+			callCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+
+			result := models.BroadcastNodeResult{NodeID: t.id}
+			hash, err := ethrpc.NewClient(t.endpoint, s.timeout).SendRawTransaction(callCtx, rawTx)
+			switch {
+			case err == nil:
+				result.Accepted = true
+				result.TxHash = strings.ToLower(hash)
+			case isAlreadyKnown(err):
+				result.Accepted = true
+			default:
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, t)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// lookupSender fills in the sender and nonce from the first node that has the
+// transaction in its pool. These drive replacement detection in the Tracker.
+func (s *Service) lookupSender(ctx context.Context, targets []target, tx *models.BroadcastTransaction) {
+	for _, t := range targets {
+		callCtx, cancel := context.WithTimeout(ctx, s.timeout)
+		pending, err := ethrpc.NewClient(t.endpoint, s.timeout).TransactionByHash(callCtx, tx.TxHash)
+		cancel()
+		if err != nil || pending == nil {
+			continue
+		}
+
+		nonce := pending.Nonce.Uint64()
+		seen := time.Now().UTC()
+		tx.FromAddress = strings.ToLower(pending.From)
+		tx.Nonce = &nonce
+		tx.LastSeenAt = &seen
+		return
+	}
+}
+
+// healthyTargets returns up to limit running nodes of chain in random order
+func healthyTargets(ctx context.Context, db *pgxpool.Pool, chain models.ChainType, limit int) ([]target, error) {
+	rows, err := db.Query(ctx, `
+		SELECT id, endpoint_url FROM nodes
+		WHERE chain_type = $1 AND status = $2
+		ORDER BY random()
+		LIMIT $3`,
+		chain, models.NodeStatusRunning, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []target
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.id, &t.endpoint); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, rows.Err()
+}
+
+// isAlreadyKnown reports whether a node rejected a transaction only because it
+// already has it, which geth, erigon, nethermind and besu phrase differently
+func isAlreadyKnown(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") ||
+		strings.Contains(msg, "known transaction") ||
+		strings.Contains(msg, "alreadyknown") ||
+		strings.Contains(msg, "already imported")
+}
+
+const transactionColumns = `tx_hash, chain_type, user_id, raw_transaction, from_address, nonce, status,
+	block_number, block_hash, receipt_status, webhook_url, created_at, updated_at, last_seen_at`
+
+func scanTransaction(row pgx.Row) (*models.BroadcastTransaction, error) {
+	var tx models.BroadcastTransaction
+	var nonce, blockNumber, receiptStatus *int64
+	err := row.Scan(
+		&tx.TxHash,
+		&tx.ChainType,
+		&tx.UserID,
+		&tx.RawTransaction,
+		&tx.FromAddress,
+		&nonce,
+		&tx.Status,
+		&blockNumber,
+		&tx.BlockHash,
+		&receiptStatus,
+		&tx.WebhookURL,
+		&tx.CreatedAt,
+		&tx.UpdatedAt,
+		&tx.LastSeenAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.Nonce = nullableUint64(nonce)
+	tx.BlockNumber = nullableUint64(blockNumber)
+	tx.ReceiptStatus = nullableUint64(receiptStatus)
+	return &tx, nil
+}
+
+func nullableInt64(v *uint64) *int64 {
+	if v == nil {
+		return nil
+	}
+	i := int64(*v)
+	return &i
+}
+
+func nullableUint64(v *int64) *uint64 {
+	if v == nil {
+		return nil
+	}
+	u := uint64(*v)
+	return &u
+}
@@ -0,0 +1,388 @@
+package broadcast
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/ethrpc"
+	"go.uber.org/zap"
+)
+
+// Tracker follows broadcast transactions until they are finalized, replaced
+// or dropped, rebroadcasting transactions that disappear from the mempool
+type Tracker struct {
+	db            *pgxpool.Pool
+	logger        *zap.Logger
+	notifier      *Notifier
+	interval      time.Duration
+	timeout       time.Duration
+	dropTimeout   time.Duration
+	finalityDepth uint64
+	fanout        int
+}
+
+// NewTracker creates a new Tracker
+func NewTracker(db *pgxpool.Pool, logger *zap.Logger, notifier *Notifier, interval, timeout, dropTimeout time.Duration, finalityDepth uint64, fanout int) *Tracker {
+	return &Tracker{
+		db:            db,
+		logger:        logger,
+		notifier:      notifier,
+		interval:      interval,
+		timeout:       timeout,
+		dropTimeout:   dropTimeout,
+		finalityDepth: finalityDepth,
+		fanout:        fanout,
+	}
+}
+
+// Run tracks open transactions until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		t.trackAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Tracker) trackAll(ctx context.Context) {
+	rows, err := t.db.Query(ctx,
+		`SELECT `+transactionColumns+` FROM broadcast_transactions WHERE status IN ($1, $2)`,
+		models.TxStatusPending, models.TxStatusIncluded,
+	)
+	if err != nil {
+		t.logger.Error("Failed to load open transactions", zap.Error(err))
+		return
+	}
+
+	var open []*models.BroadcastTransaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			rows.Close()
+			t.logger.Error("Failed to scan transaction", zap.Error(err))
+			return
+		}
+		open = append(open, tx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		t.logger.Error("Failed to load open transactions", zap.Error(err))
+		return
+	}
+
+	for _, tx := range open {
+		if ctx.Err() != nil {
+			return
+		}
+		t.track(ctx, tx)
+	}
+}
+
+// track advances a single transaction through its lifecycle. Up to fanout
+// healthy nodes are asked about it and a status only changes when a majority
+// of those that answered agree, so one lagging or forked node can't move a
+// transaction back to pending or into a block the rest don't have.
+func (t *Tracker) track(ctx context.Context, tx *models.BroadcastTransaction) {
+	targets, err := healthyTargets(ctx, t.db, tx.ChainType, t.fanout)
+	if err != nil {
+		t.logger.Error("Failed to load nodes", zap.String("chain", string(tx.ChainType)), zap.Error(err))
+		return
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	previous := *tx
+	now := time.Now().UTC()
+
+	v := tally(t.observe(callCtx, tx.TxHash, targets))
+	switch {
+	case v.included != nil:
+		applyReceipt(tx, v, now)
+	case v.missing:
+		// Not (or no longer) in the canonical chain, e.g. after a reorg
+		tx.Status = models.TxStatusPending
+		tx.BlockNumber = nil
+		tx.BlockHash = ""
+		tx.ReceiptStatus = nil
+		t.trackPending(callCtx, targets, tx, now)
+	default:
+		t.logger.Debug("Nodes disagree on transaction", zap.String("tx_hash", tx.TxHash), zap.Int("answered", v.answered))
+		return
+	}
+
+	if tx.Status == previous.Status && equalUint64(tx.BlockNumber, previous.BlockNumber) && tx.BlockHash == previous.BlockHash &&
+		equalTime(tx.LastSeenAt, previous.LastSeenAt) && tx.FromAddress == previous.FromAddress {
+		return
+	}
+
+	// Matching the status read above means only one replica records, and
+	// notifies, a given transition
+	tx.UpdatedAt = now
+	result, err := t.db.Exec(ctx, `
+		UPDATE broadcast_transactions
+		SET status = $1, block_number = $2, block_hash = $3, receipt_status = $4,
+			from_address = $5, nonce = $6, last_seen_at = $7, updated_at = $8
+		WHERE tx_hash = $9 AND status = $10`,
+		tx.Status, nullableInt64(tx.BlockNumber), tx.BlockHash, nullableInt64(tx.ReceiptStatus),
+		tx.FromAddress, nullableInt64(tx.Nonce), tx.LastSeenAt, tx.UpdatedAt, tx.TxHash, previous.Status,
+	)
+	if err != nil {
+		t.logger.Error("Failed to update transaction", zap.String("tx_hash", tx.TxHash), zap.Error(err))
+		return
+	}
+	if result.RowsAffected() != 1 {
+		t.logger.Debug("Transaction changed concurrently", zap.String("tx_hash", tx.TxHash))
+		return
+	}
+
+	if tx.Status != previous.Status && tx.WebhookURL != "" {
+		go t.notifier.Notify(ctx, tx.WebhookURL, models.TransactionWebhookEvent{
+			TxHash:         tx.TxHash,
+			ChainType:      tx.ChainType,
+			Status:         tx.Status,
+			PreviousStatus: previous.Status,
+			BlockNumber:    tx.BlockNumber,
+			BlockHash:      tx.BlockHash,
+			ReceiptStatus:  tx.ReceiptStatus,
+			Timestamp:      now,
+		})
+	}
+}
+
+// observation is what one node reports about a transaction
+type observation struct {
+	// answered is false when the node couldn't be asked; it doesn't vote
+	answered bool
+	// receipt is set when the transaction is in the node's canonical chain
+	receipt *ethrpc.Receipt
+	final   bool
+}
+
+// observe asks each target for the transaction's receipt in parallel
+func (t *Tracker) observe(ctx context.Context, txHash string, targets []target) []observation {
+	observations := make([]observation, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int, node target) {
+			defer wg.Done()
+			client := ethrpc.NewClient(node.endpoint, t.timeout)
+			receipt, err := client.TransactionReceipt(ctx, txHash)
+			if err != nil {
+				t.logger.Debug("Failed to fetch receipt",
+					zap.String("tx_hash", txHash),
+					zap.String("node_id", node.id.String()),
+					zap.Error(err),
+				)
+				return
+			}
+			obs := observation{answered: true}
+			if receipt != nil {
+				canonical, err := t.isCanonical(ctx, client, receipt)
+				if err != nil {
+					return
+				}
+				if canonical {
+					obs.receipt = receipt
+					obs.final = t.isFinal(ctx, client, receipt.BlockNumber.Uint64())
+				}
+			}
+			observations[i] = obs
+		}(i, targets[i])
+	}
+	wg.Wait()
+	return observations
+}
+
+// verdict is what a majority of the nodes that answered agree on. included
+// and missing are both unset when there is no majority either way.
+type verdict struct {
+	answered int
+	included *ethrpc.Receipt
+	final    bool
+	missing  bool
+}
+
+// tally finds the majority among observations. Nodes including the
+// transaction only agree when they have it in the same block, and it is
+// final when a majority also consider that block final.
+func tally(observations []observation) verdict {
+	var v verdict
+	blocks := map[string][]observation{}
+	missing := 0
+	for _, obs := range observations {
+		if !obs.answered {
+			continue
+		}
+		v.answered++
+		if obs.receipt == nil {
+			missing++
+			continue
+		}
+		hash := strings.ToLower(obs.receipt.BlockHash)
+		blocks[hash] = append(blocks[hash], obs)
+	}
+	if v.answered == 0 {
+		return v
+	}
+	quorum := v.answered/2 + 1
+
+	if missing >= quorum {
+		v.missing = true
+		return v
+	}
+	for _, votes := range blocks {
+		if len(votes) < quorum {
+			continue
+		}
+		v.included = votes[0].receipt
+		final := 0
+		for _, obs := range votes {
+			if obs.final {
+				final++
+			}
+		}
+		v.final = final >= quorum
+	}
+	return v
+}
+
+// trackPending handles a transaction without a canonical receipt: it detects
+// replacement by a different transaction with the same nonce, rebroadcasts
+// transactions missing from the mempool and eventually declares them dropped.
+// Any node still holding the transaction keeps it alive, while replacement
+// needs a majority of the nodes that answered.
+func (t *Tracker) trackPending(ctx context.Context, targets []target, tx *models.BroadcastTransaction, now time.Time) {
+	clients := make([]*ethrpc.Client, len(targets))
+	for i, target := range targets {
+		clients[i] = ethrpc.NewClient(target.endpoint, t.timeout)
+	}
+
+	for _, client := range clients {
+		pending, err := client.TransactionByHash(ctx, tx.TxHash)
+		if err != nil || pending == nil {
+			continue
+		}
+		if tx.Nonce == nil {
+			nonce := pending.Nonce.Uint64()
+			tx.Nonce = &nonce
+			tx.FromAddress = strings.ToLower(pending.From)
+		}
+		tx.LastSeenAt = &now
+		return
+	}
+
+	if tx.Nonce != nil && tx.FromAddress != "" {
+		answered, spent := 0, 0
+		for _, client := range clients {
+			count, err := client.TransactionCount(ctx, tx.FromAddress, "latest")
+			if err != nil {
+				continue
+			}
+			answered++
+			if count > *tx.Nonce {
+				spent++
+			}
+		}
+		if answered > 0 && spent >= answered/2+1 {
+			// The nonce is also spent if the transaction itself was mined
+			// since its receipt was checked, and replaced is terminal
+			v := tally(t.observe(ctx, tx.TxHash, targets))
+			switch {
+			case v.included != nil:
+				applyReceipt(tx, v, now)
+			case v.missing:
+				tx.Status = models.TxStatusReplaced
+			}
+			return
+		}
+	}
+
+	lastSeen := tx.CreatedAt
+	if tx.LastSeenAt != nil {
+		lastSeen = *tx.LastSeenAt
+	}
+	if now.Sub(lastSeen) > t.dropTimeout {
+		tx.Status = models.TxStatusDropped
+		return
+	}
+
+	// Give the transaction another chance to propagate
+	for i, client := range clients {
+		if _, err := client.SendRawTransaction(ctx, tx.RawTransaction); err != nil && !isAlreadyKnown(err) {
+			t.logger.Debug("Rebroadcast rejected",
+				zap.String("tx_hash", tx.TxHash),
+				zap.String("node_id", targets[i].id.String()),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// applyReceipt records the block a majority of nodes included tx in
+func applyReceipt(tx *models.BroadcastTransaction, v verdict, now time.Time) {
+	receipt := v.included
+	blockNumber := receipt.BlockNumber.Uint64()
+	receiptStatus := receipt.Status.Uint64()
+	tx.BlockNumber = &blockNumber
+	tx.BlockHash = strings.ToLower(receipt.BlockHash)
+	tx.ReceiptStatus = &receiptStatus
+	tx.LastSeenAt = &now
+	tx.Status = models.TxStatusIncluded
+	if v.final {
+		tx.Status = models.TxStatusFinalized
+	}
+}
+
+// isCanonical reports whether the receipt's block is still part of the
+// node's chain. An error means the node couldn't tell.
+func (t *Tracker) isCanonical(ctx context.Context, client *ethrpc.Client, receipt *ethrpc.Receipt) (bool, error) {
+	header, err := client.BlockHeaderByNumber(ctx, receipt.BlockNumber.Uint64())
+	if err != nil {
+		return false, err
+	}
+	return header != nil && strings.EqualFold(header.Hash, receipt.BlockHash), nil
+}
+
+// isFinal prefers the consensus layer's finalized block and falls back to a
+// fixed confirmation depth on chains that don't expose the finalized tag
+func (t *Tracker) isFinal(ctx context.Context, client *ethrpc.Client, blockNumber uint64) bool {
+	if finalized, err := client.BlockHeaderByTag(ctx, "finalized"); err == nil && finalized != nil {
+		return blockNumber <= finalized.Number.Uint64()
+	}
+
+	latest, err := client.BlockNumber(ctx)
+	if err != nil || latest < blockNumber {
+		return false
+	}
+	return latest-blockNumber+1 >= t.finalityDepth
+}
+
+func equalUint64(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,206 @@
+package broadcast
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+// webhookAttempts is how many times a status update is delivered before giving up
+const webhookAttempts = 4
+
+// ErrInvalidWebhook is returned for webhook URLs the gateway won't call
+var ErrInvalidWebhook = errors.New("invalid webhook URL")
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP doesn't
+// count as private
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP reports whether ip may receive webhooks. Loopback, private,
+// link-local (which holds cloud metadata services), multicast and
+// unspecified addresses are refused.
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
+
+// WebhookPolicy decides which URLs webhooks may be delivered to. Only https
+// URLs are accepted, and when AllowedHosts is set the host must be one of
+// them or a subdomain of an entry starting with a dot, such as
+// .hooks.example.com.
+type WebhookPolicy struct {
+	AllowedHosts []string
+}
+
+func (p WebhookPolicy) allowedHost(host string) bool {
+	if len(p.AllowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, allowed := range p.AllowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL checks the scheme and host of a webhook URL without resolving it
+func (p WebhookPolicy) checkURL(u *url.URL) error {
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("%w: webhooks must use an https URL", ErrInvalidWebhook)
+	}
+	if !p.allowedHost(u.Hostname()) {
+		return fmt.Errorf("%w: host %s is not allowed", ErrInvalidWebhook, u.Hostname())
+	}
+	return nil
+}
+
+// Validate checks rawURL against the policy and that its host resolves only
+// to public addresses. Delivery checks the address again on every
+// connection, so a host that later resolves elsewhere is still refused.
+func (p WebhookPolicy) Validate(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if err := p.checkURL(u); err != nil {
+		return err
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: host %s doesn't resolve", ErrInvalidWebhook, u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("%w: host %s resolves to a non-public address", ErrInvalidWebhook, u.Hostname())
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to non-public addresses. It runs after
+// the host is resolved, so DNS rebinding can't reach internal services.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("%w: refusing to connect to %s", ErrInvalidWebhook, host)
+	}
+	return nil
+}
+
+// Notifier delivers transaction status updates to webhooks. When a secret is
+// configured each request carries an X-Twist-Signature header with the
+// hex-encoded HMAC-SHA256 of the body.
+type Notifier struct {
+	secret     []byte
+	policy     WebhookPolicy
+	logger     *zap.Logger
+	httpClient *http.Client
+}
+
+// NewNotifier creates a new webhook Notifier that only connects to public
+// addresses allowed by policy. Proxies from the environment are ignored so
+// the address check applies to the webhook host itself.
+func NewNotifier(secret string, policy WebhookPolicy, timeout time.Duration, logger *zap.Logger) *Notifier {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	n := &Notifier{
+		secret: []byte(secret),
+		policy: policy,
+		logger: logger,
+	}
+	n.httpClient = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many webhook redirects")
+			}
+			return n.policy.checkURL(req.URL)
+		},
+	}
+	return n
+}
+
+// Notify delivers event to url, retrying with exponential backoff
+func (n *Notifier) Notify(ctx context.Context, url string, event models.TransactionWebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		n.logger.Error("Failed to encode webhook event", zap.Error(err))
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err = n.deliver(ctx, url, body)
+		if err == nil {
+			return
+		}
+
+		if attempt == webhookAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	n.logger.Warn("Failed to deliver transaction webhook",
+		zap.String("tx_hash", event.TxHash),
+		zap.String("status", string(event.Status)),
+		zap.Error(err),
+	)
+}
+
+func (n *Notifier) deliver(ctx context.Context, rawURL string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if err := n.policy.checkURL(req.URL); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(n.secret) > 0 {
+		mac := hmac.New(sha256.New, n.secret)
+		mac.Write(body)
+		req.Header.Set("X-Twist-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -15,6 +15,18 @@ type Config struct {
 	Redis       RedisConfig
 	JWT         JWTConfig
 	Services    ServicesConfig
+	Monitoring  MonitoringConfig
+	Broadcast   BroadcastConfig
+	Registry    RegistryConfig
+	Token       TokenConfig
+	Tiers       TiersConfig
+	SIWE        SIWEConfig
+	OIDC        OIDCConfig
+	MFA         MFAConfig
+	Lockout     LockoutConfig
+	Email       EmailConfig
+	GRPC        GRPCConfig
+	NodeEvents  NodeEventsConfig `mapstructure:"node_events"`
 }
 
 type ServerConfig struct {
@@ -39,8 +51,15 @@ type RedisConfig struct {
 }
 
 type JWTConfig struct {
-	Secret        string
-	ExpiryMinutes int
+	Secret             string
+	ExpiryMinutes      int `mapstructure:"expiry_minutes"`
+	RefreshExpiryHours int `mapstructure:"refresh_expiry_hours"`
+	Algorithm          string
+	Issuer             string
+	Audience           string
+	ExtraAudiences     []string `mapstructure:"extra_audiences"`
+	RotationHours      int      `mapstructure:"rotation_hours"`
+	KeyReloadSeconds   int      `mapstructure:"key_reload_seconds"`
 }
 
 type ServicesConfig struct {
@@ -54,6 +73,134 @@ type ServiceConfig struct {
 	Port int
 }
 
+type MonitoringConfig struct {
+	ConsensusPollSeconds  int `mapstructure:"consensus_poll_seconds"`
+	GasPollSeconds        int `mapstructure:"gas_poll_seconds"`
+	RequestTimeoutSeconds int `mapstructure:"request_timeout_seconds"`
+	// DriftPollSeconds is how often running nodes are compared with their
+	// stored config; DriftAlerts raises a node alert on critical drift
+	DriftPollSeconds int  `mapstructure:"drift_poll_seconds"`
+	DriftAlerts      bool `mapstructure:"drift_alerts"`
+}
+
+type BroadcastConfig struct {
+	DefaultFanout      int    `mapstructure:"default_fanout"`
+	PollSeconds        int    `mapstructure:"poll_seconds"`
+	DropTimeoutSeconds int    `mapstructure:"drop_timeout_seconds"`
+	FinalityDepth      uint64 `mapstructure:"finality_depth"`
+	WebhookSecret      string `mapstructure:"webhook_secret"`
+	// WebhookAllowedHosts limits webhooks to these hosts when set; entries
+	// starting with a dot also allow their subdomains
+	WebhookAllowedHosts []string `mapstructure:"webhook_allowed_hosts"`
+}
+
+type RegistryConfig struct {
+	RPCURL          string `mapstructure:"rpc_url"`
+	ContractAddress string `mapstructure:"contract_address"`
+	StartBlock      uint64 `mapstructure:"start_block"`
+	Confirmations   uint64 `mapstructure:"confirmations"`
+	PollSeconds     int    `mapstructure:"poll_seconds"`
+	// TrustedOwners are the addresses whose registered nodes are mirrored;
+	// anyone can register a node, so other owners' nodes are ignored
+	TrustedOwners []string `mapstructure:"trusted_owners"`
+	Publisher     RegistryPublisherConfig
+}
+
+type RegistryPublisherConfig struct {
+	Enabled               bool
+	KeystorePath          string  `mapstructure:"keystore_path"`
+	KeystorePassword      string  `mapstructure:"keystore_password"`
+	PollSeconds           int     `mapstructure:"poll_seconds"`
+	MaxTxPerCycle         int     `mapstructure:"max_tx_per_cycle"`
+	MinUpdateSeconds      int     `mapstructure:"min_update_seconds"`
+	BlockDelta            uint64  `mapstructure:"block_delta"`
+	ReceiptTimeoutSeconds int     `mapstructure:"receipt_timeout_seconds"`
+	MaxFeeBumps           int     `mapstructure:"max_fee_bumps"`
+	MaxFeeGwei            float64 `mapstructure:"max_fee_gwei"`
+}
+
+type TokenConfig struct {
+	RPCURL          string `mapstructure:"rpc_url"`
+	ContractAddress string `mapstructure:"contract_address"`
+	CacheTTLSeconds int    `mapstructure:"cache_ttl_seconds"`
+	CurvePoints     int    `mapstructure:"curve_points"`
+}
+
+type TiersConfig struct {
+	RefreshSeconds        int    `mapstructure:"refresh_seconds"`
+	DowngradeGraceSeconds int    `mapstructure:"downgrade_grace_seconds"`
+	ChallengeTTLSeconds   int    `mapstructure:"challenge_ttl_seconds"`
+	BasicMinTokens        uint64 `mapstructure:"basic_min_tokens"`
+	ProMinTokens          uint64 `mapstructure:"pro_min_tokens"`
+	EnterpriseMinTokens   uint64 `mapstructure:"enterprise_min_tokens"`
+}
+
+type SIWEConfig struct {
+	Domain          string
+	ChainIDs        []int64 `mapstructure:"chain_ids"`
+	NonceTTLSeconds int     `mapstructure:"nonce_ttl_seconds"`
+}
+
+type OIDCConfig struct {
+	IssuerURL            string `mapstructure:"issuer_url"`
+	ClientID             string `mapstructure:"client_id"`
+	ClientSecret         string `mapstructure:"client_secret"`
+	RedirectURL          string `mapstructure:"redirect_url"`
+	Scopes               []string
+	UsernameClaim        string   `mapstructure:"username_claim"`
+	GroupsClaim          string   `mapstructure:"groups_claim"`
+	AdminGroups          []string `mapstructure:"admin_groups"`
+	AllowedGroups        []string `mapstructure:"allowed_groups"`
+	StateTTLSeconds      int      `mapstructure:"state_ttl_seconds"`
+	PostLoginRedirectURL string   `mapstructure:"post_login_redirect_url"`
+}
+
+type MFAConfig struct {
+	Issuer              string
+	ChallengeTTLSeconds int `mapstructure:"challenge_ttl_seconds"`
+	MaxAttempts         int `mapstructure:"max_attempts"`
+}
+
+type LockoutConfig struct {
+	WindowSeconds    int `mapstructure:"window_seconds"`
+	BackoffAfter     int `mapstructure:"backoff_after"`
+	BaseDelaySeconds int `mapstructure:"base_delay_seconds"`
+	MaxDelaySeconds  int `mapstructure:"max_delay_seconds"`
+	UserMaxFailures  int `mapstructure:"user_max_failures"`
+	IPMaxFailures    int `mapstructure:"ip_max_failures"`
+	LockoutSeconds   int `mapstructure:"lockout_seconds"`
+	StuffingAccounts int `mapstructure:"stuffing_accounts"`
+}
+
+type EmailConfig struct {
+	Driver               string
+	From                 string
+	FileDir              string `mapstructure:"file_dir"`
+	LinkBaseURL          string `mapstructure:"link_base_url"`
+	VerificationTTLHours int    `mapstructure:"verification_ttl_hours"`
+	ResetTTLMinutes      int    `mapstructure:"reset_ttl_minutes"`
+	RequireVerified      bool   `mapstructure:"require_verified"`
+	SMTP                 SMTPConfig
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+type GRPCConfig struct {
+	Enabled    bool
+	Port       int
+	Reflection bool
+}
+
+type NodeEventsConfig struct {
+	PollIntervalSeconds int   `mapstructure:"poll_interval_seconds"`
+	StreamLength        int64 `mapstructure:"stream_length"`
+}
+
 func LoadConfig() (*Config, error) {
 	// Set default config values
 	viper.SetDefault("environment", "development")
@@ -61,6 +208,67 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("server.port", 8000)
 	viper.SetDefault("database.sslmode", "disable")
 	viper.SetDefault("jwt.expiry_minutes", 60)
+	viper.SetDefault("jwt.refresh_expiry_hours", 720)
+	viper.SetDefault("jwt.algorithm", "RS256")
+	viper.SetDefault("jwt.issuer", "twist-api-gateway")
+	viper.SetDefault("jwt.audience", "twist-api-gateway")
+	viper.SetDefault("jwt.extra_audiences", []string{"twist-core-engine", "twist-analytics"})
+	viper.SetDefault("jwt.rotation_hours", 720)
+	viper.SetDefault("jwt.key_reload_seconds", 60)
+	viper.SetDefault("monitoring.consensus_poll_seconds", 30)
+	viper.SetDefault("monitoring.gas_poll_seconds", 15)
+	viper.SetDefault("monitoring.request_timeout_seconds", 10)
+	viper.SetDefault("monitoring.drift_poll_seconds", 300)
+	viper.SetDefault("monitoring.drift_alerts", false)
+	viper.SetDefault("broadcast.default_fanout", 3)
+	viper.SetDefault("broadcast.poll_seconds", 12)
+	viper.SetDefault("broadcast.drop_timeout_seconds", 1800)
+	viper.SetDefault("broadcast.finality_depth", 64)
+	viper.SetDefault("registry.confirmations", 12)
+	viper.SetDefault("registry.poll_seconds", 15)
+	viper.SetDefault("registry.publisher.poll_seconds", 60)
+	viper.SetDefault("registry.publisher.max_tx_per_cycle", 10)
+	viper.SetDefault("registry.publisher.min_update_seconds", 900)
+	viper.SetDefault("registry.publisher.block_delta", 1000)
+	viper.SetDefault("registry.publisher.receipt_timeout_seconds", 120)
+	viper.SetDefault("registry.publisher.max_fee_bumps", 3)
+	viper.SetDefault("token.cache_ttl_seconds", 300)
+	viper.SetDefault("token.curve_points", 24)
+	viper.SetDefault("tiers.refresh_seconds", 300)
+	viper.SetDefault("tiers.downgrade_grace_seconds", 259200)
+	viper.SetDefault("tiers.challenge_ttl_seconds", 300)
+	viper.SetDefault("tiers.basic_min_tokens", 1000)
+	viper.SetDefault("tiers.pro_min_tokens", 10000)
+	viper.SetDefault("tiers.enterprise_min_tokens", 100000)
+	viper.SetDefault("siwe.chain_ids", []int64{1})
+	viper.SetDefault("siwe.nonce_ttl_seconds", 300)
+	viper.SetDefault("oidc.scopes", []string{"openid", "profile", "email"})
+	viper.SetDefault("oidc.username_claim", "preferred_username")
+	viper.SetDefault("oidc.groups_claim", "groups")
+	viper.SetDefault("oidc.state_ttl_seconds", 600)
+	viper.SetDefault("mfa.issuer", "Twist")
+	viper.SetDefault("mfa.challenge_ttl_seconds", 300)
+	viper.SetDefault("mfa.max_attempts", 5)
+	viper.SetDefault("lockout.window_seconds", 900)
+	viper.SetDefault("lockout.backoff_after", 3)
+	viper.SetDefault("lockout.base_delay_seconds", 1)
+	viper.SetDefault("lockout.max_delay_seconds", 300)
+	viper.SetDefault("lockout.user_max_failures", 10)
+	viper.SetDefault("lockout.ip_max_failures", 100)
+	viper.SetDefault("lockout.lockout_seconds", 900)
+	viper.SetDefault("lockout.stuffing_accounts", 20)
+	viper.SetDefault("email.driver", "log")
+	viper.SetDefault("email.from", "Twist <no-reply@twist.local>")
+	viper.SetDefault("email.file_dir", "./mail")
+	viper.SetDefault("email.link_base_url", "http://localhost:3000")
+	viper.SetDefault("email.verification_ttl_hours", 48)
+	viper.SetDefault("email.reset_ttl_minutes", 60)
+	viper.SetDefault("email.smtp.port", 587)
+	viper.SetDefault("grpc.enabled", true)
+	viper.SetDefault("grpc.port", 9000)
+	viper.SetDefault("grpc.reflection", true)
+	viper.SetDefault("node_events.poll_interval_seconds", 5)
+	viper.SetDefault("node_events.stream_length", 10000)
 
 	// Read configuration from environment variables
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
@@ -88,6 +296,13 @@ func LoadConfig() (*Config, error) {
 	// JWT
 	mapEnvToConfig("JWT_SECRET", "jwt.secret")
 	mapEnvToConfig("JWT_EXPIRY_MINUTES", "jwt.expiry_minutes")
+	mapEnvToConfig("JWT_REFRESH_EXPIRY_HOURS", "jwt.refresh_expiry_hours")
+	mapEnvToConfig("JWT_ALGORITHM", "jwt.algorithm")
+	mapEnvToConfig("JWT_ISSUER", "jwt.issuer")
+	mapEnvToConfig("JWT_AUDIENCE", "jwt.audience")
+	mapEnvToConfig("JWT_EXTRA_AUDIENCES", "jwt.extra_audiences")
+	mapEnvToConfig("JWT_ROTATION_HOURS", "jwt.rotation_hours")
+	mapEnvToConfig("JWT_KEY_RELOAD_SECONDS", "jwt.key_reload_seconds")
 
 	// Services
 	mapEnvToConfig("CORE_ENGINE_HOST", "services.core_engine.host")
@@ -97,10 +312,115 @@ func LoadConfig() (*Config, error) {
 	mapEnvToConfig("ANALYTICS_HOST", "services.analytics.host")
 	mapEnvToConfig("ANALYTICS_PORT", "services.analytics.port")
 
+	// Monitoring
+	mapEnvToConfig("MONITORING_CONSENSUS_POLL_SECONDS", "monitoring.consensus_poll_seconds")
+	mapEnvToConfig("MONITORING_GAS_POLL_SECONDS", "monitoring.gas_poll_seconds")
+	mapEnvToConfig("MONITORING_REQUEST_TIMEOUT_SECONDS", "monitoring.request_timeout_seconds")
+	mapEnvToConfig("MONITORING_DRIFT_POLL_SECONDS", "monitoring.drift_poll_seconds")
+	mapEnvToConfig("MONITORING_DRIFT_ALERTS", "monitoring.drift_alerts")
+
+	// Transaction broadcast
+	mapEnvToConfig("BROADCAST_DEFAULT_FANOUT", "broadcast.default_fanout")
+	mapEnvToConfig("BROADCAST_POLL_SECONDS", "broadcast.poll_seconds")
+	mapEnvToConfig("BROADCAST_DROP_TIMEOUT_SECONDS", "broadcast.drop_timeout_seconds")
+	mapEnvToConfig("BROADCAST_FINALITY_DEPTH", "broadcast.finality_depth")
+	mapEnvToConfig("BROADCAST_WEBHOOK_SECRET", "broadcast.webhook_secret")
+	mapEnvToConfig("BROADCAST_WEBHOOK_ALLOWED_HOSTS", "broadcast.webhook_allowed_hosts")
+
+	// On-chain node registry
+	mapEnvToConfig("REGISTRY_RPC_URL", "registry.rpc_url")
+	mapEnvToConfig("REGISTRY_CONTRACT_ADDRESS", "registry.contract_address")
+	mapEnvToConfig("REGISTRY_START_BLOCK", "registry.start_block")
+	mapEnvToConfig("REGISTRY_CONFIRMATIONS", "registry.confirmations")
+	mapEnvToConfig("REGISTRY_POLL_SECONDS", "registry.poll_seconds")
+	mapEnvToConfig("REGISTRY_TRUSTED_OWNERS", "registry.trusted_owners")
+	mapEnvToConfig("REGISTRY_PUBLISHER_ENABLED", "registry.publisher.enabled")
+	mapEnvToConfig("REGISTRY_PUBLISHER_KEYSTORE_PATH", "registry.publisher.keystore_path")
+	mapEnvToConfig("REGISTRY_PUBLISHER_KEYSTORE_PASSWORD", "registry.publisher.keystore_password")
+	mapEnvToConfig("REGISTRY_PUBLISHER_POLL_SECONDS", "registry.publisher.poll_seconds")
+	mapEnvToConfig("REGISTRY_PUBLISHER_MAX_TX_PER_CYCLE", "registry.publisher.max_tx_per_cycle")
+	mapEnvToConfig("REGISTRY_PUBLISHER_MIN_UPDATE_SECONDS", "registry.publisher.min_update_seconds")
+	mapEnvToConfig("REGISTRY_PUBLISHER_BLOCK_DELTA", "registry.publisher.block_delta")
+	mapEnvToConfig("REGISTRY_PUBLISHER_RECEIPT_TIMEOUT_SECONDS", "registry.publisher.receipt_timeout_seconds")
+	mapEnvToConfig("REGISTRY_PUBLISHER_MAX_FEE_BUMPS", "registry.publisher.max_fee_bumps")
+	mapEnvToConfig("REGISTRY_PUBLISHER_MAX_FEE_GWEI", "registry.publisher.max_fee_gwei")
+
+	// TWIST token
+	mapEnvToConfig("TOKEN_RPC_URL", "token.rpc_url")
+	mapEnvToConfig("TOKEN_CONTRACT_ADDRESS", "token.contract_address")
+	mapEnvToConfig("TOKEN_CACHE_TTL_SECONDS", "token.cache_ttl_seconds")
+	mapEnvToConfig("TOKEN_CURVE_POINTS", "token.curve_points")
+
+	// Token-gated API tiers
+	mapEnvToConfig("TIERS_REFRESH_SECONDS", "tiers.refresh_seconds")
+	mapEnvToConfig("TIERS_DOWNGRADE_GRACE_SECONDS", "tiers.downgrade_grace_seconds")
+	mapEnvToConfig("TIERS_CHALLENGE_TTL_SECONDS", "tiers.challenge_ttl_seconds")
+	mapEnvToConfig("TIERS_BASIC_MIN_TOKENS", "tiers.basic_min_tokens")
+	mapEnvToConfig("TIERS_PRO_MIN_TOKENS", "tiers.pro_min_tokens")
+	mapEnvToConfig("TIERS_ENTERPRISE_MIN_TOKENS", "tiers.enterprise_min_tokens")
+
+	// Sign-In with Ethereum
+	mapEnvToConfig("SIWE_DOMAIN", "siwe.domain")
+	mapEnvToConfig("SIWE_CHAIN_IDS", "siwe.chain_ids")
+	mapEnvToConfig("SIWE_NONCE_TTL_SECONDS", "siwe.nonce_ttl_seconds")
+
+	// OpenID Connect single sign-on
+	mapEnvToConfig("OIDC_ISSUER_URL", "oidc.issuer_url")
+	mapEnvToConfig("OIDC_CLIENT_ID", "oidc.client_id")
+	mapEnvToConfig("OIDC_CLIENT_SECRET", "oidc.client_secret")
+	mapEnvToConfig("OIDC_REDIRECT_URL", "oidc.redirect_url")
+	mapEnvToConfig("OIDC_SCOPES", "oidc.scopes")
+	mapEnvToConfig("OIDC_USERNAME_CLAIM", "oidc.username_claim")
+	mapEnvToConfig("OIDC_GROUPS_CLAIM", "oidc.groups_claim")
+	mapEnvToConfig("OIDC_ADMIN_GROUPS", "oidc.admin_groups")
+	mapEnvToConfig("OIDC_ALLOWED_GROUPS", "oidc.allowed_groups")
+	mapEnvToConfig("OIDC_STATE_TTL_SECONDS", "oidc.state_ttl_seconds")
+	mapEnvToConfig("OIDC_POST_LOGIN_REDIRECT_URL", "oidc.post_login_redirect_url")
+
+	// Multi-factor authentication
+	mapEnvToConfig("MFA_ISSUER", "mfa.issuer")
+	mapEnvToConfig("MFA_CHALLENGE_TTL_SECONDS", "mfa.challenge_ttl_seconds")
+	mapEnvToConfig("MFA_MAX_ATTEMPTS", "mfa.max_attempts")
+
+	// Brute-force protection
+	mapEnvToConfig("LOCKOUT_WINDOW_SECONDS", "lockout.window_seconds")
+	mapEnvToConfig("LOCKOUT_BACKOFF_AFTER", "lockout.backoff_after")
+	mapEnvToConfig("LOCKOUT_BASE_DELAY_SECONDS", "lockout.base_delay_seconds")
+	mapEnvToConfig("LOCKOUT_MAX_DELAY_SECONDS", "lockout.max_delay_seconds")
+	mapEnvToConfig("LOCKOUT_USER_MAX_FAILURES", "lockout.user_max_failures")
+	mapEnvToConfig("LOCKOUT_IP_MAX_FAILURES", "lockout.ip_max_failures")
+	mapEnvToConfig("LOCKOUT_SECONDS", "lockout.lockout_seconds")
+	mapEnvToConfig("LOCKOUT_STUFFING_ACCOUNTS", "lockout.stuffing_accounts")
+
+	// Email
+	mapEnvToConfig("EMAIL_DRIVER", "email.driver")
+	mapEnvToConfig("EMAIL_FROM", "email.from")
+	mapEnvToConfig("EMAIL_FILE_DIR", "email.file_dir")
+	mapEnvToConfig("EMAIL_LINK_BASE_URL", "email.link_base_url")
+	mapEnvToConfig("EMAIL_VERIFICATION_TTL_HOURS", "email.verification_ttl_hours")
+	mapEnvToConfig("EMAIL_RESET_TTL_MINUTES", "email.reset_ttl_minutes")
+	mapEnvToConfig("EMAIL_REQUIRE_VERIFIED", "email.require_verified")
+	mapEnvToConfig("SMTP_HOST", "email.smtp.host")
+	mapEnvToConfig("SMTP_PORT", "email.smtp.port")
+	mapEnvToConfig("SMTP_USERNAME", "email.smtp.username")
+	mapEnvToConfig("SMTP_PASSWORD", "email.smtp.password")
+
+	// gRPC
+	mapEnvToConfig("GRPC_ENABLED", "grpc.enabled")
+	mapEnvToConfig("GRPC_PORT", "grpc.port")
+	mapEnvToConfig("GRPC_REFLECTION", "grpc.reflection")
+
+	// Node events
+	mapEnvToConfig("NODE_EVENTS_POLL_INTERVAL_SECONDS", "node_events.poll_interval_seconds")
+	mapEnvToConfig("NODE_EVENTS_STREAM_LENGTH", "node_events.stream_length")
+
 	// Validate required fields
 	if err := validateRequiredConfig(); err != nil {
 		return nil, err
 	}
+	if err := validatePositive(); err != nil {
+		return nil, err
+	}
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -139,3 +459,46 @@ func validateRequiredConfig() error {
 
 	return nil
 }
+
+// validatePositive rejects non-positive intervals and durations, which would
+// make background loops panic on start or redo their work on every tick.
+// Settings of features that aren't configured are not checked.
+func validatePositive() error {
+	var invalidFields []string
+	for _, field := range positiveFields() {
+		if viper.GetInt(field) <= 0 {
+			invalidFields = append(invalidFields, field)
+		}
+	}
+
+	if len(invalidFields) > 0 {
+		return fmt.Errorf("configuration must be a positive number: %s", strings.Join(invalidFields, ", "))
+	}
+
+	return nil
+}
+
+// positiveFields lists the settings validatePositive checks
+func positiveFields() []string {
+	fields := []string{
+		"monitoring.consensus_poll_seconds",
+		"monitoring.request_timeout_seconds",
+		"monitoring.gas_poll_seconds",
+		"broadcast.poll_seconds",
+		// A key would otherwise be rotated on every reload
+		"jwt.rotation_hours",
+		"jwt.key_reload_seconds",
+		"node_events.poll_interval_seconds",
+		"monitoring.drift_poll_seconds",
+	}
+	if viper.GetString("registry.rpc_url") != "" && viper.GetString("registry.contract_address") != "" {
+		fields = append(fields, "registry.poll_seconds")
+		if viper.GetBool("registry.publisher.enabled") {
+			fields = append(fields, "registry.publisher.poll_seconds")
+		}
+	}
+	if viper.GetString("token.rpc_url") != "" && viper.GetString("token.contract_address") != "" {
+		fields = append(fields, "tiers.refresh_seconds")
+	}
+	return fields
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/twist/api-gateway/internal/accounttokens"
+	"github.com/twist/api-gateway/internal/lockout"
+	"github.com/twist/api-gateway/internal/mailer"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// mailSendTimeout bounds sending one email in the background
+	mailSendTimeout = 30 * time.Second
+
+	// mailResendInterval is how often the same verification or reset email
+	// can be requested
+	mailResendInterval = time.Minute
+)
+
+func (h *Handler) accountTokens() *accounttokens.Tokens {
+	return accounttokens.NewTokens(h.db, h.config.JWT.Secret)
+}
+
+// sendMail sends msg in the background so the response does not wait on
+// the mail server or reveal whether a message was sent
+func (h *Handler) sendMail(msg mailer.Message) {
+	if h.mailer == nil {
+		h.logger.Warn("No mailer configured, dropping email", zap.String("subject", msg.Subject))
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailSendTimeout)
+		defer cancel()
+		if err := h.mailer.Send(ctx, msg); err != nil {
+			h.logger.Error("Failed to send email", zap.String("subject", msg.Subject), zap.Error(err))
+		}
+	}()
+}
+
+// accountLink returns the frontend link that submits token at path
+func (h *Handler) accountLink(path, token string) string {
+	return strings.TrimRight(h.config.Email.LinkBaseURL, "/") + path + "?" + url.Values{"token": {token}}.Encode()
+}
+
+// sendVerificationEmail mails user a link to verify their current email address
+func (h *Handler) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	ttl := time.Duration(h.config.Email.VerificationTTLHours) * time.Hour
+	token, err := h.accountTokens().Issue(ctx, user.ID, accounttokens.PurposeVerifyEmail, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	h.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm this email address for your Twist account by opening the link below:\n\n%s\n\n"+
+			"The link expires in %s. If you didn't request this, you can ignore this email.\n",
+			user.Username, h.accountLink("/verify-email", token), ttl),
+	})
+	return nil
+}
+
+// sendPasswordResetEmail mails user a single-use link to choose a new password
+func (h *Handler) sendPasswordResetEmail(ctx context.Context, user *models.User) error {
+	ttl := time.Duration(h.config.Email.ResetTTLMinutes) * time.Minute
+	token, err := h.accountTokens().Issue(ctx, user.ID, accounttokens.PurposePasswordReset, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	h.sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nA password reset was requested for your Twist account. Choose a new password here:\n\n%s\n\n"+
+			"The link expires in %s and can be used once. If you didn't request this, you can ignore this email.\n",
+			user.Username, h.accountLink("/reset-password", token), ttl),
+	})
+	return nil
+}
+
+// throttleMail reports whether an email identified by key may be sent now,
+// allowing one per mailResendInterval
+func (h *Handler) throttleMail(ctx context.Context, key string) (bool, error) {
+	return h.redisClient.SetNX(ctx, "mail:throttle:"+key, "1", mailResendInterval).Result()
+}
+
+// GetCurrentUser handles returning the authenticated user
+func (h *Handler) GetCurrentUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	user, err := scanUser(h.db.QueryRow(c.Request.Context(), `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("User not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load user", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load user"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(user.ToResponse(), ""))
+}
+
+// UpdateCurrentUser handles changing the authenticated user's email address
+// or password. A new email address must be verified again; a new password
+// ends the user's other sessions.
+func (h *Handler) UpdateCurrentUser(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := scanUser(h.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("User not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load user", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to update user"))
+		return
+	}
+
+	emailChanged := req.Email != nil && !strings.EqualFold(*req.Email, user.Email)
+	if req.Password != nil && user.Password != "" {
+		if req.CurrentPassword == nil ||
+			bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(*req.CurrentPassword)) != nil {
+			c.JSON(http.StatusUnauthorized, models.NewErrorResponse("current_password is incorrect"))
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	if emailChanged {
+		user.Email = *req.Email
+		user.EmailVerifiedAt = nil
+	}
+	if req.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			h.logger.Error("Failed to hash password", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to update user"))
+			return
+		}
+		user.Password = string(hash)
+		user.PasswordResetRequired = false
+	}
+	user.UpdatedAt = now
+
+	_, err = h.db.Exec(ctx, `
+		UPDATE users SET email = NULLIF($1, ''), password_hash = NULLIF($2, ''), email_verified_at = $3,
+			password_reset_required = password_reset_required AND NOT $4, updated_at = $5
+		WHERE id = $6`,
+		user.Email, user.Password, user.EmailVerifiedAt, req.Password != nil, user.UpdatedAt, user.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Email is already taken"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to update user", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to update user"))
+		return
+	}
+
+	if emailChanged {
+		if err := h.sendVerificationEmail(ctx, user); err != nil {
+			h.logger.Error("Failed to send verification email", zap.String("user_id", userID.String()), zap.Error(err))
+		}
+	}
+	if req.Password != nil {
+		sessionID, _ := currentSessionID(c)
+		if _, err := h.sessionStore().RevokeOthers(ctx, userID, sessionID, "password changed"); err != nil {
+			h.logger.Error("Failed to revoke sessions after password change", zap.String("user_id", userID.String()), zap.Error(err))
+		}
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(user.ToResponse(), "User updated"))
+}
+
+// ResendVerificationEmail handles mailing a new verification link for the
+// authenticated user's email address
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := scanUser(h.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if err != nil {
+		h.logger.Error("Failed to load user", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to send verification email"))
+		return
+	}
+	if user.Email == "" {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Your account has no email address"))
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Email address is already verified"))
+		return
+	}
+
+	allowed, err := h.throttleMail(ctx, "verify:"+userID.String())
+	if err != nil {
+		h.logger.Error("Failed to throttle verification email", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to send verification email"))
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusTooManyRequests, models.NewErrorResponse("A verification email was sent recently, try again shortly"))
+		return
+	}
+
+	if err := h.sendVerificationEmail(ctx, user); err != nil {
+		h.logger.Error("Failed to send verification email", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to send verification email"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.NewSuccessResponse(nil, "Verification email sent"))
+}
+
+// VerifyEmail handles confirming an email address with a mailed token
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	claim, err := h.accountTokens().Consume(ctx, req.Token, accounttokens.PurposeVerifyEmail)
+	if errors.Is(err, accounttokens.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to consume verification token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to verify email"))
+		return
+	}
+
+	// The token only verifies the address it was sent to
+	now := time.Now().UTC()
+	tag, err := h.db.Exec(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, $1), updated_at = $1
+		WHERE id = $2 AND email = $3`,
+		now, claim.UserID, claim.Email)
+	if err != nil {
+		h.logger.Error("Failed to mark email verified", zap.String("user_id", claim.UserID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to verify email"))
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Your email address has changed since this link was sent"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "Email address verified"))
+}
+
+// ForgotPassword handles mailing a password reset link. It responds the same
+// whether or not the address belongs to an account.
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	accepted := func() {
+		c.JSON(http.StatusAccepted, models.NewSuccessResponse(nil,
+			"If an account with a password uses this address, a reset link has been sent"))
+	}
+
+	ctx := c.Request.Context()
+	allowed, err := h.throttleMail(ctx, "reset:"+strings.ToLower(req.Email))
+	if err != nil {
+		h.logger.Error("Failed to throttle password reset email", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to send reset email"))
+		return
+	}
+	if !allowed {
+		accepted()
+		return
+	}
+
+	// Accounts without a password sign in through SSO or a wallet and
+	// can't gain one this way
+	user, err := scanUser(h.db.QueryRow(ctx, `
+		SELECT `+userColumns+` FROM users
+		WHERE lower(email) = lower($1) AND password_hash IS NOT NULL`, req.Email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		accepted()
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load user for password reset", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to send reset email"))
+		return
+	}
+
+	if err := h.sendPasswordResetEmail(ctx, user); err != nil {
+		h.logger.Error("Failed to send password reset email", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to send reset email"))
+		return
+	}
+	accepted()
+}
+
+// ResetPassword handles setting a new password with a mailed token. All of
+// the user's sessions end and any login lockout is cleared.
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	claim, err := h.accountTokens().Consume(ctx, req.Token, accounttokens.PurposePasswordReset)
+	if errors.Is(err, accounttokens.ErrInvalidToken) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to consume password reset token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to reset password"))
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		h.logger.Error("Failed to hash password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to reset password"))
+		return
+	}
+
+	// Receiving the link proves control of the address, so it counts as verified
+	now := time.Now().UTC()
+	var username string
+	err = h.db.QueryRow(ctx, `
+		UPDATE users SET password_hash = $1, email_verified_at = COALESCE(email_verified_at, $2),
+			password_reset_required = FALSE, updated_at = $2
+		WHERE id = $3 AND email = $4
+		RETURNING username`,
+		string(hash), now, claim.UserID, claim.Email,
+	).Scan(&username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(accounttokens.ErrInvalidToken.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to reset password", zap.String("user_id", claim.UserID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to reset password"))
+		return
+	}
+
+	if _, err := h.sessionStore().RevokeAll(ctx, claim.UserID, "password reset"); err != nil {
+		h.logger.Error("Failed to revoke sessions after password reset", zap.String("user_id", claim.UserID.String()), zap.Error(err))
+	}
+	if h.guard != nil {
+		if err := h.guard.Unlock(ctx, lockout.ScopeUser, username); err != nil {
+			h.logger.Warn("Failed to clear lockout after password reset", zap.String("user_id", claim.UserID.String()), zap.Error(err))
+		}
+	}
+
+	h.logger.Info("Password reset", zap.String("user_id", claim.UserID.String()))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "Password reset, log in with your new password"))
+}
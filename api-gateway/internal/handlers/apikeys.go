@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/apikeys"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) apiKeyStore() *apikeys.Store {
+	return apikeys.NewStore(h.db)
+}
+
+// ListAPIKeys handles listing the current user's API keys
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	keys, err := h.apiKeyStore().List(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list API keys", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list API keys"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(keys, ""))
+}
+
+// CreateAPIKey handles issuing an API key for the current user. The key is
+// only shown in this response, and only satisfies roles that require MFA when
+// the current session passed MFA.
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("expires_at must be in the future"))
+		return
+	}
+
+	key, err := h.apiKeyStore().Create(c.Request.Context(), userID, req.Name, req.ExpiresAt, c.GetBool("mfa"))
+	if err != nil {
+		h.logger.Error("Failed to create API key", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to create API key"))
+		return
+	}
+
+	h.auditRecorder().Record(c.Request.Context(), models.AuditEvent{
+		Action:     models.AuditAPIKeyCreated,
+		ActorID:    audit.UserID(userID),
+		Actor:      c.GetString("username"),
+		TargetType: "api_key",
+		TargetID:   key.ID.String(),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Metadata:   map[string]interface{}{"name": key.Name},
+	})
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(key, "API key created, store it now as it won't be shown again"))
+}
+
+// DeleteAPIKey handles deleting one of the current user's API keys
+func (h *Handler) DeleteAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid API key ID"))
+		return
+	}
+
+	err = h.apiKeyStore().Delete(c.Request.Context(), userID, id)
+	if errors.Is(err, apikeys.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to delete API key", zap.String("id", id.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to delete API key"))
+		return
+	}
+
+	h.auditRecorder().Record(c.Request.Context(), models.AuditEvent{
+		Action:     models.AuditAPIKeyDeleted,
+		ActorID:    audit.UserID(userID),
+		Actor:      c.GetString("username"),
+		TargetType: "api_key",
+		TargetID:   id.String(),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "API key deleted"))
+}
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/lockout"
+	"github.com/twist/api-gateway/internal/middleware"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/sessions"
+	"github.com/twist/api-gateway/internal/tiers"
+	"github.com/twist/api-gateway/pkg/ethsig"
+	"github.com/twist/api-gateway/pkg/siwe"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// siweClockSkew is how far client and server clocks may disagree when
+// checking a SIWE message's timestamps
+const siweClockSkew = time.Minute
+
+// userColumns is the column list scanned by scanUser
+const userColumns = `id, username, COALESCE(email, ''), COALESCE(password_hash, ''), role, created_at, updated_at, last_login, email_verified_at,
+	disabled_at, disabled_reason, password_reset_required`
+
+func scanUser(row pgx.Row) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.LastLogin,
+		&user.EmailVerifiedAt,
+		&user.DisabledAt,
+		&user.DisabledReason,
+		&user.PasswordResetRequired,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func siweNonceKey(nonce string) string {
+	return "siwe:nonce:" + nonce
+}
+
+func (h *Handler) sessionStore() *sessions.Store {
+	return sessions.NewStore(
+		h.db,
+		sessions.NewDenylist(h.redisClient),
+		time.Duration(h.config.JWT.RefreshExpiryHours)*time.Hour,
+	)
+}
+
+// issueToken signs the JWT accepted by middleware.Auth for user's session
+// and returns it with its jti and expiry. mfa records whether the session
+// was established with a second factor; actor is set when an admin is
+// impersonating user.
+func (h *Handler) issueToken(user *models.User, sessionID uuid.UUID, mfa bool, actor *middleware.ActorClaim) (string, string, time.Time, error) {
+	jti := uuid.NewString()
+	claims := &middleware.JWTClaims{
+		UserID:    user.ID.String(),
+		Username:  user.Username,
+		Role:      string(user.Role),
+		SessionID: sessionID.String(),
+		MFA:       mfa,
+		Actor:     actor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:      jti,
+			Subject: user.ID.String(),
+		},
+	}
+
+	token, expiresAt, err := h.tokens.Sign(claims)
+	return token, jti, expiresAt, err
+}
+
+// sessionTokens issues an access token for user's session and returns it
+// with the session's refresh token
+func (h *Handler) sessionTokens(c *gin.Context, user *models.User, sessionID uuid.UUID, refreshToken string, mfa bool) (*models.LoginResponse, error) {
+	token, jti, expiresAt, err := h.issueToken(user, sessionID, mfa, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	if err := h.sessionStore().SetAccessToken(c.Request.Context(), sessionID, jti, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to record access token: %w", err)
+	}
+
+	return &models.LoginResponse{
+		User:         user.ToResponse(),
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// respondWithTokens responds with new tokens for user's rotated session
+func (h *Handler) respondWithTokens(c *gin.Context, user *models.User, rotation *sessions.Rotation, status int, message string) {
+	response, err := h.sessionTokens(c, user, rotation.SessionID, rotation.RefreshToken, rotation.MFA)
+	if errors.Is(err, sessions.ErrSessionRevoked) {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to issue tokens", zap.String("session_id", rotation.SessionID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to issue token"))
+		return
+	}
+
+	c.JSON(status, models.NewSuccessResponse(response, message))
+}
+
+// startSession records the login and starts a session for user. mfa records
+// whether the login presented a second factor.
+func (h *Handler) startSession(c *gin.Context, user *models.User, mfa bool) (*models.LoginResponse, error) {
+	now := time.Now().UTC()
+	if _, err := h.db.Exec(c.Request.Context(), `UPDATE users SET last_login = $1 WHERE id = $2`, now, user.ID); err != nil {
+		h.logger.Warn("Failed to record login", zap.String("user_id", user.ID.String()), zap.Error(err))
+	} else {
+		user.LastLogin = &now
+	}
+
+	session, refreshToken, err := h.sessionStore().Create(c.Request.Context(), user.ID, c.Request.UserAgent(), c.ClientIP(), mfa)
+	if err != nil {
+		return nil, err
+	}
+	return h.sessionTokens(c, user, session.ID, refreshToken, mfa)
+}
+
+// loginChallenge returns the MFA challenge user must complete before a
+// session is started, or nil when user has no second factor. account is the
+// lockout account of the first factor, cleared once the challenge completes.
+func (h *Handler) loginChallenge(c *gin.Context, user *models.User, account string) (*models.MFAChallenge, error) {
+	if h.mfa == nil {
+		return nil, nil
+	}
+	enabled, err := h.mfa.Enabled(c.Request.Context(), user.ID)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	return h.mfa.CreateChallenge(c.Request.Context(), user.ID, account)
+}
+
+// allowLogin responds with 403 and returns false when user is disabled
+func (h *Handler) allowLogin(c *gin.Context, user *models.User) bool {
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse("This account has been disabled"))
+		return false
+	}
+	return true
+}
+
+// completeLogin starts a session for user and responds with its tokens, or
+// with an MFA challenge when user has a second factor. The failures of
+// attempt, the first factor, are only cleared once no second factor remains.
+func (h *Handler) completeLogin(c *gin.Context, user *models.User, attempt lockout.Attempt, status int, message string) {
+	if !h.allowLogin(c, user) {
+		return
+	}
+
+	challenge, err := h.loginChallenge(c, user, attempt.Account)
+	if err != nil {
+		h.logger.Error("Failed to create MFA challenge", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log in"))
+		return
+	}
+	if challenge != nil {
+		c.JSON(http.StatusOK, models.NewSuccessResponse(challenge, "Multi-factor authentication required"))
+		return
+	}
+
+	h.successfulAttempt(c, attempt)
+	response, err := h.startSession(c, user, false)
+	if err != nil {
+		h.logger.Error("Failed to start session", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log in"))
+		return
+	}
+
+	c.JSON(status, models.NewSuccessResponse(response, message))
+}
+
+// Login handles username and password authentication
+func (h *Handler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	attempt := loginAttempt(c, lockout.MethodPassword, req.Username)
+	if !h.allowAttempt(c, attempt) {
+		return
+	}
+
+	user, err := scanUser(h.db.QueryRow(c.Request.Context(),
+		`SELECT `+userColumns+` FROM users WHERE username = $1`, req.Username))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		h.logger.Error("Failed to load user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log in"))
+		return
+	}
+
+	// Wallet-only accounts have no password and can't log in this way
+	if user == nil || user.Password == "" ||
+		bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		h.failedAttempt(c, attempt, "invalid_credentials")
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid username or password"))
+		return
+	}
+
+	if user.PasswordResetRequired && user.DisabledAt == nil {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse(
+			"Your password must be reset, use the link emailed to you or request a new one at /api/v1/auth/password/forgot"))
+		return
+	}
+	h.completeLogin(c, user, attempt, http.StatusOK, "Login successful")
+}
+
+// Register handles creating a new account with a username and password
+func (h *Handler) Register(c *gin.Context) {
+	var req models.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		h.logger.Error("Failed to hash password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to register"))
+		return
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:        uuid.New(),
+		Username:  req.Username,
+		Email:     req.Email,
+		Password:  string(hash),
+		Role:      models.RoleUser,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	_, err = h.db.Exec(c.Request.Context(), `
+		INSERT INTO users (id, username, email, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		user.ID, user.Username, user.Email, user.Password, user.Role, user.CreatedAt, user.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Username or email is already taken"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to create user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to register"))
+		return
+	}
+
+	if user.Email != "" {
+		if err := h.sendVerificationEmail(c.Request.Context(), user); err != nil {
+			h.logger.Error("Failed to send verification email", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+	}
+
+	h.completeLogin(c, user, lockout.Attempt{}, http.StatusCreated, "Registration successful")
+}
+
+// SIWENonce handles issuing a single-use nonce for a Sign-In with Ethereum message
+func (h *Handler) SIWENonce(c *gin.Context) {
+	if h.config.SIWE.Domain == "" {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Sign-In with Ethereum is not configured"))
+		return
+	}
+
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		h.logger.Error("Failed to generate SIWE nonce", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to create nonce"))
+		return
+	}
+	nonce := hex.EncodeToString(raw)
+
+	ttl := time.Duration(h.config.SIWE.NonceTTLSeconds) * time.Second
+	if err := h.redisClient.Set(c.Request.Context(), siweNonceKey(nonce), "1", ttl).Err(); err != nil {
+		h.logger.Error("Failed to store SIWE nonce", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to create nonce"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.SIWENonceResponse{
+		Nonce:     nonce,
+		Domain:    h.config.SIWE.Domain,
+		ChainIDs:  h.config.SIWE.ChainIDs,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}, ""))
+}
+
+// SIWEVerify handles signing in with a signed EIP-4361 message. The signing
+// address logs in to the account it is linked to; an unlinked address is
+// linked to the caller's account when a bearer token is sent, and otherwise
+// gets a new wallet-only account. A bearer token that fails middleware.Auth's
+// checks is rejected rather than ignored.
+func (h *Handler) SIWEVerify(c *gin.Context) {
+	if h.config.SIWE.Domain == "" {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Sign-In with Ethereum is not configured"))
+		return
+	}
+
+	var req models.SIWEVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	msg, err := siwe.Parse(req.Message)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid SIWE message: "+err.Error()))
+		return
+	}
+	attempt := loginAttempt(c, lockout.MethodSIWE, msg.Address.Hex())
+	if !h.allowAttempt(c, attempt) {
+		return
+	}
+	if err := msg.Validate(h.config.SIWE.Domain, h.config.SIWE.ChainIDs, time.Now(), siweClockSkew); err != nil {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid SIWE message: "+err.Error()))
+		return
+	}
+
+	// The nonce is consumed before the signature is checked so it can't be retried
+	ctx := c.Request.Context()
+	if err := h.redisClient.GetDel(ctx, siweNonceKey(msg.Nonce)).Err(); errors.Is(err, redis.Nil) {
+		h.failedAttempt(c, attempt, "unknown_nonce")
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Unknown or expired nonce"))
+		return
+	} else if err != nil {
+		h.logger.Error("Failed to consume SIWE nonce", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to sign in"))
+		return
+	}
+
+	if err := ethsig.VerifyPersonal(msg.Address, []byte(req.Message), req.Signature); err != nil {
+		h.failedAttempt(c, attempt, "invalid_signature")
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Signature verification failed"))
+		return
+	}
+
+	address := tiers.NormalizeAddress(msg.Address)
+	user, err := scanUser(h.db.QueryRow(ctx, `
+		SELECT `+userColumns+` FROM users
+		WHERE id = (SELECT user_id FROM user_wallets WHERE address = $1)`, address))
+	if err == nil {
+		h.completeLogin(c, user, attempt, http.StatusOK, "Login successful")
+		return
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		h.logger.Error("Failed to load wallet user", zap.String("address", address), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to sign in"))
+		return
+	}
+
+	status, message := http.StatusOK, "Wallet linked and login successful"
+	user, ok := h.bearerUser(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	err = pgx.BeginFunc(ctx, h.db, func(tx pgx.Tx) error {
+		if user == nil {
+			status, message = http.StatusCreated, "Account created and login successful"
+			user = &models.User{
+				ID:        uuid.New(),
+				Username:  address,
+				Role:      models.RoleUser,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO users (id, username, role, created_at, updated_at)
+				VALUES ($1, $2, $3, $4, $5)`,
+				user.ID, user.Username, user.Role, user.CreatedAt, user.UpdatedAt); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO user_wallets (address, user_id, linked_at)
+			VALUES ($1, $2, $3)`,
+			address, user.ID, now)
+		return err
+	})
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		// Another request linked the address or took the username concurrently
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Wallet is already linked to an account, sign in again"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to link wallet on sign in", zap.String("address", address), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to sign in"))
+		return
+	}
+
+	h.refreshTier(c, user.ID, "wallet linked")
+	h.completeLogin(c, user, attempt, status, message)
+}
+
+// bearerUser returns the user of the bearer token on the request, or nil
+// when there is none. The token is checked as middleware.Auth checks it, and
+// impersonation tokens and disabled users are refused. It responds and
+// returns false when the token is rejected.
+func (h *Handler) bearerUser(c *gin.Context) (*models.User, bool) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		return nil, true
+	}
+
+	ctx := c.Request.Context()
+	identity, status, err := middleware.AuthenticateBearer(ctx, h.tokens, sessions.NewDenylist(h.redisClient), header)
+	if err != nil {
+		c.JSON(status, models.NewErrorResponse(err.Error()))
+		return nil, false
+	}
+	if identity.ImpersonatorID != nil {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse("Not allowed while impersonating a user"))
+		return nil, false
+	}
+
+	user, err := scanUser(h.db.QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE id = $1`, identity.UserID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid or expired token"))
+		return nil, false
+	}
+	if err != nil {
+		h.logger.Error("Failed to load bearer token user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to sign in"))
+		return nil, false
+	}
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse("This account has been disabled"))
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// currentUserID returns the authenticated user's ID set by middleware.Auth
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	value, ok := c.Get("user_id")
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, ok := value.(uuid.UUID)
+	return id, ok
+}
+
+// currentUserRole returns the authenticated user's role set by middleware.Auth
+func currentUserRole(c *gin.Context) models.UserRole {
+	return models.UserRole(c.GetString("role"))
+}
+
+// currentSessionID returns the session of the authenticated access token set by middleware.Auth
+func currentSessionID(c *gin.Context) (uuid.UUID, bool) {
+	value, ok := c.Get("session_id")
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, ok := value.(uuid.UUID)
+	return id, ok
+}
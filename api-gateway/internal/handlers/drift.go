@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/monitor"
+	"go.uber.org/zap"
+)
+
+// GetNodeDrift handles fetching the last comparison of a running node with
+// its stored config and version
+func (h *Handler) GetNodeDrift(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid node ID"))
+		return
+	}
+
+	if _, err := h.nodeStore().Get(c.Request.Context(), id); err != nil {
+		h.nodeError(c, err, id, "Failed to get node drift")
+		return
+	}
+
+	report, err := monitor.LoadDriftReport(c.Request.Context(), h.db, id)
+	if err != nil {
+		h.logger.Error("Failed to load drift report", zap.String("node_id", id.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to get node drift"))
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("Node has not been checked for drift yet"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(report, ""))
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// maxManifestSize caps the size of a fleet manifest body
+const maxManifestSize = 4 << 20
+
+// bindFleetManifest reads a fleet manifest written in JSON or YAML. YAML is
+// converted to JSON first so both are decoded with the same field names and
+// binding rules as CreateNode.
+func bindFleetManifest(c *gin.Context) (models.FleetManifest, error) {
+	var manifest models.FleetManifest
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxManifestSize))
+	if err != nil {
+		return manifest, fmt.Errorf("manifest can't be read: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return manifest, fmt.Errorf("manifest is empty")
+	}
+
+	if strings.Contains(c.ContentType(), "yaml") || body[0] != '{' {
+		var document interface{}
+		if err := yaml.Unmarshal(body, &document); err != nil {
+			return manifest, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if body, err = json.Marshal(document); err != nil {
+			return manifest, fmt.Errorf("invalid YAML: %w", err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("invalid manifest: %w", err)
+	}
+	for i := range manifest.Nodes {
+		if err := binding.Validator.ValidateStruct(&manifest.Nodes[i]); err != nil {
+			return manifest, fmt.Errorf("nodes[%d]: %w", i, err)
+		}
+	}
+	return manifest, nil
+}
+
+// fleetChangeNames lists the nodes of changes. Audit events record names
+// only since field values can hold credentials.
+func fleetChangeNames(changes []models.FleetChange) []string {
+	names := make([]string, len(changes))
+	for i, change := range changes {
+		names[i] = change.Name
+	}
+	return names
+}
+
+// FleetPlan handles computing the node creates, updates and, with
+// prune=true, deletes that applying a fleet manifest would make
+func (h *Handler) FleetPlan(c *gin.Context) {
+	manifest, err := bindFleetManifest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	prune, err := queryBool(c, "prune", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	plan, err := h.nodeStore().Plan(c.Request.Context(), manifest, prune)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to plan fleet manifest")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(plan, ""))
+}
+
+// FleetApply handles making the nodes match a fleet manifest in one
+// transaction. Passing the fingerprint of a plan applies it only if the
+// nodes haven't changed since it was computed.
+func (h *Handler) FleetApply(c *gin.Context) {
+	manifest, err := bindFleetManifest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	prune, err := queryBool(c, "prune", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	plan, err := h.nodeStore().Apply(c.Request.Context(), manifest, prune, c.Query("fingerprint"))
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to apply fleet manifest")
+		return
+	}
+
+	if len(plan.Creates)+len(plan.Updates)+len(plan.Deletes) > 0 {
+		userID, _ := currentUserID(c)
+		h.auditRecorder().Record(c.Request.Context(), models.AuditEvent{
+			Action:     models.AuditFleetApplied,
+			ActorID:    audit.UserID(userID),
+			Actor:      c.GetString("username"),
+			TargetType: "nodes",
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			Metadata: map[string]interface{}{
+				"selector":    manifest.Selector,
+				"prune":       prune,
+				"fingerprint": plan.Fingerprint,
+				"creates":     fleetChangeNames(plan.Creates),
+				"updates":     fleetChangeNames(plan.Updates),
+				"deletes":     fleetChangeNames(plan.Deletes),
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(plan, "Fleet manifest applied"))
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/monitor"
+	"go.uber.org/zap"
+)
+
+// GetChainGas handles the fleet-wide gas oracle endpoint
+func (h *Handler) GetChainGas(c *gin.Context) {
+	chain := models.ChainType(c.Param("chain"))
+	if !chain.IsValid() {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Unknown chain type"))
+		return
+	}
+
+	maxAge := 3 * time.Duration(h.config.Monitoring.GasPollSeconds) * time.Second
+	samples, err := monitor.LoadGasSamples(c.Request.Context(), h.db, chain, maxAge)
+	if err != nil {
+		h.logger.Error("Failed to load gas samples", zap.String("chain", string(chain)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to compute gas recommendation"))
+		return
+	}
+
+	rec := monitor.RecommendGas(chain, samples)
+	if rec == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("No healthy nodes with recent gas data for this chain"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(rec, ""))
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+// ListNodeGroups handles listing node groups
+func (h *Handler) ListNodeGroups(c *gin.Context) {
+	groups, err := h.nodeStore().ListGroups(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list node groups", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list node groups"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(groups, ""))
+}
+
+// GetNodeGroup handles fetching a node group by ID or name
+func (h *Handler) GetNodeGroup(c *gin.Context) {
+	group, err := h.nodeStore().GetGroup(c.Request.Context(), c.Param("group"))
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to get node group")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(group, ""))
+}
+
+// CreateNodeGroup handles creating a node group from a label selector,
+// explicit members or both
+func (h *Handler) CreateNodeGroup(c *gin.Context) {
+	var req models.CreateNodeGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	group, err := h.nodeStore().CreateGroup(c.Request.Context(), req, userID)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to create node group")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(group, "Node group created successfully"))
+}
+
+// UpdateNodeGroup handles renaming a node group or changing its selector
+func (h *Handler) UpdateNodeGroup(c *gin.Context) {
+	var req models.UpdateNodeGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	group, err := h.nodeStore().UpdateGroup(c.Request.Context(), c.Param("group"), req)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to update node group")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(group, "Node group updated successfully"))
+}
+
+// DeleteNodeGroup handles removing a node group. Its nodes are kept.
+func (h *Handler) DeleteNodeGroup(c *gin.Context) {
+	id, err := h.nodeStore().DeleteGroup(c.Request.Context(), c.Param("group"))
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to delete node group")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{"id": id}, "Node group deleted successfully"))
+}
+
+// AddNodeGroupMembers handles adding nodes to a node group explicitly
+func (h *Handler) AddNodeGroupMembers(c *gin.Context) {
+	var req models.NodeGroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	group, err := h.nodeStore().AddGroupMembers(c.Request.Context(), c.Param("group"), req.NodeIDs)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to add node group members")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(group, "Nodes added to group"))
+}
+
+// RemoveNodeGroupMembers handles removing explicit members from a node group
+func (h *Handler) RemoveNodeGroupMembers(c *gin.Context) {
+	var req models.NodeGroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	group, err := h.nodeStore().RemoveGroupMembers(c.Request.Context(), c.Param("group"), req.NodeIDs)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to remove node group members")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(group, "Nodes removed from group"))
+}
+
+// BulkNodes handles changing the status or labels of, or deleting, every node
+// in a group, matching a selector or listed by ID at once. Only admins may
+// delete in bulk.
+func (h *Handler) BulkNodes(c *gin.Context) {
+	var req models.BulkNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if req.Action == models.BulkNodeDelete && currentUserRole(c) != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse("Insufficient permissions"))
+		return
+	}
+
+	response, err := h.nodeStore().Bulk(c.Request.Context(), req)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to apply bulk node action")
+		return
+	}
+
+	if !response.DryRun && response.Count > 0 {
+		metadata := map[string]interface{}{
+			"action":   req.Action,
+			"target":   req.Target,
+			"node_ids": response.NodeIDs,
+		}
+		switch req.Action {
+		case models.BulkNodeSetStatus:
+			metadata["status"] = req.Status
+		case models.BulkNodeLabel:
+			metadata["labels"] = req.Labels
+			metadata["remove_labels"] = req.RemoveLabels
+		}
+		userID, _ := currentUserID(c)
+		h.auditRecorder().Record(c.Request.Context(), models.AuditEvent{
+			Action:     models.AuditNodesBulk,
+			ActorID:    audit.UserID(userID),
+			Actor:      c.GetString("username"),
+			TargetType: "nodes",
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			Metadata:   metadata,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(response, ""))
+}
@@ -0,0 +1,568 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/twist/api-gateway/internal/apikeys"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/middleware"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodes"
+	gatewayv1 "github.com/twist/api-gateway/pkg/api/gateway/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RegisterGRPC registers the gRPC equivalents of the node, user and API key
+// routes on server. Calls must pass through middleware.UnaryAuth and
+// middleware.StreamAuth.
+func (h *Handler) RegisterGRPC(server *grpc.Server) {
+	gatewayv1.RegisterNodeServiceServer(server, &nodeService{h: h})
+	gatewayv1.RegisterUserServiceServer(server, &userService{h: h})
+	gatewayv1.RegisterAPIKeyServiceServer(server, &apiKeyService{h: h})
+}
+
+// grpcIdentity returns the caller set by the gRPC auth interceptors
+func grpcIdentity(ctx context.Context) (*middleware.Identity, error) {
+	identity, ok := middleware.IdentityFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "Authentication required")
+	}
+	return identity, nil
+}
+
+// grpcPage validates page and pageSize, applying the REST defaults to zero values
+func grpcPage(page, pageSize uint64) (uint64, uint64, error) {
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		return 0, 0, status.Error(codes.InvalidArgument, "Invalid page_size, must be between 1 and 100")
+	}
+	return page, pageSize, nil
+}
+
+func grpcUUID(value, what string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "Invalid "+what+" ID")
+	}
+	return id, nil
+}
+
+// grpcAudit records an event for the gRPC caller in ctx
+func (h *Handler) grpcAudit(ctx context.Context, identity *middleware.Identity, event models.AuditEvent) {
+	event.ActorID = audit.UserID(identity.UserID)
+	event.Actor = identity.Username
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		event.IPAddress = p.Addr.String()
+		if host, _, err := net.SplitHostPort(event.IPAddress); err == nil {
+			event.IPAddress = host
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if agents := md.Get("user-agent"); len(agents) > 0 {
+			event.UserAgent = agents[0]
+		}
+	}
+	h.auditRecorder().Record(ctx, event)
+}
+
+func timestampOrNil(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func nodeToProto(node *models.BlockchainNode) (*gatewayv1.Node, error) {
+	pb := &gatewayv1.Node{
+		Id:          node.ID.String(),
+		Name:        node.Name,
+		ChainType:   string(node.ChainType),
+		EndpointUrl: node.EndpointURL,
+		Status:      string(node.Status),
+		Version:     node.Version,
+		SyncStatus: &gatewayv1.SyncStatus{
+			IsSyncing:          node.SyncStatus.IsSyncing,
+			CurrentBlock:       node.SyncStatus.CurrentBlock,
+			HighestBlock:       node.SyncStatus.HighestBlock,
+			StartingBlock:      node.SyncStatus.StartingBlock,
+			ProgressPercentage: node.SyncStatus.ProgressPercentage,
+		},
+		CreatedAt:            timestamppb.New(node.CreatedAt),
+		UpdatedAt:            timestamppb.New(node.UpdatedAt),
+		Region:               node.Region,
+		Provider:             string(node.Provider),
+		PerformanceMetrics:   node.PerformanceMetrics,
+		ConsensusEndpointUrl: node.ConsensusEndpointURL,
+		Source:               string(node.Source),
+		OnchainId:            node.OnchainID,
+		OnchainOwner:         node.OnchainOwner,
+		PublishOnchain:       node.PublishOnchain,
+		Labels:               node.Labels,
+	}
+	if node.Config != nil {
+		config, err := structpb.NewStruct(node.Config)
+		if err != nil {
+			return nil, err
+		}
+		pb.Config = config
+	}
+	if cl := node.ConsensusStatus; cl != nil {
+		pb.ConsensusStatus = &gatewayv1.ConsensusStatus{
+			Health:       string(cl.Health),
+			IsSyncing:    cl.IsSyncing,
+			IsOptimistic: cl.IsOptimistic,
+			ElOffline:    cl.ELOffline,
+			HeadSlot:     cl.HeadSlot,
+			SyncDistance: cl.SyncDistance,
+			PeerCount:    cl.PeerCount,
+			Error:        cl.Error,
+			CheckedAt:    timestamppb.New(cl.CheckedAt),
+		}
+	}
+	return pb, nil
+}
+
+func adminUserToProto(user models.AdminUserResponse) *gatewayv1.User {
+	pb := userToProto(user.UserResponse)
+	pb.HasPassword = user.HasPassword
+	pb.Disabled = user.Disabled
+	pb.DisabledAt = timestampOrNil(user.DisabledAt)
+	pb.DisabledReason = user.DisabledReason
+	pb.PasswordResetRequired = user.PasswordResetRequired
+	pb.UpdatedAt = timestamppb.New(user.UpdatedAt)
+	return pb
+}
+
+func userToProto(user models.UserResponse) *gatewayv1.User {
+	return &gatewayv1.User{
+		Id:            user.ID.String(),
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Role:          string(user.Role),
+		CreatedAt:     timestamppb.New(user.CreatedAt),
+		LastLogin:     timestampOrNil(user.LastLogin),
+	}
+}
+
+// nodeService implements gatewayv1.NodeServiceServer
+type nodeService struct {
+	gatewayv1.UnimplementedNodeServiceServer
+	h *Handler
+}
+
+// nodeStatus converts a node store error to a gRPC status, logging unexpected errors
+func (s *nodeService) nodeStatus(err error, id uuid.UUID, message string) error {
+	var invalid *nodes.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return status.Error(codes.InvalidArgument, invalid.Message)
+	case errors.Is(err, nodes.ErrNotFound):
+		return status.Error(codes.NotFound, "Node not found")
+	default:
+		s.h.logger.Error(message, zap.String("node_id", id.String()), zap.Error(err))
+		return status.Error(codes.Internal, message)
+	}
+}
+
+func (s *nodeService) respond(node *models.BlockchainNode) (*gatewayv1.Node, error) {
+	pb, err := nodeToProto(node)
+	if err != nil {
+		s.h.logger.Error("Failed to encode node", zap.String("node_id", node.ID.String()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to encode node")
+	}
+	return pb, nil
+}
+
+func (s *nodeService) ListNodes(ctx context.Context, req *gatewayv1.ListNodesRequest) (*gatewayv1.ListNodesResponse, error) {
+	pageSize := req.GetPageSize()
+	if pageSize == 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid page_size, must be between 1 and 100")
+	}
+	selector, err := labels.Parse(req.GetSelector())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid selector: "+err.Error())
+	}
+	sort, err := nodes.ParseSort(req.GetSort())
+	if err != nil {
+		return nil, s.nodeStatus(err, uuid.Nil, "Failed to list nodes")
+	}
+
+	list, err := s.h.nodeStore().List(ctx, nodes.Query{
+		Filter: nodes.Filter{
+			ChainTypes: typedValues[models.ChainType](req.GetChainTypes()),
+			Statuses:   typedValues[models.NodeStatus](req.GetStatuses()),
+			Regions:    req.GetRegions(),
+			Providers:  typedValues[models.CloudProvider](req.GetProviders()),
+			Versions:   req.GetVersions(),
+			Name:       req.GetName(),
+			Selector:   selector,
+		},
+		Sort:     sort,
+		PageSize: pageSize,
+		Page:     req.GetPage(),
+		Cursor:   req.GetCursor(),
+	})
+	if err != nil {
+		return nil, s.nodeStatus(err, uuid.Nil, "Failed to list nodes")
+	}
+
+	response := &gatewayv1.ListNodesResponse{
+		Items:      make([]*gatewayv1.Node, 0, len(list.Items)),
+		Total:      list.Total,
+		Page:       list.Page,
+		PageSize:   list.PageSize,
+		TotalPages: list.TotalPages,
+		NextCursor: list.NextCursor,
+	}
+	for i := range list.Items {
+		pb, err := s.respond(&list.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		response.Items = append(response.Items, pb)
+	}
+	return response, nil
+}
+
+func (s *nodeService) GetNode(ctx context.Context, req *gatewayv1.GetNodeRequest) (*gatewayv1.Node, error) {
+	id, err := grpcUUID(req.GetId(), "node")
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := s.h.nodeStore().Get(ctx, id)
+	if err != nil {
+		return nil, s.nodeStatus(err, id, "Failed to get node")
+	}
+	return s.respond(node)
+}
+
+func (s *nodeService) CreateNode(ctx context.Context, req *gatewayv1.CreateNodeRequest) (*gatewayv1.Node, error) {
+	node, err := s.h.nodeStore().Create(ctx, models.CreateNodeRequest{
+		Name:                 req.GetName(),
+		ChainType:            models.ChainType(req.GetChainType()),
+		EndpointURL:          req.GetEndpointUrl(),
+		Region:               req.GetRegion(),
+		Provider:             models.CloudProvider(req.GetProvider()),
+		Config:               req.GetConfig().AsMap(),
+		ConsensusEndpointURL: req.GetConsensusEndpointUrl(),
+		PublishOnchain:       req.GetPublishOnchain(),
+		Labels:               req.GetLabels(),
+	})
+	if err != nil {
+		return nil, s.nodeStatus(err, uuid.Nil, "Failed to create node")
+	}
+	return s.respond(node)
+}
+
+func (s *nodeService) UpdateNode(ctx context.Context, req *gatewayv1.UpdateNodeRequest) (*gatewayv1.Node, error) {
+	id, err := grpcUUID(req.GetId(), "node")
+	if err != nil {
+		return nil, err
+	}
+
+	update := models.UpdateNodeRequest{
+		Name:                 req.Name,
+		EndpointURL:          req.EndpointUrl,
+		ConsensusEndpointURL: req.ConsensusEndpointUrl,
+		PublishOnchain:       req.PublishOnchain,
+	}
+	if req.Status != nil {
+		nodeStatus := models.NodeStatus(*req.Status)
+		update.Status = &nodeStatus
+	}
+	if req.Config != nil {
+		update.Config = req.Config.AsMap()
+	}
+	if req.Labels != nil {
+		update.Labels = req.Labels.GetValues()
+		if update.Labels == nil {
+			update.Labels = map[string]string{}
+		}
+	}
+
+	node, err := s.h.nodeStore().Update(ctx, id, update)
+	if err != nil {
+		return nil, s.nodeStatus(err, id, "Failed to update node")
+	}
+	return s.respond(node)
+}
+
+func (s *nodeService) DeleteNode(ctx context.Context, req *gatewayv1.DeleteNodeRequest) (*gatewayv1.DeleteNodeResponse, error) {
+	id, err := grpcUUID(req.GetId(), "node")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.h.nodeStore().Delete(ctx, id); err != nil {
+		return nil, s.nodeStatus(err, id, "Failed to delete node")
+	}
+	return &gatewayv1.DeleteNodeResponse{Id: id.String()}, nil
+}
+
+var nodeEventTypes = map[models.NodeEventType]gatewayv1.NodeEvent_Type{
+	models.NodeEventCreated: gatewayv1.NodeEvent_TYPE_CREATED,
+	models.NodeEventUpdated: gatewayv1.NodeEvent_TYPE_UPDATED,
+	models.NodeEventDeleted: gatewayv1.NodeEvent_TYPE_DELETED,
+}
+
+func (s *nodeService) WatchNodes(req *gatewayv1.WatchNodesRequest, stream gatewayv1.NodeService_WatchNodesServer) error {
+	if s.h.nodeEvents == nil {
+		return status.Error(codes.Unavailable, "Node events are not configured")
+	}
+
+	watched := make(map[uuid.UUID]bool, len(req.GetNodeIds()))
+	for _, value := range req.GetNodeIds() {
+		id, err := grpcUUID(value, "node")
+		if err != nil {
+			return err
+		}
+		watched[id] = true
+	}
+	send := func(eventType gatewayv1.NodeEvent_Type, node *models.BlockchainNode, previous models.NodeStatus, at time.Time) error {
+		if len(watched) > 0 && !watched[node.ID] {
+			return nil
+		}
+		pb, err := s.respond(node)
+		if err != nil {
+			return err
+		}
+		return stream.Send(&gatewayv1.NodeEvent{
+			Type:           eventType,
+			Node:           pb,
+			PreviousStatus: string(previous),
+			OccurredAt:     timestamppb.New(at),
+		})
+	}
+
+	// Subscribe before reading the snapshot so no change falls in between
+	sub := s.h.nodeEvents.Subscribe()
+	defer sub.Close()
+
+	ctx := stream.Context()
+	if req.GetIncludeInitial() {
+		items, err := s.h.nodeStore().All(ctx)
+		if err != nil {
+			return s.nodeStatus(err, uuid.Nil, "Failed to list nodes")
+		}
+		now := time.Now().UTC()
+		for i := range items {
+			if err := send(gatewayv1.NodeEvent_TYPE_SNAPSHOT, &items[i], "", now); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					return status.Error(codes.ResourceExhausted, "Stream fell behind, watch again")
+				}
+				return status.Error(codes.Unavailable, "Node events stopped")
+			}
+			if err := send(nodeEventTypes[event.Type], event.Node, event.PreviousStatus, event.OccurredAt); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// userService implements gatewayv1.UserServiceServer
+type userService struct {
+	gatewayv1.UnimplementedUserServiceServer
+	h *Handler
+}
+
+func (s *userService) loadUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, err := scanUser(s.h.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "User not found")
+	}
+	if err != nil {
+		s.h.logger.Error("Failed to load user", zap.String("user_id", id.String()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to load user")
+	}
+	return user, nil
+}
+
+func (s *userService) GetCurrentUser(ctx context.Context, _ *gatewayv1.GetCurrentUserRequest) (*gatewayv1.User, error) {
+	identity, err := grpcIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadUser(ctx, identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return userToProto(user.ToResponse()), nil
+}
+
+func (s *userService) ListUsers(ctx context.Context, req *gatewayv1.ListUsersRequest) (*gatewayv1.ListUsersResponse, error) {
+	page, pageSize, err := grpcPage(req.GetPage(), req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+	filter := userFilter{Query: req.GetQuery(), Role: req.GetRole(), Status: req.GetStatus()}
+	if message := filter.validate(); message != "" {
+		return nil, status.Error(codes.InvalidArgument, message)
+	}
+
+	list, err := s.h.queryUsers(ctx, filter, page, pageSize)
+	if err != nil {
+		s.h.logger.Error("Failed to list users", zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list users")
+	}
+
+	response := &gatewayv1.ListUsersResponse{
+		Items:      make([]*gatewayv1.User, 0, len(list.Items)),
+		Total:      list.Total,
+		Page:       list.Page,
+		PageSize:   list.PageSize,
+		TotalPages: list.TotalPages,
+	}
+	for _, user := range list.Items {
+		response.Items = append(response.Items, adminUserToProto(user))
+	}
+	return response, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, req *gatewayv1.GetUserRequest) (*gatewayv1.User, error) {
+	id, err := grpcUUID(req.GetId(), "user")
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return adminUserToProto(user.ToAdminResponse()), nil
+}
+
+// apiKeyService implements gatewayv1.APIKeyServiceServer
+type apiKeyService struct {
+	gatewayv1.UnimplementedAPIKeyServiceServer
+	h *Handler
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, _ *gatewayv1.ListAPIKeysRequest) (*gatewayv1.ListAPIKeysResponse, error) {
+	identity, err := grpcIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.h.apiKeyStore().List(ctx, identity.UserID)
+	if err != nil {
+		s.h.logger.Error("Failed to list API keys", zap.String("user_id", identity.UserID.String()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to list API keys")
+	}
+
+	response := &gatewayv1.ListAPIKeysResponse{Items: make([]*gatewayv1.APIKey, 0, len(keys))}
+	for _, key := range keys {
+		response.Items = append(response.Items, &gatewayv1.APIKey{
+			Id:        key.ID.String(),
+			UserId:    key.UserID.String(),
+			Key:       key.Key,
+			Name:      key.Name,
+			CreatedAt: timestamppb.New(key.CreatedAt),
+			ExpiresAt: timestampOrNil(key.ExpiresAt),
+			LastUsed:  timestampOrNil(key.LastUsed),
+			Enabled:   key.Enabled,
+		})
+	}
+	return response, nil
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, req *gatewayv1.CreateAPIKeyRequest) (*gatewayv1.CreateAPIKeyResponse, error) {
+	identity, err := grpcIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		at := req.ExpiresAt.AsTime()
+		if !at.After(time.Now()) {
+			return nil, status.Error(codes.InvalidArgument, "expires_at must be in the future")
+		}
+		expiresAt = &at
+	}
+
+	key, err := s.h.apiKeyStore().Create(ctx, identity.UserID, req.GetName(), expiresAt, identity.MFA)
+	if err != nil {
+		s.h.logger.Error("Failed to create API key", zap.String("user_id", identity.UserID.String()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to create API key")
+	}
+
+	s.h.grpcAudit(ctx, identity, models.AuditEvent{
+		Action:     models.AuditAPIKeyCreated,
+		TargetType: "api_key",
+		TargetID:   key.ID.String(),
+		Metadata:   map[string]interface{}{"name": key.Name},
+	})
+
+	return &gatewayv1.CreateAPIKeyResponse{
+		Id:        key.ID.String(),
+		Name:      key.Name,
+		Key:       key.Key,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+		ExpiresAt: timestampOrNil(key.ExpiresAt),
+	}, nil
+}
+
+func (s *apiKeyService) DeleteAPIKey(ctx context.Context, req *gatewayv1.DeleteAPIKeyRequest) (*gatewayv1.DeleteAPIKeyResponse, error) {
+	identity, err := grpcIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := grpcUUID(req.GetId(), "API key")
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.h.apiKeyStore().Delete(ctx, identity.UserID, id)
+	if errors.Is(err, apikeys.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		s.h.logger.Error("Failed to delete API key", zap.String("id", id.String()), zap.Error(err))
+		return nil, status.Error(codes.Internal, "Failed to delete API key")
+	}
+
+	s.h.grpcAudit(ctx, identity, models.AuditEvent{
+		Action:     models.AuditAPIKeyDeleted,
+		TargetType: "api_key",
+		TargetID:   id.String(),
+	})
+
+	return &gatewayv1.DeleteAPIKeyResponse{}, nil
+}
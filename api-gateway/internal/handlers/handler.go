@@ -3,7 +3,16 @@ package handlers
 import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/audit"
 	"github.com/twist/api-gateway/internal/config"
+	"github.com/twist/api-gateway/internal/lockout"
+	"github.com/twist/api-gateway/internal/mailer"
+	"github.com/twist/api-gateway/internal/mfa"
+	"github.com/twist/api-gateway/internal/nodeevents"
+	"github.com/twist/api-gateway/internal/sso"
+	"github.com/twist/api-gateway/internal/tiers"
+	"github.com/twist/api-gateway/internal/token"
+	"github.com/twist/api-gateway/internal/tokens"
 	"go.uber.org/zap"
 )
 
@@ -13,14 +22,67 @@ type Handler struct {
 	redisClient *redis.Client
 	logger      *zap.Logger
 	config      *config.Config
+	tokens      *tokens.Manager
+	token       *token.Service
+	tiers       *tiers.Refresher
+	sso         *sso.Provider
+	mfa         *mfa.Service
+	guard       *lockout.Guard
+	mailer      mailer.Mailer
+	nodeEvents  *nodeevents.Broker
 }
 
 // NewHandler creates a new Handler instance
-func NewHandler(db *pgxpool.Pool, redisClient *redis.Client, logger *zap.Logger, config *config.Config) *Handler {
+func NewHandler(db *pgxpool.Pool, redisClient *redis.Client, logger *zap.Logger, config *config.Config, tokenManager *tokens.Manager) *Handler {
 	return &Handler{
 		db:          db,
 		redisClient: redisClient,
 		logger:      logger,
 		config:      config,
+		tokens:      tokenManager,
 	}
 }
+
+// SetTokenService enables the token endpoints, which report 503 until it is set
+func (h *Handler) SetTokenService(service *token.Service) {
+	h.token = service
+}
+
+// SetTierRefresher lets wallet changes update the user's tier immediately
+// instead of on the refresher's next cycle
+func (h *Handler) SetTierRefresher(refresher *tiers.Refresher) {
+	h.tiers = refresher
+}
+
+// SetSSOProvider enables OpenID Connect login, which reports 503 until it is set
+func (h *Handler) SetSSOProvider(provider *sso.Provider) {
+	h.sso = provider
+}
+
+// SetMFAService enables TOTP second factors and two-step login. MFA routes
+// report 503 until it is set.
+func (h *Handler) SetMFAService(service *mfa.Service) {
+	h.mfa = service
+}
+
+// SetLoginGuard enables brute-force protection on login. Logins are not
+// throttled until it is set.
+func (h *Handler) SetLoginGuard(guard *lockout.Guard) {
+	h.guard = guard
+}
+
+// SetMailer enables verification and password reset email. Messages are
+// dropped with a warning until it is set.
+func (h *Handler) SetMailer(m mailer.Mailer) {
+	h.mailer = m
+}
+
+// SetNodeEvents enables streaming node changes, which reports unavailable
+// until it is set
+func (h *Handler) SetNodeEvents(broker *nodeevents.Broker) {
+	h.nodeEvents = broker
+}
+
+func (h *Handler) auditRecorder() *audit.Recorder {
+	return audit.NewRecorder(h.db, h.logger)
+}
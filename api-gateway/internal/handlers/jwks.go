@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS handles publishing the public keys that verify access tokens. It is
+// served as a bare JWK Set, not wrapped in models.APIResponse, so standard
+// JWT libraries can consume it.
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", h.config.JWT.KeyReloadSeconds))
+	c.JSON(http.StatusOK, h.tokens.JWKS())
+}
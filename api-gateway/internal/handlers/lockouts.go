@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/lockout"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+// loginAttempt describes the current request as an attempt to authenticate
+// as account with method
+func loginAttempt(c *gin.Context, method, account string) lockout.Attempt {
+	return lockout.Attempt{
+		Method:    method,
+		Account:   account,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// allowAttempt responds with 429 and returns false when attempt's account or
+// IP is locked out or backing off
+func (h *Handler) allowAttempt(c *gin.Context, attempt lockout.Attempt) bool {
+	if h.guard == nil {
+		return true
+	}
+
+	err := h.guard.Check(c.Request.Context(), attempt)
+	var locked *lockout.LockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(int(locked.RetryAfter().Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, models.NewErrorResponse(locked.Error()))
+		return false
+	}
+	if err != nil {
+		h.logger.Error("Failed to check login throttling", zap.String("method", attempt.Method), zap.Error(err))
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Unable to process login"))
+		return false
+	}
+	return true
+}
+
+// failedAttempt records a failed authentication attempt
+func (h *Handler) failedAttempt(c *gin.Context, attempt lockout.Attempt, reason string) {
+	if h.guard != nil {
+		h.guard.Failure(c.Request.Context(), attempt, reason)
+	}
+}
+
+// successfulAttempt clears the failures of attempt's account
+func (h *Handler) successfulAttempt(c *gin.Context, attempt lockout.Attempt) {
+	if h.guard != nil {
+		h.guard.Success(c.Request.Context(), attempt)
+	}
+}
+
+// lockoutTarget reads the scope and ID of an admin lockout request
+func lockoutTarget(c *gin.Context) (string, string, bool) {
+	scope := c.Param("scope")
+	if scope != lockout.ScopeUser && scope != lockout.ScopeIP {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("scope must be user or ip"))
+		return "", "", false
+	}
+	return scope, c.Param("id"), true
+}
+
+// GetLockout handles reporting the failed logins and lockout of a user or IP
+func (h *Handler) GetLockout(c *gin.Context) {
+	if h.guard == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Login protection is not configured"))
+		return
+	}
+	scope, id, ok := lockoutTarget(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.guard.Status(c.Request.Context(), scope, id)
+	if err != nil {
+		h.logger.Error("Failed to load lockout status", zap.String("scope", scope), zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load lockout status"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(status, ""))
+}
+
+// ClearLockout handles unlocking a user or IP and resetting its failed logins
+func (h *Handler) ClearLockout(c *gin.Context) {
+	if h.guard == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Login protection is not configured"))
+		return
+	}
+	scope, id, ok := lockoutTarget(c)
+	if !ok {
+		return
+	}
+
+	if err := h.guard.Unlock(c.Request.Context(), scope, id); err != nil {
+		h.logger.Error("Failed to clear lockout", zap.String("scope", scope), zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to clear lockout"))
+		return
+	}
+
+	adminID, _ := currentUserID(c)
+	h.auditRecorder().Record(c.Request.Context(), models.AuditEvent{
+		Action:     models.AuditLockoutCleared,
+		ActorID:    audit.UserID(adminID),
+		Actor:      c.GetString("username"),
+		TargetType: scope,
+		TargetID:   id,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "Lockout cleared"))
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/twist/api-gateway/internal/lockout"
+	"github.com/twist/api-gateway/internal/mfa"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+// mfaConfigured responds with 503 and returns false when MFA is not enabled
+func (h *Handler) mfaConfigured(c *gin.Context) bool {
+	if h.mfa == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Multi-factor authentication is not configured"))
+		return false
+	}
+	return true
+}
+
+// GetMFAStatus handles reporting the current user's second factor
+func (h *Handler) GetMFAStatus(c *gin.Context) {
+	if !h.mfaConfigured(c) {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	status, err := h.mfa.Status(c.Request.Context(), userID, currentUserRole(c))
+	if err != nil {
+		h.logger.Error("Failed to load MFA status", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load MFA status"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(status, ""))
+}
+
+// EnrolTOTP handles starting TOTP enrolment. The returned provisioning URI
+// is rendered as a QR code for the user's authenticator app.
+func (h *Handler) EnrolTOTP(c *gin.Context) {
+	if !h.mfaConfigured(c) {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	enrolment, err := h.mfa.Enrol(c.Request.Context(), userID, c.GetString("username"))
+	if errors.Is(err, mfa.ErrAlreadyEnabled) {
+		c.JSON(http.StatusConflict, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to start TOTP enrolment", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to start enrolment"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(enrolment, "Confirm enrolment with a code from your authenticator app"))
+}
+
+// ActivateTOTP handles confirming TOTP enrolment with a code and returns the
+// user's recovery codes
+func (h *Handler) ActivateTOTP(c *gin.Context) {
+	if !h.mfaConfigured(c) {
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	codes, err := h.mfa.Activate(c.Request.Context(), userID, req.Code)
+	switch {
+	case errors.Is(err, mfa.ErrNotEnrolled):
+		c.JSON(http.StatusNotFound, models.NewErrorResponse(err.Error()))
+		return
+	case errors.Is(err, mfa.ErrAlreadyEnabled):
+		c.JSON(http.StatusConflict, models.NewErrorResponse(err.Error()))
+		return
+	case errors.Is(err, mfa.ErrInvalidCode):
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(err.Error()))
+		return
+	case err != nil:
+		h.logger.Error("Failed to activate TOTP", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to enable MFA"))
+		return
+	}
+
+	h.logger.Info("MFA enabled", zap.String("user_id", userID.String()))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.RecoveryCodesResponse{RecoveryCodes: codes},
+		"MFA enabled, store these recovery codes safely and log in again"))
+}
+
+// mfaAttempt describes the current request as an attempt to present a second
+// factor for userID. Code failures count against the user and the client IP
+// across logins and MFA changes alike.
+func mfaAttempt(c *gin.Context, userID uuid.UUID) lockout.Attempt {
+	return loginAttempt(c, lockout.MethodMFA, userID.String())
+}
+
+// verifyCurrentUserCode checks a TOTP or recovery code from the current user
+// before a sensitive MFA change, responding on failure
+func (h *Handler) verifyCurrentUserCode(c *gin.Context) bool {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return false
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return false
+	}
+
+	attempt := mfaAttempt(c, userID)
+	if !h.allowAttempt(c, attempt) {
+		return false
+	}
+
+	err := h.mfa.Verify(c.Request.Context(), userID, req.Code)
+	switch {
+	case errors.Is(err, mfa.ErrNotEnabled):
+		c.JSON(http.StatusNotFound, models.NewErrorResponse(err.Error()))
+		return false
+	case errors.Is(err, mfa.ErrInvalidCode):
+		h.failedAttempt(c, attempt, "invalid_mfa_code")
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(err.Error()))
+		return false
+	case err != nil:
+		h.logger.Error("Failed to verify MFA code", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to verify code"))
+		return false
+	}
+
+	h.successfulAttempt(c, attempt)
+	return true
+}
+
+// DisableTOTP handles removing the current user's second factor. Users whose
+// role requires MFA can't remove it.
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	if !h.mfaConfigured(c) {
+		return
+	}
+
+	required, err := h.mfa.Required(c.Request.Context(), currentUserRole(c))
+	if err != nil {
+		h.logger.Error("Failed to load MFA requirement", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to disable MFA"))
+		return
+	}
+	if required {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse("MFA is required for your role and can't be disabled"))
+		return
+	}
+
+	if !h.verifyCurrentUserCode(c) {
+		return
+	}
+	userID, _ := currentUserID(c)
+
+	if err := h.mfa.Disable(c.Request.Context(), userID); err != nil && !errors.Is(err, mfa.ErrNotEnabled) {
+		h.logger.Error("Failed to disable MFA", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to disable MFA"))
+		return
+	}
+
+	h.logger.Info("MFA disabled", zap.String("user_id", userID.String()))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "MFA disabled"))
+}
+
+// RegenerateRecoveryCodes handles replacing the current user's recovery codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	if !h.mfaConfigured(c) || !h.verifyCurrentUserCode(c) {
+		return
+	}
+	userID, _ := currentUserID(c)
+
+	codes, err := h.mfa.RegenerateRecoveryCodes(c.Request.Context(), userID)
+	if errors.Is(err, mfa.ErrNotEnabled) {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to regenerate recovery codes", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to regenerate recovery codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.RecoveryCodesResponse{RecoveryCodes: codes},
+		"Recovery codes regenerated, previous codes no longer work"))
+}
+
+// VerifyMFALogin handles the second step of a login that returned an MFA
+// challenge, starting the session once the code checks out. The failures of
+// the login's first factor are only cleared here.
+func (h *Handler) VerifyMFALogin(c *gin.Context) {
+	if !h.mfaConfigured(c) {
+		return
+	}
+
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	challenge, err := h.mfa.Challenge(ctx, req.MFAToken)
+	if errors.Is(err, mfa.ErrInvalidChallenge) {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load MFA challenge", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log in"))
+		return
+	}
+	attempt := mfaAttempt(c, challenge.UserID)
+	if !h.allowAttempt(c, attempt) {
+		return
+	}
+
+	userID, err := h.mfa.CompleteChallenge(ctx, req.MFAToken, req.Code)
+	switch {
+	case errors.Is(err, mfa.ErrInvalidChallenge), errors.Is(err, mfa.ErrNotEnabled):
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(mfa.ErrInvalidChallenge.Error()))
+		return
+	case errors.Is(err, mfa.ErrInvalidCode):
+		h.failedAttempt(c, attempt, "invalid_mfa_code")
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(err.Error()))
+		return
+	case err != nil:
+		h.logger.Error("Failed to verify MFA login", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log in"))
+		return
+	}
+
+	user, err := scanUser(h.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("User no longer exists"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load user", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log in"))
+		return
+	}
+	if !h.allowLogin(c, user) {
+		return
+	}
+
+	h.successfulAttempt(c, attempt)
+	h.successfulAttempt(c, loginAttempt(c, attempt.Method, challenge.Account))
+	response, err := h.startSession(c, user, true)
+	if err != nil {
+		h.logger.Error("Failed to start session", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log in"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(response, "Login successful"))
+}
+
+// ListMFARequirements handles listing which roles must use MFA
+func (h *Handler) ListMFARequirements(c *gin.Context) {
+	if !h.mfaConfigured(c) {
+		return
+	}
+
+	requirements, err := h.mfa.Requirements(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list MFA requirements", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list MFA requirements"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(requirements, ""))
+}
+
+// SetMFARequirement handles requiring or no longer requiring MFA for a role.
+// Members without a second factor keep access only to MFA enrolment.
+func (h *Handler) SetMFARequirement(c *gin.Context) {
+	if !h.mfaConfigured(c) {
+		return
+	}
+
+	role := models.UserRole(c.Param("role"))
+	if !role.IsValid() {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Unknown role"))
+		return
+	}
+
+	var req models.SetMFARoleRequirementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	adminID, _ := currentUserID(c)
+	if err := h.mfa.SetRequired(c.Request.Context(), role, *req.Required, adminID); err != nil {
+		h.logger.Error("Failed to set MFA requirement", zap.String("role", string(role)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to update MFA requirement"))
+		return
+	}
+
+	h.logger.Info("MFA requirement changed",
+		zap.String("role", string(role)),
+		zap.Bool("required", *req.Required),
+		zap.String("admin_id", adminID.String()))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.MFARoleRequirement{Role: role, Required: *req.Required}, "MFA requirement updated"))
+}
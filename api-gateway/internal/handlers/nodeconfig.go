@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeconfig"
+)
+
+// ListNodeConfigProfiles handles listing the chain types with config
+// profiles and the clients that run each
+func (h *Handler) ListNodeConfigProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nodeconfig.Chains(), ""))
+}
+
+// GetNodeConfigProfile handles fetching the JSON Schema a node's config is
+// checked against, for the chain type in the path and the client query
+// parameter, which defaults to the chain's default client
+func (h *Handler) GetNodeConfigProfile(c *gin.Context) {
+	schema, err := nodeconfig.Profile(models.ChainType(c.Param("chain")), nodeconfig.Client(c.Query("client")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(schema, ""))
+}
+
+// ValidateNodeConfig handles checking a node config without saving it. It
+// returns the config as it would be stored, with deprecated keys migrated
+// and defaults filled in.
+func (h *Handler) ValidateNodeConfig(c *gin.Context) {
+	var req models.ValidateNodeConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	result, err := nodeconfig.Normalize(req.ChainType, req.Config)
+	var invalid *nodeconfig.Error
+	if errors.As(err, &invalid) {
+		response := models.NewErrorResponse(invalid.Error())
+		response.Data = gin.H{"fields": invalid.Fields}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(result, ""))
+}
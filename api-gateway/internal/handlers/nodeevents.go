@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeevents"
+	"go.uber.org/zap"
+)
+
+const (
+	// nodeEventsHeartbeat keeps idle streams open through proxies
+	nodeEventsHeartbeat = 15 * time.Second
+	// nodeEventsRetry is how long clients wait before reconnecting, in milliseconds
+	nodeEventsRetry = 3000
+)
+
+// sseMessage is one Server-Sent Events message
+type sseMessage struct {
+	event string
+	data  interface{}
+}
+
+// sseMessages turns a node event into the messages streamed to clients: node
+// creation and deletion, status transitions, sync progress and alerts
+func sseMessages(event *models.NodeEvent) []sseMessage {
+	node := event.Node
+	var messages []sseMessage
+	switch event.Type {
+	case models.NodeEventCreated:
+		messages = append(messages, sseMessage{"created", node})
+	case models.NodeEventDeleted:
+		messages = append(messages, sseMessage{"deleted", models.NodeStatusEvent{
+			NodeID:     node.ID,
+			Name:       node.Name,
+			From:       event.PreviousStatus,
+			OccurredAt: event.OccurredAt,
+		}})
+	case models.NodeEventUpdated:
+		if event.Changed(models.NodeFieldStatus) {
+			messages = append(messages, sseMessage{"status", models.NodeStatusEvent{
+				NodeID:     node.ID,
+				Name:       node.Name,
+				From:       event.PreviousStatus,
+				To:         node.Status,
+				OccurredAt: event.OccurredAt,
+			}})
+		}
+		if event.Changed(models.NodeFieldSyncStatus) {
+			messages = append(messages, sseMessage{"sync", models.NodeSyncEvent{
+				NodeID:     node.ID,
+				Name:       node.Name,
+				SyncStatus: node.SyncStatus,
+				OccurredAt: event.OccurredAt,
+			}})
+		}
+	}
+	if event.Alert != nil {
+		messages = append(messages, sseMessage{"alert", models.NodeAlertEvent{
+			NodeID:     node.ID,
+			Name:       node.Name,
+			NodeAlert:  *event.Alert,
+			OccurredAt: event.OccurredAt,
+		}})
+	}
+	return messages
+}
+
+// writeSSE writes messages for one node event. Only the last carries the
+// event's ID, so a client that drops mid-way resumes from the previous event
+// and receives all of them again.
+func writeSSE(w gin.ResponseWriter, id string, messages []sseMessage) error {
+	for i, message := range messages {
+		data, err := json.Marshal(message.data)
+		if err != nil {
+			return err
+		}
+		if i == len(messages)-1 && id != "" {
+			if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.event, data); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+// StreamNodeEvents streams node changes as Server-Sent Events. Clients resume
+// with the Last-Event-ID header, or the last_event_id query parameter, and
+// can limit the stream to the nodes given by node_id.
+func (h *Handler) StreamNodeEvents(c *gin.Context) {
+	if h.nodeEvents == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Node events are not configured"))
+		return
+	}
+
+	watched := make(map[uuid.UUID]bool)
+	for _, value := range c.QueryArray("node_id") {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid node ID"))
+			return
+		}
+		watched[id] = true
+	}
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID != "" && !nodeevents.ValidID(lastID) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid Last-Event-ID"))
+		return
+	}
+
+	// Subscribe before replaying so no event falls in between
+	sub := h.nodeEvents.Subscribe()
+	defer sub.Close()
+
+	ctx := c.Request.Context()
+	var replay []models.NodeEvent
+	complete := true
+	if lastID != "" {
+		var err error
+		replay, complete, err = h.nodeEvents.Since(ctx, lastID)
+		if err != nil {
+			h.logger.Error("Failed to replay node events", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to replay node events"))
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", nodeEventsRetry); err != nil {
+		return
+	}
+	if !complete {
+		// Events were trimmed; the client has to reload the nodes
+		if err := writeSSE(c.Writer, "", []sseMessage{{"reset", gin.H{"reason": "Events since Last-Event-ID are no longer available"}}}); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	cursor := lastID
+	send := func(event *models.NodeEvent) error {
+		if cursor != "" && nodeevents.CompareIDs(event.ID, cursor) <= 0 {
+			return nil
+		}
+		cursor = event.ID
+		if len(watched) > 0 && !watched[event.Node.ID] {
+			return nil
+		}
+		return writeSSE(c.Writer, event.ID, sseMessages(event))
+	}
+
+	for i := range replay {
+		if err := send(&replay[i]); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(nodeEventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and resumes
+				return
+			}
+			if err := send(&event); err != nil {
+				return
+			}
+		}
+	}
+}
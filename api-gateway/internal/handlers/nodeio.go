@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeio"
+	"github.com/twist/api-gateway/internal/nodes"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxImportSize caps the size of a node import body
+	maxImportSize = 32 << 20
+	// maxImportRows caps how many records a node import may hold
+	maxImportRows = 10000
+	// exportPageSize is how many nodes an export reads at a time
+	exportPageSize = 500
+)
+
+// importFormat reads the format of an import from the format query
+// parameter, falling back to the Content-Type
+func importFormat(c *gin.Context) (nodeio.Format, error) {
+	if value := c.Query("format"); value != "" {
+		return nodeio.ParseFormat(value)
+	}
+	if format, ok := nodeio.FormatOf(c.GetHeader("Content-Type")); ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("format must be given, or the Content-Type must be text/csv, application/json or application/yaml")
+}
+
+// validateImportRecord applies the CreateNode binding rules and node checks
+// to a record
+func validateImportRecord(record *nodeio.Record) []string {
+	if record.Err != nil {
+		return []string{record.Err.Error()}
+	}
+	if err := binding.Validator.ValidateStruct(&record.Request); err != nil {
+		return strings.Split(err.Error(), "\n")
+	}
+	if err := nodes.ValidateCreate(&record.Request); err != nil {
+		var invalid *nodes.ValidationError
+		if errors.As(err, &invalid) && len(invalid.Fields) > 0 {
+			messages := make([]string, len(invalid.Fields))
+			for i, field := range invalid.Fields {
+				messages[i] = field.Field + " " + field.Message
+			}
+			return messages
+		}
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+// ImportNodes handles creating nodes from a CSV, JSON or YAML file. Records
+// are validated as they are read and reported row by row; invalid rows are
+// skipped and the valid ones created together. dry_run=true only validates.
+func (h *Handler) ImportNodes(c *gin.Context) {
+	format, err := importFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	dryRun, err := queryBool(c, "dry_run", false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	reader, err := nodeio.NewReader(format, http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid import: "+err.Error()))
+		return
+	}
+
+	report := &models.NodeImportReport{Format: string(format), DryRun: dryRun, Rows: []models.NodeImportRow{}}
+	importRows := func(create func(models.CreateNodeRequest) (*models.BlockchainNode, error)) error {
+		for {
+			record, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return &nodes.ValidationError{Message: fmt.Sprintf("Invalid import after row %d: %v", report.Total, err)}
+			}
+			if report.Total == maxImportRows {
+				return &nodes.ValidationError{Message: fmt.Sprintf("Imports are limited to %d rows", maxImportRows)}
+			}
+			report.Total++
+
+			row := models.NodeImportRow{Row: record.Index, Line: record.Line, Name: record.Request.Name}
+			row.Errors = validateImportRecord(record)
+			if len(row.Errors) == 0 && create != nil {
+				node, err := create(record.Request)
+				var invalid *nodes.ValidationError
+				switch {
+				case errors.As(err, &invalid):
+					row.Errors = []string{invalid.Message}
+				case err != nil:
+					return err
+				default:
+					id := node.ID
+					row.NodeID = &id
+				}
+			}
+
+			switch {
+			case len(row.Errors) > 0:
+				row.Status = models.NodeImportInvalid
+				report.Invalid++
+			case row.NodeID != nil:
+				row.Status = models.NodeImportCreated
+				report.Valid++
+				report.Created++
+			default:
+				row.Status = models.NodeImportValid
+				report.Valid++
+			}
+			report.Rows = append(report.Rows, row)
+		}
+	}
+
+	if dryRun {
+		err = importRows(nil)
+	} else {
+		err = h.nodeStore().Import(c.Request.Context(), importRows)
+	}
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to import nodes")
+		return
+	}
+
+	if report.Created > 0 {
+		userID, _ := currentUserID(c)
+		h.auditRecorder().Record(c.Request.Context(), models.AuditEvent{
+			Action:     models.AuditNodesImported,
+			ActorID:    audit.UserID(userID),
+			Actor:      c.GetString("username"),
+			TargetType: "nodes",
+			IPAddress:  c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			Metadata: map[string]interface{}{
+				"format":  format,
+				"created": report.Created,
+				"invalid": report.Invalid,
+			},
+		})
+	}
+
+	message := "Nodes imported"
+	if dryRun {
+		message = "Import validated; no nodes were created"
+	}
+	c.JSON(http.StatusOK, models.NewSuccessResponse(report, message))
+}
+
+// ExportNodes handles downloading the nodes matching the ListNodes filters as
+// CSV, JSON or YAML in a form ImportNodes accepts. Credentials in endpoint
+// URLs and secret config values are redacted unless redact_endpoints=false
+// or redact_config=false.
+func (h *Handler) ExportNodes(c *gin.Context) {
+	format, err := nodeio.ParseFormat(c.DefaultQuery("format", string(nodeio.JSON)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	var redact nodeio.Redaction
+	if redact.Endpoints, err = queryBool(c, "redact_endpoints", true); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if redact.Config, err = queryBool(c, "redact_config", true); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	sort, err := nodes.ParseSort(c.Query("sort"))
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to export nodes")
+		return
+	}
+	filter, err := h.nodeFilter(c)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to export nodes")
+		return
+	}
+
+	// The first page is read before anything is written so errors can still
+	// be reported with a status code
+	ctx := c.Request.Context()
+	query := nodes.Query{Filter: filter, Sort: sort, PageSize: exportPageSize}
+	page, err := h.nodeStore().List(ctx, query)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to export nodes")
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	h.auditRecorder().Record(ctx, models.AuditEvent{
+		Action:     models.AuditNodesExported,
+		ActorID:    audit.UserID(userID),
+		Actor:      c.GetString("username"),
+		TargetType: "nodes",
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Metadata: map[string]interface{}{
+			"format":           format,
+			"count":            page.Total,
+			"redact_endpoints": redact.Endpoints,
+			"redact_config":    redact.Config,
+			"query":            c.Request.URL.RawQuery,
+		},
+	})
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="nodes.%s"`, format))
+	c.Status(http.StatusOK)
+
+	writer := nodeio.NewWriter(format, c.Writer, redact)
+	for {
+		for i := range page.Items {
+			if err := writer.Write(&page.Items[i]); err != nil {
+				h.logger.Warn("Node export interrupted", zap.Error(err))
+				return
+			}
+		}
+		c.Writer.Flush()
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+		if page, err = h.nodeStore().List(ctx, query); err != nil {
+			// The response has started, so the export ends truncated
+			h.logger.Error("Failed to export nodes", zap.Error(err))
+			return
+		}
+	}
+	if err := writer.Close(); err != nil {
+		h.logger.Warn("Node export interrupted", zap.Error(err))
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodes"
+	"go.uber.org/zap"
+)
+
+func (h *Handler) nodeStore() *nodes.Store {
+	return nodes.NewStore(h.db)
+}
+
+// nodeError responds to a failed node operation, logging unexpected errors
+func (h *Handler) nodeError(c *gin.Context, err error, id uuid.UUID, message string) {
+	var invalid *nodes.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		response := models.NewErrorResponse(invalid.Message)
+		if len(invalid.Fields) > 0 {
+			response.Data = gin.H{"fields": invalid.Fields}
+		}
+		c.JSON(http.StatusBadRequest, response)
+	case errors.Is(err, nodes.ErrNotFound):
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("Node not found"))
+	case errors.Is(err, nodes.ErrGroupNotFound):
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("Node group not found"))
+	case errors.Is(err, nodes.ErrGroupExists):
+		c.JSON(http.StatusConflict, models.NewErrorResponse("A node group with this name already exists"))
+	case errors.Is(err, nodes.ErrPlanStale):
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Nodes changed since the plan was computed; plan again"))
+	default:
+		h.logger.Error(message, zap.String("node_id", id.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(message))
+	}
+}
+
+// queryValues returns every value of a repeated or comma-separated query parameter
+func queryValues(c *gin.Context, key string) []string {
+	var values []string
+	for _, value := range c.QueryArray(key) {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	}
+	return values
+}
+
+// queryBool reads a boolean query parameter, returning fallback when it's unset
+func queryBool(c *gin.Context, key string, fallback bool) (bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+	return parsed, nil
+}
+
+// nodeFilter reads the filters shared by listing and exporting nodes
+func (h *Handler) nodeFilter(c *gin.Context) (nodes.Filter, error) {
+	selector, err := labels.Parse(c.Query("selector"))
+	if err != nil {
+		return nodes.Filter{}, &nodes.ValidationError{Message: "Invalid selector: " + err.Error()}
+	}
+
+	var group *models.NodeGroup
+	if ref := c.Query("group"); ref != "" {
+		if group, err = h.nodeStore().GetGroup(c.Request.Context(), ref); err != nil {
+			return nodes.Filter{}, err
+		}
+	}
+
+	return nodes.Filter{
+		ChainTypes: typedValues[models.ChainType](queryValues(c, "chain_type")),
+		Statuses:   typedValues[models.NodeStatus](queryValues(c, "status")),
+		Regions:    queryValues(c, "region"),
+		Providers:  typedValues[models.CloudProvider](queryValues(c, "provider")),
+		Versions:   queryValues(c, "version"),
+		Name:       c.Query("name"),
+		Selector:   selector,
+		Group:      group,
+	}, nil
+}
+
+// ListNodes handles listing blockchain nodes. chain_type, status, region,
+// provider and version take one or more values, name matches part of the
+// name, selector is a label selector and group a node group's ID or name. sort lists fields to order by, each
+// prefixed with - for descending order. Follow next_cursor for the next page.
+func (h *Handler) ListNodes(c *gin.Context) {
+	page, err := strconv.ParseUint(c.DefaultQuery("page", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid page"))
+		return
+	}
+	pageSize, err := strconv.ParseUint(c.DefaultQuery("page_size", "20"), 10, 64)
+	if err != nil || pageSize == 0 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid page_size, must be between 1 and 100"))
+		return
+	}
+	sort, err := nodes.ParseSort(c.Query("sort"))
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to list nodes")
+		return
+	}
+	filter, err := h.nodeFilter(c)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to list nodes")
+		return
+	}
+
+	query := nodes.Query{
+		Filter:   filter,
+		Sort:     sort,
+		PageSize: pageSize,
+		Page:     page,
+		Cursor:   c.Query("cursor"),
+	}
+	response, err := h.nodeStore().List(c.Request.Context(), query)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to list nodes")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(response, ""))
+}
+
+func typedValues[T ~string](values []string) []T {
+	out := make([]T, len(values))
+	for i, value := range values {
+		out[i] = T(value)
+	}
+	return out
+}
+
+// GetNode handles fetching a single blockchain node
+func (h *Handler) GetNode(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid node ID"))
+		return
+	}
+
+	node, err := h.nodeStore().Get(c.Request.Context(), id)
+	if err != nil {
+		h.nodeError(c, err, id, "Failed to get node")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(node, ""))
+}
+
+// CreateNode handles registering a new blockchain node
+func (h *Handler) CreateNode(c *gin.Context) {
+	var req models.CreateNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	node, err := h.nodeStore().Create(c.Request.Context(), req)
+	if err != nil {
+		h.nodeError(c, err, uuid.Nil, "Failed to create node")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(models.NodeResponse{ID: node.ID}, "Node created successfully"))
+}
+
+// UpdateNode handles updating an existing blockchain node
+func (h *Handler) UpdateNode(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid node ID"))
+		return
+	}
+
+	var req models.UpdateNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	if _, err := h.nodeStore().Update(c.Request.Context(), id, req); err != nil {
+		h.nodeError(c, err, id, "Failed to update node")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.NodeResponse{ID: id}, "Node updated successfully"))
+}
+
+// DeleteNode handles removing a blockchain node
+func (h *Handler) DeleteNode(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid node ID"))
+		return
+	}
+
+	if err := h.nodeStore().Delete(c.Request.Context(), id); err != nil {
+		h.nodeError(c, err, id, "Failed to delete node")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.NodeResponse{ID: id}, "Node deleted successfully"))
+}
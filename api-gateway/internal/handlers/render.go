@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeconfig"
+	"github.com/twist/api-gateway/internal/noderender"
+	"go.uber.org/zap"
+)
+
+// RenderNode handles downloading a node's configuration in the format query
+// parameter: geth-toml, cli-flags, docker-compose or k8s-manifest
+func (h *Handler) RenderNode(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid node ID"))
+		return
+	}
+	format, err := noderender.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	node, err := h.nodeStore().Get(c.Request.Context(), id)
+	if err != nil {
+		h.nodeError(c, err, id, "Failed to render node")
+		return
+	}
+
+	out, err := noderender.Render(node, format)
+	var invalid *nodeconfig.Error
+	switch {
+	case errors.As(err, &invalid):
+		// The stored config predates its profile or the profile has changed
+		response := models.NewErrorResponse(invalid.Error())
+		response.Data = gin.H{"fields": invalid.Fields}
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	case errors.Is(err, noderender.ErrUnsupported):
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	case err != nil:
+		h.logger.Error("Failed to render node", zap.String("node_id", id.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to render node"))
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, out.Filename))
+	c.Data(http.StatusOK, out.ContentType, out.Body)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/sessions"
+	"go.uber.org/zap"
+)
+
+// RefreshToken handles rotating a refresh token into a new access and refresh token pair
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	store := h.sessionStore()
+	ctx := c.Request.Context()
+	rotation, err := store.Rotate(ctx, req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	switch {
+	case errors.Is(err, sessions.ErrRefreshTokenReused):
+		h.logger.Warn("Refresh token reuse detected", zap.String("ip", c.ClientIP()))
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(err.Error()))
+		return
+	case errors.Is(err, sessions.ErrInvalidRefreshToken):
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(err.Error()))
+		return
+	case err != nil:
+		h.logger.Error("Failed to rotate refresh token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to refresh token"))
+		return
+	}
+
+	user, err := scanUser(h.db.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, rotation.UserID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("User no longer exists"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load user", zap.String("user_id", rotation.UserID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to refresh token"))
+		return
+	}
+	if !h.allowLogin(c, user) {
+		return
+	}
+
+	h.respondWithTokens(c, user, rotation, http.StatusOK, "Token refreshed")
+}
+
+// Logout handles ending the current session and revoking its access token
+func (h *Handler) Logout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	// Tokens issued before sessions existed carry no session, only a jti
+	sessionID, ok := currentSessionID(c)
+	if !ok {
+		expiresAt, _ := c.Get("token_expires_at")
+		at, _ := expiresAt.(time.Time)
+		if err := sessions.NewDenylist(h.redisClient).Revoke(c.Request.Context(), c.GetString("jti"), at); err != nil {
+			h.logger.Error("Failed to revoke token", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log out"))
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "Logged out successfully"))
+		return
+	}
+
+	err := h.sessionStore().Revoke(c.Request.Context(), userID, sessionID, "logout")
+	if err != nil && !errors.Is(err, sessions.ErrNotFound) {
+		h.logger.Error("Failed to revoke session", zap.String("session_id", sessionID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log out"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "Logged out successfully"))
+}
+
+// LogoutAll handles ending every session of the current user
+func (h *Handler) LogoutAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	count, err := h.sessionStore().RevokeAll(c.Request.Context(), userID, "logout all")
+	if err != nil {
+		h.logger.Error("Failed to revoke sessions", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to log out"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(gin.H{"sessions_revoked": count}, "Logged out of all sessions"))
+}
+
+// ListSessions handles listing the current user's active sessions
+func (h *Handler) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	list, err := h.sessionStore().List(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list sessions", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list sessions"))
+		return
+	}
+
+	if current, ok := currentSessionID(c); ok {
+		for i := range list {
+			list[i].Current = list[i].ID == current
+		}
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(list, ""))
+}
+
+// RevokeSession handles ending one of the current user's sessions
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid session ID"))
+		return
+	}
+
+	err = h.sessionStore().Revoke(c.Request.Context(), userID, sessionID, "revoked by user")
+	if errors.Is(err, sessions.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("Session not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to revoke session", zap.String("session_id", sessionID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to revoke session"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "Session revoked successfully"))
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/sso"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+// usernameUnsafe matches characters not kept in usernames derived from SSO claims
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// oidcLoginState is what the gateway remembers between redirecting to the
+// provider and handling its callback
+type oidcLoginState struct {
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// oidcStateCookie binds a login's state to the browser that started it, so
+// a callback URL carrying someone else's code and state is refused
+const oidcStateCookie = "oidc_state"
+
+func oidcStateKey(state string) string {
+	return "oidc:state:" + state
+}
+
+// setOIDCStateCookie sets or, with maxAge < 0, clears the state cookie. It is
+// scoped to the directory of the login and callback routes.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, path.Dir(c.Request.URL.Path), "", true, true)
+}
+
+func randomToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// OIDCLogin handles starting an OpenID Connect login by redirecting to the
+// provider. The state is also set in a cookie that the callback checks.
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	if h.sso == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Single sign-on is not configured"))
+		return
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		h.logger.Error("Failed to generate OIDC state", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to start login"))
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		h.logger.Error("Failed to generate OIDC nonce", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to start login"))
+		return
+	}
+	login := oidcLoginState{Nonce: nonce, Verifier: oauth2.GenerateVerifier()}
+
+	authURL, err := h.sso.AuthCodeURL(c.Request.Context(), state, login.Nonce, login.Verifier)
+	if err != nil {
+		h.logger.Error("Failed to build OIDC authorization URL", zap.Error(err))
+		c.JSON(http.StatusBadGateway, models.NewErrorResponse("Identity provider is unavailable"))
+		return
+	}
+
+	data, _ := json.Marshal(login)
+	ttl := time.Duration(h.config.OIDC.StateTTLSeconds) * time.Second
+	if err := h.redisClient.Set(c.Request.Context(), oidcStateKey(state), data, ttl).Err(); err != nil {
+		h.logger.Error("Failed to store OIDC state", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to start login"))
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(ttl.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback handles the provider's redirect back after login. It
+// provisions the user on first login, syncs their role from group claims and
+// issues the gateway's own tokens.
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if h.sso == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Single sign-on is not configured"))
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		message := providerErr
+		if description := c.Query("error_description"); description != "" {
+			message += ": " + description
+		}
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Login was not completed: "+message))
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("code and state are required"))
+		return
+	}
+
+	// The state must come back to the browser that started the login
+	cookie, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Login was started in another browser, start it again"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	data, err := h.redisClient.GetDel(ctx, oidcStateKey(state)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Unknown or expired login state"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load OIDC state", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to complete login"))
+		return
+	}
+	var login oidcLoginState
+	if err := json.Unmarshal(data, &login); err != nil {
+		h.logger.Error("Failed to decode OIDC state", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to complete login"))
+		return
+	}
+
+	identity, err := h.sso.Exchange(ctx, code, login.Verifier, login.Nonce)
+	if errors.Is(err, sso.ErrNotAllowed) {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse("Your account is not permitted to use this service"))
+		return
+	}
+	if err != nil {
+		h.logger.Warn("OIDC login failed", zap.Error(err))
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Login could not be verified"))
+		return
+	}
+
+	user, err := h.provisionSSOUser(ctx, identity)
+	if err != nil {
+		h.logger.Error("Failed to provision SSO user", zap.String("subject", identity.Subject), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to complete login"))
+		return
+	}
+	if !h.allowLogin(c, user) {
+		return
+	}
+
+	// Users with a second factor still complete it here, even if the
+	// identity provider enforced its own
+	challenge, err := h.loginChallenge(c, user, "")
+	if err != nil {
+		h.logger.Error("Failed to create MFA challenge", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to complete login"))
+		return
+	}
+	redirect := h.config.OIDC.PostLoginRedirectURL
+	if challenge != nil {
+		if redirect != "" {
+			fragment := url.Values{
+				"mfa_token":  {challenge.MFAToken},
+				"expires_at": {challenge.ExpiresAt.Format(time.RFC3339)},
+			}
+			c.Redirect(http.StatusFound, redirect+"#"+fragment.Encode())
+			return
+		}
+		c.JSON(http.StatusOK, models.NewSuccessResponse(challenge, "Multi-factor authentication required"))
+		return
+	}
+
+	response, err := h.startSession(c, user, false)
+	if err != nil {
+		h.logger.Error("Failed to start session", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to complete login"))
+		return
+	}
+
+	// Browser logins hand the tokens to the frontend in the URL fragment,
+	// which is never sent to a server
+	if redirect != "" {
+		fragment := url.Values{
+			"token":         {response.Token},
+			"refresh_token": {response.RefreshToken},
+			"expires_at":    {response.ExpiresAt.Format(time.RFC3339)},
+		}
+		c.Redirect(http.StatusFound, redirect+"#"+fragment.Encode())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(response, "Login successful"))
+}
+
+// provisionSSOUser returns the user linked to identity, creating it on first
+// login. The user's role follows the identity's groups on every login.
+func (h *Handler) provisionSSOUser(ctx context.Context, identity *sso.Identity) (*models.User, error) {
+	var user *models.User
+	now := time.Now().UTC()
+	err := pgx.BeginFunc(ctx, h.db, func(tx pgx.Tx) error {
+		var userID uuid.UUID
+		err := tx.QueryRow(ctx, `
+			SELECT user_id FROM user_identities
+			WHERE issuer = $1 AND subject = $2
+			FOR UPDATE`, identity.Issuer, identity.Subject,
+		).Scan(&userID)
+
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			if userID, err = createSSOUser(ctx, tx, identity, now); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO user_identities (issuer, subject, user_id, email, groups, created_at, last_login_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+				identity.Issuer, identity.Subject, userID, identity.Email, identity.Groups, now); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if _, err := tx.Exec(ctx, `
+				UPDATE user_identities SET email = $1, groups = $2, last_login_at = $3
+				WHERE issuer = $4 AND subject = $5`,
+				identity.Email, identity.Groups, now, identity.Issuer, identity.Subject); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, `
+				UPDATE users SET role = $1, updated_at = $2
+				WHERE id = $3 AND role <> $1`,
+				identity.Role, now, userID); err != nil {
+				return err
+			}
+		}
+
+		user, err = scanUser(tx.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+		return err
+	})
+	return user, err
+}
+
+// createSSOUser inserts a password-less user for identity. The username is
+// derived from the identity's claims and suffixed if already taken; a
+// verified email is kept unless another account uses it.
+func createSSOUser(ctx context.Context, tx pgx.Tx, identity *sso.Identity, now time.Time) (uuid.UUID, error) {
+	base := usernameUnsafe.ReplaceAllString(identity.Username, "")
+	if base == "" {
+		local, _, _ := strings.Cut(identity.Email, "@")
+		base = usernameUnsafe.ReplaceAllString(local, "")
+	}
+	if len(base) < 3 {
+		base = "sso-user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	var email, verifiedAt interface{}
+	if identity.EmailVerified && identity.Email != "" {
+		var taken bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE email = $1)`, identity.Email).Scan(&taken); err != nil {
+			return uuid.Nil, err
+		}
+		if !taken {
+			email, verifiedAt = identity.Email, now
+		}
+	}
+
+	id := uuid.New()
+	for attempt := 0; attempt < 5; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix := make([]byte, 3)
+			if _, err := rand.Read(suffix); err != nil {
+				return uuid.Nil, err
+			}
+			username = base + "-" + hex.EncodeToString(suffix)
+		}
+
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO users (id, username, email, email_verified_at, role, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $6)
+			ON CONFLICT DO NOTHING`,
+			id, username, email, verifiedAt, identity.Role, now)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if tag.RowsAffected() == 1 {
+			return id, nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("could not find a free username for %q", base)
+}
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/config"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/sso"
+	"github.com/twist/api-gateway/internal/sso/ssotest"
+	"github.com/twist/api-gateway/internal/tokens"
+	"github.com/twist/api-gateway/migrations"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+)
+
+const (
+	oidcLoginPath    = "/api/v1/auth/oidc/login"
+	oidcCallbackPath = "/api/v1/auth/oidc/callback"
+)
+
+// ssoMigrations create the tables an SSO login touches
+var ssoMigrations = []string{
+	"0007_users.sql",
+	"0008_sessions.sql",
+	"0010_user_identities.sql",
+	"0011_user_mfa.sql",
+	"0013_account_tokens.sql",
+	"0014_user_admin.sql",
+}
+
+// ssoTest is the gateway's OIDC routes wired to a mock provider
+type ssoTest struct {
+	provider *ssotest.Provider
+	router   *gin.Engine
+	db       *pgxpool.Pool
+}
+
+// newSSOTest builds the OIDC routes. db and redisClient may be nil for
+// tests that are rejected before either is used.
+func newSSOTest(t *testing.T, db *pgxpool.Pool, redisClient *redis.Client) *ssoTest {
+	t.Helper()
+
+	provider, err := ssotest.NewProvider("gateway", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(provider.Close)
+
+	manager, err := tokens.NewManager(context.Background(), nil, zap.NewNop(), tokens.Options{
+		Algorithm: tokens.AlgorithmHS256,
+		Secret:    []byte("sso-test-secret"),
+		Issuer:    "twist-api-gateway",
+		Audience:  "twist-api-gateway",
+		TokenTTL:  time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.JWT.RefreshExpiryHours = 24
+	cfg.OIDC.StateTTLSeconds = 600
+
+	h := NewHandler(db, redisClient, zap.NewNop(), cfg, manager)
+	h.SetSSOProvider(sso.NewProvider(sso.Config{
+		IssuerURL:     provider.Issuer(),
+		ClientID:      provider.ClientID,
+		ClientSecret:  provider.ClientSecret,
+		RedirectURL:   "http://gateway.test" + oidcCallbackPath,
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		AdminGroups:   []string{"platform-admins"},
+		HTTPClient:    provider.Client(),
+	}))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(oidcLoginPath, h.OIDCLogin)
+	router.GET(oidcCallbackPath, h.OIDCCallback)
+	return &ssoTest{provider: provider, router: router, db: db}
+}
+
+// newSSOTestWithStores connects to TEST_DATABASE_URL and TEST_REDIS_URL,
+// skipping the test unless both are set
+func newSSOTestWithStores(t *testing.T) *ssoTest {
+	t.Helper()
+
+	databaseURL, redisURL := os.Getenv("TEST_DATABASE_URL"), os.Getenv("TEST_REDIS_URL")
+	if databaseURL == "" || redisURL == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_REDIS_URL are not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	schema := fmt.Sprintf("sso_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	dbConfig, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+	dbConfig.ConnConfig.RuntimeParams["search_path"] = schema
+	db, err := pgxpool.NewWithConfig(ctx, dbConfig)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+	for _, file := range ssoMigrations {
+		migration, err := fs.ReadFile(migrations.FS, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(ctx, string(migration)); err != nil {
+			t.Fatalf("failed to apply %s: %v", file, err)
+		}
+	}
+
+	redisOptions, err := redis.ParseURL(redisURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	redisClient := redis.NewClient(redisOptions)
+	t.Cleanup(func() { _ = redisClient.Close() })
+
+	return newSSOTest(t, db, redisClient)
+}
+
+// start begins a login and returns the provider URL and the state cookie
+func (s *ssoTest) start(t *testing.T) (string, *http.Cookie) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, oidcLoginPath, nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("login status = %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == oidcStateCookie {
+			return rec.Header().Get("Location"), cookie
+		}
+	}
+	t.Fatal("login did not set the state cookie")
+	return "", nil
+}
+
+// authorize signs in at the provider with claims. edit may change the
+// provider URL first.
+func (s *ssoTest) authorize(t *testing.T, authURL string, claims map[string]interface{}, edit func(url.Values)) string {
+	t.Helper()
+
+	if edit != nil {
+		parsed, _ := url.Parse(authURL)
+		query := parsed.Query()
+		edit(query)
+		parsed.RawQuery = query.Encode()
+		authURL = parsed.String()
+	}
+	callback, err := s.provider.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	return callback
+}
+
+// callback delivers the provider's redirect, with cookie when it's not nil
+func (s *ssoTest) callback(t *testing.T, callbackURL string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, oidcCallbackPath+"?"+parsed.RawQuery, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// login runs a full login and returns the callback response
+func (s *ssoTest) login(t *testing.T, claims map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	authURL, cookie := s.start(t)
+	return s.callback(t, s.authorize(t, authURL, claims, nil), cookie)
+}
+
+func decodeLogin(t *testing.T, rec *httptest.ResponseRecorder) models.LoginResponse {
+	t.Helper()
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback status = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Data models.LoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Data
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	s := newSSOTest(t, nil, nil)
+	callbackURL := "http://gateway.test" + oidcCallbackPath + "?code=code-1&state=state-1"
+
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+	}{
+		{name: "missing cookie"},
+		{name: "other login's state", cookie: &http.Cookie{Name: oidcStateCookie, Value: "state-2"}},
+		{name: "empty cookie", cookie: &http.Cookie{Name: oidcStateCookie, Value: ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := s.callback(t, callbackURL, tt.cookie)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginSetsStateCookie(t *testing.T) {
+	s := newSSOTestWithStores(t)
+
+	authURL, cookie := s.start(t)
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := parsed.Query().Get("state"); state == "" || cookie.Value != state {
+		t.Errorf("cookie = %q, want the login state %q", cookie.Value, state)
+	}
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("cookie flags = httponly %t secure %t samesite %v", cookie.HttpOnly, cookie.Secure, cookie.SameSite)
+	}
+	if cookie.Path != "/api/v1/auth/oidc" || cookie.MaxAge != 600 {
+		t.Errorf("cookie path/max-age = %s/%d", cookie.Path, cookie.MaxAge)
+	}
+	if parsed.Query().Get("code_challenge_method") != "S256" || parsed.Query().Get("nonce") == "" {
+		t.Errorf("provider URL lacks PKCE or nonce: %s", authURL)
+	}
+}
+
+func TestOIDCCallbackProvisionsUser(t *testing.T) {
+	s := newSSOTestWithStores(t)
+	ctx := context.Background()
+
+	response := decodeLogin(t, s.login(t, map[string]interface{}{
+		"sub":                "user-1",
+		"email":              "ada@example.com",
+		"email_verified":     true,
+		"preferred_username": "ada",
+		"groups":             []string{"engineering"},
+	}))
+	if response.Token == "" || response.RefreshToken == "" {
+		t.Error("login did not issue tokens")
+	}
+	user := response.User
+	if user.Username != "ada" || user.Email != "ada@example.com" || !user.EmailVerified || user.Role != models.RoleUser {
+		t.Errorf("user = %+v", user)
+	}
+
+	var linked string
+	err := s.db.QueryRow(ctx, `SELECT user_id::text FROM user_identities WHERE issuer = $1 AND subject = $2`,
+		s.provider.Issuer(), "user-1").Scan(&linked)
+	if err != nil || linked != user.ID.String() {
+		t.Fatalf("identity link = %q, %v, want %s", linked, err, user.ID)
+	}
+
+	// Another subject with the same username gets a suffixed one, and the
+	// taken email isn't copied
+	other := decodeLogin(t, s.login(t, map[string]interface{}{
+		"sub":                "user-2",
+		"email":              "ada@example.com",
+		"email_verified":     true,
+		"preferred_username": "ada",
+	})).User
+	if other.ID == user.ID || other.Username == "ada" || other.Email != "" {
+		t.Errorf("second user = %+v", other)
+	}
+
+	// The same subject logs in to the same account
+	again := decodeLogin(t, s.login(t, map[string]interface{}{"sub": "user-1", "preferred_username": "ada"})).User
+	if again.ID != user.ID {
+		t.Errorf("returning login got user %s, want %s", again.ID, user.ID)
+	}
+}
+
+func TestOIDCCallbackSyncsRoleFromGroups(t *testing.T) {
+	s := newSSOTestWithStores(t)
+
+	steps := []struct {
+		groups []string
+		role   models.UserRole
+	}{
+		{groups: []string{"platform-admins"}, role: models.RoleAdmin},
+		{groups: []string{"engineering"}, role: models.RoleUser},
+		{groups: []string{"engineering", "platform-admins"}, role: models.RoleAdmin},
+	}
+	for i, step := range steps {
+		user := decodeLogin(t, s.login(t, map[string]interface{}{
+			"sub":                "user-1",
+			"preferred_username": "grace",
+			"groups":             step.groups,
+		})).User
+		if user.Role != step.role {
+			t.Errorf("login %d with %v: role = %s, want %s", i+1, step.groups, user.Role, step.role)
+		}
+	}
+}
+
+func TestOIDCCallbackRejectsNonceMismatch(t *testing.T) {
+	s := newSSOTestWithStores(t)
+
+	authURL, cookie := s.start(t)
+	callbackURL := s.authorize(t, authURL, map[string]interface{}{"sub": "user-1"}, func(query url.Values) {
+		query.Set("nonce", "replayed-nonce")
+	})
+	if rec := s.callback(t, callbackURL, cookie); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestOIDCCallbackRequiresPKCE(t *testing.T) {
+	s := newSSOTestWithStores(t)
+
+	authURL, cookie := s.start(t)
+	callbackURL := s.authorize(t, authURL, map[string]interface{}{"sub": "user-1"}, func(query url.Values) {
+		query.Set("code_challenge", oauth2.S256ChallengeFromVerifier(oauth2.GenerateVerifier()))
+	})
+	if rec := s.callback(t, callbackURL, cookie); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestOIDCCallbackStateIsSingleUse(t *testing.T) {
+	s := newSSOTestWithStores(t)
+
+	authURL, cookie := s.start(t)
+	callbackURL := s.authorize(t, authURL, map[string]interface{}{"sub": "user-1", "preferred_username": "linus"}, nil)
+	decodeLogin(t, s.callback(t, callbackURL, cookie))
+
+	if rec := s.callback(t, callbackURL, cookie); rec.Code != http.StatusBadRequest {
+		t.Fatalf("replayed callback status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+// GetTokenVesting handles fetching the TWIST vesting position of an address
+func (h *Handler) GetTokenVesting(c *gin.Context) {
+	if h.token == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Token API is not configured"))
+		return
+	}
+
+	address := c.Param("address")
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid address"))
+		return
+	}
+
+	vesting, err := h.token.Vesting(c.Request.Context(), common.HexToAddress(address))
+	if err != nil {
+		h.logger.Error("Failed to get token vesting", zap.String("address", address), zap.Error(err))
+		c.JSON(http.StatusBadGateway, models.NewErrorResponse("Failed to read token vesting"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(vesting, ""))
+}
+
+// GetTokenSupply handles fetching the TWIST circulating and maximum supply
+func (h *Handler) GetTokenSupply(c *gin.Context) {
+	if h.token == nil {
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse("Token API is not configured"))
+		return
+	}
+
+	supply, err := h.token.Supply(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get token supply", zap.Error(err))
+		c.JSON(http.StatusBadGateway, models.NewErrorResponse("Failed to read token supply"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(supply, ""))
+}
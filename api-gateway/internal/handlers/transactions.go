@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/twist/api-gateway/internal/broadcast"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+var txHashPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+func (h *Handler) broadcastService() *broadcast.Service {
+	return broadcast.NewService(
+		h.db,
+		h.logger,
+		time.Duration(h.config.Monitoring.RequestTimeoutSeconds)*time.Second,
+		h.config.Broadcast.DefaultFanout,
+		broadcast.WebhookPolicy{AllowedHosts: h.config.Broadcast.WebhookAllowedHosts},
+	)
+}
+
+// BroadcastTransaction handles fanning a signed raw transaction out to healthy nodes
+func (h *Handler) BroadcastTransaction(c *gin.Context) {
+	chain := models.ChainType(c.Param("chain"))
+	if !chain.IsValid() {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Unknown chain type"))
+		return
+	}
+
+	var req models.BroadcastTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	response, err := h.broadcastService().Broadcast(c.Request.Context(), userID, chain, req)
+	switch {
+	case errors.Is(err, broadcast.ErrInvalidTransaction), errors.Is(err, broadcast.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+	case errors.Is(err, broadcast.ErrNoHealthyNodes):
+		c.JSON(http.StatusServiceUnavailable, models.NewErrorResponse(err.Error()))
+	case errors.Is(err, broadcast.ErrRejected):
+		c.JSON(http.StatusBadGateway, models.APIResponse{Success: false, Error: err.Error(), Data: response})
+	case err != nil:
+		h.logger.Error("Failed to broadcast transaction", zap.String("chain", string(chain)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to broadcast transaction"))
+	default:
+		c.JSON(http.StatusAccepted, models.NewSuccessResponse(response, "Transaction broadcast"))
+	}
+}
+
+// GetTransaction handles fetching the tracking state of a broadcast transaction
+func (h *Handler) GetTransaction(c *gin.Context) {
+	chain := models.ChainType(c.Param("chain"))
+	hash := c.Param("hash")
+	if !chain.IsValid() || !txHashPattern.MatchString(hash) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid chain type or transaction hash"))
+		return
+	}
+
+	tx, err := h.broadcastService().Get(c.Request.Context(), chain, hash)
+	if errors.Is(err, broadcast.ErrNotFound) {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("Transaction not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to get transaction", zap.String("tx_hash", hash), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to get transaction"))
+		return
+	}
+
+	userID, _ := currentUserID(c)
+	if tx.UserID != userID && currentUserRole(c) != models.RoleAdmin {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("Transaction not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(tx, ""))
+}
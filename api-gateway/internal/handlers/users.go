@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/middleware"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// likeEscaper escapes the LIKE wildcards in user supplied search text
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// auditAdminAction records an action the current admin took on target
+func (h *Handler) auditAdminAction(c *gin.Context, action string, target uuid.UUID, metadata map[string]interface{}) {
+	adminID, _ := currentUserID(c)
+	h.auditRecorder().Record(c.Request.Context(), models.AuditEvent{
+		Action:     action,
+		ActorID:    audit.UserID(adminID),
+		Actor:      c.GetString("username"),
+		TargetType: "user",
+		TargetID:   target.String(),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		Metadata:   metadata,
+	})
+}
+
+// targetUser loads the user named by the id path parameter, responding with
+// an error and returning nil when it can't
+func (h *Handler) targetUser(c *gin.Context) *models.User {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid user ID"))
+		return nil
+	}
+
+	user, err := scanUser(h.db.QueryRow(c.Request.Context(), `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("User not found"))
+		return nil
+	}
+	if err != nil {
+		h.logger.Error("Failed to load user", zap.String("user_id", id.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load user"))
+		return nil
+	}
+	return user
+}
+
+// notSelf responds with 409 and returns false when target is the current
+// admin, so admins can't lock themselves out
+func notSelf(c *gin.Context, target *models.User, action string) bool {
+	if adminID, _ := currentUserID(c); adminID == target.ID {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("You can't "+action+" your own account"))
+		return false
+	}
+	return true
+}
+
+// userFilter selects the users listed to admins. Query matches the start of
+// the username or email; Status is "active" or "disabled".
+type userFilter struct {
+	Query  string
+	Role   string
+	Status string
+}
+
+// validate returns a message describing why f can't be used, or ""
+func (f userFilter) validate() string {
+	if f.Role != "" && !models.UserRole(f.Role).IsValid() {
+		return "Invalid role"
+	}
+	if f.Status != "" && f.Status != "active" && f.Status != "disabled" {
+		return "status must be active or disabled"
+	}
+	return ""
+}
+
+// queryUsers returns a page of the users matching filter, newest first
+func (h *Handler) queryUsers(ctx context.Context, filter userFilter, page, pageSize uint64) (*models.ListUsersResponse, error) {
+	var conditions []string
+	var args []interface{}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		args = append(args, strings.ToLower(likeEscaper.Replace(q))+"%")
+		conditions = append(conditions, fmt.Sprintf("(lower(username) LIKE $%d OR lower(email) LIKE $%[1]d)", len(args)))
+	}
+	if filter.Role != "" {
+		args = append(args, filter.Role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+	switch filter.Status {
+	case "active":
+		conditions = append(conditions, "disabled_at IS NULL")
+	case "disabled":
+		conditions = append(conditions, "disabled_at IS NOT NULL")
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total uint64
+	if err := h.db.QueryRow(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := h.db.Query(ctx, fmt.Sprintf(
+		`SELECT `+userColumns+` FROM users%s ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d`,
+		where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.AdminUserResponse, 0, pageSize)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, user.ToAdminResponse())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &models.ListUsersResponse{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: uint64(math.Ceil(float64(total) / float64(pageSize))),
+	}, nil
+}
+
+// ListUsers handles listing users for admins. q matches the start of the
+// username or email, role and status (active or disabled) filter.
+func (h *Handler) ListUsers(c *gin.Context) {
+	page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page == 0 {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid page"))
+		return
+	}
+	pageSize, err := strconv.ParseUint(c.DefaultQuery("page_size", "20"), 10, 64)
+	if err != nil || pageSize == 0 || pageSize > 100 {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid page_size, must be between 1 and 100"))
+		return
+	}
+
+	filter := userFilter{Query: c.Query("q"), Role: c.Query("role"), Status: c.Query("status")}
+	if message := filter.validate(); message != "" {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(message))
+		return
+	}
+
+	response, err := h.queryUsers(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		h.logger.Error("Failed to list users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list users"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(response, ""))
+}
+
+// GetUser handles fetching a single user for admins
+func (h *Handler) GetUser(c *gin.Context) {
+	user := h.targetUser(c)
+	if user == nil {
+		return
+	}
+	c.JSON(http.StatusOK, models.NewSuccessResponse(user.ToAdminResponse(), ""))
+}
+
+// CreateUser handles an admin creating a user. Without a password the user
+// is mailed a link to choose one; otherwise to verify their email.
+func (h *Handler) CreateUser(c *gin.Context) {
+	var req models.AdminCreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleUser
+	}
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid role"))
+		return
+	}
+
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:        uuid.New(),
+		Username:  req.Username,
+		Email:     req.Email,
+		Role:      req.Role,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			h.logger.Error("Failed to hash password", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to create user"))
+			return
+		}
+		user.Password = string(hash)
+	}
+
+	ctx := c.Request.Context()
+	_, err := h.db.Exec(ctx, `
+		INSERT INTO users (id, username, email, password_hash, role, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)`,
+		user.ID, user.Username, user.Email, user.Password, user.Role, user.CreatedAt, user.UpdatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Username or email is already taken"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to create user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to create user"))
+		return
+	}
+
+	if user.Password == "" {
+		err = h.sendPasswordResetEmail(ctx, user)
+	} else {
+		err = h.sendVerificationEmail(ctx, user)
+	}
+	if err != nil {
+		h.logger.Error("Failed to send welcome email", zap.String("user_id", user.ID.String()), zap.Error(err))
+	}
+
+	h.auditAdminAction(c, models.AuditUserCreated, user.ID, map[string]interface{}{
+		"username": user.Username,
+		"role":     user.Role,
+	})
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(user.ToAdminResponse(), "User created"))
+}
+
+// UpdateUser handles an admin changing a user's email, role or disabled
+// state. Role changes and disabling end the user's sessions, so their
+// tokens stop working immediately; disabled users' API keys are rejected.
+func (h *Handler) UpdateUser(c *gin.Context) {
+	var req models.AdminUpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if req.Role != nil && !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid role"))
+		return
+	}
+
+	user := h.targetUser(c)
+	if user == nil {
+		return
+	}
+
+	roleChanged := req.Role != nil && *req.Role != user.Role
+	emailChanged := req.Email != nil && !strings.EqualFold(*req.Email, user.Email)
+	disabling := req.Disabled != nil && *req.Disabled && user.DisabledAt == nil
+	enabling := req.Disabled != nil && !*req.Disabled && user.DisabledAt != nil
+	if (roleChanged && !notSelf(c, user, "change the role of")) || (disabling && !notSelf(c, user, "disable")) {
+		return
+	}
+
+	now := time.Now().UTC()
+	previousRole := user.Role
+	if roleChanged {
+		user.Role = *req.Role
+	}
+	if emailChanged {
+		user.Email = *req.Email
+		user.EmailVerifiedAt = nil
+	}
+	switch {
+	case disabling:
+		user.DisabledAt = &now
+		if req.DisabledReason != nil {
+			user.DisabledReason = *req.DisabledReason
+		}
+	case enabling:
+		user.DisabledAt = nil
+		user.DisabledReason = ""
+	case user.DisabledAt != nil && req.DisabledReason != nil:
+		user.DisabledReason = *req.DisabledReason
+	}
+	user.UpdatedAt = now
+
+	ctx := c.Request.Context()
+	_, err := h.db.Exec(ctx, `
+		UPDATE users SET email = NULLIF($1, ''), email_verified_at = $2, role = $3,
+			disabled_at = $4, disabled_reason = $5, updated_at = $6
+		WHERE id = $7`,
+		user.Email, user.EmailVerifiedAt, user.Role, user.DisabledAt, user.DisabledReason, user.UpdatedAt, user.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Email is already taken"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to update user", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to update user"))
+		return
+	}
+
+	// Access tokens carry the role, so they are revoked rather than left to
+	// act with the old one until they expire
+	if roleChanged || disabling {
+		reason := "role changed"
+		if disabling {
+			reason = "user disabled"
+		}
+		if _, err := h.sessionStore().RevokeAll(ctx, user.ID, reason); err != nil {
+			h.logger.Error("Failed to revoke sessions", zap.String("user_id", user.ID.String()), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse("User updated but their sessions could not be revoked"))
+			return
+		}
+	}
+
+	if emailChanged && user.Email != "" {
+		if err := h.sendVerificationEmail(ctx, user); err != nil {
+			h.logger.Error("Failed to send verification email", zap.String("user_id", user.ID.String()), zap.Error(err))
+		}
+		h.auditAdminAction(c, models.AuditUserUpdated, user.ID, map[string]interface{}{"email": user.Email})
+	}
+	if roleChanged {
+		h.auditAdminAction(c, models.AuditUserRoleChanged, user.ID, map[string]interface{}{
+			"from": previousRole,
+			"to":   user.Role,
+		})
+	}
+	if disabling {
+		h.auditAdminAction(c, models.AuditUserDisabled, user.ID, map[string]interface{}{"reason": user.DisabledReason})
+	}
+	if enabling {
+		h.auditAdminAction(c, models.AuditUserEnabled, user.ID, nil)
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(user.ToAdminResponse(), "User updated"))
+}
+
+// DeleteUser handles an admin deleting a user with their credentials,
+// sessions and linked wallets and identities. Audit events and transaction
+// history are kept.
+func (h *Handler) DeleteUser(c *gin.Context) {
+	user := h.targetUser(c)
+	if user == nil || !notSelf(c, user, "delete") {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.sessionStore().RevokeAll(ctx, user.ID, "user deleted"); err != nil {
+		h.logger.Error("Failed to revoke sessions", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to delete user"))
+		return
+	}
+
+	err := pgx.BeginFunc(ctx, h.db, func(tx pgx.Tx) error {
+		for _, table := range []string{
+			"api_keys", "account_tokens", "user_mfa", "mfa_recovery_codes",
+			"user_identities", "user_wallets", "user_tiers", "user_sessions",
+		} {
+			if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, user.ID); err != nil {
+				return fmt.Errorf("failed to delete from %s: %w", table, err)
+			}
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, user.ID)
+		return err
+	})
+	if err != nil {
+		h.logger.Error("Failed to delete user", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to delete user"))
+		return
+	}
+
+	h.auditAdminAction(c, models.AuditUserDeleted, user.ID, map[string]interface{}{"username": user.Username})
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "User deleted"))
+}
+
+// ForcePasswordReset handles an admin requiring a user to choose a new
+// password. The user is logged out everywhere, can't log in with their old
+// password and is mailed a reset link.
+func (h *Handler) ForcePasswordReset(c *gin.Context) {
+	user := h.targetUser(c)
+	if user == nil {
+		return
+	}
+	if user.Password == "" {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("User has no password, they log in with SSO or a wallet"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now().UTC()
+	if _, err := h.db.Exec(ctx, `
+		UPDATE users SET password_reset_required = TRUE, updated_at = $1 WHERE id = $2`, now, user.ID); err != nil {
+		h.logger.Error("Failed to require password reset", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to require password reset"))
+		return
+	}
+	user.PasswordResetRequired = true
+	user.UpdatedAt = now
+
+	if _, err := h.sessionStore().RevokeAll(ctx, user.ID, "password reset required"); err != nil {
+		h.logger.Error("Failed to revoke sessions", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Password reset required but sessions could not be revoked"))
+		return
+	}
+
+	mailed := false
+	if user.Email != "" {
+		if err := h.sendPasswordResetEmail(ctx, user); err != nil {
+			h.logger.Error("Failed to send password reset email", zap.String("user_id", user.ID.String()), zap.Error(err))
+		} else {
+			mailed = true
+		}
+	}
+
+	h.auditAdminAction(c, models.AuditUserPasswordReset, user.ID, map[string]interface{}{"mailed": mailed})
+
+	message := "Password reset required, a reset link was emailed to the user"
+	if !mailed {
+		message = "Password reset required, but no reset link could be emailed to the user"
+	}
+	c.JSON(http.StatusOK, models.NewSuccessResponse(user.ToAdminResponse(), message))
+}
+
+// ImpersonateUser handles issuing an admin a short-lived access token to act
+// as a user for support. The token has no refresh token, names the admin in
+// its act claim and can't be used to change the user's credentials.
+func (h *Handler) ImpersonateUser(c *gin.Context) {
+	user := h.targetUser(c)
+	if user == nil || !notSelf(c, user, "impersonate") {
+		return
+	}
+	if user.Role == models.RoleAdmin {
+		c.JSON(http.StatusForbidden, models.NewErrorResponse("Admins can't be impersonated"))
+		return
+	}
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Disabled users can't be impersonated"))
+		return
+	}
+
+	adminID, _ := currentUserID(c)
+	ctx := c.Request.Context()
+	ttl := time.Duration(h.config.JWT.ExpiryMinutes) * time.Minute
+	session, err := h.sessionStore().Impersonate(ctx, user.ID, adminID, c.Request.UserAgent(), c.ClientIP(), ttl)
+	if err != nil {
+		h.logger.Error("Failed to start impersonation session", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to impersonate user"))
+		return
+	}
+
+	actor := &middleware.ActorClaim{Subject: adminID.String(), Username: c.GetString("username")}
+	token, jti, expiresAt, err := h.issueToken(user, session.ID, true, actor)
+	if err != nil {
+		h.logger.Error("Failed to sign impersonation token", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to impersonate user"))
+		return
+	}
+	if err := h.sessionStore().SetAccessToken(ctx, session.ID, jti, expiresAt); err != nil {
+		h.logger.Error("Failed to record impersonation token", zap.String("session_id", session.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to impersonate user"))
+		return
+	}
+
+	h.auditAdminAction(c, models.AuditUserImpersonated, user.ID, map[string]interface{}{
+		"session_id": session.ID,
+		"expires_at": expiresAt,
+	})
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(models.ImpersonationResponse{
+		User:           user.ToResponse(),
+		Token:          token,
+		ExpiresAt:      expiresAt,
+		ImpersonatorID: adminID,
+	}, "Impersonation started"))
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/tiers"
+	"github.com/twist/api-gateway/pkg/ethsig"
+	"go.uber.org/zap"
+)
+
+// tierChangeHistory is how many recent tier changes are returned with a user's tier
+const tierChangeHistory = 20
+
+func walletChallengeKey(userID uuid.UUID, address common.Address) string {
+	return fmt.Sprintf("wallet:challenge:%s:%s", userID, tiers.NormalizeAddress(address))
+}
+
+// refreshTier updates the user's tier after a wallet change. Failures are
+// logged; the periodic refresh will catch up.
+func (h *Handler) refreshTier(c *gin.Context, userID uuid.UUID, reason string) {
+	if h.tiers == nil {
+		return
+	}
+	if err := h.tiers.RefreshUser(c.Request.Context(), userID, reason); err != nil {
+		h.logger.Warn("Failed to refresh user tier", zap.String("user_id", userID.String()), zap.Error(err))
+	}
+}
+
+// CreateWalletChallenge handles issuing a message for a wallet to sign to prove control
+func (h *Handler) CreateWalletChallenge(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	var req models.WalletChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if !common.IsHexAddress(req.Address) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid address"))
+		return
+	}
+	address := common.HexToAddress(req.Address)
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		h.logger.Error("Failed to generate wallet challenge nonce", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to create challenge"))
+		return
+	}
+
+	ttl := time.Duration(h.config.Tiers.ChallengeTTLSeconds) * time.Second
+	issuedAt := time.Now().UTC().Truncate(time.Second)
+	challenge := models.WalletChallenge{
+		Address:   address.Hex(),
+		ExpiresAt: issuedAt.Add(ttl),
+	}
+	challenge.Message = fmt.Sprintf(
+		"Sign this message to link your wallet to your Twist account.\n\nAccount: %s\nAddress: %s\nNonce: %s\nIssued At: %s\nExpiration Time: %s",
+		c.GetString("username"),
+		challenge.Address,
+		hex.EncodeToString(nonce),
+		issuedAt.Format(time.RFC3339),
+		challenge.ExpiresAt.Format(time.RFC3339),
+	)
+
+	if err := h.redisClient.Set(c.Request.Context(), walletChallengeKey(userID, address), challenge.Message, ttl).Err(); err != nil {
+		h.logger.Error("Failed to store wallet challenge", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to create challenge"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(challenge, "Sign the message with the wallet to link it"))
+}
+
+// LinkWallet handles linking a wallet that signed its challenge to the current user
+func (h *Handler) LinkWallet(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	var req models.LinkWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse(err.Error()))
+		return
+	}
+	if !common.IsHexAddress(req.Address) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid address"))
+		return
+	}
+	address := common.HexToAddress(req.Address)
+
+	// A challenge can only be used once, whether or not the signature verifies
+	ctx := c.Request.Context()
+	message, err := h.redisClient.GetDel(ctx, walletChallengeKey(userID, address)).Result()
+	if errors.Is(err, redis.Nil) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("No pending challenge for this address, or it has expired"))
+		return
+	}
+	if err != nil {
+		h.logger.Error("Failed to load wallet challenge", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to link wallet"))
+		return
+	}
+
+	if err := ethsig.VerifyPersonal(address, []byte(message), req.Signature); err != nil {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Signature verification failed: "+err.Error()))
+		return
+	}
+
+	wallet := models.UserWallet{
+		Address:  tiers.NormalizeAddress(address),
+		Holdings: "0",
+		LinkedAt: time.Now().UTC(),
+	}
+	tag, err := h.db.Exec(ctx, `
+		INSERT INTO user_wallets (address, user_id, linked_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (address) DO NOTHING`,
+		wallet.Address, userID, wallet.LinkedAt)
+	if err != nil {
+		h.logger.Error("Failed to link wallet", zap.String("address", wallet.Address), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to link wallet"))
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusConflict, models.NewErrorResponse("Wallet is already linked to an account"))
+		return
+	}
+
+	h.refreshTier(c, userID, "wallet linked")
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(wallet, "Wallet linked successfully"))
+}
+
+// ListWallets handles listing the current user's linked wallets
+func (h *Handler) ListWallets(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	rows, err := h.db.Query(c.Request.Context(), `
+		SELECT address, holdings::text, linked_at, checked_at
+		FROM user_wallets WHERE user_id = $1
+		ORDER BY linked_at`, userID)
+	if err != nil {
+		h.logger.Error("Failed to list wallets", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list wallets"))
+		return
+	}
+	defer rows.Close()
+
+	wallets := []models.UserWallet{}
+	for rows.Next() {
+		var wallet models.UserWallet
+		if err := rows.Scan(&wallet.Address, &wallet.Holdings, &wallet.LinkedAt, &wallet.CheckedAt); err != nil {
+			h.logger.Error("Failed to scan wallet", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list wallets"))
+			return
+		}
+		wallets = append(wallets, wallet)
+	}
+	if err := rows.Err(); err != nil {
+		h.logger.Error("Failed to list wallets", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to list wallets"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(wallets, ""))
+}
+
+// UnlinkWallet handles removing a linked wallet from the current user
+func (h *Handler) UnlinkWallet(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	address := c.Param("address")
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, models.NewErrorResponse("Invalid address"))
+		return
+	}
+
+	tag, err := h.db.Exec(c.Request.Context(),
+		`DELETE FROM user_wallets WHERE address = $1 AND user_id = $2`,
+		tiers.NormalizeAddress(common.HexToAddress(address)), userID)
+	if err != nil {
+		h.logger.Error("Failed to unlink wallet", zap.String("address", address), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to unlink wallet"))
+		return
+	}
+	if tag.RowsAffected() == 0 {
+		c.JSON(http.StatusNotFound, models.NewErrorResponse("Wallet not found"))
+		return
+	}
+
+	h.refreshTier(c, userID, "wallet unlinked")
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(nil, "Wallet unlinked successfully"))
+}
+
+// GetCurrentUserTier handles fetching the current user's API tier, limits and tier history
+func (h *Handler) GetCurrentUserTier(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.NewErrorResponse("Authentication required"))
+		return
+	}
+
+	tier, err := tiers.LoadUserTier(c.Request.Context(), h.db, userID, tierChangeHistory)
+	if err != nil {
+		h.logger.Error("Failed to load user tier", zap.String("user_id", userID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, models.NewErrorResponse("Failed to load tier"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(tier, ""))
+}
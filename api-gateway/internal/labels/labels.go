@@ -0,0 +1,273 @@
+// Package labels implements Kubernetes-style key/value labels and the
+// selectors that match them.
+package labels
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	maxNameLength   = 63
+	maxPrefixLength = 253
+	maxValueLength  = 63
+	// MaxLabels is how many labels a single object may carry
+	MaxLabels = 64
+)
+
+var (
+	namePattern   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	prefixPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidateKey checks that key is an optional DNS subdomain prefix and a name,
+// such as team or twist.io/environment
+func ValidateKey(key string) error {
+	prefix, name, hasPrefix := strings.Cut(key, "/")
+	if !hasPrefix {
+		name, prefix = prefix, ""
+	}
+	if hasPrefix && (prefix == "" || len(prefix) > maxPrefixLength || !prefixPattern.MatchString(prefix)) {
+		return fmt.Errorf("label key %q must have a DNS subdomain prefix", key)
+	}
+	if len(name) == 0 || len(name) > maxNameLength || !namePattern.MatchString(name) {
+		return fmt.Errorf("label key %q must be 63 alphanumeric characters, '-', '_' or '.' at most, starting and ending with an alphanumeric", key)
+	}
+	return nil
+}
+
+// ValidateValue checks that value is empty or a valid label name
+func ValidateValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > maxValueLength || !namePattern.MatchString(value) {
+		return fmt.Errorf("label value %q must be 63 alphanumeric characters, '-', '_' or '.' at most, starting and ending with an alphanumeric", value)
+	}
+	return nil
+}
+
+// Validate checks every key and value in labels
+func Validate(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("at most %d labels are allowed", MaxLabels)
+	}
+	for key, value := range labels {
+		if err := ValidateKey(key); err != nil {
+			return err
+		}
+		if err := ValidateValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormatSet writes labels as comma-separated key=value pairs sorted by key
+func FormatSet(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		keys[i] = key + "=" + labels[key]
+	}
+	return strings.Join(keys, ",")
+}
+
+// ParseSet reads labels written by FormatSet and validates them
+func ParseSet(set string) (map[string]string, error) {
+	labels := map[string]string{}
+	if strings.TrimSpace(set) == "" {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(set, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("label %q must be written as key=value", strings.TrimSpace(pair))
+		}
+		key = strings.TrimSpace(key)
+		if _, dup := labels[key]; dup {
+			return nil, fmt.Errorf("label key %q is set more than once", key)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	if err := Validate(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// Operator is how a Requirement compares a label
+type Operator string
+
+const (
+	Equals       Operator = "="
+	NotEquals    Operator = "!="
+	In           Operator = "in"
+	NotIn        Operator = "notin"
+	Exists       Operator = "exists"
+	DoesNotExist Operator = "!"
+)
+
+// Requirement is one condition of a Selector
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Matches reports whether labels satisfy r
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case Exists:
+		return ok
+	case DoesNotExist:
+		return !ok
+	case Equals, In:
+		return ok && r.has(value)
+	case NotEquals, NotIn:
+		return !ok || !r.has(value)
+	}
+	return false
+}
+
+func (r Requirement) has(value string) bool {
+	for _, v := range r.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Requirement) String() string {
+	switch r.Operator {
+	case Exists:
+		return r.Key
+	case DoesNotExist:
+		return "!" + r.Key
+	case In, NotIn:
+		return r.Key + " " + string(r.Operator) + " (" + strings.Join(r.Values, ",") + ")"
+	}
+	return r.Key + string(r.Operator) + r.Values[0]
+}
+
+// Selector matches labels meeting all of its requirements. The empty
+// selector matches everything.
+type Selector []Requirement
+
+// Matches reports whether labels satisfy every requirement of s
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats s in the syntax accepted by Parse
+func (s Selector) String() string {
+	parts := make([]string, len(s))
+	for i, r := range s {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// Parse reads a comma-separated list of requirements in the Kubernetes
+// syntax: key=value, key==value, key!=value, key in (a,b), key notin (a,b),
+// key and !key.
+func Parse(selector string) (Selector, error) {
+	var s Selector
+	for _, part := range splitRequirements(selector) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty requirement in selector %q", selector)
+		}
+		r, err := parseRequirement(part)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+// splitRequirements splits on the commas outside parentheses
+func splitRequirements(selector string) []string {
+	if strings.TrimSpace(selector) == "" {
+		return nil
+	}
+	var parts []string
+	depth, start := 0, 0
+	for i, ch := range selector {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+func parseRequirement(part string) (Requirement, error) {
+	if strings.HasPrefix(part, "!") && !strings.Contains(part, "=") {
+		key := strings.TrimSpace(part[1:])
+		return Requirement{Key: key, Operator: DoesNotExist}, ValidateKey(key)
+	}
+
+	for _, op := range []string{"!=", "==", "="} {
+		if key, value, ok := strings.Cut(part, op); ok {
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			operator := Equals
+			if op == "!=" {
+				operator = NotEquals
+			}
+			if err := ValidateKey(key); err != nil {
+				return Requirement{}, err
+			}
+			if err := ValidateValue(value); err != nil {
+				return Requirement{}, err
+			}
+			return Requirement{Key: key, Operator: operator, Values: []string{value}}, nil
+		}
+	}
+
+	if open := strings.Index(part, "("); open >= 0 {
+		if !strings.HasSuffix(part, ")") {
+			return Requirement{}, fmt.Errorf("unterminated value set in %q", part)
+		}
+		fields := strings.Fields(part[:open])
+		if len(fields) != 2 || (fields[1] != string(In) && fields[1] != string(NotIn)) {
+			return Requirement{}, fmt.Errorf("expected \"key in (...)\" or \"key notin (...)\" in %q", part)
+		}
+		if err := ValidateKey(fields[0]); err != nil {
+			return Requirement{}, err
+		}
+		var values []string
+		for _, value := range strings.Split(part[open+1:len(part)-1], ",") {
+			value = strings.TrimSpace(value)
+			if err := ValidateValue(value); err != nil {
+				return Requirement{}, err
+			}
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		return Requirement{Key: fields[0], Operator: Operator(fields[1]), Values: values}, nil
+	}
+
+	key := strings.TrimSpace(part)
+	return Requirement{Key: key, Operator: Exists}, ValidateKey(key)
+}
@@ -0,0 +1,300 @@
+// Package lockout throttles authentication attempts. Failures are counted
+// per account and per client IP in Redis: repeated failures for an account
+// add an exponentially growing delay and then lock it temporarily, too many
+// failures from one IP lock the IP, and an IP failing against many distinct
+// accounts is treated as credential stuffing and blocked.
+package lockout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// Authentication methods guarded by a Guard
+const (
+	MethodPassword = "password"
+	MethodAPIKey   = "api_key"
+	MethodSIWE     = "siwe"
+	MethodMFA      = "mfa"
+)
+
+// Lockout scopes
+const (
+	ScopeUser = "user"
+	ScopeIP   = "ip"
+)
+
+// LockedError is returned when an attempt is refused because its account or
+// client IP is locked out or backing off
+type LockedError struct {
+	Scope string
+	Retry time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again in %s", e.Retry.Round(time.Second))
+}
+
+// RetryAfter returns how long the caller must wait before trying again
+func (e *LockedError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Options configures a Guard
+type Options struct {
+	// Window is how long failures are remembered
+	Window time.Duration
+
+	// BackoffAfter is the number of account failures after which each
+	// further attempt must wait BaseDelay, doubling per failure up to MaxDelay
+	BackoffAfter int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+
+	// UserMaxFailures and IPMaxFailures are the failures within Window that
+	// lock an account or a client IP for LockoutDuration
+	UserMaxFailures int
+	IPMaxFailures   int
+	LockoutDuration time.Duration
+
+	// StuffingAccounts is the number of distinct accounts failing from one
+	// IP within Window that blocks the IP as credential stuffing
+	StuffingAccounts int
+}
+
+// Guard tracks failed authentication attempts
+type Guard struct {
+	redis   *redis.Client
+	logger  *zap.Logger
+	metrics *metrics.PrometheusClient
+	audit   *audit.Recorder
+	opts    Options
+}
+
+// NewGuard creates a new Guard
+func NewGuard(redisClient *redis.Client, logger *zap.Logger, metrics *metrics.PrometheusClient, recorder *audit.Recorder, opts Options) *Guard {
+	return &Guard{redis: redisClient, logger: logger, metrics: metrics, audit: recorder, opts: opts}
+}
+
+// Attempt identifies an authentication attempt. Account is the username,
+// address or other identifier tried, empty when there is none (API keys).
+type Attempt struct {
+	Method    string
+	Account   string
+	IP        string
+	UserAgent string
+}
+
+func (a Attempt) account() string {
+	return strings.ToLower(a.Account)
+}
+
+func failuresKey(scope, id string) string {
+	return "auth:fail:" + scope + ":" + id
+}
+
+func backoffKey(id string) string {
+	return "auth:backoff:user:" + id
+}
+
+func lockKey(scope, id string) string {
+	return "auth:lock:" + scope + ":" + id
+}
+
+func accountsKey(ip string) string {
+	return "auth:accounts:ip:" + ip
+}
+
+// Check refuses attempt with a *LockedError when its account or IP is
+// locked out or its account is backing off. Redis errors are returned as is;
+// callers fail closed.
+func (g *Guard) Check(ctx context.Context, attempt Attempt) error {
+	type scopedKey struct{ scope, key string }
+	keys := []scopedKey{{ScopeIP, lockKey(ScopeIP, attempt.IP)}}
+	if account := attempt.account(); account != "" {
+		keys = append(keys,
+			scopedKey{ScopeUser, lockKey(ScopeUser, account)},
+			scopedKey{ScopeUser, backoffKey(account)})
+	}
+
+	pipe := g.redis.Pipeline()
+	ttls := make([]*redis.DurationCmd, len(keys))
+	for i, k := range keys {
+		ttls[i] = pipe.PTTL(ctx, k.key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	for i, ttl := range ttls {
+		// PTTL reports negative durations for missing keys. Blocked attempts
+		// are only counted: auditing each one would let an attacker flood
+		// the audit log.
+		if retry := ttl.Val(); retry > 0 {
+			g.metrics.RecordAuthFailure(attempt.Method, "locked")
+			return &LockedError{Scope: keys[i].scope, Retry: retry}
+		}
+	}
+	return nil
+}
+
+// Failure records a failed attempt, applying backoff and lockouts. reason
+// labels the failure in metrics and the audit log.
+func (g *Guard) Failure(ctx context.Context, attempt Attempt, reason string) {
+	g.metrics.RecordAuthFailure(attempt.Method, reason)
+	g.record(ctx, models.AuditLoginFailed, attempt, map[string]interface{}{"reason": reason})
+
+	if err := g.failure(ctx, attempt); err != nil {
+		g.logger.Error("Failed to record authentication failure",
+			zap.String("method", attempt.Method), zap.String("ip", attempt.IP), zap.Error(err))
+	}
+}
+
+func (g *Guard) failure(ctx context.Context, attempt Attempt) error {
+	account := attempt.account()
+
+	pipe := g.redis.TxPipeline()
+	ipFailures := pipe.Incr(ctx, failuresKey(ScopeIP, attempt.IP))
+	pipe.Expire(ctx, failuresKey(ScopeIP, attempt.IP), g.opts.Window)
+	var userFailures *redis.IntCmd
+	var accounts *redis.IntCmd
+	if account != "" {
+		userFailures = pipe.Incr(ctx, failuresKey(ScopeUser, account))
+		pipe.Expire(ctx, failuresKey(ScopeUser, account), g.opts.Window)
+		pipe.PFAdd(ctx, accountsKey(attempt.IP), account)
+		pipe.Expire(ctx, accountsKey(attempt.IP), g.opts.Window)
+		accounts = pipe.PFCount(ctx, accountsKey(attempt.IP))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if accounts != nil && g.opts.StuffingAccounts > 0 && accounts.Val() >= int64(g.opts.StuffingAccounts) {
+		locked, err := g.lock(ctx, ScopeIP, attempt.IP)
+		if err != nil {
+			return err
+		}
+		if locked {
+			g.metrics.RecordCredentialStuffing(attempt.Method)
+			g.record(ctx, models.AuditCredentialStuffing, attempt, map[string]interface{}{
+				"distinct_accounts": accounts.Val(),
+				"lockout_seconds":   int(g.opts.LockoutDuration.Seconds()),
+			})
+		}
+	} else if ipFailures.Val() >= int64(g.opts.IPMaxFailures) {
+		if err := g.lockAndRecord(ctx, ScopeIP, attempt.IP, attempt, ipFailures.Val()); err != nil {
+			return err
+		}
+	}
+
+	if userFailures == nil {
+		return nil
+	}
+	failures := userFailures.Val()
+	if failures >= int64(g.opts.UserMaxFailures) {
+		return g.lockAndRecord(ctx, ScopeUser, account, attempt, failures)
+	}
+	if failures >= int64(g.opts.BackoffAfter) {
+		return g.redis.Set(ctx, backoffKey(account), "1", g.backoff(failures)).Err()
+	}
+	return nil
+}
+
+// backoff returns the delay imposed after failures account failures
+func (g *Guard) backoff(failures int64) time.Duration {
+	delay := g.opts.BaseDelay
+	for i := int64(g.opts.BackoffAfter); i < failures && delay < g.opts.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.opts.MaxDelay {
+		delay = g.opts.MaxDelay
+	}
+	return delay
+}
+
+// lock locks id in scope and reports whether it was not locked already
+func (g *Guard) lock(ctx context.Context, scope, id string) (bool, error) {
+	return g.redis.SetNX(ctx, lockKey(scope, id), "1", g.opts.LockoutDuration).Result()
+}
+
+func (g *Guard) lockAndRecord(ctx context.Context, scope, id string, attempt Attempt, failures int64) error {
+	locked, err := g.lock(ctx, scope, id)
+	if err != nil || !locked {
+		return err
+	}
+	g.metrics.RecordAuthLockout(attempt.Method, scope)
+	g.record(ctx, models.AuditLockout, attempt, map[string]interface{}{
+		"scope":           scope,
+		"failures":        failures,
+		"lockout_seconds": int(g.opts.LockoutDuration.Seconds()),
+	})
+	return nil
+}
+
+// Success clears the failure count and backoff of attempt's account. The
+// IP's failures still count towards its own limits.
+func (g *Guard) Success(ctx context.Context, attempt Attempt) {
+	account := attempt.account()
+	if account == "" {
+		return
+	}
+	if err := g.redis.Del(ctx, failuresKey(ScopeUser, account), backoffKey(account)).Err(); err != nil {
+		g.logger.Warn("Failed to reset authentication failures", zap.String("account", account), zap.Error(err))
+	}
+}
+
+// Status reports the failures and remaining lockout of an account or IP
+func (g *Guard) Status(ctx context.Context, scope, id string) (*models.LockoutStatus, error) {
+	if scope == ScopeUser {
+		id = strings.ToLower(id)
+	}
+
+	pipe := g.redis.Pipeline()
+	failures := pipe.Get(ctx, failuresKey(scope, id))
+	lock := pipe.PTTL(ctx, lockKey(scope, id))
+	backoff := pipe.PTTL(ctx, backoffKey(id))
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	status := &models.LockoutStatus{Scope: scope, ID: id}
+	status.Failures, _ = failures.Int()
+	if retry := lock.Val(); retry > 0 {
+		status.Locked = true
+		status.RetryAfterSeconds = int(retry.Seconds())
+	} else if retry := backoff.Val(); scope == ScopeUser && retry > 0 {
+		status.RetryAfterSeconds = int(retry.Seconds())
+	}
+	return status, nil
+}
+
+// Unlock clears the lockout, backoff and failures of an account or IP
+func (g *Guard) Unlock(ctx context.Context, scope, id string) error {
+	if scope == ScopeUser {
+		id = strings.ToLower(id)
+		return g.redis.Del(ctx, lockKey(scope, id), backoffKey(id), failuresKey(scope, id)).Err()
+	}
+	return g.redis.Del(ctx, lockKey(scope, id), failuresKey(scope, id), accountsKey(id)).Err()
+}
+
+func (g *Guard) record(ctx context.Context, action string, attempt Attempt, metadata map[string]interface{}) {
+	metadata["method"] = attempt.Method
+	g.audit.Record(ctx, models.AuditEvent{
+		Action:     action,
+		Actor:      attempt.Account,
+		TargetType: "account",
+		TargetID:   attempt.account(),
+		IPAddress:  attempt.IP,
+		UserAgent:  attempt.UserAgent,
+		Metadata:   metadata,
+	})
+}
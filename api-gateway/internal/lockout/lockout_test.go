@@ -0,0 +1,220 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/audit"
+	"github.com/twist/api-gateway/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// testMetrics is shared because the collectors register globally
+var testMetrics = metrics.NewPrometheusClient()
+
+var testOptions = Options{
+	Window:          time.Minute,
+	BackoffAfter:    2,
+	BaseDelay:       time.Second,
+	MaxDelay:        4 * time.Second,
+	UserMaxFailures: 5,
+	IPMaxFailures:   100,
+	LockoutDuration: time.Minute,
+}
+
+func TestBackoff(t *testing.T) {
+	g := &Guard{opts: Options{BackoffAfter: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}}
+
+	tests := []struct {
+		failures int64
+		want     time.Duration
+	}{
+		{failures: 3, want: time.Second},
+		{failures: 4, want: 2 * time.Second},
+		{failures: 5, want: 4 * time.Second},
+		{failures: 6, want: 8 * time.Second},
+		{failures: 7, want: 10 * time.Second},
+		{failures: 50, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := g.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// newTestGuard connects to TEST_REDIS_URL, skipping the test unless it is
+// set. Audit events go to a database that isn't there, which the recorder
+// only logs.
+func newTestGuard(t *testing.T, opts Options) *Guard {
+	t.Helper()
+
+	redisURL := os.Getenv("TEST_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+	redisOptions, err := redis.ParseURL(redisURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	redisClient := redis.NewClient(redisOptions)
+	t.Cleanup(func() { _ = redisClient.Close() })
+
+	db, err := pgxpool.New(context.Background(), "postgres://lockout@127.0.0.1:1/lockout?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+
+	logger := zap.NewNop()
+	return NewGuard(redisClient, logger, testMetrics, audit.NewRecorder(db, logger), opts)
+}
+
+// newAttempt returns an attempt with an account and IP no other test uses
+func newAttempt(t *testing.T, g *Guard) Attempt {
+	t.Helper()
+
+	attempt := Attempt{Method: MethodPassword, Account: "User-" + uuid.NewString(), IP: "ip-" + uuid.NewString()}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = g.Unlock(ctx, ScopeUser, attempt.Account)
+		_ = g.Unlock(ctx, ScopeIP, attempt.IP)
+	})
+	return attempt
+}
+
+// retryAfter returns how long Check makes attempt wait, failing the test
+// unless it is refused in scope
+func retryAfter(t *testing.T, g *Guard, attempt Attempt, scope string) time.Duration {
+	t.Helper()
+
+	err := g.Check(context.Background(), attempt)
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("Check = %v, want a *LockedError", err)
+	}
+	if locked.Scope != scope {
+		t.Errorf("Scope = %s, want %s", locked.Scope, scope)
+	}
+	return locked.RetryAfter()
+}
+
+func TestFailuresEscalate(t *testing.T) {
+	g := newTestGuard(t, testOptions)
+	ctx := context.Background()
+	attempt := newAttempt(t, g)
+
+	g.Failure(ctx, attempt, "invalid_password")
+	if err := g.Check(ctx, attempt); err != nil {
+		t.Fatalf("Check after one failure = %v, want nil", err)
+	}
+
+	// Backoff doubles from BaseDelay per failure, up to MaxDelay
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		g.Failure(ctx, attempt, "invalid_password")
+		retry := retryAfter(t, g, attempt, ScopeUser)
+		if retry <= want/2 || retry > want {
+			t.Errorf("RetryAfter = %s, want up to %s", retry, want)
+		}
+	}
+
+	// UserMaxFailures locks the account for LockoutDuration
+	g.Failure(ctx, attempt, "invalid_password")
+	if retry := retryAfter(t, g, attempt, ScopeUser); retry <= testOptions.MaxDelay || retry > testOptions.LockoutDuration {
+		t.Errorf("RetryAfter = %s, want up to %s", retry, testOptions.LockoutDuration)
+	}
+
+	// The account is locked whatever the case of the name and the IP
+	other := attempt
+	other.Account = "user-" + attempt.Account[len("User-"):]
+	other.IP = "ip-" + uuid.NewString()
+	retryAfter(t, g, other, ScopeUser)
+
+	status, err := g.Status(ctx, ScopeUser, attempt.Account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Locked || status.Failures != 5 || status.RetryAfterSeconds <= 0 {
+		t.Errorf("Status = %+v, want locked after 5 failures", status)
+	}
+
+	// A success doesn't lift the lockout, an admin unlock does
+	g.Success(ctx, attempt)
+	retryAfter(t, g, attempt, ScopeUser)
+	if err := g.Unlock(ctx, ScopeUser, attempt.Account); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Check(ctx, attempt); err != nil {
+		t.Errorf("Check after unlock = %v, want nil", err)
+	}
+}
+
+func TestSuccessClearsBackoff(t *testing.T) {
+	g := newTestGuard(t, testOptions)
+	ctx := context.Background()
+	attempt := newAttempt(t, g)
+
+	for i := 0; i < testOptions.BackoffAfter; i++ {
+		g.Failure(ctx, attempt, "invalid_password")
+	}
+	retryAfter(t, g, attempt, ScopeUser)
+
+	g.Success(ctx, attempt)
+	if err := g.Check(ctx, attempt); err != nil {
+		t.Errorf("Check after success = %v, want nil", err)
+	}
+	status, err := g.Status(ctx, ScopeUser, attempt.Account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Failures != 0 {
+		t.Errorf("Failures = %d, want 0", status.Failures)
+	}
+}
+
+func TestIPLockout(t *testing.T) {
+	opts := testOptions
+	opts.IPMaxFailures = 3
+	g := newTestGuard(t, opts)
+	ctx := context.Background()
+	attempt := newAttempt(t, g)
+
+	// API key failures have no account and only count against the IP
+	keyAttempt := Attempt{Method: MethodAPIKey, IP: attempt.IP}
+	for i := 0; i < opts.IPMaxFailures; i++ {
+		if err := g.Check(ctx, keyAttempt); err != nil {
+			t.Fatalf("Check after %d failures = %v, want nil", i, err)
+		}
+		g.Failure(ctx, keyAttempt, "invalid_key")
+	}
+
+	retryAfter(t, g, keyAttempt, ScopeIP)
+	if retry := retryAfter(t, g, attempt, ScopeIP); retry > opts.LockoutDuration {
+		t.Errorf("RetryAfter = %s, want up to %s", retry, opts.LockoutDuration)
+	}
+}
+
+func TestCredentialStuffing(t *testing.T) {
+	opts := testOptions
+	opts.StuffingAccounts = 3
+	g := newTestGuard(t, opts)
+	ctx := context.Background()
+	ip := newAttempt(t, g).IP
+
+	// One failure each against distinct accounts stays below every account limit
+	for i := 0; i < opts.StuffingAccounts; i++ {
+		attempt := newAttempt(t, g)
+		attempt.IP = ip
+		g.Failure(ctx, attempt, "invalid_password")
+	}
+
+	fresh := newAttempt(t, g)
+	fresh.IP = ip
+	retryAfter(t, g, fresh, ScopeIP)
+}
@@ -0,0 +1,515 @@
+// Package mfa manages TOTP second factors: enrolment, code and recovery code
+// verification, the short-lived challenges that complete a two-step login,
+// and the roles that are required to use MFA.
+package mfa
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/totp"
+)
+
+var (
+	// ErrNotEnrolled is returned when confirming an enrolment that was never started
+	ErrNotEnrolled = errors.New("no TOTP enrolment in progress")
+
+	// ErrAlreadyEnabled is returned when enrolling a user who already has MFA
+	ErrAlreadyEnabled = errors.New("MFA is already enabled")
+
+	// ErrNotEnabled is returned for operations that need MFA to be enabled
+	ErrNotEnabled = errors.New("MFA is not enabled")
+
+	// ErrInvalidCode is returned for wrong, expired or already used codes
+	ErrInvalidCode = errors.New("invalid or already used code")
+
+	// ErrInvalidChallenge is returned for unknown, expired or exhausted MFA tokens
+	ErrInvalidChallenge = errors.New("invalid or expired MFA token")
+)
+
+const (
+	// codeSkew is how many 30 second steps of clock drift are tolerated
+	codeSkew = 1
+
+	recoveryCodeCount   = 10
+	requirementCacheTTL = time.Minute
+)
+
+// Options configures a Service
+type Options struct {
+	// Issuer names the account in authenticator apps
+	Issuer string
+
+	// ChallengeTTL is how long a login has to present its second factor
+	ChallengeTTL time.Duration
+
+	// MaxAttempts is how many wrong codes a challenge accepts before it is discarded
+	MaxAttempts int
+}
+
+// Service manages users' second factors
+type Service struct {
+	db    *pgxpool.Pool
+	redis *redis.Client
+	aead  cipher.AEAD
+	opts  Options
+}
+
+// NewService creates a new Service. TOTP secrets are encrypted at rest with
+// a key derived from secret.
+func NewService(db *pgxpool.Pool, redisClient *redis.Client, secret string, opts Options) (*Service, error) {
+	key := sha256.Sum256([]byte("twist-mfa-secret:" + secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{db: db, redis: redisClient, aead: aead, opts: opts}, nil
+}
+
+func (s *Service) seal(plaintext string) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, []byte(plaintext), nil), nil
+}
+
+func (s *Service) open(ciphertext []byte) (string, error) {
+	if len(ciphertext) < s.aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:s.aead.NonceSize()], ciphertext[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// Status returns userID's MFA status. role decides whether MFA is required.
+func (s *Service) Status(ctx context.Context, userID uuid.UUID, role models.UserRole) (*models.MFAStatus, error) {
+	var status models.MFAStatus
+	var enrolled bool
+	err := s.db.QueryRow(ctx, `
+		SELECT m.enabled_at,
+			(SELECT COUNT(*) FROM mfa_recovery_codes r WHERE r.user_id = m.user_id AND r.used_at IS NULL)
+		FROM user_mfa m WHERE m.user_id = $1`, userID,
+	).Scan(&status.EnabledAt, &status.RecoveryCodesRemaining)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, err
+	default:
+		enrolled = true
+	}
+	status.Enabled = status.EnabledAt != nil
+	status.PendingEnrolment = enrolled && !status.Enabled
+
+	if status.Required, err = s.Required(ctx, role); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Enabled reports whether userID has confirmed a TOTP second factor
+func (s *Service) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_mfa WHERE user_id = $1 AND enabled_at IS NOT NULL)`, userID,
+	).Scan(&enabled)
+	return enabled, err
+}
+
+// Enrol starts TOTP enrolment for userID with a new secret, replacing any
+// unconfirmed one. account labels the entry in authenticator apps.
+func (s *Service) Enrol(ctx context.Context, userID uuid.UUID, account string) (*models.TOTPEnrolment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.db.Exec(ctx, `
+		INSERT INTO user_mfa (user_id, secret, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_used_step = 0
+		WHERE user_mfa.enabled_at IS NULL`,
+		userID, sealed, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrAlreadyEnabled
+	}
+
+	return &models.TOTPEnrolment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.opts.Issuer, account, secret),
+	}, nil
+}
+
+// Activate confirms userID's pending enrolment with a code from their
+// authenticator and returns a fresh set of recovery codes
+func (s *Service) Activate(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	var codes []string
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var sealed []byte
+		var enabledAt *time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT secret, enabled_at FROM user_mfa WHERE user_id = $1 FOR UPDATE`, userID,
+		).Scan(&sealed, &enabledAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+		if enabledAt != nil {
+			return ErrAlreadyEnabled
+		}
+
+		secret, err := s.open(sealed)
+		if err != nil {
+			return err
+		}
+		step, ok := totp.Validate(secret, code, time.Now(), codeSkew)
+		if !ok {
+			return ErrInvalidCode
+		}
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE user_mfa SET enabled_at = $1, last_used_step = $2 WHERE user_id = $3`,
+			time.Now().UTC(), step, userID); err != nil {
+			return err
+		}
+		codes, err = replaceRecoveryCodes(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP or recovery code for userID. Each code is accepted
+// only once.
+func (s *Service) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != totp.Digits {
+		return s.useRecoveryCode(ctx, userID, code)
+	}
+
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var sealed []byte
+		var lastStep int64
+		err := tx.QueryRow(ctx, `
+			SELECT secret, last_used_step FROM user_mfa
+			WHERE user_id = $1 AND enabled_at IS NOT NULL
+			FOR UPDATE`, userID,
+		).Scan(&sealed, &lastStep)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotEnabled
+		}
+		if err != nil {
+			return err
+		}
+
+		secret, err := s.open(sealed)
+		if err != nil {
+			return err
+		}
+		step, ok := totp.Validate(secret, code, time.Now(), codeSkew)
+		if !ok || step <= lastStep {
+			return ErrInvalidCode
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE user_mfa SET last_used_step = $1 WHERE user_id = $2`, step, userID)
+		return err
+	})
+}
+
+func (s *Service) useRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+		AND EXISTS (SELECT 1 FROM user_mfa WHERE user_id = $2 AND enabled_at IS NOT NULL)`,
+		time.Now().UTC(), userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// Disable removes userID's second factor and recovery codes
+func (s *Service) Disable(ctx context.Context, userID uuid.UUID) error {
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotEnabled
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID)
+		return err
+	})
+}
+
+// RegenerateRecoveryCodes replaces userID's recovery codes
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	var codes []string
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var enabled bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM user_mfa WHERE user_id = $1 AND enabled_at IS NOT NULL)`, userID,
+		).Scan(&enabled); err != nil {
+			return err
+		}
+		if !enabled {
+			return ErrNotEnabled
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID uuid.UUID) ([]string, error) {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`,
+			userID, hashRecoveryCode(code), now); err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a code formatted as two groups of five characters
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw))[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// hashRecoveryCode hashes code ignoring case and separators
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func challengeKey(token string) string {
+	return "mfa:challenge:" + token
+}
+
+// Challenge is the pending second step of a login
+type Challenge struct {
+	UserID uuid.UUID
+
+	// Account is the lockout account of the login's first factor, whose
+	// failures are cleared once the challenge completes. Empty when the
+	// first factor isn't throttled.
+	Account string
+}
+
+// CreateChallenge starts the second step of userID's login and returns the
+// token that must be presented with a code. account is the lockout account
+// of the first factor.
+func (s *Service) CreateChallenge(ctx context.Context, userID uuid.UUID, account string) (*models.MFAChallenge, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	key := challengeKey(token)
+	pipe := s.redis.TxPipeline()
+	pipe.HSet(ctx, key, "user_id", userID.String(), "account", account, "attempts", 0)
+	pipe.Expire(ctx, key, s.opts.ChallengeTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return &models.MFAChallenge{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresAt:   time.Now().UTC().Add(s.opts.ChallengeTTL),
+	}, nil
+}
+
+// Challenge returns the pending login behind token without verifying a code
+func (s *Service) Challenge(ctx context.Context, token string) (*Challenge, error) {
+	values, err := s.redis.HMGet(ctx, challengeKey(token), "user_id", "account").Result()
+	if err != nil {
+		return nil, err
+	}
+	value, _ := values[0].(string)
+	if value == "" {
+		return nil, ErrInvalidChallenge
+	}
+	userID, err := uuid.Parse(value)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	account, _ := values[1].(string)
+	return &Challenge{UserID: userID, Account: account}, nil
+}
+
+// CompleteChallenge verifies code for the login behind token and returns its
+// user. The token is discarded once it succeeds or has seen too many wrong codes.
+func (s *Service) CompleteChallenge(ctx context.Context, token, code string) (uuid.UUID, error) {
+	challenge, err := s.Challenge(ctx, token)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	userID := challenge.UserID
+	key := challengeKey(token)
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		if errors.Is(err, ErrInvalidCode) {
+			attempts, incrErr := s.redis.HIncrBy(ctx, key, "attempts", 1).Result()
+			if incrErr == nil && attempts >= int64(s.opts.MaxAttempts) {
+				incrErr = s.redis.Del(ctx, key).Err()
+			}
+			if incrErr != nil {
+				return uuid.Nil, incrErr
+			}
+		}
+		return uuid.Nil, err
+	}
+
+	// A concurrent request may have completed the same challenge first
+	deleted, err := s.redis.Del(ctx, key).Result()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if deleted == 0 {
+		return uuid.Nil, ErrInvalidChallenge
+	}
+	return userID, nil
+}
+
+func requirementKey(role models.UserRole) string {
+	return "mfa:required:" + string(role)
+}
+
+// Required reports whether members of role must use MFA
+func (s *Service) Required(ctx context.Context, role models.UserRole) (bool, error) {
+	key := requirementKey(role)
+	if cached, err := s.redis.Get(ctx, key).Result(); err == nil {
+		return cached == "1", nil
+	} else if !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	var required bool
+	if err := s.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM mfa_role_requirements WHERE role = $1)`, role,
+	).Scan(&required); err != nil {
+		return false, err
+	}
+
+	value := "0"
+	if required {
+		value = "1"
+	}
+	if err := s.redis.Set(ctx, key, value, requirementCacheTTL).Err(); err != nil {
+		return false, err
+	}
+	return required, nil
+}
+
+// Requirements lists whether MFA is required for each role
+func (s *Service) Requirements(ctx context.Context) ([]models.MFARoleRequirement, error) {
+	rows, err := s.db.Query(ctx, `SELECT role, updated_at FROM mfa_role_requirements`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	updated := make(map[models.UserRole]time.Time)
+	for rows.Next() {
+		var role models.UserRole
+		var at time.Time
+		if err := rows.Scan(&role, &at); err != nil {
+			return nil, err
+		}
+		updated[role] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	requirements := make([]models.MFARoleRequirement, 0, len(models.UserRoles))
+	for _, role := range models.UserRoles {
+		requirement := models.MFARoleRequirement{Role: role}
+		if at, ok := updated[role]; ok {
+			requirement.Required = true
+			requirement.UpdatedAt = &at
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// SetRequired requires or stops requiring MFA for members of role
+func (s *Service) SetRequired(ctx context.Context, role models.UserRole, required bool, updatedBy uuid.UUID) error {
+	var err error
+	if required {
+		_, err = s.db.Exec(ctx, `
+			INSERT INTO mfa_role_requirements (role, updated_by, updated_at) VALUES ($1, $2, $3)
+			ON CONFLICT (role) DO UPDATE SET updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at`,
+			role, updatedBy, time.Now().UTC())
+	} else {
+		_, err = s.db.Exec(ctx, `DELETE FROM mfa_role_requirements WHERE role = $1`, role)
+	}
+	if err != nil {
+		return err
+	}
+	return s.redis.Del(ctx, requirementKey(role)).Err()
+}
@@ -1,78 +1,314 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/apikeys"
 	"github.com/twist/api-gateway/internal/models"
 )
 
 // JWTClaims represents the claims in the JWT
 type JWTClaims struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	MFA       bool   `json:"mfa,omitempty"`
+
+	// Actor identifies the admin acting as the user while impersonating them
+	Actor *ActorClaim `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// Auth middleware validates the JWT token
-func Auth(secret string) gin.HandlerFunc {
+// ActorClaim is the RFC 8693 actor claim of an impersonation token
+type ActorClaim struct {
+	Subject  string `json:"sub"`
+	Username string `json:"username,omitempty"`
+}
+
+// TokenDenylist reports whether an access token has been revoked by its jti
+type TokenDenylist interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// TokenParser verifies an access token and returns its claims
+type TokenParser interface {
+	Parse(tokenString string) (*JWTClaims, error)
+}
+
+// MFAPolicy reports whether members of a role must use a second factor
+type MFAPolicy interface {
+	Required(ctx context.Context, role models.UserRole) (bool, error)
+}
+
+// APIKeyAuthenticator resolves an API key to the user it belongs to
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key, ip string) (*apikeys.Principal, error)
+}
+
+// Identity is the authenticated caller of a request
+type Identity struct {
+	UserID    uuid.UUID
+	Username  string
+	Role      models.UserRole
+	MFA       bool
+	SessionID uuid.UUID
+	JTI       string
+	ExpiresAt time.Time
+
+	// AuthMethod is "api_key" for API keys and empty for access tokens
+	AuthMethod string
+
+	// ImpersonatorID is the admin acting as the user, nil unless impersonating
+	ImpersonatorID *uuid.UUID
+	Impersonator   string
+}
+
+// authError is why a request could not be authenticated
+type authError struct {
+	status     int
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *authError) Error() string {
+	return e.message
+}
+
+// authenticateBearer verifies a "Bearer <token>" authorization value
+func authenticateBearer(ctx context.Context, parser TokenParser, denylist TokenDenylist, authHeader string) (*Identity, *authError) {
+	if authHeader == "" {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Authorization header is required"}
+	}
+
+	// Check if it's a Bearer token
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid authorization format, Bearer token required"}
+	}
+
+	// Parse the token
+	claims, err := parser.Parse(parts[1])
+	if err != nil {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid or expired token"}
+	}
+
+	// Check if token is expired
+	if time.Until(claims.ExpiresAt.Time) < 0 {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Token expired"}
+	}
+
+	// Check if token has been revoked
+	if claims.ID != "" {
+		revoked, err := denylist.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return nil, &authError{status: http.StatusServiceUnavailable, message: "Unable to verify token"}
+		}
+		if revoked {
+			return nil, &authError{status: http.StatusUnauthorized, message: "Token has been revoked"}
+		}
+	}
+
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid user ID in token"}
+	}
+
+	identity := &Identity{
+		UserID:    userID,
+		Username:  claims.Username,
+		Role:      models.UserRole(claims.Role),
+		MFA:       claims.MFA,
+		JTI:       claims.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if sessionID, err := uuid.Parse(claims.SessionID); err == nil {
+		identity.SessionID = sessionID
+	}
+	if claims.Actor != nil {
+		impersonatorID, err := uuid.Parse(claims.Actor.Subject)
+		if err != nil {
+			return nil, &authError{status: http.StatusUnauthorized, message: "Invalid actor in token"}
+		}
+		identity.ImpersonatorID = &impersonatorID
+		identity.Impersonator = claims.Actor.Username
+	}
+	return identity, nil
+}
+
+// AuthenticateBearer verifies a "Bearer <token>" authorization value the way
+// Auth does, for handlers outside the protected routes. On failure it returns
+// the status Auth would respond with.
+func AuthenticateBearer(ctx context.Context, parser TokenParser, denylist TokenDenylist, authHeader string) (*Identity, int, error) {
+	identity, authErr := authenticateBearer(ctx, parser, denylist, authHeader)
+	if authErr != nil {
+		return nil, authErr.status, authErr
+	}
+	return identity, http.StatusOK, nil
+}
+
+// authenticateKey verifies an API key. A key counts as second-factor
+// authenticated only when the session that created it passed MFA.
+func authenticateKey(ctx context.Context, apiKeys APIKeyAuthenticator, key, ip string) (*Identity, *authError) {
+	principal, err := apiKeys.AuthenticateAPIKey(ctx, key, ip)
+	var limited interface{ RetryAfter() time.Duration }
+	switch {
+	case errors.As(err, &limited):
+		return nil, &authError{status: http.StatusTooManyRequests, message: err.Error(), retryAfter: limited.RetryAfter()}
+	case errors.Is(err, apikeys.ErrInvalidKey):
+		return nil, &authError{status: http.StatusUnauthorized, message: "Invalid or expired API key"}
+	case errors.Is(err, apikeys.ErrUserDisabled):
+		return nil, &authError{status: http.StatusForbidden, message: err.Error()}
+	case err != nil:
+		return nil, &authError{status: http.StatusServiceUnavailable, message: "Unable to verify API key"}
+	}
+
+	return &Identity{
+		UserID:     principal.User.ID,
+		Username:   principal.User.Username,
+		Role:       principal.User.Role,
+		MFA:        principal.MFA,
+		AuthMethod: "api_key",
+	}, nil
+}
+
+// Auth middleware validates the JWT token and rejects revoked tokens.
+// Requests may instead authenticate with an X-API-Key header.
+func Auth(parser TokenParser, denylist TokenDenylist, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get the Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse("Authorization header is required"))
+		var identity *Identity
+		var authErr *authError
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			identity, authErr = authenticateKey(c.Request.Context(), apiKeys, key, c.ClientIP())
+		} else {
+			identity, authErr = authenticateBearer(c.Request.Context(), parser, denylist, c.GetHeader("Authorization"))
+		}
+		if authErr != nil {
+			if authErr.retryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(authErr.retryAfter.Seconds())+1))
+			}
+			c.AbortWithStatusJSON(authErr.status, models.NewErrorResponse(authErr.message))
 			return
 		}
 
-		// Check if it's a Bearer token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid authorization format, Bearer token required"))
-			return
+		// Set user data in the context
+		c.Set("user_id", identity.UserID)
+		c.Set("username", identity.Username)
+		c.Set("role", string(identity.Role))
+		c.Set("mfa", identity.MFA)
+		if identity.AuthMethod != "" {
+			c.Set("auth_method", identity.AuthMethod)
+		} else {
+			c.Set("jti", identity.JTI)
+			c.Set("token_expires_at", identity.ExpiresAt)
+		}
+		if identity.SessionID != uuid.Nil {
+			c.Set("session_id", identity.SessionID)
+		}
+		if identity.ImpersonatorID != nil {
+			c.Set("impersonator_id", *identity.ImpersonatorID)
+			c.Set("impersonator", identity.Impersonator)
 		}
 
-		// Extract the token
-		tokenString := parts[1]
+		c.Next()
+	}
+}
 
-		// Parse the token
-		token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+// mfaRequiredMessage explains how a caller authenticated with authMethod can
+// satisfy a role's MFA requirement
+func mfaRequiredMessage(authMethod string) string {
+	if authMethod == "api_key" {
+		return "Multi-factor authentication is required for your role, create a new API key after logging in with MFA"
+	}
+	return "Multi-factor authentication is required for your role, enable it at /api/v1/users/me/mfa and log in again"
+}
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid or expired token"))
+// RequireMFA rejects tokens issued without a second factor, and API keys
+// created without one, when the user's role requires MFA. It must run after
+// Auth.
+func RequireMFA(policy MFAPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("mfa") {
+			c.Next()
 			return
 		}
 
-		// Extract claims
-		claims, ok := token.Claims.(*JWTClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid token claims"))
+		required, err := policy.Required(c.Request.Context(), models.UserRole(c.GetString("role")))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.NewErrorResponse("Unable to verify MFA policy"))
+			return
+		}
+		if required {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse(mfaRequiredMessage(c.GetString("auth_method"))))
 			return
 		}
 
-		// Check if token is expired
-		if time.Until(claims.ExpiresAt.Time) < 0 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse("Token expired"))
+		c.Next()
+	}
+}
+
+// EmailVerifier reports whether a user has verified their email address
+type EmailVerifier interface {
+	EmailVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// RequireVerifiedEmail rejects requests that change state from users who have
+// not verified their email address. Safe methods pass. It must run after Auth.
+func RequireVerifiedEmail(verifier EmailVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
 			return
 		}
 
-		// Set user data in the context
-		userID, err := uuid.Parse(claims.UserID)
+		userID, _ := c.Get("user_id")
+		id, _ := userID.(uuid.UUID)
+		verified, err := verifier.EmailVerified(c.Request.Context(), id)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, models.NewErrorResponse("Invalid user ID in token"))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, models.NewErrorResponse("Unable to verify email status"))
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse(
+				"Verify your email address before making changes, request a new link at /api/v1/users/me/email/verification"))
 			return
 		}
 
-		c.Set("user_id", userID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role)
+		c.Next()
+	}
+}
 
+// ForbidImpersonation rejects requests made by an admin impersonating the
+// user, for actions only the user should take. It must run after Auth.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("impersonator_id"); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse("Not allowed while impersonating a user"))
+			return
+		}
 		c.Next()
 	}
 }
+
+// RequireRole rejects users whose role is not one of roles. It must run after Auth.
+func RequireRole(roles ...models.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := models.UserRole(c.GetString("role"))
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse("Insufficient permissions"))
+	}
+}
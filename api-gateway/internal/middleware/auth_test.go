@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/apikeys"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// fakeKeys authenticates every key in its map as an admin
+type fakeKeys map[string]bool
+
+func (k fakeKeys) AuthenticateAPIKey(_ context.Context, key, _ string) (*apikeys.Principal, error) {
+	mfa, ok := k[key]
+	if !ok {
+		return nil, apikeys.ErrInvalidKey
+	}
+	return &apikeys.Principal{
+		User: models.User{ID: uuid.New(), Username: "ada", Role: models.RoleAdmin},
+		MFA:  mfa,
+	}, nil
+}
+
+// requiredFor requires MFA for the listed roles
+type requiredFor []models.UserRole
+
+func (p requiredFor) Required(_ context.Context, role models.UserRole) (bool, error) {
+	for _, r := range p {
+		if r == role {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestRequireMFAChecksAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := fakeKeys{"twk_mfa": true, "twk_plain": false}
+
+	tests := []struct {
+		name   string
+		key    string
+		policy requiredFor
+		status int
+	}{
+		{name: "key created with MFA", key: "twk_mfa", policy: requiredFor{models.RoleAdmin}, status: http.StatusOK},
+		{name: "key created without MFA", key: "twk_plain", policy: requiredFor{models.RoleAdmin}, status: http.StatusForbidden},
+		{name: "MFA not required", key: "twk_plain", status: http.StatusOK},
+		{name: "unknown key", key: "twk_other", policy: requiredFor{models.RoleAdmin}, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", Auth(nil, nil, keys), RequireMFA(tt.policy), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-API-Key", tt.key)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if rec.Code == http.StatusForbidden && !strings.Contains(rec.Body.String(), "API key") {
+				t.Errorf("body = %s, want a hint to replace the API key", rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/twist/api-gateway/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller authenticated by the gRPC auth
+// interceptors
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// GRPCPolicy is what a gRPC method requires of its caller beyond
+// authentication, like the middleware on the matching REST route
+type GRPCPolicy struct {
+	// Roles the caller must have one of; empty allows every role
+	Roles []models.UserRole
+
+	// RequireVerifiedEmail applies RequireVerifiedEmail when a verifier is configured
+	RequireVerifiedEmail bool
+
+	// ForbidImpersonation rejects admins impersonating the caller
+	ForbidImpersonation bool
+}
+
+// GRPCAuthOptions configures the gRPC auth interceptors
+type GRPCAuthOptions struct {
+	Parser   TokenParser
+	Denylist TokenDenylist
+	APIKeys  APIKeyAuthenticator
+	MFA      MFAPolicy
+
+	// EmailVerifier enforces RequireVerifiedEmail policies; nil disables them
+	EmailVerifier EmailVerifier
+
+	// Policies holds per-method requirements keyed by full method name
+	Policies map[string]GRPCPolicy
+
+	// PublicServices are full service names, such as grpc.health.v1.Health,
+	// that are served without authentication
+	PublicServices []string
+}
+
+// grpcCodes maps the HTTP statuses used by Auth to gRPC codes
+var grpcCodes = map[int]codes.Code{
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusTooManyRequests:    codes.ResourceExhausted,
+	http.StatusServiceUnavailable: codes.Unavailable,
+}
+
+func (o *GRPCAuthOptions) public(fullMethod string) bool {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	for _, public := range o.PublicServices {
+		if service == public {
+			return true
+		}
+	}
+	return false
+}
+
+// authorize authenticates the call in ctx and checks fullMethod's policy. It
+// returns ctx with the caller's Identity.
+func (o *GRPCAuthOptions) authorize(ctx context.Context, fullMethod string, setHeader func(metadata.MD) error) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	var identity *Identity
+	var authErr *authError
+	if key := first("x-api-key"); key != "" {
+		identity, authErr = authenticateKey(ctx, o.APIKeys, key, peerIP(ctx))
+	} else {
+		identity, authErr = authenticateBearer(ctx, o.Parser, o.Denylist, first("authorization"))
+	}
+	if authErr != nil {
+		if authErr.retryAfter > 0 {
+			_ = setHeader(metadata.Pairs("retry-after", strconv.Itoa(int(authErr.retryAfter.Seconds())+1)))
+		}
+		return nil, status.Error(grpcCodes[authErr.status], authErr.message)
+	}
+
+	if !identity.MFA {
+		required, err := o.MFA.Required(ctx, identity.Role)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, "Unable to verify MFA policy")
+		}
+		if required {
+			return nil, status.Error(codes.PermissionDenied, mfaRequiredMessage(identity.AuthMethod))
+		}
+	}
+
+	policy := o.Policies[fullMethod]
+	if len(policy.Roles) > 0 && !hasRole(identity.Role, policy.Roles) {
+		return nil, status.Error(codes.PermissionDenied, "Insufficient permissions")
+	}
+	if policy.ForbidImpersonation && identity.ImpersonatorID != nil {
+		return nil, status.Error(codes.PermissionDenied, "Not allowed while impersonating a user")
+	}
+	if policy.RequireVerifiedEmail && o.EmailVerifier != nil {
+		verified, err := o.EmailVerifier.EmailVerified(ctx, identity.UserID)
+		if err != nil {
+			return nil, status.Error(codes.Unavailable, "Unable to verify email status")
+		}
+		if !verified {
+			return nil, status.Error(codes.PermissionDenied,
+				"Verify your email address before making changes, request a new link at /api/v1/users/me/email/verification")
+		}
+	}
+
+	return WithIdentity(ctx, identity), nil
+}
+
+func hasRole(role models.UserRole, roles []models.UserRole) bool {
+	for _, allowed := range roles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// peerIP returns the IP address of the client connected to the call
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// UnaryAuth authenticates unary gRPC calls from the authorization or
+// x-api-key metadata like Auth, and applies the MFA policy like RequireMFA
+func UnaryAuth(opts GRPCAuthOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if opts.public(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := opts.authorize(ctx, info.FullMethod, func(md metadata.MD) error {
+			return grpc.SetHeader(ctx, md)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticatedStream carries the caller's Identity in its context
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamAuth is UnaryAuth for streaming calls
+func StreamAuth(opts GRPCAuthOptions) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if opts.public(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		ctx, err := opts.authorize(ss.Context(), info.FullMethod, ss.SetHeader)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+// TierResolver looks up the API tier of a user
+type TierResolver interface {
+	Tier(ctx context.Context, userID uuid.UUID) (models.Tier, error)
+}
+
+// RateLimit middleware applies the per-minute request limit of the
+// authenticated user's tier. It must run after Auth. Lookup and counter
+// failures are logged and let the request through.
+func RateLimit(resolver TierResolver, redisClient *redis.Client, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get("user_id")
+		userID, ok := value.(uuid.UUID)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		tier, err := resolver.Tier(ctx, userID)
+		if err != nil {
+			logger.Warn("Failed to resolve user tier", zap.String("user_id", userID.String()), zap.Error(err))
+			tier = models.TierFree
+		}
+		c.Set("tier", string(tier))
+
+		now := time.Now()
+		window := now.Truncate(time.Minute)
+		key := fmt.Sprintf("ratelimit:%s:%d", userID, window.Unix())
+
+		pipe := redisClient.TxPipeline()
+		count := pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, 2*time.Minute)
+		if _, err := pipe.Exec(ctx); err != nil {
+			logger.Warn("Failed to update rate limit counter", zap.String("user_id", userID.String()), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		limit := int64(tier.RequestsPerMinute())
+		remaining := limit - count.Val()
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(window.Add(time.Minute).Unix(), 10))
+
+		if count.Val() > limit {
+			retryAfter := int(window.Add(time.Minute).Sub(now).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, models.NewErrorResponse(
+				fmt.Sprintf("Rate limit of %d requests per minute exceeded for the %s tier", limit, tier)))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRPCMethods middleware rejects requests from users whose tier does
+// not allow every one of methods. It must run after RateLimit.
+func RequireRPCMethods(methods ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tier := models.Tier(c.GetString("tier"))
+		if !tier.IsValid() {
+			tier = models.TierFree
+		}
+
+		for _, method := range methods {
+			if !tier.AllowsMethod(method) {
+				c.AbortWithStatusJSON(http.StatusForbidden, models.NewErrorResponse(
+					fmt.Sprintf("The %s tier does not allow %s", tier, method)))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
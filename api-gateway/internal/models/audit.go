@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions
+const (
+	AuditLoginFailed        = "auth.login_failed"
+	AuditLockout            = "auth.lockout"
+	AuditCredentialStuffing = "auth.credential_stuffing"
+	AuditLockoutCleared     = "auth.lockout_cleared"
+	AuditAPIKeyCreated      = "api_key.created"
+	AuditAPIKeyDeleted      = "api_key.deleted"
+	AuditUserCreated        = "user.created"
+	AuditUserUpdated        = "user.updated"
+	AuditUserRoleChanged    = "user.role_changed"
+	AuditUserDisabled       = "user.disabled"
+	AuditUserEnabled        = "user.enabled"
+	AuditUserDeleted        = "user.deleted"
+	AuditUserPasswordReset  = "user.password_reset_forced"
+	AuditUserImpersonated   = "user.impersonated"
+	AuditNodesBulk          = "node.bulk_action"
+	AuditFleetApplied       = "fleet.applied"
+	AuditNodesImported      = "node.imported"
+	AuditNodesExported      = "node.exported"
+)
+
+// AuditEvent is a recorded security-relevant event. Actor identifies who
+// acted when there is no user ID, such as the username of a failed login.
+type AuditEvent struct {
+	ID         int64                  `json:"id"`
+	Action     string                 `json:"action"`
+	ActorID    *uuid.UUID             `json:"actor_id,omitempty"`
+	Actor      string                 `json:"actor,omitempty"`
+	TargetType string                 `json:"target_type,omitempty"`
+	TargetID   string                 `json:"target_id,omitempty"`
+	IPAddress  string                 `json:"ip_address,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// LockoutStatus reports the failed authentication attempts of an account or client IP
+type LockoutStatus struct {
+	Scope             string `json:"scope"`
+	ID                string `json:"id"`
+	Failures          int    `json:"failures"`
+	Locked            bool   `json:"locked"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+}
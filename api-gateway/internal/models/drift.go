@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DriftSeverity is how much a drift finding matters
+type DriftSeverity string
+
+const (
+	// DriftNone means the running node matches its stored config
+	DriftNone DriftSeverity = "none"
+	// DriftInfo is a difference that doesn't change how the node serves
+	DriftInfo DriftSeverity = "info"
+	// DriftWarning is a difference operators should reconcile
+	DriftWarning DriftSeverity = "warning"
+	// DriftCritical means the node isn't serving what it is registered as
+	DriftCritical DriftSeverity = "critical"
+	// DriftUnknown means the node couldn't be checked
+	DriftUnknown DriftSeverity = "unknown"
+)
+
+// Level orders severities from none to critical; unknown ranks below none
+func (s DriftSeverity) Level() int {
+	switch s {
+	case DriftInfo:
+		return 1
+	case DriftWarning:
+		return 2
+	case DriftCritical:
+		return 3
+	case DriftUnknown:
+		return -1
+	}
+	return 0
+}
+
+// DriftFinding is one way the running node differs from its stored config
+type DriftFinding struct {
+	// Field is the config key or node field that differs, such as
+	// config.http_api or version
+	Field    string        `json:"field"`
+	Severity DriftSeverity `json:"severity"`
+	Expected interface{}   `json:"expected,omitempty"`
+	Actual   interface{}   `json:"actual,omitempty"`
+	Message  string        `json:"message"`
+}
+
+// NodeRuntime is what a running node reports about itself. Fields a node
+// doesn't expose are left empty and the methods that failed are listed in
+// Unavailable.
+type NodeRuntime struct {
+	ClientVersion string   `json:"client_version,omitempty"`
+	Client        string   `json:"client,omitempty"`
+	Version       string   `json:"version,omitempty"`
+	ChainID       *uint64  `json:"chain_id,omitempty"`
+	Modules       []string `json:"modules,omitempty"`
+	P2PPort       int64    `json:"p2p_port,omitempty"`
+	// Archive reports whether the node still serves the state of block 1
+	Archive     *bool    `json:"archive,omitempty"`
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// NodeDriftReport compares a running node with its stored config and version.
+// Severity is that of the most severe finding.
+type NodeDriftReport struct {
+	NodeID    uuid.UUID      `json:"node_id"`
+	Severity  DriftSeverity  `json:"severity"`
+	Findings  []DriftFinding `json:"findings"`
+	Runtime   NodeRuntime    `json:"runtime"`
+	Error     string         `json:"error,omitempty"`
+	CheckedAt time.Time      `json:"checked_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "github.com/google/uuid"
+
+// FleetManifest describes the desired gateway-managed nodes. Nodes are
+// matched to existing nodes by name. Selector scopes the manifest to the
+// nodes whose labels match it, so several manifests can share the fleet.
+type FleetManifest struct {
+	Selector string              `json:"selector,omitempty"`
+	Nodes    []CreateNodeRequest `json:"nodes"`
+}
+
+// FleetChangeType is what applying a plan does to a node
+type FleetChangeType string
+
+const (
+	FleetCreate FleetChangeType = "create"
+	FleetUpdate FleetChangeType = "update"
+	FleetDelete FleetChangeType = "delete"
+)
+
+// FleetFieldChange is a field an update changes
+type FleetFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// FleetChange is a node a plan creates, updates or deletes
+type FleetChange struct {
+	Type   FleetChangeType    `json:"type"`
+	Name   string             `json:"name"`
+	NodeID *uuid.UUID         `json:"node_id,omitempty"`
+	Fields []FleetFieldChange `json:"fields,omitempty"`
+}
+
+// FleetPlan is the difference between a manifest and the current nodes.
+// Fingerprint identifies the state it was computed against; applying with
+// it fails if the nodes changed since.
+type FleetPlan struct {
+	Creates     []FleetChange `json:"creates"`
+	Updates     []FleetChange `json:"updates"`
+	Deletes     []FleetChange `json:"deletes"`
+	Unchanged   int           `json:"unchanged"`
+	Prune       bool          `json:"prune"`
+	Fingerprint string        `json:"fingerprint"`
+	Applied     bool          `json:"applied"`
+}
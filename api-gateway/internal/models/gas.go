@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GasSample is the latest fee market and mempool snapshot collected from a node
+type GasSample struct {
+	NodeID             uuid.UUID `json:"node_id"`
+	ChainType          ChainType `json:"chain_type"`
+	GasPriceGwei       float64   `json:"gas_price_gwei"`
+	MaxPriorityFeeGwei float64   `json:"max_priority_fee_gwei"`
+	BaseFeeGwei        float64   `json:"base_fee_gwei"`
+	PriorityFeeP10Gwei float64   `json:"priority_fee_p10_gwei"`
+	PriorityFeeP50Gwei float64   `json:"priority_fee_p50_gwei"`
+	PriorityFeeP90Gwei float64   `json:"priority_fee_p90_gwei"`
+	TxpoolPending      uint64    `json:"txpool_pending"`
+	TxpoolQueued       uint64    `json:"txpool_queued"`
+	SampledAt          time.Time `json:"sampled_at"`
+}
+
+// GasEstimate is a fee recommendation for a single inclusion speed
+type GasEstimate struct {
+	MaxPriorityFeeGwei float64 `json:"max_priority_fee_gwei"`
+	MaxFeeGwei         float64 `json:"max_fee_gwei"`
+	GasPriceGwei       float64 `json:"gas_price_gwei"`
+}
+
+// GasRecommendation is the fleet-wide gas oracle response for a chain
+type GasRecommendation struct {
+	ChainType     ChainType   `json:"chain_type"`
+	BaseFeeGwei   float64     `json:"base_fee_gwei"`
+	Slow          GasEstimate `json:"slow"`
+	Standard      GasEstimate `json:"standard"`
+	Fast          GasEstimate `json:"fast"`
+	TxpoolPending uint64      `json:"txpool_pending"`
+	TxpoolQueued  uint64      `json:"txpool_queued"`
+	NodesSampled  int         `json:"nodes_sampled"`
+	NodesExcluded []uuid.UUID `json:"nodes_excluded,omitempty"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NodeGroup is a named set of nodes: those matching Selector together with
+// the explicit members
+type NodeGroup struct {
+	ID          uuid.UUID   `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Selector    string      `json:"selector,omitempty"`
+	MemberIDs   []uuid.UUID `json:"member_ids"`
+	NodeCount   uint64      `json:"node_count"`
+	CreatedBy   *uuid.UUID  `json:"created_by,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// CreateNodeGroupRequest is used to create a node group
+type CreateNodeGroupRequest struct {
+	Name        string      `json:"name" binding:"required,max=100"`
+	Description string      `json:"description,omitempty" binding:"max=500"`
+	Selector    string      `json:"selector,omitempty"`
+	MemberIDs   []uuid.UUID `json:"member_ids,omitempty"`
+}
+
+// UpdateNodeGroupRequest changes the fields of a node group that are set
+type UpdateNodeGroupRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=500"`
+	Selector    *string `json:"selector,omitempty"`
+}
+
+// NodeGroupMembersRequest adds or removes explicit members of a node group
+type NodeGroupMembersRequest struct {
+	NodeIDs []uuid.UUID `json:"node_ids" binding:"required,min=1"`
+}
+
+// BulkNodeAction is an operation applied to many nodes at once
+type BulkNodeAction string
+
+const (
+	// BulkNodeSetStatus sets Status on every node
+	BulkNodeSetStatus BulkNodeAction = "set_status"
+	// BulkNodeMaintenance puts every node into maintenance
+	BulkNodeMaintenance BulkNodeAction = "maintenance"
+	// BulkNodeLabel sets Labels and removes RemoveLabels on every node
+	BulkNodeLabel BulkNodeAction = "label"
+	// BulkNodeDelete removes every node
+	BulkNodeDelete BulkNodeAction = "delete"
+)
+
+// NodeTarget selects the nodes of a bulk operation. Exactly one of Group,
+// Selector and NodeIDs must be set.
+type NodeTarget struct {
+	// Group is a node group's ID or name
+	Group    string      `json:"group,omitempty"`
+	Selector string      `json:"selector,omitempty"`
+	NodeIDs  []uuid.UUID `json:"node_ids,omitempty"`
+}
+
+// BulkNodeRequest applies Action to the nodes selected by Target
+type BulkNodeRequest struct {
+	Target       NodeTarget        `json:"target"`
+	Action       BulkNodeAction    `json:"action" binding:"required"`
+	Status       NodeStatus        `json:"status,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	RemoveLabels []string          `json:"remove_labels,omitempty"`
+
+	// DryRun reports the nodes that would be affected without changing them
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// BulkNodeResponse reports the nodes a bulk operation affected
+type BulkNodeResponse struct {
+	Action  BulkNodeAction `json:"action"`
+	NodeIDs []uuid.UUID    `json:"node_ids"`
+	Count   int            `json:"count"`
+	DryRun  bool           `json:"dry_run"`
+}
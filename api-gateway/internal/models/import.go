@@ -0,0 +1,39 @@
+package models
+
+import "github.com/google/uuid"
+
+// NodeImportRowStatus is the outcome of one record of a node import
+type NodeImportRowStatus string
+
+const (
+	// NodeImportCreated rows were created
+	NodeImportCreated NodeImportRowStatus = "created"
+	// NodeImportValid rows passed validation in a dry run
+	NodeImportValid NodeImportRowStatus = "valid"
+	// NodeImportInvalid rows were skipped; Errors says why
+	NodeImportInvalid NodeImportRowStatus = "invalid"
+)
+
+// NodeImportRow reports one record of a node import. Row counts records
+// from 1; Line is where the record starts in the file, when known.
+type NodeImportRow struct {
+	Row    int                 `json:"row"`
+	Line   int                 `json:"line,omitempty"`
+	Name   string              `json:"name,omitempty"`
+	Status NodeImportRowStatus `json:"status"`
+	NodeID *uuid.UUID          `json:"node_id,omitempty"`
+	Errors []string            `json:"errors,omitempty"`
+}
+
+// NodeImportReport is the result of a node import. Valid rows are created in
+// one transaction committed after the whole file has been read; invalid rows
+// are skipped.
+type NodeImportReport struct {
+	Format  string          `json:"format"`
+	DryRun  bool            `json:"dry_run"`
+	Total   int             `json:"total"`
+	Valid   int             `json:"valid"`
+	Invalid int             `json:"invalid"`
+	Created int             `json:"created"`
+	Rows    []NodeImportRow `json:"rows"`
+}
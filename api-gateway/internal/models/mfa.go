@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// MFAStatus describes a user's second factor
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	PendingEnrolment       bool       `json:"pending_enrolment"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+	Required               bool       `json:"required"`
+}
+
+// TOTPEnrolment is a new TOTP secret awaiting confirmation. ProvisioningURI
+// is the otpauth:// URI to render as a QR code for authenticator apps.
+type TOTPEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFACodeRequest carries a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse lists newly generated recovery codes. They are only
+// shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge is returned by login instead of tokens when the user has MFA
+// enabled. MFAToken is exchanged with a code at /auth/mfa/verify.
+type MFAChallenge struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// MFAVerifyRequest completes a login that returned an MFAChallenge
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFARoleRequirement reports whether members of a role must use MFA
+type MFARoleRequirement struct {
+	Role      UserRole   `json:"role"`
+	Required  bool       `json:"required"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// SetMFARoleRequirementRequest is used to require or stop requiring MFA for a role
+type SetMFARoleRequirementRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
@@ -17,6 +17,15 @@ const (
 	ChainTypeCustom   ChainType = "custom"
 )
 
+// IsValid reports whether c is a known chain type
+func (c ChainType) IsValid() bool {
+	switch c {
+	case ChainTypeEthereum, ChainTypePolygon, ChainTypeArbitrum, ChainTypeBSC, ChainTypeCustom:
+		return true
+	}
+	return false
+}
+
 // NodeStatus represents the status of a blockchain node
 type NodeStatus string
 
@@ -29,6 +38,15 @@ const (
 	NodeStatusMaintenance NodeStatus = "maintenance"
 )
 
+// IsValid reports whether s is a known node status
+func (s NodeStatus) IsValid() bool {
+	switch s {
+	case NodeStatusRunning, NodeStatusStopped, NodeStatusStarting, NodeStatusSyncing, NodeStatusError, NodeStatusMaintenance:
+		return true
+	}
+	return false
+}
+
 // CloudProvider represents the cloud provider where the node is hosted
 type CloudProvider string
 
@@ -40,6 +58,25 @@ const (
 	CloudProviderOnPremise    CloudProvider = "onpremise"
 )
 
+// IsValid reports whether p is a known cloud provider
+func (p CloudProvider) IsValid() bool {
+	switch p {
+	case CloudProviderAWS, CloudProviderGCP, CloudProviderAzure, CloudProviderDigitalOcean, CloudProviderOnPremise:
+		return true
+	}
+	return false
+}
+
+// NodeSource represents where a node record originates from
+type NodeSource string
+
+const (
+	// NodeSourceGateway nodes are managed through the gateway API
+	NodeSourceGateway NodeSource = "gateway"
+	// NodeSourceOnchain nodes are mirrored from the BlockchainNodeRegistry contract
+	NodeSourceOnchain NodeSource = "onchain"
+)
+
 // SyncStatus represents the synchronization status of a blockchain node
 type SyncStatus struct {
 	IsSyncing          bool    `json:"is_syncing"`
@@ -49,6 +86,28 @@ type SyncStatus struct {
 	ProgressPercentage float64 `json:"progress_percentage"`
 }
 
+// ConsensusHealth represents the health reported by a beacon node
+type ConsensusHealth string
+
+const (
+	ConsensusHealthReady       ConsensusHealth = "ready"
+	ConsensusHealthSyncing     ConsensusHealth = "syncing"
+	ConsensusHealthUnavailable ConsensusHealth = "unavailable"
+)
+
+// ConsensusStatus represents the state of the consensus (beacon) client paired with an Ethereum node
+type ConsensusStatus struct {
+	Health       ConsensusHealth `json:"health"`
+	IsSyncing    bool            `json:"is_syncing"`
+	IsOptimistic bool            `json:"is_optimistic"`
+	ELOffline    bool            `json:"el_offline"`
+	HeadSlot     uint64          `json:"head_slot"`
+	SyncDistance uint64          `json:"sync_distance"`
+	PeerCount    uint64          `json:"peer_count"`
+	Error        string          `json:"error,omitempty"`
+	CheckedAt    time.Time       `json:"checked_at"`
+}
+
 // BlockchainNode represents a blockchain node in the system
 type BlockchainNode struct {
 	ID                 uuid.UUID              `json:"id"`
@@ -64,6 +123,41 @@ type BlockchainNode struct {
 	Provider           CloudProvider          `json:"provider"`
 	PerformanceMetrics map[string]float64     `json:"performance_metrics,omitempty"`
 	Config             map[string]interface{} `json:"config,omitempty"`
+	Labels             map[string]string      `json:"labels"`
+
+	// ConsensusEndpointURL is the beacon API endpoint of the consensus client
+	// paired with an Ethereum execution node
+	ConsensusEndpointURL string           `json:"consensus_endpoint_url,omitempty"`
+	ConsensusStatus      *ConsensusStatus `json:"consensus_status,omitempty"`
+
+	// Source records whether the node is managed here or mirrored from the
+	// on-chain registry; OnchainID and OnchainOwner identify it in the registry
+	Source       NodeSource `json:"source"`
+	OnchainID    string     `json:"onchain_id,omitempty"`
+	OnchainOwner string     `json:"onchain_owner,omitempty"`
+
+	// PublishOnchain opts the node in to having its status published to the registry
+	PublishOnchain bool `json:"publish_onchain"`
+}
+
+// ApplyConsensusStatus rolls the paired consensus client's state into the
+// node's overall status. A running execution node is only reported as running
+// when its beacon node is ready; nodes in a terminal or operator-controlled
+// state are left untouched.
+func (n *BlockchainNode) ApplyConsensusStatus() {
+	if n.ConsensusEndpointURL == "" || n.Status != NodeStatusRunning {
+		return
+	}
+
+	cl := n.ConsensusStatus
+	switch {
+	case cl == nil:
+		n.Status = NodeStatusStarting
+	case cl.Health == ConsensusHealthUnavailable || cl.ELOffline:
+		n.Status = NodeStatusError
+	case cl.Health == ConsensusHealthSyncing || cl.IsSyncing || cl.IsOptimistic:
+		n.Status = NodeStatusSyncing
+	}
 }
 
 // CreateNodeRequest is used to create a new blockchain node
@@ -74,6 +168,10 @@ type CreateNodeRequest struct {
 	Region      string                 `json:"region" binding:"required"`
 	Provider    CloudProvider          `json:"provider" binding:"required"`
 	Config      map[string]interface{} `json:"config,omitempty"`
+	Labels      map[string]string      `json:"labels,omitempty"`
+
+	ConsensusEndpointURL string `json:"consensus_endpoint_url,omitempty" binding:"omitempty,url"`
+	PublishOnchain       bool   `json:"publish_onchain,omitempty"`
 }
 
 // UpdateNodeRequest is used to update an existing blockchain node
@@ -82,18 +180,118 @@ type UpdateNodeRequest struct {
 	EndpointURL *string                `json:"endpoint_url,omitempty"`
 	Status      *NodeStatus            `json:"status,omitempty"`
 	Config      map[string]interface{} `json:"config,omitempty"`
+	// Labels replaces the node's labels when set; an empty map removes them
+	Labels map[string]string `json:"labels,omitempty"`
+
+	ConsensusEndpointURL *string `json:"consensus_endpoint_url,omitempty"`
+	PublishOnchain       *bool   `json:"publish_onchain,omitempty"`
+}
+
+// ValidateNodeConfigRequest checks a node config without saving it
+type ValidateNodeConfigRequest struct {
+	ChainType ChainType              `json:"chain_type" binding:"required"`
+	Config    map[string]interface{} `json:"config"`
 }
 
-// ListNodesResponse is the response for listing nodes
+// ListNodesResponse is the response for listing nodes. Page and TotalPages
+// are only set when paging by offset; NextCursor continues after the last item
+// and is empty on the last page.
 type ListNodesResponse struct {
 	Items      []BlockchainNode `json:"items"`
 	Total      uint64           `json:"total"`
-	Page       uint64           `json:"page"`
+	Page       uint64           `json:"page,omitempty"`
 	PageSize   uint64           `json:"page_size"`
-	TotalPages uint64           `json:"total_pages"`
+	TotalPages uint64           `json:"total_pages,omitempty"`
+	NextCursor string           `json:"next_cursor,omitempty"`
 }
 
 // NodeResponse is the standard response for node operations
 type NodeResponse struct {
 	ID uuid.UUID `json:"id"`
 }
+
+// NodeEventType is the kind of change a NodeEvent reports
+type NodeEventType string
+
+const (
+	NodeEventCreated NodeEventType = "created"
+	NodeEventUpdated NodeEventType = "updated"
+	NodeEventDeleted NodeEventType = "deleted"
+)
+
+// NodeField names a part of a node whose changes are reported
+type NodeField string
+
+const (
+	NodeFieldName            NodeField = "name"
+	NodeFieldEndpointURL     NodeField = "endpoint_url"
+	NodeFieldStatus          NodeField = "status"
+	NodeFieldVersion         NodeField = "version"
+	NodeFieldSyncStatus      NodeField = "sync_status"
+	NodeFieldConsensusStatus NodeField = "consensus_status"
+	// NodeFieldConfigDrift reports a change in how far the running node has
+	// drifted from its stored config
+	NodeFieldConfigDrift NodeField = "config_drift"
+)
+
+// NodeAlertSeverity is how urgent a NodeAlert is
+type NodeAlertSeverity string
+
+const (
+	NodeAlertCritical NodeAlertSeverity = "critical"
+	NodeAlertResolved NodeAlertSeverity = "resolved"
+)
+
+// NodeAlert is raised when a node fails or recovers
+type NodeAlert struct {
+	Severity NodeAlertSeverity `json:"severity"`
+	Message  string            `json:"message"`
+}
+
+// NodeEvent reports a change to a node. Node is the node's new state, with
+// only its ID set for deleted nodes. ID orders events and is assigned when
+// the event is published.
+type NodeEvent struct {
+	ID             string          `json:"id,omitempty"`
+	Type           NodeEventType   `json:"type"`
+	Node           *BlockchainNode `json:"node"`
+	PreviousStatus NodeStatus      `json:"previous_status,omitempty"`
+	Changes        []NodeField     `json:"changes,omitempty"`
+	Alert          *NodeAlert      `json:"alert,omitempty"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+}
+
+// Changed reports whether field is among the event's changes
+func (e *NodeEvent) Changed(field NodeField) bool {
+	for _, changed := range e.Changes {
+		if changed == field {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeStatusEvent is streamed when a node's status changes
+type NodeStatusEvent struct {
+	NodeID     uuid.UUID  `json:"node_id"`
+	Name       string     `json:"name"`
+	From       NodeStatus `json:"from,omitempty"`
+	To         NodeStatus `json:"to,omitempty"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// NodeSyncEvent is streamed when a node's sync progress changes
+type NodeSyncEvent struct {
+	NodeID     uuid.UUID  `json:"node_id"`
+	Name       string     `json:"name"`
+	SyncStatus SyncStatus `json:"sync_status"`
+	OccurredAt time.Time  `json:"occurred_at"`
+}
+
+// NodeAlertEvent is streamed when an alert is raised for a node
+type NodeAlertEvent struct {
+	NodeID uuid.UUID `json:"node_id"`
+	Name   string    `json:"name"`
+	NodeAlert
+	OccurredAt time.Time `json:"occurred_at"`
+}
@@ -8,6 +8,13 @@ type APIResponse struct {
 	Error   string      `json:"error,omitempty"`
 }
 
+// FieldError explains why one field of a request was rejected. Field is a
+// path such as config.http_port or config.http_api[1].
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // NewSuccessResponse creates a new success response
 func NewSuccessResponse(data interface{}, message string) APIResponse {
 	return APIResponse{
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Tier is an API tier derived from the TWIST held by a user's linked wallets
+type Tier string
+
+const (
+	TierFree       Tier = "free"
+	TierBasic      Tier = "basic"
+	TierPro        Tier = "pro"
+	TierEnterprise Tier = "enterprise"
+)
+
+// Tiers lists the tiers from lowest to highest
+var Tiers = []Tier{TierFree, TierBasic, TierPro, TierEnterprise}
+
+// tierRequestsPerMinute is the rate limit applied to each tier
+var tierRequestsPerMinute = map[Tier]int{
+	TierFree:       60,
+	TierBasic:      300,
+	TierPro:        1200,
+	TierEnterprise: 6000,
+}
+
+// tierRPCMethods lists the RPC methods each tier adds to the tiers below it.
+// A trailing "*" matches any method with that prefix.
+var tierRPCMethods = map[Tier][]string{
+	TierFree: {
+		"eth_blockNumber", "eth_call", "eth_chainId", "eth_estimateGas", "eth_feeHistory",
+		"eth_gasPrice", "eth_getBalance", "eth_getBlockByHash", "eth_getBlockByNumber",
+		"eth_getCode", "eth_getTransactionByHash", "eth_getTransactionCount",
+		"eth_getTransactionReceipt", "eth_maxPriorityFeePerGas", "eth_sendRawTransaction",
+		"net_version", "web3_clientVersion",
+	},
+	TierBasic: {
+		"eth_getLogs", "eth_getProof", "eth_getStorageAt", "eth_syncing",
+	},
+	TierPro: {
+		"debug_traceCall", "debug_traceTransaction", "eth_getBlockReceipts", "txpool_*",
+	},
+	TierEnterprise: {"*"},
+}
+
+// IsValid reports whether t is a known tier
+func (t Tier) IsValid() bool {
+	return t.Rank() >= 0
+}
+
+// Rank returns the position of t in Tiers, or -1 for an unknown tier
+func (t Tier) Rank() int {
+	for i, tier := range Tiers {
+		if tier == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// RequestsPerMinute returns the rate limit of t
+func (t Tier) RequestsPerMinute() int {
+	if limit, ok := tierRequestsPerMinute[t]; ok {
+		return limit
+	}
+	return tierRequestsPerMinute[TierFree]
+}
+
+// RPCMethods returns the RPC method patterns allowed on t
+func (t Tier) RPCMethods() []string {
+	var methods []string
+	for _, tier := range Tiers[:t.Rank()+1] {
+		methods = append(methods, tierRPCMethods[tier]...)
+	}
+	return methods
+}
+
+// AllowsMethod reports whether t may call the RPC method
+func (t Tier) AllowsMethod(method string) bool {
+	for _, pattern := range t.RPCMethods() {
+		if pattern == method {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TierLimits is what a tier allows
+type TierLimits struct {
+	RequestsPerMinute int      `json:"requests_per_minute"`
+	RPCMethods        []string `json:"rpc_methods"`
+}
+
+// UserWallet is an Ethereum address a user has proven control of. Holdings
+// is the TWIST balance plus unclaimed vested amount, in base units.
+type UserWallet struct {
+	Address   string     `json:"address"`
+	Holdings  string     `json:"holdings"`
+	LinkedAt  time.Time  `json:"linked_at"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// WalletChallengeRequest is used to request a wallet link challenge
+type WalletChallengeRequest struct {
+	Address string `json:"address" binding:"required"`
+}
+
+// WalletChallenge is the message a wallet must sign to be linked
+type WalletChallenge struct {
+	Address   string    `json:"address"`
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LinkWalletRequest is used to link a wallet with a signed challenge
+type LinkWalletRequest struct {
+	Address   string `json:"address" binding:"required"`
+	Signature string `json:"signature" binding:"required,startswith=0x"`
+}
+
+// TierChange is a recorded change of a user's tier
+type TierChange struct {
+	FromTier  Tier      `json:"from_tier"`
+	ToTier    Tier      `json:"to_tier"`
+	Holdings  string    `json:"holdings"`
+	Reason    string    `json:"reason"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// UserTierResponse describes a user's current tier, any scheduled
+// downgrade, and recent tier changes
+type UserTierResponse struct {
+	Tier        Tier         `json:"tier"`
+	Holdings    string       `json:"holdings"`
+	Decimals    int          `json:"decimals"`
+	Limits      TierLimits   `json:"limits"`
+	PendingTier *Tier        `json:"pending_tier,omitempty"`
+	DowngradeAt *time.Time   `json:"downgrade_at,omitempty"`
+	UpdatedAt   *time.Time   `json:"updated_at,omitempty"`
+	Changes     []TierChange `json:"changes"`
+}
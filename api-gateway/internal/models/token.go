@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// TokenDecimals is the number of decimals of the TWIST token. Amounts in the
+// token API are decimal strings in base units.
+const TokenDecimals = 18
+
+// UnlockPoint is a point on a beneficiary's linear vesting unlock curve
+type UnlockPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Unlocked  string    `json:"unlocked"`
+}
+
+// TokenVesting is a beneficiary's vesting position read at a single block
+type TokenVesting struct {
+	Address      string        `json:"address"`
+	BlockNumber  uint64        `json:"block_number"`
+	BlockTime    time.Time     `json:"block_time"`
+	Decimals     int           `json:"decimals"`
+	Vested       string        `json:"vested"`
+	Unlocked     string        `json:"unlocked"`
+	Claimed      string        `json:"claimed"`
+	Claimable    string        `json:"claimable"`
+	Locked       string        `json:"locked"`
+	VestingStart time.Time     `json:"vesting_start"`
+	VestingEnd   time.Time     `json:"vesting_end"`
+	UnlockCurve  []UnlockPoint `json:"unlock_curve"`
+}
+
+// TokenSupply is the TWIST token supply read at a single block. Vesting
+// allocations are minted when claimed, so the total supply is what circulates.
+type TokenSupply struct {
+	BlockNumber      uint64    `json:"block_number"`
+	BlockTime        time.Time `json:"block_time"`
+	Decimals         int       `json:"decimals"`
+	MaxSupply        string    `json:"max_supply"`
+	Circulating      string    `json:"circulating"`
+	Unminted         string    `json:"unminted"`
+	TotalVested      string    `json:"total_vested"`
+	CirculatingRatio float64   `json:"circulating_ratio"`
+}
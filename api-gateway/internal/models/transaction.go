@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TxStatus represents the lifecycle state of a broadcast transaction
+type TxStatus string
+
+const (
+	TxStatusPending   TxStatus = "pending"
+	TxStatusIncluded  TxStatus = "included"
+	TxStatusFinalized TxStatus = "finalized"
+	TxStatusReplaced  TxStatus = "replaced"
+	TxStatusDropped   TxStatus = "dropped"
+)
+
+// IsTerminal reports whether no further status changes are expected
+func (s TxStatus) IsTerminal() bool {
+	return s == TxStatusFinalized || s == TxStatusReplaced || s == TxStatusDropped
+}
+
+// BroadcastTransactionRequest is used to fan a signed transaction out to several nodes
+type BroadcastTransactionRequest struct {
+	RawTransaction string `json:"raw_transaction" binding:"required,startswith=0x"`
+	Fanout         int    `json:"fanout,omitempty" binding:"omitempty,min=1,max=20"`
+	WebhookURL     string `json:"webhook_url,omitempty" binding:"omitempty,url,startswith=https://"`
+}
+
+// BroadcastNodeResult is the outcome of submitting a transaction to one node
+type BroadcastNodeResult struct {
+	NodeID   uuid.UUID `json:"node_id"`
+	Accepted bool      `json:"accepted"`
+	TxHash   string    `json:"tx_hash,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// BroadcastTransactionResponse is the response to a broadcast request
+type BroadcastTransactionResponse struct {
+	TxHash   string                `json:"tx_hash"`
+	Accepted int                   `json:"accepted"`
+	Results  []BroadcastNodeResult `json:"results"`
+}
+
+// BroadcastTransaction is a transaction submitted through the gateway and
+// tracked until it is finalized, replaced or dropped
+type BroadcastTransaction struct {
+	TxHash         string     `json:"tx_hash"`
+	ChainType      ChainType  `json:"chain_type"`
+	UserID         uuid.UUID  `json:"user_id"`
+	RawTransaction string     `json:"-"`
+	FromAddress    string     `json:"from_address,omitempty"`
+	Nonce          *uint64    `json:"nonce,omitempty"`
+	Status         TxStatus   `json:"status"`
+	BlockNumber    *uint64    `json:"block_number,omitempty"`
+	BlockHash      string     `json:"block_hash,omitempty"`
+	ReceiptStatus  *uint64    `json:"receipt_status,omitempty"`
+	WebhookURL     string     `json:"webhook_url,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	LastSeenAt     *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// TransactionWebhookEvent is the payload delivered to a broadcast transaction's webhook
+type TransactionWebhookEvent struct {
+	TxHash         string    `json:"tx_hash"`
+	ChainType      ChainType `json:"chain_type"`
+	Status         TxStatus  `json:"status"`
+	PreviousStatus TxStatus  `json:"previous_status"`
+	BlockNumber    *uint64   `json:"block_number,omitempty"`
+	BlockHash      string    `json:"block_hash,omitempty"`
+	ReceiptStatus  *uint64   `json:"receipt_status,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
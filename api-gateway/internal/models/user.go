@@ -14,6 +14,19 @@ const (
 	RoleUser  UserRole = "user"
 )
 
+// UserRoles lists every user role
+var UserRoles = []UserRole{RoleAdmin, RoleUser}
+
+// IsValid reports whether r is a known role
+func (r UserRole) IsValid() bool {
+	for _, role := range UserRoles {
+		if role == r {
+			return true
+		}
+	}
+	return false
+}
+
 // User represents a user in the system
 type User struct {
 	ID        uuid.UUID  `json:"id"`
@@ -24,6 +37,16 @@ type User struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 	LastLogin *time.Time `json:"last_login,omitempty"`
+
+	// EmailVerifiedAt is when Email was verified, nil while it is unverified
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	// DisabledAt is when an admin disabled the user, nil while enabled
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+
+	// PasswordResetRequired blocks password login until the password is reset
+	PasswordResetRequired bool `json:"password_reset_required"`
 }
 
 // APIKey represents an API key for authentication
@@ -36,6 +59,9 @@ type APIKey struct {
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 	LastUsed  *time.Time `json:"last_used,omitempty"`
 	Enabled   bool       `json:"enabled"`
+
+	// MFA reports whether the key was created from a session that passed MFA
+	MFA bool `json:"mfa"`
 }
 
 // LoginRequest is used for user login
@@ -51,10 +77,42 @@ type RegisterRequest struct {
 	Password string `json:"password" binding:"required,min=8"`
 }
 
-// UpdateUserRequest is used to update user data
+// SIWENonceResponse is the nonce a Sign-In with Ethereum message must carry
+type SIWENonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	Domain    string    `json:"domain"`
+	ChainIDs  []int64   `json:"chain_ids"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SIWEVerifyRequest is used to sign in with a signed EIP-4361 message
+type SIWEVerifyRequest struct {
+	Message   string `json:"message" binding:"required"`
+	Signature string `json:"signature" binding:"required,startswith=0x"`
+}
+
+// UpdateUserRequest is used to update user data. Changing the password of an
+// account that has one requires CurrentPassword.
 type UpdateUserRequest struct {
-	Email    *string `json:"email,omitempty" binding:"omitempty,email"`
-	Password *string `json:"password,omitempty" binding:"omitempty,min=8"`
+	Email           *string `json:"email,omitempty" binding:"omitempty,email"`
+	Password        *string `json:"password,omitempty" binding:"omitempty,min=8"`
+	CurrentPassword *string `json:"current_password,omitempty"`
+}
+
+// VerifyEmailRequest is used to confirm an email address with a mailed token
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest is used to request a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest is used to set a new password with a mailed token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
 }
 
 // CreateAPIKeyRequest is used to create a new API key
@@ -65,18 +123,115 @@ type CreateAPIKeyRequest struct {
 
 // UserResponse is used for API responses involving users
 type UserResponse struct {
-	ID        uuid.UUID  `json:"id"`
-	Username  string     `json:"username"`
-	Email     string     `json:"email"`
-	Role      UserRole   `json:"role"`
-	CreatedAt time.Time  `json:"created_at"`
-	LastLogin *time.Time `json:"last_login,omitempty"`
+	ID            uuid.UUID  `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	Role          UserRole   `json:"role"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastLogin     *time.Time `json:"last_login,omitempty"`
+}
+
+// ToResponse converts a User to its API representation
+func (u *User) ToResponse() UserResponse {
+	return UserResponse{
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerifiedAt != nil,
+		Role:          u.Role,
+		CreatedAt:     u.CreatedAt,
+		LastLogin:     u.LastLogin,
+	}
+}
+
+// AdminUserResponse is a user as shown to admins
+type AdminUserResponse struct {
+	UserResponse
+	EmailVerifiedAt       *time.Time `json:"email_verified_at,omitempty"`
+	HasPassword           bool       `json:"has_password"`
+	Disabled              bool       `json:"disabled"`
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason        string     `json:"disabled_reason,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// ToAdminResponse converts a User to its admin API representation
+func (u *User) ToAdminResponse() AdminUserResponse {
+	return AdminUserResponse{
+		UserResponse:          u.ToResponse(),
+		EmailVerifiedAt:       u.EmailVerifiedAt,
+		HasPassword:           u.Password != "",
+		Disabled:              u.DisabledAt != nil,
+		DisabledAt:            u.DisabledAt,
+		DisabledReason:        u.DisabledReason,
+		PasswordResetRequired: u.PasswordResetRequired,
+		UpdatedAt:             u.UpdatedAt,
+	}
+}
+
+// ListUsersResponse is a page of users
+type ListUsersResponse struct {
+	Items      []AdminUserResponse `json:"items"`
+	Total      uint64              `json:"total"`
+	Page       uint64              `json:"page"`
+	PageSize   uint64              `json:"page_size"`
+	TotalPages uint64              `json:"total_pages"`
+}
+
+// AdminCreateUserRequest is used by admins to create a user. A user created
+// without a password is mailed a link to set one.
+type AdminCreateUserRequest struct {
+	Username string   `json:"username" binding:"required,min=3,max=50"`
+	Email    string   `json:"email" binding:"required,email"`
+	Password string   `json:"password,omitempty" binding:"omitempty,min=8"`
+	Role     UserRole `json:"role,omitempty"`
+}
+
+// AdminUpdateUserRequest is used by admins to change a user. Omitted fields
+// are left unchanged.
+type AdminUpdateUserRequest struct {
+	Email          *string   `json:"email,omitempty" binding:"omitempty,email"`
+	Role           *UserRole `json:"role,omitempty"`
+	Disabled       *bool     `json:"disabled,omitempty"`
+	DisabledReason *string   `json:"disabled_reason,omitempty" binding:"omitempty,max=500"`
+}
+
+// ImpersonationResponse is an access token an admin can use to act as a user
+type ImpersonationResponse struct {
+	User           UserResponse `json:"user"`
+	Token          string       `json:"token"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	ImpersonatorID uuid.UUID    `json:"impersonator_id"`
 }
 
 // LoginResponse is the response to a successful login
 type LoginResponse struct {
-	User  UserResponse `json:"user"`
-	Token string       `json:"token"`
+	User         UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+}
+
+// RefreshTokenRequest is used to exchange a refresh token for new tokens
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Session is an active login session
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+
+	// ImpersonatorID is the admin who started the session as the user
+	ImpersonatorID *uuid.UUID `json:"impersonator_id,omitempty"`
 }
 
 // APIKeyResponse is the response to a successful API key creation
@@ -0,0 +1,134 @@
+package monitor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/beacon"
+	"go.uber.org/zap"
+)
+
+// ConsensusMonitor polls the beacon API of every Ethereum node that has a
+// paired consensus client and stores the observed state on the node row
+type ConsensusMonitor struct {
+	db       *pgxpool.Pool
+	logger   *zap.Logger
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// NewConsensusMonitor creates a new ConsensusMonitor
+func NewConsensusMonitor(db *pgxpool.Pool, logger *zap.Logger, interval, timeout time.Duration) *ConsensusMonitor {
+	return &ConsensusMonitor{
+		db:       db,
+		logger:   logger,
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+// Run polls until ctx is cancelled
+func (m *ConsensusMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.pollAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type consensusTarget struct {
+	id       uuid.UUID
+	endpoint string
+}
+
+func (m *ConsensusMonitor) pollAll(ctx context.Context) {
+	rows, err := m.db.Query(ctx, `
+		SELECT id, consensus_endpoint_url
+		FROM nodes
+		WHERE chain_type = $1 AND consensus_endpoint_url <> ''`,
+		models.ChainTypeEthereum,
+	)
+	if err != nil {
+		m.logger.Error("Failed to load consensus targets", zap.Error(err))
+		return
+	}
+
+	var targets []consensusTarget
+	for rows.Next() {
+		var t consensusTarget
+		if err := rows.Scan(&t.id, &t.endpoint); err != nil {
+			rows.Close()
+			m.logger.Error("Failed to scan consensus target", zap.Error(err))
+			return
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		m.logger.Error("Failed to load consensus targets", zap.Error(err))
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t consensusTarget) {
+			defer wg.Done()
+			status := m.probe(ctx, t.endpoint)
+			if _, err := m.db.Exec(ctx, `UPDATE nodes SET consensus_status = $1 WHERE id = $2`, status, t.id); err != nil {
+				m.logger.Error("Failed to store consensus status", zap.String("node_id", t.id.String()), zap.Error(err))
+			}
+		}(t)
+	}
+	wg.Wait()
+}
+
+// probe queries the three beacon node endpoints. Failures are recorded on the
+// returned status rather than returned, so an unreachable beacon node shows up
+// as unavailable instead of keeping its last good state.
+func (m *ConsensusMonitor) probe(ctx context.Context, endpoint string) *models.ConsensusStatus {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	client := beacon.NewClient(endpoint, m.timeout)
+	status := &models.ConsensusStatus{CheckedAt: time.Now().UTC()}
+
+	health, err := client.Health(ctx)
+	status.Health = models.ConsensusHealth(health)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	syncing, err := client.Syncing(ctx)
+	if err != nil {
+		status.Health = models.ConsensusHealthUnavailable
+		status.Error = err.Error()
+		return status
+	}
+	status.IsSyncing = syncing.IsSyncing
+	status.IsOptimistic = syncing.IsOptimistic
+	status.ELOffline = syncing.ELOffline
+	status.HeadSlot = syncing.HeadSlot
+	status.SyncDistance = syncing.SyncDistance
+
+	peers, err := client.PeerCount(ctx)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.PeerCount = peers
+
+	return status
+}
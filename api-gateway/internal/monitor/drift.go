@@ -0,0 +1,467 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeconfig"
+	"github.com/twist/api-gateway/internal/nodeevents"
+	"github.com/twist/api-gateway/internal/nodes"
+	"github.com/twist/api-gateway/pkg/ethrpc"
+	"github.com/twist/api-gateway/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+// zeroAddress is the account whose balance at block 1 tells archive nodes
+// from pruned ones
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// sensitiveModules are JSON-RPC namespaces that let callers manage the node
+// or read its internals; serving one that isn't configured is a warning
+var sensitiveModules = map[string]bool{"admin": true, "debug": true, "personal": true}
+
+// DriftMonitor compares what every running node reports about itself with
+// its stored config and version, and stores a drift report per node. With
+// alerts enabled, a node becoming critically drifted or recovering from it
+// is published as a node alert.
+type DriftMonitor struct {
+	db       *pgxpool.Pool
+	store    *nodes.Store
+	logger   *zap.Logger
+	metrics  *metrics.PrometheusClient
+	events   *nodeevents.Broker
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// NewDriftMonitor creates a new DriftMonitor. events may be nil to disable
+// alerts.
+func NewDriftMonitor(db *pgxpool.Pool, logger *zap.Logger, metrics *metrics.PrometheusClient, events *nodeevents.Broker, interval, timeout time.Duration) *DriftMonitor {
+	return &DriftMonitor{
+		db:       db,
+		store:    nodes.NewStore(db),
+		logger:   logger,
+		metrics:  metrics,
+		events:   events,
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+// Run polls until ctx is cancelled
+func (m *DriftMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.pollAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *DriftMonitor) pollAll(ctx context.Context) {
+	items, err := m.store.All(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("Failed to load drift targets", zap.Error(err))
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range items {
+		node := &items[i]
+		if node.Status != models.NodeStatusRunning {
+			continue
+		}
+
+		wg.Add(1)
+		go func(node *models.BlockchainNode) {
+			defer wg.Done()
+
+			report := m.check(ctx, node)
+			previous, err := storeDriftReport(ctx, m.db, report)
+			if err != nil {
+				m.logger.Error("Failed to store drift report", zap.String("node_id", node.ID.String()), zap.Error(err))
+				return
+			}
+			if report.Severity != models.DriftUnknown {
+				m.metrics.SetConfigDrift(string(node.ChainType), node.ID.String(), findingCounts(report))
+			}
+			m.alert(ctx, node, previous, report)
+		}(node)
+	}
+	wg.Wait()
+}
+
+// findingCounts counts a report's findings by severity, including zeros so
+// resolved drift clears the metric
+func findingCounts(report *models.NodeDriftReport) map[string]int {
+	counts := map[string]int{
+		string(models.DriftInfo):     0,
+		string(models.DriftWarning):  0,
+		string(models.DriftCritical): 0,
+	}
+	for _, finding := range report.Findings {
+		counts[string(finding.Severity)]++
+	}
+	return counts
+}
+
+// alert publishes a node alert when a node becomes critically drifted or
+// recovers from it. previous is the last known severity, so a node that
+// can't be checked for a while isn't alerted on again.
+func (m *DriftMonitor) alert(ctx context.Context, node *models.BlockchainNode, previous models.DriftSeverity, report *models.NodeDriftReport) {
+	if m.events == nil || report.Severity == models.DriftUnknown {
+		return
+	}
+
+	var alert *models.NodeAlert
+	switch {
+	case report.Severity == models.DriftCritical && previous != models.DriftCritical:
+		var messages []string
+		for _, finding := range report.Findings {
+			if finding.Severity == models.DriftCritical {
+				messages = append(messages, finding.Message)
+			}
+		}
+		alert = &models.NodeAlert{
+			Severity: models.NodeAlertCritical,
+			Message:  "Node has drifted from its config: " + strings.Join(messages, "; "),
+		}
+	case previous == models.DriftCritical && report.Severity != models.DriftCritical:
+		alert = &models.NodeAlert{
+			Severity: models.NodeAlertResolved,
+			Message:  "Node no longer drifts critically from its config",
+		}
+	default:
+		return
+	}
+
+	event := &models.NodeEvent{
+		Type:           models.NodeEventUpdated,
+		Node:           node,
+		PreviousStatus: node.Status,
+		Changes:        []models.NodeField{models.NodeFieldConfigDrift},
+		Alert:          alert,
+		OccurredAt:     report.CheckedAt,
+	}
+	if err := m.events.Publish(ctx, event); err != nil && ctx.Err() == nil {
+		m.logger.Warn("Failed to publish drift alert", zap.String("node_id", node.ID.String()), zap.Error(err))
+	}
+}
+
+// check asks node what it is running and compares the answers with its
+// stored config and version. Only web3_clientVersion is mandatory; the
+// other methods are often disabled and are skipped when they fail.
+func (m *DriftMonitor) check(ctx context.Context, node *models.BlockchainNode) *models.NodeDriftReport {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	report := &models.NodeDriftReport{
+		NodeID:    node.ID,
+		Findings:  []models.DriftFinding{},
+		CheckedAt: time.Now().UTC(),
+	}
+	runtime := &report.Runtime
+	client := ethrpc.NewClient(node.EndpointURL, m.timeout)
+
+	clientVersion, err := client.ClientVersion(ctx)
+	if err != nil {
+		report.Severity = models.DriftUnknown
+		report.Error = err.Error()
+		return report
+	}
+	runtime.ClientVersion = clientVersion
+	runtime.Client, runtime.Version = parseClientVersion(clientVersion)
+
+	if chainID, err := client.ChainID(ctx); err == nil {
+		runtime.ChainID = &chainID
+	} else {
+		runtime.Unavailable = append(runtime.Unavailable, "eth_chainId")
+	}
+
+	if modules, err := client.RPCModules(ctx); err == nil {
+		for module := range modules {
+			if module = strings.ToLower(module); module != "rpc" {
+				runtime.Modules = append(runtime.Modules, module)
+			}
+		}
+		sort.Strings(runtime.Modules)
+	} else {
+		runtime.Unavailable = append(runtime.Unavailable, "rpc_modules")
+	}
+
+	if info, err := client.NodeInfo(ctx); err == nil {
+		runtime.P2PPort = info.Ports.Listener
+	} else {
+		runtime.Unavailable = append(runtime.Unavailable, "admin_nodeInfo")
+	}
+
+	// A syncing node hasn't got the state it will keep yet
+	if !node.SyncStatus.IsSyncing {
+		_, err := client.Balance(ctx, zeroAddress, "0x1")
+		var rpcErr *ethrpc.Error
+		switch {
+		case err == nil:
+			archive := true
+			runtime.Archive = &archive
+		case errors.As(err, &rpcErr):
+			archive := false
+			runtime.Archive = &archive
+		default:
+			runtime.Unavailable = append(runtime.Unavailable, "eth_getBalance")
+		}
+	}
+
+	report.Findings = compareRuntime(node, runtime)
+	report.Severity = models.DriftNone
+	for _, finding := range report.Findings {
+		if finding.Severity.Level() > report.Severity.Level() {
+			report.Severity = finding.Severity
+		}
+	}
+	return report
+}
+
+// parseClientVersion splits a web3_clientVersion such as
+// Geth/v1.13.5-stable-916d6a44/linux-amd64/go1.21.4 into the client's name
+// and version
+func parseClientVersion(clientVersion string) (string, string) {
+	parts := strings.Split(clientVersion, "/")
+	client := strings.ToLower(parts[0])
+	if len(parts) < 2 {
+		return client, ""
+	}
+	return client, strings.TrimPrefix(strings.TrimPrefix(parts[1], "v"), "V")
+}
+
+// versionCore drops the v prefix and any pre-release or build suffix, so
+// 1.13.5 matches v1.13.5-stable-916d6a44
+func versionCore(version string) string {
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	return version
+}
+
+// expectedConfig returns the client and config node is meant to run. A
+// stored config that no longer passes its profile is compared as stored.
+func expectedConfig(node *models.BlockchainNode) (nodeconfig.Client, map[string]interface{}) {
+	if result, err := nodeconfig.Normalize(node.ChainType, node.Config); err == nil {
+		return result.Client, result.Config
+	}
+	client, _ := node.Config["client"].(string)
+	return nodeconfig.Client(client), node.Config
+}
+
+// expectArchive reports whether config keeps historical state, and whether
+// that can be told for the client. Nitro is left out since the early blocks
+// of Arbitrum One predate it.
+func expectArchive(client nodeconfig.Client, config map[string]interface{}) (bool, bool) {
+	str := func(key string) string {
+		value, _ := config[key].(string)
+		return value
+	}
+	switch client {
+	case nodeconfig.Geth, nodeconfig.Bor:
+		return str("gc_mode") == "archive", true
+	case nodeconfig.Erigon, nodeconfig.Reth:
+		return str("prune_mode") == "archive", true
+	case nodeconfig.Nethermind:
+		return str("sync_mode") == "archive", true
+	case nodeconfig.Besu:
+		return str("sync_mode") == "FULL" && str("data_storage_format") == "FOREST", true
+	}
+	return false, false
+}
+
+// compareRuntime lists how runtime differs from node's config and version
+func compareRuntime(node *models.BlockchainNode, runtime *models.NodeRuntime) []models.DriftFinding {
+	findings := []models.DriftFinding{}
+	client, config := expectedConfig(node)
+
+	if client != "" && runtime.Client != string(client) {
+		findings = append(findings, models.DriftFinding{
+			Field:    "config.client",
+			Severity: models.DriftCritical,
+			Expected: client,
+			Actual:   runtime.Client,
+			Message:  fmt.Sprintf("node runs %s instead of %s", runtime.Client, client),
+		})
+	}
+
+	if node.Version != "" && runtime.Version != "" && versionCore(node.Version) != versionCore(runtime.Version) {
+		findings = append(findings, models.DriftFinding{
+			Field:    "version",
+			Severity: models.DriftWarning,
+			Expected: node.Version,
+			Actual:   runtime.Version,
+			Message:  fmt.Sprintf("node runs version %s, not %s", runtime.Version, node.Version),
+		})
+	}
+
+	if expected, ok := nodeconfig.ChainID(node.ChainType, config); ok && runtime.ChainID != nil && uint64(expected) != *runtime.ChainID {
+		findings = append(findings, models.DriftFinding{
+			Field:    "config.chain_id",
+			Severity: models.DriftCritical,
+			Expected: expected,
+			Actual:   *runtime.ChainID,
+			Message:  fmt.Sprintf("node is on chain %d instead of %d", *runtime.ChainID, expected),
+		})
+	}
+
+	if runtime.Modules != nil {
+		findings = append(findings, compareModules(config, runtime.Modules)...)
+	}
+
+	if port, ok := config["p2p_port"].(float64); ok && runtime.P2PPort != 0 && int64(port) != runtime.P2PPort {
+		findings = append(findings, models.DriftFinding{
+			Field:    "config.p2p_port",
+			Severity: models.DriftWarning,
+			Expected: int64(port),
+			Actual:   runtime.P2PPort,
+			Message:  fmt.Sprintf("node listens for peers on port %d instead of %d", runtime.P2PPort, int64(port)),
+		})
+	}
+
+	if expected, known := expectArchive(client, config); known && runtime.Archive != nil && expected != *runtime.Archive {
+		finding := models.DriftFinding{
+			Field:    "sync_mode",
+			Severity: models.DriftCritical,
+			Expected: "archive",
+			Actual:   "pruned",
+			Message:  "node is configured as an archive node but has pruned historical state",
+		}
+		if !expected {
+			finding.Severity = models.DriftInfo
+			finding.Expected, finding.Actual = finding.Actual, finding.Expected
+			finding.Message = "node keeps historical state its config would prune"
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+// compareModules compares the namespaces in config.http_api with those the
+// node serves
+func compareModules(config map[string]interface{}, served []string) []models.DriftFinding {
+	configured := map[string]bool{}
+	list, _ := config["http_api"].([]interface{})
+	for _, value := range list {
+		if module, ok := value.(string); ok {
+			configured[strings.ToLower(module)] = true
+		}
+	}
+	serving := map[string]bool{}
+	for _, module := range served {
+		serving[module] = true
+	}
+
+	var missing, extra []string
+	for module := range configured {
+		if !serving[module] {
+			missing = append(missing, module)
+		}
+	}
+	severity := models.DriftInfo
+	for _, module := range served {
+		if !configured[module] {
+			extra = append(extra, module)
+			if sensitiveModules[module] {
+				severity = models.DriftWarning
+			}
+		}
+	}
+	sort.Strings(missing)
+
+	var findings []models.DriftFinding
+	if len(missing) > 0 {
+		findings = append(findings, models.DriftFinding{
+			Field:    "config.http_api",
+			Severity: models.DriftWarning,
+			Expected: missing,
+			Message:  "node doesn't serve " + strings.Join(missing, ", "),
+		})
+	}
+	if len(extra) > 0 {
+		findings = append(findings, models.DriftFinding{
+			Field:    "config.http_api",
+			Severity: severity,
+			Actual:   extra,
+			Message:  "node also serves " + strings.Join(extra, ", "),
+		})
+	}
+	return findings
+}
+
+// storeDriftReport saves report and returns the node's last known severity.
+// A node's first report is inserted before anything is read, so a replica
+// checking the same node at once waits for it and then sees its severity
+// rather than no report at all. Later reports lock the row while replacing
+// it. A check that failed keeps the last known severity.
+func storeDriftReport(ctx context.Context, db *pgxpool.Pool, report *models.NodeDriftReport) (models.DriftSeverity, error) {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+
+	var previous models.DriftSeverity
+	err = pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		inserted, err := tx.Exec(ctx, `
+			INSERT INTO node_drift_reports (node_id, severity, report, checked_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (node_id) DO NOTHING`,
+			report.NodeID, report.Severity, payload, report.CheckedAt,
+		)
+		if err != nil || inserted.RowsAffected() == 1 {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, `SELECT severity FROM node_drift_reports WHERE node_id = $1 FOR UPDATE`, report.NodeID).Scan(&previous)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE node_drift_reports SET
+				severity = CASE WHEN $2::text = $5::text THEN severity ELSE $2::text END,
+				report = $3,
+				checked_at = $4
+			WHERE node_id = $1`,
+			report.NodeID, report.Severity, payload, report.CheckedAt, models.DriftUnknown,
+		)
+		return err
+	})
+	return previous, err
+}
+
+// LoadDriftReport returns the last drift report of a node, or nil when the
+// node hasn't been checked yet
+func LoadDriftReport(ctx context.Context, db *pgxpool.Pool, nodeID uuid.UUID) (*models.NodeDriftReport, error) {
+	var report models.NodeDriftReport
+	err := db.QueryRow(ctx, `SELECT report FROM node_drift_reports WHERE node_id = $1`, nodeID).Scan(&report)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,376 @@
+package monitor
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/ethrpc"
+	"github.com/twist/api-gateway/pkg/metrics"
+	"go.uber.org/zap"
+)
+
+const (
+	// feeHistoryBlocks is how many recent blocks are sampled by eth_feeHistory
+	feeHistoryBlocks = 20
+
+	// outlierThreshold is how many scaled median absolute deviations a node's
+	// gas price may differ from the fleet median before it is excluded
+	outlierThreshold = 3.0
+)
+
+// feeHistoryPercentiles are the reward percentiles backing slow/standard/fast
+var feeHistoryPercentiles = []float64{10, 50, 90}
+
+// GasMonitor polls fee market and txpool data from every running node
+type GasMonitor struct {
+	db       *pgxpool.Pool
+	logger   *zap.Logger
+	metrics  *metrics.PrometheusClient
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// NewGasMonitor creates a new GasMonitor
+func NewGasMonitor(db *pgxpool.Pool, logger *zap.Logger, metrics *metrics.PrometheusClient, interval, timeout time.Duration) *GasMonitor {
+	return &GasMonitor{
+		db:       db,
+		logger:   logger,
+		metrics:  metrics,
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+// Run polls until ctx is cancelled
+func (m *GasMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.pollAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// maxSampleAge is how old a sample may be before the oracle ignores it
+func (m *GasMonitor) maxSampleAge() time.Duration {
+	return 3 * m.interval
+}
+
+type gasTarget struct {
+	id        uuid.UUID
+	chainType models.ChainType
+	endpoint  string
+}
+
+func (m *GasMonitor) pollAll(ctx context.Context) {
+	rows, err := m.db.Query(ctx, `SELECT id, chain_type, endpoint_url FROM nodes WHERE status = $1`, models.NodeStatusRunning)
+	if err != nil {
+		m.logger.Error("Failed to load gas targets", zap.Error(err))
+		return
+	}
+
+	var targets []gasTarget
+	for rows.Next() {
+		var t gasTarget
+		if err := rows.Scan(&t.id, &t.chainType, &t.endpoint); err != nil {
+			rows.Close()
+			m.logger.Error("Failed to scan gas target", zap.Error(err))
+			return
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		m.logger.Error("Failed to load gas targets", zap.Error(err))
+		return
+	}
+
+	chains := make(map[models.ChainType]struct{})
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		chains[t.chainType] = struct{}{}
+
+		wg.Add(1)
+		go func(t gasTarget) {
+			defer wg.Done()
+
+			sample, err := m.sample(ctx, t)
+			if err != nil {
+				m.logger.Warn("Failed to sample gas data", zap.String("node_id", t.id.String()), zap.Error(err))
+				return
+			}
+			if err := storeGasSample(ctx, m.db, sample); err != nil {
+				m.logger.Error("Failed to store gas sample", zap.String("node_id", t.id.String()), zap.Error(err))
+				return
+			}
+			m.metrics.SetTxpoolSize(string(t.chainType), t.id.String(), sample.TxpoolPending, sample.TxpoolQueued)
+		}(t)
+	}
+	wg.Wait()
+
+	for chain := range chains {
+		samples, err := LoadGasSamples(ctx, m.db, chain, m.maxSampleAge())
+		if err != nil {
+			m.logger.Error("Failed to load gas samples", zap.String("chain", string(chain)), zap.Error(err))
+			continue
+		}
+		if rec := RecommendGas(chain, samples); rec != nil {
+			m.metrics.SetGasRecommendation(string(chain), "slow", rec.Slow.MaxFeeGwei)
+			m.metrics.SetGasRecommendation(string(chain), "standard", rec.Standard.MaxFeeGwei)
+			m.metrics.SetGasRecommendation(string(chain), "fast", rec.Fast.MaxFeeGwei)
+		}
+	}
+}
+
+// sample collects a snapshot from one node. Only eth_gasPrice is mandatory;
+// the EIP-1559 and txpool methods are best effort because legacy chains and
+// locked-down nodes don't expose them.
+func (m *GasMonitor) sample(ctx context.Context, t gasTarget) (*models.GasSample, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	client := ethrpc.NewClient(t.endpoint, m.timeout)
+
+	gasPrice, err := client.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sample := &models.GasSample{
+		NodeID:       t.id,
+		ChainType:    t.chainType,
+		GasPriceGwei: weiToGwei(gasPrice),
+		SampledAt:    time.Now().UTC(),
+	}
+
+	if tip, err := client.MaxPriorityFeePerGas(ctx); err == nil {
+		sample.MaxPriorityFeeGwei = weiToGwei(tip)
+	}
+
+	if history, err := client.FeeHistory(ctx, feeHistoryBlocks, feeHistoryPercentiles); err == nil {
+		if n := len(history.BaseFeePerGas); n > 0 {
+			// The last entry is the base fee of the next, not yet mined block
+			sample.BaseFeeGwei = weiToGwei(history.BaseFeePerGas[n-1].Big())
+		}
+		sample.PriorityFeeP10Gwei = rewardMedian(history.Reward, 0)
+		sample.PriorityFeeP50Gwei = rewardMedian(history.Reward, 1)
+		sample.PriorityFeeP90Gwei = rewardMedian(history.Reward, 2)
+	}
+
+	if pool, err := client.TxpoolStatus(ctx); err == nil {
+		sample.TxpoolPending = pool.Pending.Uint64()
+		sample.TxpoolQueued = pool.Queued.Uint64()
+	}
+
+	return sample, nil
+}
+
+func storeGasSample(ctx context.Context, db *pgxpool.Pool, s *models.GasSample) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO node_gas_samples (node_id, chain_type, gas_price_gwei, max_priority_fee_gwei,
+			base_fee_gwei, priority_fee_p10_gwei, priority_fee_p50_gwei, priority_fee_p90_gwei,
+			txpool_pending, txpool_queued, sampled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (node_id) DO UPDATE SET
+			chain_type = EXCLUDED.chain_type,
+			gas_price_gwei = EXCLUDED.gas_price_gwei,
+			max_priority_fee_gwei = EXCLUDED.max_priority_fee_gwei,
+			base_fee_gwei = EXCLUDED.base_fee_gwei,
+			priority_fee_p10_gwei = EXCLUDED.priority_fee_p10_gwei,
+			priority_fee_p50_gwei = EXCLUDED.priority_fee_p50_gwei,
+			priority_fee_p90_gwei = EXCLUDED.priority_fee_p90_gwei,
+			txpool_pending = EXCLUDED.txpool_pending,
+			txpool_queued = EXCLUDED.txpool_queued,
+			sampled_at = EXCLUDED.sampled_at`,
+		s.NodeID, s.ChainType, s.GasPriceGwei, s.MaxPriorityFeeGwei,
+		s.BaseFeeGwei, s.PriorityFeeP10Gwei, s.PriorityFeeP50Gwei, s.PriorityFeeP90Gwei,
+		int64(s.TxpoolPending), int64(s.TxpoolQueued), s.SampledAt,
+	)
+	return err
+}
+
+// LoadGasSamples returns samples for chain that are younger than maxAge and
+// come from nodes that are currently running
+func LoadGasSamples(ctx context.Context, db *pgxpool.Pool, chain models.ChainType, maxAge time.Duration) ([]models.GasSample, error) {
+	rows, err := db.Query(ctx, `
+		SELECT s.node_id, s.chain_type, s.gas_price_gwei, s.max_priority_fee_gwei, s.base_fee_gwei,
+			s.priority_fee_p10_gwei, s.priority_fee_p50_gwei, s.priority_fee_p90_gwei,
+			s.txpool_pending, s.txpool_queued, s.sampled_at
+		FROM node_gas_samples s
+		JOIN nodes n ON n.id = s.node_id
+		WHERE s.chain_type = $1 AND n.status = $2 AND s.sampled_at > $3`,
+		chain, models.NodeStatusRunning, time.Now().UTC().Add(-maxAge),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var samples []models.GasSample
+	for rows.Next() {
+		var s models.GasSample
+		var pending, queued int64
+		err := rows.Scan(&s.NodeID, &s.ChainType, &s.GasPriceGwei, &s.MaxPriorityFeeGwei, &s.BaseFeeGwei,
+			&s.PriorityFeeP10Gwei, &s.PriorityFeeP50Gwei, &s.PriorityFeeP90Gwei,
+			&pending, &queued, &s.SampledAt)
+		if err != nil {
+			return nil, err
+		}
+		s.TxpoolPending = uint64(pending)
+		s.TxpoolQueued = uint64(queued)
+		samples = append(samples, s)
+	}
+
+	return samples, rows.Err()
+}
+
+// RecommendGas aggregates node samples into slow/standard/fast fee
+// recommendations. Nodes whose gas price is an outlier relative to the rest of
+// the fleet are excluded. It returns nil when there are no samples.
+func RecommendGas(chain models.ChainType, samples []models.GasSample) *models.GasRecommendation {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	rec := &models.GasRecommendation{
+		ChainType: chain,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	// Exclude outliers using the median absolute deviation of gas prices.
+	// With fewer than three nodes there is no meaningful majority.
+	included := samples
+	if len(samples) >= 3 {
+		prices := make([]float64, len(samples))
+		for i, s := range samples {
+			prices[i] = s.GasPriceGwei
+		}
+		med := median(prices)
+		deviations := make([]float64, len(prices))
+		for i, p := range prices {
+			deviations[i] = math.Abs(p - med)
+		}
+		// 1.4826 scales the MAD to a standard deviation for normal data
+		mad := 1.4826 * median(deviations)
+
+		if mad > 0 {
+			included = included[:0:0]
+			for i, s := range samples {
+				if deviations[i]/mad > outlierThreshold {
+					rec.NodesExcluded = append(rec.NodesExcluded, s.NodeID)
+					continue
+				}
+				included = append(included, s)
+			}
+		}
+	}
+
+	var gasPrice, tip, baseFee, p10, p50, p90, pending, queued []float64
+	for _, s := range included {
+		gasPrice = append(gasPrice, s.GasPriceGwei)
+		tip = append(tip, s.MaxPriorityFeeGwei)
+		baseFee = append(baseFee, s.BaseFeeGwei)
+		p10 = append(p10, s.PriorityFeeP10Gwei)
+		p50 = append(p50, s.PriorityFeeP50Gwei)
+		p90 = append(p90, s.PriorityFeeP90Gwei)
+		pending = append(pending, float64(s.TxpoolPending))
+		queued = append(queued, float64(s.TxpoolQueued))
+	}
+
+	rec.NodesSampled = len(included)
+	rec.BaseFeeGwei = median(baseFee)
+	rec.TxpoolPending = uint64(median(pending))
+	rec.TxpoolQueued = uint64(median(queued))
+
+	if rec.BaseFeeGwei == 0 {
+		// Legacy (pre EIP-1559) pricing: scale the fleet's median gas price
+		price := median(gasPrice)
+		rec.Slow = gasEstimateLegacy(price * 0.9)
+		rec.Standard = gasEstimateLegacy(price)
+		rec.Fast = gasEstimateLegacy(price * 1.25)
+		return rec
+	}
+
+	// Fall back to the node-suggested tip when fee history carries no rewards
+	standardTip := median(p50)
+	if standardTip == 0 {
+		standardTip = median(tip)
+	}
+	slowTip := median(p10)
+	if slowTip == 0 {
+		slowTip = standardTip
+	}
+	fastTip := median(p90)
+	if fastTip < standardTip {
+		fastTip = standardTip
+	}
+
+	rec.Slow = gasEstimate1559(rec.BaseFeeGwei, slowTip)
+	rec.Standard = gasEstimate1559(rec.BaseFeeGwei, standardTip)
+	rec.Fast = gasEstimate1559(rec.BaseFeeGwei, fastTip)
+	return rec
+}
+
+// gasEstimateLegacy builds an estimate for chains that only support gasPrice
+func gasEstimateLegacy(price float64) models.GasEstimate {
+	return models.GasEstimate{
+		MaxPriorityFeeGwei: price,
+		MaxFeeGwei:         price,
+		GasPriceGwei:       price,
+	}
+}
+
+// gasEstimate1559 sets maxFee to twice the base fee plus tip, which keeps the
+// transaction valid through six consecutive full blocks
+func gasEstimate1559(baseFee, tip float64) models.GasEstimate {
+	return models.GasEstimate{
+		MaxPriorityFeeGwei: tip,
+		MaxFeeGwei:         2*baseFee + tip,
+		GasPriceGwei:       baseFee + tip,
+	}
+}
+
+// rewardMedian returns the median across blocks of one reward percentile column
+func rewardMedian(reward [][]ethrpc.Quantity, column int) float64 {
+	var values []float64
+	for _, block := range reward {
+		if column < len(block) {
+			values = append(values, weiToGwei(block[column].Big()))
+		}
+	}
+	return median(values)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+var weiPerGwei = big.NewFloat(1e9)
+
+func weiToGwei(wei *big.Int) float64 {
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), weiPerGwei).Float64()
+	return gwei
+}
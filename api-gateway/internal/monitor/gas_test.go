@@ -0,0 +1,187 @@
+package monitor
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/ethrpc"
+)
+
+// legacySample is a node on a chain without a base fee
+func legacySample(gasPrice float64) models.GasSample {
+	return models.GasSample{NodeID: uuid.New(), GasPriceGwei: gasPrice}
+}
+
+// feeMarketSample is a node reporting EIP-1559 fee history
+func feeMarketSample(baseFee, p10, p50, p90 float64) models.GasSample {
+	return models.GasSample{
+		NodeID:             uuid.New(),
+		GasPriceGwei:       baseFee + p50,
+		BaseFeeGwei:        baseFee,
+		PriorityFeeP10Gwei: p10,
+		PriorityFeeP50Gwei: p50,
+		PriorityFeeP90Gwei: p90,
+	}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func sameEstimate(a, b models.GasEstimate) bool {
+	return closeTo(a.MaxPriorityFeeGwei, b.MaxPriorityFeeGwei) && closeTo(a.MaxFeeGwei, b.MaxFeeGwei) && closeTo(a.GasPriceGwei, b.GasPriceGwei)
+}
+
+func TestRecommendGas(t *testing.T) {
+	outlier := legacySample(100)
+	tipOnly := feeMarketSample(10, 0, 0, 0)
+	tipOnly.MaxPriorityFeeGwei = 3
+
+	tests := []struct {
+		name                 string
+		samples              []models.GasSample
+		slow, standard, fast models.GasEstimate
+		sampled              int
+		excluded             []uuid.UUID
+	}{
+		{
+			name:     "single legacy sample",
+			samples:  []models.GasSample{legacySample(20)},
+			slow:     gasEstimateLegacy(18),
+			standard: gasEstimateLegacy(20),
+			fast:     gasEstimateLegacy(25),
+			sampled:  1,
+		},
+		{
+			name:     "single fee market sample",
+			samples:  []models.GasSample{feeMarketSample(10, 1, 2, 4)},
+			slow:     models.GasEstimate{MaxPriorityFeeGwei: 1, MaxFeeGwei: 21, GasPriceGwei: 11},
+			standard: models.GasEstimate{MaxPriorityFeeGwei: 2, MaxFeeGwei: 22, GasPriceGwei: 12},
+			fast:     models.GasEstimate{MaxPriorityFeeGwei: 4, MaxFeeGwei: 24, GasPriceGwei: 14},
+			sampled:  1,
+		},
+		{
+			name:     "outlier node dropped",
+			samples:  []models.GasSample{legacySample(10), legacySample(11), legacySample(12), outlier},
+			slow:     gasEstimateLegacy(11 * 0.9),
+			standard: gasEstimateLegacy(11),
+			fast:     gasEstimateLegacy(11 * 1.25),
+			sampled:  3,
+			excluded: []uuid.UUID{outlier.NodeID},
+		},
+		{
+			name:     "two nodes are never outliers",
+			samples:  []models.GasSample{legacySample(10), legacySample(100)},
+			slow:     gasEstimateLegacy(55 * 0.9),
+			standard: gasEstimateLegacy(55),
+			fast:     gasEstimateLegacy(55 * 1.25),
+			sampled:  2,
+		},
+		{
+			name:     "mixed samples priced by the fee market majority",
+			samples:  []models.GasSample{legacySample(30), feeMarketSample(10, 1, 2, 4), feeMarketSample(10, 1, 2, 4)},
+			slow:     models.GasEstimate{MaxPriorityFeeGwei: 1, MaxFeeGwei: 21, GasPriceGwei: 11},
+			standard: models.GasEstimate{MaxPriorityFeeGwei: 2, MaxFeeGwei: 22, GasPriceGwei: 12},
+			fast:     models.GasEstimate{MaxPriorityFeeGwei: 4, MaxFeeGwei: 24, GasPriceGwei: 14},
+			sampled:  3,
+		},
+		{
+			name:     "mixed samples priced by the legacy majority",
+			samples:  []models.GasSample{legacySample(20), legacySample(20), feeMarketSample(10, 1, 2, 4)},
+			slow:     gasEstimateLegacy(18),
+			standard: gasEstimateLegacy(20),
+			fast:     gasEstimateLegacy(25),
+			sampled:  3,
+		},
+		{
+			name:     "suggested tip without fee history rewards",
+			samples:  []models.GasSample{tipOnly},
+			slow:     models.GasEstimate{MaxPriorityFeeGwei: 3, MaxFeeGwei: 23, GasPriceGwei: 13},
+			standard: models.GasEstimate{MaxPriorityFeeGwei: 3, MaxFeeGwei: 23, GasPriceGwei: 13},
+			fast:     models.GasEstimate{MaxPriorityFeeGwei: 3, MaxFeeGwei: 23, GasPriceGwei: 13},
+			sampled:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := RecommendGas(models.ChainTypeEthereum, tt.samples)
+			if rec == nil {
+				t.Fatal("RecommendGas returned nil")
+			}
+			if !sameEstimate(rec.Slow, tt.slow) || !sameEstimate(rec.Standard, tt.standard) || !sameEstimate(rec.Fast, tt.fast) {
+				t.Errorf("estimates = %+v / %+v / %+v, want %+v / %+v / %+v", rec.Slow, rec.Standard, rec.Fast, tt.slow, tt.standard, tt.fast)
+			}
+			if rec.NodesSampled != tt.sampled {
+				t.Errorf("NodesSampled = %d, want %d", rec.NodesSampled, tt.sampled)
+			}
+			if !reflect.DeepEqual(rec.NodesExcluded, tt.excluded) {
+				t.Errorf("NodesExcluded = %v, want %v", rec.NodesExcluded, tt.excluded)
+			}
+		})
+	}
+}
+
+func TestRecommendGasWithoutSamples(t *testing.T) {
+	if rec := RecommendGas(models.ChainTypeEthereum, nil); rec != nil {
+		t.Errorf("RecommendGas = %+v, want nil", rec)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   float64
+	}{
+		{name: "empty", want: 0},
+		{name: "single", values: []float64{7}, want: 7},
+		{name: "odd count", values: []float64{9, 1, 5}, want: 5},
+		{name: "even count", values: []float64{4, 1, 3, 2}, want: 2.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append([]float64(nil), tt.values...)
+			if got := median(tt.values); got != tt.want {
+				t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.values, before) {
+				t.Errorf("median reordered its input to %v", tt.values)
+			}
+		})
+	}
+}
+
+func TestRewardMedian(t *testing.T) {
+	gwei := func(n int64) ethrpc.Quantity {
+		return ethrpc.Quantity(*new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9)))
+	}
+	// The second block is missing the upper percentile
+	reward := [][]ethrpc.Quantity{
+		{gwei(1), gwei(2)},
+		{gwei(3)},
+		{gwei(5), gwei(6)},
+	}
+
+	tests := []struct {
+		name   string
+		reward [][]ethrpc.Quantity
+		column int
+		want   float64
+	}{
+		{name: "every block", reward: reward, column: 0, want: 3},
+		{name: "short blocks skipped", reward: reward, column: 1, want: 4},
+		{name: "column no block has", reward: reward, column: 2, want: 0},
+		{name: "no blocks", column: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewardMedian(tt.reward, tt.column); got != tt.want {
+				t.Errorf("rewardMedian = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package nodeconfig
+
+import "github.com/twist/api-gateway/internal/models"
+
+// chainIDs is the chain catalog: the chain ID of each network a chain type's
+// profile allows
+var chainIDs = map[models.ChainType]map[string]int64{
+	models.ChainTypeEthereum: {"mainnet": 1, "sepolia": 11155111, "holesky": 17000},
+	models.ChainTypePolygon:  {"mainnet": 137, "amoy": 80002},
+	models.ChainTypeArbitrum: {"one": 42161, "nova": 42170, "sepolia": 421614},
+	models.ChainTypeBSC:      {"mainnet": 56, "testnet": 97},
+}
+
+// ChainID returns the chain ID of a normalized config: its chain_id when
+// set, otherwise that of its network in the catalog
+func ChainID(chainType models.ChainType, config map[string]interface{}) (int64, bool) {
+	if id, ok := config["chain_id"].(float64); ok {
+		return int64(id), true
+	}
+	network, _ := config["network"].(string)
+	id, ok := chainIDs[chainType][network]
+	return id, ok
+}
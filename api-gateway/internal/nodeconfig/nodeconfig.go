@@ -0,0 +1,247 @@
+// Package nodeconfig checks BlockchainNode.Config against JSON Schema
+// profiles. Every node's profile starts from profiles/base.json, which holds
+// the keys all clients share; the schema of the node's client and then that
+// of its chain type replace or add properties. Normalize moves deprecated
+// keys to their replacements, validates the result field by field and fills
+// in defaults.
+package nodeconfig
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/twist/api-gateway/internal/models"
+)
+
+//go:embed profiles
+var profileFS embed.FS
+
+// Client is a node client implementation
+type Client string
+
+const (
+	Geth       Client = "geth"
+	Erigon     Client = "erigon"
+	Nethermind Client = "nethermind"
+	Besu       Client = "besu"
+	Reth       Client = "reth"
+	Bor        Client = "bor"
+	Nitro      Client = "nitro"
+)
+
+// Clients lists every client with a profile
+var Clients = []Client{Geth, Erigon, Nethermind, Besu, Reth, Bor, Nitro}
+
+// Chain describes the clients that can run a chain type
+type Chain struct {
+	ChainType     models.ChainType `json:"chain_type"`
+	Title         string           `json:"title"`
+	Clients       []Client         `json:"clients"`
+	DefaultClient Client           `json:"default_client,omitempty"`
+	schema        *Schema
+}
+
+// chainFile is a chain profile: a schema with the clients that run the chain
+type chainFile struct {
+	Schema
+	Clients       []Client `json:"x-clients"`
+	DefaultClient Client   `json:"x-default-client"`
+}
+
+// Migration records a deprecated key or value Normalize rewrote
+type Migration struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Result is a normalized config
+type Result struct {
+	// Client is the client whose profile checked the config; it is empty for
+	// custom chains whose config doesn't name one
+	Client     Client                 `json:"client,omitempty"`
+	Config     map[string]interface{} `json:"config"`
+	Migrations []Migration            `json:"migrations,omitempty"`
+}
+
+// Error lists every field of a config that breaks its profile
+type Error struct {
+	Fields []models.FieldError
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		parts[i] = field.Field + " " + field.Message
+	}
+	return "Invalid config: " + strings.Join(parts, "; ")
+}
+
+type registry struct {
+	base     *Schema
+	clients  map[Client]*Schema
+	chains   map[models.ChainType]*Chain
+	profiles map[string]*Schema
+}
+
+// profiles holds the embedded schemas; they are checked when the package loads
+var profiles = mustLoad()
+
+func readSchema(name string, into interface{}) error {
+	payload, err := profileFS.ReadFile(path.Join("profiles", name))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload, into); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func mustLoad() *registry {
+	r := &registry{
+		base:     &Schema{},
+		clients:  make(map[Client]*Schema),
+		chains:   make(map[models.ChainType]*Chain),
+		profiles: make(map[string]*Schema),
+	}
+	if err := readSchema("base.json", r.base); err != nil {
+		panic(err)
+	}
+	if err := r.base.compile(); err != nil {
+		panic(fmt.Errorf("base.json: %w", err))
+	}
+
+	for _, client := range Clients {
+		schema := &Schema{}
+		name := "clients/" + string(client) + ".json"
+		if err := readSchema(name, schema); err != nil {
+			panic(err)
+		}
+		if err := schema.compile(); err != nil {
+			panic(fmt.Errorf("%s: %w", name, err))
+		}
+		r.clients[client] = schema
+	}
+
+	for _, chainType := range []models.ChainType{
+		models.ChainTypeEthereum, models.ChainTypePolygon, models.ChainTypeArbitrum, models.ChainTypeBSC, models.ChainTypeCustom,
+	} {
+		var file chainFile
+		name := "chains/" + string(chainType) + ".json"
+		if err := readSchema(name, &file); err != nil {
+			panic(err)
+		}
+		if err := file.Schema.compile(); err != nil {
+			panic(fmt.Errorf("%s: %w", name, err))
+		}
+		for _, client := range file.Clients {
+			if _, ok := r.clients[client]; !ok {
+				panic(fmt.Errorf("%s: unknown client %q", name, client))
+			}
+		}
+		chain := &Chain{
+			ChainType:     chainType,
+			Title:         file.Title,
+			Clients:       file.Clients,
+			DefaultClient: file.DefaultClient,
+			schema:        &file.Schema,
+		}
+		r.chains[chainType] = chain
+
+		for _, client := range chain.Clients {
+			r.profiles[profileKey(chainType, client)] = overlay(overlay(r.base, r.clients[client]), chain.schema)
+		}
+		// A chain no client is named for keeps unknown keys
+		loose := overlay(r.base, chain.schema)
+		open := true
+		loose.AdditionalProperties = &open
+		r.profiles[profileKey(chainType, "")] = loose
+	}
+	return r
+}
+
+func profileKey(chainType models.ChainType, client Client) string {
+	return string(chainType) + "/" + string(client)
+}
+
+// Chains returns the chain types with a profile
+func Chains() []Chain {
+	chains := make([]Chain, 0, len(profiles.chains))
+	for _, chain := range profiles.chains {
+		chains = append(chains, *chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].ChainType < chains[j].ChainType })
+	return chains
+}
+
+// Profile returns the schema a config for client on chainType is checked
+// against. An empty client selects the chain's default client.
+func Profile(chainType models.ChainType, client Client) (*Schema, error) {
+	chain, ok := profiles.chains[chainType]
+	if !ok {
+		return nil, fmt.Errorf("unknown chain type %q", chainType)
+	}
+	if client == "" {
+		client = chain.DefaultClient
+	}
+	schema, ok := profiles.profiles[profileKey(chainType, client)]
+	if !ok {
+		return nil, fmt.Errorf("%s doesn't run %s; use one of %s", client, chainType, clientList(chain.Clients))
+	}
+	return schema, nil
+}
+
+func clientList(clients []Client) string {
+	names := make([]string, len(clients))
+	for i, client := range clients {
+		names[i] = string(client)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Normalize checks config against the profile of its client on chainType
+// and returns a copy with deprecated keys migrated and defaults filled in.
+// The client is read from config's client key, falling back to the chain's
+// default client. Field problems are reported together as an *Error.
+func Normalize(chainType models.ChainType, config map[string]interface{}) (*Result, error) {
+	chain, ok := profiles.chains[chainType]
+	if !ok {
+		return nil, &Error{Fields: []models.FieldError{{Field: "chain_type", Message: "has no config profile"}}}
+	}
+
+	object, _ := clone(config).(map[string]interface{})
+	if object == nil {
+		object = map[string]interface{}{}
+	}
+	result := &Result{Config: object}
+
+	client := chain.DefaultClient
+	if value, set := object["client"]; set {
+		name, _ := value.(string)
+		client = Client(name)
+		if _, known := profiles.profiles[profileKey(chainType, client)]; !known || client == "" {
+			return nil, &Error{Fields: []models.FieldError{{
+				Field:   "config.client",
+				Message: fmt.Sprintf("must be one of %s for %s", clientList(chain.Clients), chainType),
+			}}}
+		}
+	}
+	schema := profiles.profiles[profileKey(chainType, client)]
+
+	var fields []models.FieldError
+	schema.migrate("config", object, result, &fields)
+	schema.validate("config", object, &fields)
+	if len(fields) > 0 {
+		return nil, &Error{Fields: fields}
+	}
+	schema.applyDefaults(object)
+	if client != "" {
+		object["client"] = string(client)
+	}
+	result.Client = client
+	return result, nil
+}
@@ -0,0 +1,349 @@
+package nodeconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// Schema is the subset of JSON Schema the profiles use, together with two
+// extensions: x-replaced-by names the key a deprecated key moved to, and
+// x-value-migrations maps retired values to their replacements.
+type Schema struct {
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+
+	ReplacedBy      string            `json:"x-replaced-by,omitempty"`
+	ValueMigrations map[string]string `json:"x-value-migrations,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// compile prepares s and its subschemas for validation
+func (s *Schema) compile() error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if err := property.compile(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if property.ReplacedBy != "" && s.Properties[property.ReplacedBy] == nil {
+			return fmt.Errorf("%s is replaced by unknown property %s", name, property.ReplacedBy)
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// overlay returns base with the properties of top replacing its own. Other
+// keywords of top win when set.
+func overlay(base, top *Schema) *Schema {
+	merged := *base
+	merged.Properties = make(map[string]*Schema, len(base.Properties)+len(top.Properties))
+	for name, property := range base.Properties {
+		merged.Properties[name] = property
+	}
+	for name, property := range top.Properties {
+		merged.Properties[name] = property
+	}
+	if top.Title != "" {
+		merged.Title = top.Title
+	}
+	if top.Description != "" {
+		merged.Description = top.Description
+	}
+	if top.AdditionalProperties != nil {
+		merged.AdditionalProperties = top.AdditionalProperties
+	}
+	merged.Required = append(append([]string{}, base.Required...), top.Required...)
+	return &merged
+}
+
+func child(path, name string) string {
+	return path + "." + name
+}
+
+// migrate moves deprecated keys of object to their replacements and rewrites
+// retired values, recording what changed
+func (s *Schema) migrate(path string, object map[string]interface{}, result *Result, fields *[]models.FieldError) {
+	for _, name := range sortedKeys(object) {
+		property := s.Properties[name]
+		if property == nil || !property.Deprecated {
+			continue
+		}
+		value := object[name]
+		if replacement, ok := property.ValueMigrations[fmt.Sprint(value)]; ok {
+			value = replacement
+		}
+		if property.ReplacedBy == "" {
+			result.Migrations = append(result.Migrations, Migration{
+				Field:   child(path, name),
+				Message: "deprecated and ignored by the client",
+			})
+			continue
+		}
+		if _, set := object[property.ReplacedBy]; set {
+			*fields = append(*fields, models.FieldError{
+				Field:   child(path, name),
+				Message: fmt.Sprintf("deprecated; remove it, %s is already set", property.ReplacedBy),
+			})
+			continue
+		}
+		delete(object, name)
+		object[property.ReplacedBy] = value
+		result.Migrations = append(result.Migrations, Migration{
+			Field:   child(path, name),
+			Message: "renamed to " + property.ReplacedBy,
+		})
+	}
+
+	for _, name := range sortedKeys(object) {
+		property := s.Properties[name]
+		if property == nil {
+			continue
+		}
+		value := object[name]
+		if replacement, ok := property.ValueMigrations[fmt.Sprint(value)]; ok && !property.Deprecated {
+			object[name] = replacement
+			result.Migrations = append(result.Migrations, Migration{
+				Field:   child(path, name),
+				Message: fmt.Sprintf("%v is replaced by %s", value, replacement),
+			})
+		}
+		if nested, ok := value.(map[string]interface{}); ok && property.Type == "object" {
+			property.migrate(child(path, name), nested, result, fields)
+		}
+	}
+}
+
+// applyDefaults fills in the defaults of properties object doesn't set
+func (s *Schema) applyDefaults(object map[string]interface{}) {
+	for name, property := range s.Properties {
+		value, set := object[name]
+		if !set && property.Default != nil {
+			object[name] = clone(property.Default)
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok && property.Type == "object" {
+			property.applyDefaults(nested)
+		}
+	}
+}
+
+// validate appends an error to fields for each way value breaks s
+func (s *Schema) validate(path string, value interface{}, fields *[]models.FieldError) {
+	fail := func(format string, args ...interface{}) {
+		*fields = append(*fields, models.FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && !hasType(value, s.Type) {
+		fail("must be %s %s", article(s.Type), s.Type)
+		return
+	}
+	if len(s.Enum) > 0 && !contains(s.Enum, value) {
+		fail("must be one of %s", formatEnum(s.Enum))
+		return
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.ExclusiveMinimum != nil && v <= *s.ExclusiveMinimum {
+			fail("must be greater than %v", *s.ExclusiveMinimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			if *s.MinLength == 1 {
+				fail("can't be empty")
+			} else {
+				fail("must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.Pattern)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range v {
+			if s.UniqueItems && contains(v[:i], item) {
+				*fields = append(*fields, models.FieldError{Field: fmt.Sprintf("%s[%d]", path, i), Message: "is listed more than once"})
+			}
+			if s.Items != nil {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, fields)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, set := v[name]; !set {
+				*fields = append(*fields, models.FieldError{Field: child(path, name), Message: "is required"})
+			}
+		}
+		for _, name := range sortedKeys(v) {
+			property := s.Properties[name]
+			if property == nil {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					message := "is not a known field"
+					if suggestion := s.closest(name); suggestion != "" {
+						message += "; did you mean " + suggestion + "?"
+					}
+					*fields = append(*fields, models.FieldError{Field: child(path, name), Message: message})
+				}
+				continue
+			}
+			property.validate(child(path, name), v[name], fields)
+		}
+	}
+}
+
+// closest returns the property whose name is nearest to name, if any is
+// near enough to be a likely typo
+func (s *Schema) closest(name string) string {
+	best, bestDistance := "", len(name)/3+1
+	for _, candidate := range sortedKeys(s.Properties) {
+		if s.Properties[candidate].Deprecated {
+			continue
+		}
+		if distance := levenshtein(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func hasType(value interface{}, kind string) bool {
+	switch v := value.(type) {
+	case string:
+		return kind == "string"
+	case bool:
+		return kind == "boolean"
+	case float64:
+		return kind == "number" || kind == "integer" && v == math.Trunc(v)
+	case []interface{}:
+		return kind == "array"
+	case map[string]interface{}:
+		return kind == "object"
+	case nil:
+		return kind == "null"
+	}
+	return false
+}
+
+func article(kind string) string {
+	if strings.ContainsAny(kind[:1], "aeiou") {
+		return "an"
+	}
+	return "a"
+}
+
+func contains(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// clone deep copies a value decoded from JSON
+func clone(value interface{}) interface{} {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var out interface{}
+	if err := json.Unmarshal(payload, &out); err != nil {
+		return value
+	}
+	return out
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,184 @@
+package nodeconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// testSchema uses every keyword and extension the profiles rely on
+const testSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["network"],
+	"properties": {
+		"network": {"type": "string", "minLength": 1},
+		"cache_size": {"type": "integer", "minimum": 128},
+		"cpu": {"type": "number", "exclusiveMinimum": 0},
+		"sync_mode": {"type": "string", "enum": ["snap", "full"], "default": "snap", "x-value-migrations": {"fast": "snap"}},
+		"syncmode": {"deprecated": true, "x-replaced-by": "sync_mode"},
+		"http_port": {"type": "integer", "default": 8545},
+		"resources": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {
+				"disk_gb": {"type": "integer", "default": 100},
+				"storage_class": {"type": "string", "default": "standard"}
+			}
+		}
+	}
+}`
+
+func decode(t *testing.T, value string) map[string]interface{} {
+	t.Helper()
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &object); err != nil {
+		t.Fatalf("%s: %v", value, err)
+	}
+	return object
+}
+
+func TestSchema(t *testing.T) {
+	var schema Schema
+	if err := json.Unmarshal([]byte(testSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		config     string
+		want       string
+		migrations []Migration
+		fields     []models.FieldError
+	}{
+		{
+			name:   "unknown key with a close match",
+			config: `{"network": "main", "cache_sise": 256}`,
+			fields: []models.FieldError{{Field: "config.cache_sise", Message: "is not a known field; did you mean cache_size?"}},
+		},
+		{
+			name:   "unknown key without a close match",
+			config: `{"network": "main", "colour": "red"}`,
+			fields: []models.FieldError{{Field: "config.colour", Message: "is not a known field"}},
+		},
+		{
+			name:   "unknown nested key",
+			config: `{"network": "main", "resources": {"disk": 500}}`,
+			fields: []models.FieldError{{Field: "config.resources.disk", Message: "is not a known field"}},
+		},
+		{
+			name:       "deprecated key renamed",
+			config:     `{"network": "main", "syncmode": "full"}`,
+			want:       `{"network": "main", "sync_mode": "full", "http_port": 8545}`,
+			migrations: []Migration{{Field: "config.syncmode", Message: "renamed to sync_mode"}},
+		},
+		{
+			name:   "deprecated key and its replacement both set",
+			config: `{"network": "main", "syncmode": "full", "sync_mode": "snap"}`,
+			fields: []models.FieldError{{Field: "config.syncmode", Message: "deprecated; remove it, sync_mode is already set"}},
+		},
+		{
+			name:   "retired value replaced",
+			config: `{"network": "main", "sync_mode": "fast"}`,
+			want:   `{"network": "main", "sync_mode": "snap", "http_port": 8545}`,
+			migrations: []Migration{
+				{Field: "config.sync_mode", Message: "fast is replaced by snap"},
+			},
+		},
+		{
+			name:   "renamed key with a retired value",
+			config: `{"network": "main", "syncmode": "fast"}`,
+			want:   `{"network": "main", "sync_mode": "snap", "http_port": 8545}`,
+			migrations: []Migration{
+				{Field: "config.syncmode", Message: "renamed to sync_mode"},
+				{Field: "config.sync_mode", Message: "fast is replaced by snap"},
+			},
+		},
+		{
+			name:   "integer given a whole number",
+			config: `{"network": "main", "cache_size": 256}`,
+			want:   `{"network": "main", "cache_size": 256, "sync_mode": "snap", "http_port": 8545}`,
+		},
+		{
+			name:   "integer given a fraction",
+			config: `{"network": "main", "cache_size": 256.5}`,
+			fields: []models.FieldError{{Field: "config.cache_size", Message: "must be an integer"}},
+		},
+		{
+			name:   "integer given a string",
+			config: `{"network": "main", "cache_size": "256"}`,
+			fields: []models.FieldError{{Field: "config.cache_size", Message: "must be an integer"}},
+		},
+		{
+			name:   "number given a fraction",
+			config: `{"network": "main", "cpu": 0.5}`,
+			want:   `{"network": "main", "cpu": 0.5, "sync_mode": "snap", "http_port": 8545}`,
+		},
+		{
+			name:   "number out of range",
+			config: `{"network": "main", "cpu": 0, "cache_size": 64}`,
+			fields: []models.FieldError{
+				{Field: "config.cache_size", Message: "must be at least 128"},
+				{Field: "config.cpu", Message: "must be greater than 0"},
+			},
+		},
+		{
+			name:   "value outside the enum",
+			config: `{"network": "main", "sync_mode": "light"}`,
+			fields: []models.FieldError{{Field: "config.sync_mode", Message: "must be one of snap, full"}},
+		},
+		{
+			name:   "required key missing",
+			config: `{"http_port": 8545}`,
+			fields: []models.FieldError{{Field: "config.network", Message: "is required"}},
+		},
+		{
+			name:   "defaults don't overwrite set values",
+			config: `{"network": "main", "http_port": 9000, "sync_mode": "full", "resources": {"disk_gb": 500}}`,
+			want:   `{"network": "main", "http_port": 9000, "sync_mode": "full", "resources": {"disk_gb": 500, "storage_class": "standard"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := decode(t, tt.config)
+			result := &Result{Config: config}
+			var fields []models.FieldError
+			schema.migrate("config", config, result, &fields)
+			schema.validate("config", config, &fields)
+
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Fatalf("fields = %+v, want %+v", fields, tt.fields)
+			}
+			if len(fields) > 0 {
+				return
+			}
+			schema.applyDefaults(config)
+			if want := decode(t, tt.want); !reflect.DeepEqual(config, want) {
+				t.Errorf("config = %v, want %v", config, want)
+			}
+			if !reflect.DeepEqual(result.Migrations, tt.migrations) {
+				t.Errorf("migrations = %+v, want %+v", result.Migrations, tt.migrations)
+			}
+		})
+	}
+}
+
+// TestNormalizeSuggestsKnownKey checks the embedded profiles the same way
+func TestNormalizeSuggestsKnownKey(t *testing.T) {
+	_, err := Normalize(models.ChainTypeEthereum, map[string]interface{}{"cache_sise": float64(2048)})
+	var configErr *Error
+	if !errors.As(err, &configErr) {
+		t.Fatalf("err = %v, want *Error", err)
+	}
+	want := []models.FieldError{{Field: "config.cache_sise", Message: "is not a known field; did you mean cache_size?"}}
+	if !reflect.DeepEqual(configErr.Fields, want) {
+		t.Errorf("fields = %+v, want %+v", configErr.Fields, want)
+	}
+}
@@ -0,0 +1,260 @@
+package nodeevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// streamKey holds recent events so clients can resume after reconnecting
+	streamKey = "node_events:stream"
+	// channel carries each event to every replica as it is published
+	channel = "node_events"
+	// leaseKey names the replica whose Watcher polls for changes
+	leaseKey = "node_events:watcher"
+
+	replayPageSize = 500
+)
+
+// renewScript extends the lease only if this replica still holds it
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseScript gives up the lease only if this replica holds it
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// ErrInvalidID is returned for event IDs that were not issued by a Broker
+var ErrInvalidID = errors.New("invalid event ID")
+
+// Broker distributes node events between gateway replicas through Redis
+type Broker struct {
+	redis     *redis.Client
+	hub       *Hub
+	logger    *zap.Logger
+	maxLen    int64
+	replicaID string
+
+	mu     sync.Mutex
+	lastID string
+}
+
+// NewBroker creates a Broker delivering to hub that keeps about maxLen events
+// for replay
+func NewBroker(client *redis.Client, hub *Hub, logger *zap.Logger, maxLen int64) *Broker {
+	return &Broker{
+		redis:     client,
+		hub:       hub,
+		logger:    logger,
+		maxLen:    maxLen,
+		replicaID: uuid.NewString(),
+	}
+}
+
+// Lead takes or renews the watcher lease for ttl and reports whether this
+// replica holds it
+func (b *Broker) Lead(ctx context.Context, ttl time.Duration) (bool, error) {
+	taken, err := b.redis.SetNX(ctx, leaseKey, b.replicaID, ttl).Result()
+	if err != nil || taken {
+		return taken, err
+	}
+	renewed, err := renewScript.Run(ctx, b.redis, []string{leaseKey}, b.replicaID, ttl.Milliseconds()).Int()
+	return renewed == 1, err
+}
+
+// Resign gives up the watcher lease so another replica takes over at once
+func (b *Broker) Resign(ctx context.Context) {
+	if err := releaseScript.Run(ctx, b.redis, []string{leaseKey}, b.replicaID).Err(); err != nil {
+		b.logger.Warn("Failed to release node watcher lease", zap.Error(err))
+	}
+}
+
+// Subscribe returns a subscription to the events delivered to this replica
+// from now on
+func (b *Broker) Subscribe() *Subscription {
+	return b.hub.Subscribe()
+}
+
+// Publish records event in the stream, setting its ID, and sends it to every
+// replica
+func (b *Broker) Publish(ctx context.Context, event *models.NodeEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	id, err := b.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{"event": payload},
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	payload, err = json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.redis.Publish(ctx, channel, payload).Err()
+}
+
+// Run delivers the events published by any replica to the local hub until
+// ctx is cancelled. Events missed while resubscribing are read back from the
+// stream.
+func (b *Broker) Run(ctx context.Context) {
+	pubsub := b.redis.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	subscribed := false
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.logger.Warn("Node event subscription interrupted", zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" && subscribed {
+				b.backfill(ctx)
+			}
+			subscribed = true
+		case *redis.Message:
+			var event models.NodeEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				b.logger.Warn("Ignoring malformed node event", zap.Error(err))
+				continue
+			}
+			b.deliver(event)
+		}
+	}
+}
+
+// backfill delivers the events published since the last one delivered
+func (b *Broker) backfill(ctx context.Context) {
+	b.mu.Lock()
+	lastID := b.lastID
+	b.mu.Unlock()
+	if lastID == "" {
+		return
+	}
+
+	events, _, err := b.Since(ctx, lastID)
+	if err != nil {
+		b.logger.Warn("Failed to read back missed node events", zap.Error(err))
+		return
+	}
+	for _, event := range events {
+		b.deliver(event)
+	}
+}
+
+// deliver publishes event to the hub unless it was already delivered
+func (b *Broker) deliver(event models.NodeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.lastID != "" && CompareIDs(event.ID, b.lastID) <= 0 {
+		return
+	}
+	b.lastID = event.ID
+	b.hub.Publish(event)
+}
+
+// Since returns the events published after lastID, oldest first. complete is
+// false when older events have been trimmed from the stream, so some after
+// lastID may be missing.
+func (b *Broker) Since(ctx context.Context, lastID string) (events []models.NodeEvent, complete bool, err error) {
+	if _, _, err := parseID(lastID); err != nil {
+		return nil, false, err
+	}
+
+	oldest, err := b.redis.XRangeN(ctx, streamKey, "-", "+", 1).Result()
+	if err != nil {
+		return nil, false, err
+	}
+	complete = len(oldest) == 0 || CompareIDs(lastID, oldest[0].ID) >= 0
+
+	start := lastID
+	for {
+		page, err := b.redis.XRangeN(ctx, streamKey, "("+start, "+", replayPageSize).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		for _, entry := range page {
+			payload, _ := entry.Values["event"].(string)
+			var event models.NodeEvent
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				b.logger.Warn("Ignoring malformed node event", zap.String("id", entry.ID), zap.Error(err))
+				continue
+			}
+			event.ID = entry.ID
+			events = append(events, event)
+		}
+		if len(page) < replayPageSize {
+			return events, complete, nil
+		}
+		start = page[len(page)-1].ID
+	}
+}
+
+// ValidID reports whether id has the form of an event ID
+func ValidID(id string) bool {
+	_, _, err := parseID(id)
+	return err == nil
+}
+
+// CompareIDs orders two event IDs, returning -1, 0 or 1. Invalid IDs sort first.
+func CompareIDs(a, b string) int {
+	aMs, aSeq, _ := parseID(a)
+	bMs, bSeq, _ := parseID(b)
+	switch {
+	case aMs < bMs || (aMs == bMs && aSeq < bSeq):
+		return -1
+	case aMs > bMs || aSeq > bSeq:
+		return 1
+	}
+	return 0
+}
+
+// parseID splits a Redis stream ID into its millisecond time and sequence
+func parseID(id string) (uint64, uint64, error) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, ErrInvalidID
+	}
+	msValue, err := strconv.ParseUint(ms, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidID
+	}
+	seqValue, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return 0, 0, ErrInvalidID
+	}
+	return msValue, seqValue, nil
+}
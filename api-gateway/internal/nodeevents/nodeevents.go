@@ -0,0 +1,81 @@
+// Package nodeevents turns changes to stored nodes into a stream of events.
+// One replica's Watcher polls the nodes table, since nodes are also updated
+// outside the gateway, and publishes what changed through a Broker. The
+// Broker keeps a replayable history in a Redis stream and fans events out to
+// every replica over Redis pub/sub, where each delivers them to its local Hub.
+package nodeevents
+
+import (
+	"sync"
+
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// subscriptionBuffer is how many events a subscriber may fall behind by
+// before it is dropped
+const subscriptionBuffer = 256
+
+// Subscription receives the events published to a Hub. C is closed when the
+// subscription is closed or dropped for falling behind.
+type Subscription struct {
+	C <-chan models.NodeEvent
+
+	hub     *Hub
+	ch      chan models.NodeEvent
+	dropped bool
+}
+
+// Dropped reports whether C was closed because the subscriber fell behind
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
+
+// Close stops delivery to s
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.ch)
+	}
+}
+
+// Hub fans events out to subscribers
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// NewHub creates a new Hub
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription to every event published after it
+func (h *Hub) Subscribe() *Subscription {
+	ch := make(chan models.NodeEvent, subscriptionBuffer)
+	sub := &Subscription{C: ch, hub: h, ch: ch}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Publish delivers event to every subscriber without blocking. Subscribers
+// whose buffer is full are dropped rather than silently missing events.
+func (h *Hub) Publish(event models.NodeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped = true
+			delete(h.subs, sub)
+			close(sub.ch)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package nodeevents
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodes"
+	"go.uber.org/zap"
+)
+
+// leaseIntervals is how many poll intervals the watching replica's lease
+// outlives its last poll, so a stalled replica is replaced
+const leaseIntervals = 3
+
+// nodeState is the part of a node whose changes are reported. Performance
+// metrics and probe timestamps change constantly and are left out.
+type nodeState struct {
+	status     models.NodeStatus
+	version    string
+	name       string
+	endpoint   string
+	sync       models.SyncStatus
+	consensus  models.ConsensusStatus
+	updatedAt  time.Time
+	hasBeacon  bool
+	beaconSeen bool
+}
+
+func stateOf(node *models.BlockchainNode) nodeState {
+	state := nodeState{
+		status:    node.Status,
+		version:   node.Version,
+		name:      node.Name,
+		endpoint:  node.EndpointURL,
+		sync:      node.SyncStatus,
+		updatedAt: node.UpdatedAt,
+		hasBeacon: node.ConsensusEndpointURL != "",
+	}
+	if node.ConsensusStatus != nil {
+		state.consensus = *node.ConsensusStatus
+		state.consensus.CheckedAt = time.Time{}
+		state.beaconSeen = true
+	}
+	return state
+}
+
+// changes lists the reported fields that differ between two states of a
+// node. Other edits, such as to its config, only move updatedAt.
+func changes(previous, current nodeState) []models.NodeField {
+	var fields []models.NodeField
+	if previous.name != current.name {
+		fields = append(fields, models.NodeFieldName)
+	}
+	if previous.endpoint != current.endpoint {
+		fields = append(fields, models.NodeFieldEndpointURL)
+	}
+	if previous.status != current.status {
+		fields = append(fields, models.NodeFieldStatus)
+	}
+	if previous.version != current.version {
+		fields = append(fields, models.NodeFieldVersion)
+	}
+	if previous.sync != current.sync {
+		fields = append(fields, models.NodeFieldSyncStatus)
+	}
+	if previous.consensus != current.consensus || previous.hasBeacon != current.hasBeacon || previous.beaconSeen != current.beaconSeen {
+		fields = append(fields, models.NodeFieldConsensusStatus)
+	}
+	return fields
+}
+
+// alertFor raises an alert when a node enters or leaves the error state
+func alertFor(previous models.NodeStatus, node *models.BlockchainNode) *models.NodeAlert {
+	switch {
+	case node.Status == models.NodeStatusError && previous != models.NodeStatusError:
+		message := "Node entered the error state"
+		if cl := node.ConsensusStatus; cl != nil && cl.Error != "" {
+			message += ": consensus client " + cl.Error
+		} else if cl != nil && cl.ELOffline {
+			message += ": consensus client reports the execution client offline"
+		}
+		return &models.NodeAlert{Severity: models.NodeAlertCritical, Message: message}
+	case previous == models.NodeStatusError && node.Status != models.NodeStatusError:
+		return &models.NodeAlert{
+			Severity: models.NodeAlertResolved,
+			Message:  "Node recovered and is now " + string(node.Status),
+		}
+	}
+	return nil
+}
+
+// Watcher polls the nodes table and publishes the changes it finds. Only the
+// replica holding the broker's lease polls, so each change is published once.
+type Watcher struct {
+	store    *nodes.Store
+	broker   *Broker
+	logger   *zap.Logger
+	interval time.Duration
+
+	last map[uuid.UUID]nodeState
+}
+
+// NewWatcher creates a Watcher that polls every interval
+func NewWatcher(store *nodes.Store, broker *Broker, logger *zap.Logger, interval time.Duration) *Watcher {
+	return &Watcher{store: store, broker: broker, logger: logger, interval: interval}
+}
+
+// Run polls until ctx is cancelled. The first poll after taking the lease
+// records the current state without publishing it.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	w.poll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			w.broker.Resign(context.Background())
+			return
+		case <-ticker.C:
+			w.poll(ctx)
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context) {
+	leading, err := w.broker.Lead(ctx, leaseIntervals*w.interval)
+	if err != nil || !leading {
+		if err != nil && ctx.Err() == nil {
+			w.logger.Warn("Failed to renew node watcher lease", zap.Error(err))
+		}
+		// Another replica is watching; start afresh if the lease comes back
+		w.last = nil
+		return
+	}
+
+	items, err := w.store.All(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Warn("Failed to poll nodes for changes", zap.Error(err))
+		}
+		return
+	}
+
+	now := time.Now().UTC()
+	current := make(map[uuid.UUID]nodeState, len(items))
+	var events []models.NodeEvent
+	for i := range items {
+		node := &items[i]
+		state := stateOf(node)
+		current[node.ID] = state
+		if w.last == nil {
+			continue
+		}
+
+		previous, known := w.last[node.ID]
+		if !known {
+			events = append(events, models.NodeEvent{
+				Type:       models.NodeEventCreated,
+				Node:       node,
+				Alert:      alertFor("", node),
+				OccurredAt: now,
+			})
+			continue
+		}
+		if previous != state {
+			events = append(events, models.NodeEvent{
+				Type:           models.NodeEventUpdated,
+				Node:           node,
+				PreviousStatus: previous.status,
+				Changes:        changes(previous, state),
+				Alert:          alertFor(previous.status, node),
+				OccurredAt:     now,
+			})
+		}
+	}
+	for id, previous := range w.last {
+		if _, ok := current[id]; !ok {
+			events = append(events, models.NodeEvent{
+				Type:           models.NodeEventDeleted,
+				Node:           &models.BlockchainNode{ID: id, Name: previous.name},
+				PreviousStatus: previous.status,
+				OccurredAt:     now,
+			})
+		}
+	}
+
+	for i := range events {
+		if err := w.broker.Publish(ctx, &events[i]); err != nil {
+			// Keep the old state of what wasn't published so it is retried
+			if ctx.Err() == nil {
+				w.logger.Warn("Failed to publish node event", zap.Error(err))
+			}
+			for _, event := range events[i:] {
+				if previous, ok := w.last[event.Node.ID]; ok {
+					current[event.Node.ID] = previous
+				} else {
+					delete(current, event.Node.ID)
+				}
+			}
+			break
+		}
+	}
+	w.last = current
+}
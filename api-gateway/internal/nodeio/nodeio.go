@@ -0,0 +1,117 @@
+// Package nodeio reads and writes nodes as CSV, JSON or YAML for bulk import
+// and export. Records have the fields of models.CreateNodeRequest, so an
+// export can be imported again. Readers and writers work one record at a
+// time and never hold a whole file.
+package nodeio
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// Format is a file format nodes can be imported from and exported to
+type Format string
+
+const (
+	// CSV has a header row naming the columns. config is a JSON object and
+	// labels are comma-separated key=value pairs.
+	CSV Format = "csv"
+	// JSON is an array of objects, or a stream of objects one after another
+	JSON Format = "json"
+	// YAML is a sequence of mappings, or a stream of documents with one
+	// mapping or sequence each
+	YAML Format = "yaml"
+)
+
+// ParseFormat reads a format name
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(value))); format {
+	case CSV, JSON, YAML:
+		return format, nil
+	case "yml":
+		return YAML, nil
+	case "ndjson", "jsonl":
+		return JSON, nil
+	}
+	return "", fmt.Errorf("unknown format %q, must be csv, json or yaml", value)
+}
+
+// FormatOf returns the format of a body with the given Content-Type
+func FormatOf(contentType string) (Format, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case mediaType == "text/csv":
+		return CSV, true
+	case strings.HasSuffix(mediaType, "json"):
+		return JSON, true
+	case strings.HasSuffix(mediaType, "yaml"):
+		return YAML, true
+	}
+	return "", false
+}
+
+// ContentType is the media type of an export in f
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case YAML:
+		return "application/yaml"
+	}
+	return "application/json"
+}
+
+// columns are the CSV columns in the order they are exported
+var columns = []string{
+	"name",
+	"chain_type",
+	"endpoint_url",
+	"region",
+	"provider",
+	"config",
+	"labels",
+	"consensus_endpoint_url",
+	"publish_onchain",
+}
+
+// record is an exported node. Its fields and names match
+// models.CreateNodeRequest.
+type record struct {
+	Name                 string                 `json:"name" yaml:"name"`
+	ChainType            models.ChainType       `json:"chain_type" yaml:"chain_type"`
+	EndpointURL          string                 `json:"endpoint_url" yaml:"endpoint_url"`
+	Region               string                 `json:"region" yaml:"region"`
+	Provider             models.CloudProvider   `json:"provider" yaml:"provider"`
+	Config               map[string]interface{} `json:"config,omitempty" yaml:"config,omitempty"`
+	Labels               map[string]string      `json:"labels,omitempty" yaml:"labels,omitempty"`
+	ConsensusEndpointURL string                 `json:"consensus_endpoint_url,omitempty" yaml:"consensus_endpoint_url,omitempty"`
+	PublishOnchain       bool                   `json:"publish_onchain,omitempty" yaml:"publish_onchain,omitempty"`
+}
+
+func newRecord(node *models.BlockchainNode, redact Redaction) record {
+	r := record{
+		Name:                 node.Name,
+		ChainType:            node.ChainType,
+		EndpointURL:          node.EndpointURL,
+		Region:               node.Region,
+		Provider:             node.Provider,
+		Config:               node.Config,
+		Labels:               node.Labels,
+		ConsensusEndpointURL: node.ConsensusEndpointURL,
+		PublishOnchain:       node.PublishOnchain,
+	}
+	if redact.Endpoints {
+		r.EndpointURL = RedactURL(r.EndpointURL)
+		r.ConsensusEndpointURL = RedactURL(r.ConsensusEndpointURL)
+	}
+	if redact.Config {
+		r.Config = RedactConfig(r.Config)
+	}
+	return r
+}
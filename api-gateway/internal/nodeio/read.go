@@ -0,0 +1,283 @@
+package nodeio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Record is a node read from an import. Err is set when the record itself
+// can't be decoded; reading carries on with the next one.
+type Record struct {
+	// Index counts records from 1
+	Index int
+	// Line is where the record starts, when the format tracks it
+	Line    int
+	Request models.CreateNodeRequest
+	Err     error
+}
+
+// Reader reads an import a record at a time. Next returns io.EOF after the
+// last record and any other error when the file as a whole is malformed.
+type Reader interface {
+	Next() (*Record, error)
+}
+
+// NewReader returns a Reader for format
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case CSV:
+		return newCSVReader(r)
+	case JSON:
+		return newJSONReader(r)
+	case YAML:
+		return &yamlReader{decoder: yaml.NewDecoder(r)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// decodeRequest decodes a JSON object strictly, rejecting unknown fields
+func decodeRequest(payload []byte, req *models.CreateNodeRequest) error {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(req)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns []string
+	index   int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("CSV has no header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		if i == 0 {
+			// Spreadsheets often start UTF-8 files with a byte order mark
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !known[column] {
+			return nil, fmt.Errorf("unknown CSV column %q, columns are %s", column, strings.Join(columns, ", "))
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("CSV column %q appears more than once", column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+	return &csvReader{reader: reader, columns: header}, nil
+}
+
+func (cr *csvReader) Next() (*Record, error) {
+	row, err := cr.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	cr.index++
+	line, _ := cr.reader.FieldPos(0)
+	record := &Record{Index: cr.index, Line: line}
+	if len(row) != len(cr.columns) {
+		record.Err = fmt.Errorf("row has %d fields, the header has %d", len(row), len(cr.columns))
+		return record, nil
+	}
+
+	req := &record.Request
+	fail := func(err error) {
+		if record.Err == nil {
+			record.Err = err
+		}
+	}
+	for i, column := range cr.columns {
+		value := strings.TrimSpace(row[i])
+		switch column {
+		case "name":
+			req.Name = value
+		case "chain_type":
+			req.ChainType = models.ChainType(value)
+		case "endpoint_url":
+			req.EndpointURL = value
+		case "region":
+			req.Region = value
+		case "provider":
+			req.Provider = models.CloudProvider(value)
+		case "config":
+			if value != "" {
+				if err := json.Unmarshal([]byte(value), &req.Config); err != nil {
+					fail(fmt.Errorf("config must be a JSON object: %w", err))
+				}
+			}
+		case "labels":
+			set, err := labels.ParseSet(value)
+			if err != nil {
+				fail(err)
+			}
+			req.Labels = set
+		case "consensus_endpoint_url":
+			req.ConsensusEndpointURL = value
+		case "publish_onchain":
+			if value != "" {
+				publish, err := strconv.ParseBool(value)
+				if err != nil {
+					fail(fmt.Errorf("publish_onchain must be true or false"))
+				}
+				req.PublishOnchain = publish
+			}
+		}
+	}
+	return record, nil
+}
+
+// jsonReader reads the elements of an array, or objects one after another
+type jsonReader struct {
+	decoder *json.Decoder
+	array   bool
+	index   int
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	buffered := bufio.NewReader(r)
+	first, err := firstByte(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	jr := &jsonReader{decoder: json.NewDecoder(buffered)}
+	if first == '[' {
+		if _, err := jr.decoder.Token(); err != nil {
+			return nil, err
+		}
+		jr.array = true
+	}
+	return jr, nil
+}
+
+// firstByte peeks at the first byte that isn't white space
+func firstByte(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("import is empty")
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			if _, err := r.ReadByte(); err != nil {
+				return 0, err
+			}
+		case 0xef:
+			// Skip a UTF-8 byte order mark
+			if bom, _ := r.Peek(3); bytes.Equal(bom, []byte{0xef, 0xbb, 0xbf}) {
+				if _, err := r.Discard(3); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			return b[0], nil
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+func (jr *jsonReader) Next() (*Record, error) {
+	if jr.array && !jr.decoder.More() {
+		if _, err := jr.decoder.Token(); err != nil {
+			return nil, err
+		}
+		if _, err := jr.decoder.Token(); !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("unexpected data after the JSON array")
+		}
+		return nil, io.EOF
+	}
+
+	var payload json.RawMessage
+	if err := jr.decoder.Decode(&payload); err != nil {
+		if errors.Is(err, io.EOF) && !jr.array {
+			return nil, io.EOF
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	jr.index++
+	record := &Record{Index: jr.index}
+	record.Err = decodeRequest(payload, &record.Request)
+	return record, nil
+}
+
+// yamlReader reads the items of each document's sequence, or the single
+// mapping a document holds
+type yamlReader struct {
+	decoder *yaml.Decoder
+	pending []*yaml.Node
+	index   int
+}
+
+func (yr *yamlReader) Next() (*Record, error) {
+	for len(yr.pending) == 0 {
+		var document yaml.Node
+		if err := yr.decoder.Decode(&document); err != nil {
+			return nil, err
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+		node := document.Content[0]
+		if node.Kind == yaml.SequenceNode {
+			yr.pending = node.Content
+		} else {
+			yr.pending = []*yaml.Node{node}
+		}
+	}
+	node := yr.pending[0]
+	yr.pending = yr.pending[1:]
+	yr.index++
+
+	record := &Record{Index: yr.index, Line: node.Line}
+	if node.Kind != yaml.MappingNode {
+		record.Err = fmt.Errorf("node must be a mapping")
+		return record, nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		record.Err = err
+		return record, nil
+	}
+	payload, err := json.Marshal(value)
+	if err != nil {
+		record.Err = err
+		return record, nil
+	}
+	record.Err = decodeRequest(payload, &record.Request)
+	return record, nil
+}
@@ -0,0 +1,134 @@
+package nodeio
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces secrets in exports
+const Redacted = "REDACTED"
+
+// Redaction selects the secrets an export hides
+type Redaction struct {
+	// Endpoints hides credentials in endpoint URLs
+	Endpoints bool
+	// Config hides config values whose keys name a secret
+	Config bool
+}
+
+var (
+	// tokenSegment matches path segments that look like API keys, such as
+	// the project ID in https://mainnet.infura.io/v3/<key>
+	tokenSegment = regexp.MustCompile(`^[A-Za-z0-9_-]{20,}$`)
+	digit        = regexp.MustCompile(`[0-9]`)
+
+	secretKeys = []string{"secret", "password", "passwd", "token", "key", "jwt", "auth", "credential", "mnemonic"}
+)
+
+// RedactURL hides the user info, query values and API key path segments of
+// an endpoint URL. A value that doesn't parse is hidden entirely.
+func RedactURL(value string) string {
+	if value == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return Redacted
+	}
+	if u.User != nil {
+		u.User = url.User(Redacted)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query[key] = []string{Redacted}
+		}
+		u.RawQuery = query.Encode()
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if isTokenSegment(segment) {
+			segments[i] = Redacted
+		}
+	}
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// PublicURL strips what RedactURL hides from an endpoint URL instead of
+// masking it: the user info, query, fragment and API key path segments. It
+// returns "" for a value that doesn't parse.
+func PublicURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	segments := strings.Split(u.Path, "/")
+	kept := segments[:0]
+	for _, segment := range segments {
+		if !isTokenSegment(segment) {
+			kept = append(kept, segment)
+		}
+	}
+	u.Path = strings.Join(kept, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// isTokenSegment reports whether a URL path segment looks like an API key
+func isTokenSegment(segment string) bool {
+	return tokenSegment.MatchString(segment) && digit.MatchString(segment)
+}
+
+// isSecretKey reports whether a config key names a secret
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactConfig returns a copy of config with the values of secret keys
+// hidden and URLs redacted, at any depth
+func RedactConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if isSecretKey(key) {
+			out[key] = Redacted
+			continue
+		}
+		out[key] = redactValue(value)
+	}
+	return out
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return RedactConfig(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	case string:
+		if strings.Contains(v, "://") {
+			return RedactURL(v)
+		}
+	}
+	return value
+}
@@ -0,0 +1,138 @@
+package nodeio
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Writer writes nodes one at a time. Close finishes the file.
+type Writer interface {
+	Write(node *models.BlockchainNode) error
+	Close() error
+}
+
+// NewWriter returns a Writer for format that hides the secrets selected by
+// redact
+func NewWriter(format Format, w io.Writer, redact Redaction) Writer {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w), redact: redact}
+	case YAML:
+		return &yamlWriter{w: w, redact: redact}
+	}
+	return &jsonWriter{w: w, redact: redact}
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	redact        Redaction
+	headerWritten bool
+}
+
+func (cw *csvWriter) header() error {
+	if cw.headerWritten {
+		return nil
+	}
+	cw.headerWritten = true
+	return cw.w.Write(columns)
+}
+
+func (cw *csvWriter) Write(node *models.BlockchainNode) error {
+	if err := cw.header(); err != nil {
+		return err
+	}
+	r := newRecord(node, cw.redact)
+	config := ""
+	if len(r.Config) > 0 {
+		payload, err := json.Marshal(r.Config)
+		if err != nil {
+			return err
+		}
+		config = string(payload)
+	}
+	err := cw.w.Write([]string{
+		r.Name,
+		string(r.ChainType),
+		r.EndpointURL,
+		r.Region,
+		string(r.Provider),
+		config,
+		labels.FormatSet(r.Labels),
+		r.ConsensusEndpointURL,
+		strconv.FormatBool(r.PublishOnchain),
+	})
+	if err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	if err := cw.header(); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// jsonWriter writes an array with one object per line
+type jsonWriter struct {
+	w       io.Writer
+	redact  Redaction
+	written bool
+}
+
+func (jw *jsonWriter) Write(node *models.BlockchainNode) error {
+	payload, err := json.Marshal(newRecord(node, jw.redact))
+	if err != nil {
+		return err
+	}
+	prefix := ",\n"
+	if !jw.written {
+		prefix = "[\n"
+		jw.written = true
+	}
+	_, err = io.WriteString(jw.w, prefix+string(payload))
+	return err
+}
+
+func (jw *jsonWriter) Close() error {
+	if !jw.written {
+		_, err := io.WriteString(jw.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(jw.w, "\n]\n")
+	return err
+}
+
+// yamlWriter writes a single sequence document an item at a time
+type yamlWriter struct {
+	w       io.Writer
+	redact  Redaction
+	written bool
+}
+
+func (yw *yamlWriter) Write(node *models.BlockchainNode) error {
+	payload, err := yaml.Marshal([]record{newRecord(node, yw.redact)})
+	if err != nil {
+		return err
+	}
+	yw.written = true
+	_, err = yw.w.Write(payload)
+	return err
+}
+
+func (yw *yamlWriter) Close() error {
+	if !yw.written {
+		_, err := io.WriteString(yw.w, "[]\n")
+		return err
+	}
+	return nil
+}
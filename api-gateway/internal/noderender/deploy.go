@@ -0,0 +1,281 @@
+package noderender
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodeIDLabel carries the node's ID on the resources it's rendered into
+const nodeIDLabel = "twist.io/node-id"
+
+// marshalYAML writes documents as one YAML stream. Structs keep their field
+// order and maps are written with sorted keys, so output is stable.
+func marshalYAML(documents ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, document := range documents {
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// labels returns the node's labels with its ID added
+func (s *spec) labels() map[string]string {
+	labels := make(map[string]string, len(s.node.Labels)+1)
+	for key, value := range s.node.Labels {
+		labels[key] = value
+	}
+	labels[nodeIDLabel] = s.node.ID.String()
+	return labels
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+	Volumes  map[string]struct{}       `yaml:"volumes"`
+}
+
+type composeService struct {
+	Image   string            `yaml:"image"`
+	Command []string          `yaml:"command"`
+	Ports   []string          `yaml:"ports,omitempty"`
+	Volumes []string          `yaml:"volumes"`
+	Restart string            `yaml:"restart"`
+	Labels  map[string]string `yaml:"labels"`
+	Deploy  *composeDeploy    `yaml:"deploy,omitempty"`
+}
+
+type composeDeploy struct {
+	Resources composeResources `yaml:"resources"`
+}
+
+type composeResources struct {
+	Limits composeLimits `yaml:"limits"`
+}
+
+type composeLimits struct {
+	CPUs   string `yaml:"cpus,omitempty"`
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// compose returns a Compose file running the node as one service, with its
+// data on a named volume and its resource hints as limits
+func (s *spec) compose() ([]byte, error) {
+	args, err := s.args()
+	if err != nil {
+		return nil, err
+	}
+	volume := s.name + "-data"
+	service := composeService{
+		Image:   s.settings.str("image"),
+		Command: args,
+		Volumes: []string{volume + ":" + s.settings.str("data_dir")},
+		Restart: "unless-stopped",
+		Labels:  s.labels(),
+	}
+	for _, p := range s.ports() {
+		mapping := fmt.Sprintf("%d:%d", p.Port, p.Port)
+		if p.Protocol == "UDP" {
+			mapping += "/udp"
+		}
+		service.Ports = append(service.Ports, mapping)
+	}
+
+	var limits composeLimits
+	resources := s.settings.resources()
+	if cpu, ok := resources.number("cpu"); ok {
+		limits.CPUs = formatValue(cpu)
+	}
+	if memory, ok := resources.number("memory_gb"); ok {
+		limits.Memory = formatValue(memory) + "g"
+	}
+	if limits != (composeLimits{}) {
+		service.Deploy = &composeDeploy{Resources: composeResources{Limits: limits}}
+	}
+
+	return marshalYAML(composeFile{
+		Services: map[string]composeService{s.name: service},
+		Volumes:  map[string]struct{}{volume: {}},
+	})
+}
+
+type k8sMetadata struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type k8sStatefulSet struct {
+	APIVersion string             `yaml:"apiVersion"`
+	Kind       string             `yaml:"kind"`
+	Metadata   k8sMetadata        `yaml:"metadata"`
+	Spec       k8sStatefulSetSpec `yaml:"spec"`
+}
+
+type k8sStatefulSetSpec struct {
+	ServiceName          string           `yaml:"serviceName"`
+	Replicas             int              `yaml:"replicas"`
+	Selector             k8sSelector      `yaml:"selector"`
+	Template             k8sPodTemplate   `yaml:"template"`
+	VolumeClaimTemplates []k8sVolumeClaim `yaml:"volumeClaimTemplates"`
+}
+
+type k8sSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type k8sPodTemplate struct {
+	Metadata k8sPodMetadata `yaml:"metadata"`
+	Spec     k8sPodSpec     `yaml:"spec"`
+}
+
+type k8sPodMetadata struct {
+	Labels map[string]string `yaml:"labels"`
+}
+
+type k8sPodSpec struct {
+	Containers []k8sContainer `yaml:"containers"`
+}
+
+type k8sContainer struct {
+	Name         string            `yaml:"name"`
+	Image        string            `yaml:"image"`
+	Args         []string          `yaml:"args"`
+	Ports        []k8sPort         `yaml:"ports,omitempty"`
+	VolumeMounts []k8sVolumeMount  `yaml:"volumeMounts"`
+	Resources    *k8sResourceHints `yaml:"resources,omitempty"`
+}
+
+type k8sPort struct {
+	Name          string `yaml:"name"`
+	ContainerPort int64  `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol"`
+}
+
+type k8sVolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+}
+
+type k8sResourceHints struct {
+	Requests map[string]string `yaml:"requests"`
+	Limits   map[string]string `yaml:"limits"`
+}
+
+type k8sVolumeClaim struct {
+	Metadata k8sMetadata `yaml:"metadata"`
+	Spec     k8sPVCSpec  `yaml:"spec"`
+}
+
+type k8sPVCSpec struct {
+	AccessModes      []string         `yaml:"accessModes"`
+	StorageClassName string           `yaml:"storageClassName,omitempty"`
+	Resources        k8sStorageLimits `yaml:"resources"`
+}
+
+type k8sStorageLimits struct {
+	Requests map[string]string `yaml:"requests"`
+}
+
+type k8sService struct {
+	APIVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Metadata   k8sMetadata    `yaml:"metadata"`
+	Spec       k8sServiceSpec `yaml:"spec"`
+}
+
+type k8sServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []k8sServicePort  `yaml:"ports"`
+}
+
+type k8sServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int64  `yaml:"port"`
+	TargetPort string `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol"`
+}
+
+// kubernetes returns a StatefulSet running the node with its data on a
+// persistent volume sized by resources.disk_gb, and a Service in front of
+// its ports. Resource hints become both requests and limits.
+func (s *spec) kubernetes() ([]byte, error) {
+	resources := s.settings.resources()
+	if !resources.has("disk_gb") {
+		return nil, fmt.Errorf("%w: config.resources.disk_gb is needed to size the data volume", ErrUnsupported)
+	}
+	args, err := s.args()
+	if err != nil {
+		return nil, err
+	}
+
+	selector := map[string]string{
+		"app.kubernetes.io/name":     string(s.client),
+		"app.kubernetes.io/instance": s.name,
+	}
+	labels := s.labels()
+	for key, value := range selector {
+		labels[key] = value
+	}
+	labels["app.kubernetes.io/managed-by"] = "twist"
+
+	container := k8sContainer{
+		Name:         string(s.client),
+		Image:        s.settings.str("image"),
+		Args:         args,
+		VolumeMounts: []k8sVolumeMount{{Name: "data", MountPath: s.settings.str("data_dir")}},
+	}
+	service := k8sService{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   k8sMetadata{Name: s.name, Labels: labels},
+		Spec:       k8sServiceSpec{Selector: selector, Ports: []k8sServicePort{}},
+	}
+	for _, p := range s.ports() {
+		container.Ports = append(container.Ports, k8sPort{Name: p.Name, ContainerPort: p.Port, Protocol: p.Protocol})
+		service.Spec.Ports = append(service.Spec.Ports, k8sServicePort{Name: p.Name, Port: p.Port, TargetPort: p.Name, Protocol: p.Protocol})
+	}
+
+	hints := map[string]string{}
+	if cpu, ok := resources.number("cpu"); ok {
+		hints["cpu"] = formatValue(cpu)
+	}
+	if memory, ok := resources.number("memory_gb"); ok {
+		hints["memory"] = formatValue(memory) + "Gi"
+	}
+	if len(hints) > 0 {
+		container.Resources = &k8sResourceHints{Requests: hints, Limits: hints}
+	}
+
+	statefulSet := k8sStatefulSet{
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+		Metadata:   k8sMetadata{Name: s.name, Labels: labels},
+		Spec: k8sStatefulSetSpec{
+			ServiceName: s.name,
+			Replicas:    1,
+			Selector:    k8sSelector{MatchLabels: selector},
+			Template: k8sPodTemplate{
+				Metadata: k8sPodMetadata{Labels: labels},
+				Spec:     k8sPodSpec{Containers: []k8sContainer{container}},
+			},
+			VolumeClaimTemplates: []k8sVolumeClaim{{
+				Metadata: k8sMetadata{Name: "data"},
+				Spec: k8sPVCSpec{
+					AccessModes:      []string{"ReadWriteOnce"},
+					StorageClassName: resources.str("storage_class"),
+					Resources: k8sStorageLimits{Requests: map[string]string{
+						"storage": fmt.Sprintf("%dGi", resources.integer("disk_gb")),
+					}},
+				},
+			}},
+		},
+	}
+	return marshalYAML(statefulSet, service)
+}
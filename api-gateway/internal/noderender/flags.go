@@ -0,0 +1,381 @@
+package noderender
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeconfig"
+)
+
+// listenAddr is the address rendered nodes bind their APIs to. Containers
+// are reached through their published ports, so they listen everywhere.
+const listenAddr = "0.0.0.0"
+
+// gethVerbosity maps log levels to the numeric verbosity of geth and bor
+var gethVerbosity = map[string]int{"error": 1, "warn": 2, "info": 3, "debug": 4, "trace": 5}
+
+// rethVerbosity maps log levels to reth's repeated -v flags
+var rethVerbosity = map[string]string{"error": "-v", "warn": "-vv", "info": "-vvv", "debug": "-vvvv", "trace": "-vvvvv"}
+
+// argList builds a command line in a fixed order
+type argList []string
+
+func (a *argList) add(args ...string) {
+	*a = append(*a, args...)
+}
+
+func (a *argList) set(name string, value interface{}) {
+	*a = append(*a, "--"+name+"="+formatValue(value))
+}
+
+// formatValue writes a config value the way command lines expect, keeping
+// whole numbers out of exponent notation
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// setIf adds name=value when the config sets key
+func (a *argList) setIf(s settings, key, name string) {
+	if s.has(key) {
+		a.set(name, s[key])
+	}
+}
+
+// setList adds name with the list in key joined by commas, when it has items
+func (a *argList) setList(s settings, key, name string) {
+	if values := s.strings(key); len(values) > 0 {
+		a.set(name, strings.Join(values, ","))
+	}
+}
+
+// args returns the arguments that start the node's client, without the
+// binary itself. extra_flags always come last.
+func (s *spec) args() ([]string, error) {
+	var args argList
+	var err error
+	switch s.client {
+	case nodeconfig.Geth:
+		err = s.gethArgs(&args)
+	case nodeconfig.Erigon:
+		err = s.erigonArgs(&args)
+	case nodeconfig.Nethermind:
+		s.nethermindArgs(&args)
+	case nodeconfig.Besu:
+		s.besuArgs(&args)
+	case nodeconfig.Reth:
+		s.rethArgs(&args)
+	case nodeconfig.Bor:
+		s.borArgs(&args)
+	case nodeconfig.Nitro:
+		err = s.nitroArgs(&args)
+	default:
+		return nil, fmt.Errorf("%w: no flags are known for client %q", ErrUnsupported, s.client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	args.add(s.settings.strings("extra_flags")...)
+	return args, nil
+}
+
+// namedNetwork reports whether the node is on Ethereum, whose networks every
+// execution client knows by name
+func (s *spec) namedNetwork() bool {
+	return s.node.ChainType == models.ChainTypeEthereum
+}
+
+// httpArgs adds the HTTP and WebSocket JSON-RPC flags geth, bor, erigon and
+// reth share
+func (s *spec) httpArgs(args *argList, vhosts bool) {
+	c := s.settings
+	args.add("--http")
+	args.set("http.addr", listenAddr)
+	args.setIf(c, "http_port", "http.port")
+	args.setList(c, "http_api", "http.api")
+	if vhosts {
+		args.set("http.vhosts", "*")
+	}
+	args.add("--ws")
+	args.set("ws.addr", listenAddr)
+	args.setIf(c, "ws_port", "ws.port")
+	args.setList(c, "http_api", "ws.api")
+}
+
+func (s *spec) gethArgs(args *argList) error {
+	c := s.settings
+	if s.namedNetwork() {
+		args.add("--" + c.str("network"))
+	} else {
+		id, err := s.chainID()
+		if err != nil {
+			return err
+		}
+		args.set("networkid", id)
+	}
+	args.setIf(c, "data_dir", "datadir")
+	args.setIf(c, "sync_mode", "syncmode")
+	args.setIf(c, "gc_mode", "gcmode")
+	args.setIf(c, "cache_size", "cache")
+	args.setIf(c, "p2p_port", "port")
+	args.setIf(c, "max_peers", "maxpeers")
+	s.httpArgs(args, true)
+	args.set("authrpc.addr", listenAddr)
+	args.setIf(c, "authrpc_port", "authrpc.port")
+	args.set("authrpc.vhosts", "*")
+	args.setIf(c, "jwt_secret_path", "authrpc.jwtsecret")
+	args.setIf(c, "txpool_price_limit", "txpool.pricelimit")
+	args.set("verbosity", gethVerbosity[c.str("log_level")])
+	if c.has("metrics_port") {
+		args.add("--metrics")
+		args.set("metrics.addr", listenAddr)
+		args.set("metrics.port", c.integer("metrics_port"))
+	}
+	args.setList(c, "bootnodes", "bootnodes")
+	return nil
+}
+
+// erigonChains names the networks erigon knows outside Ethereum
+var erigonChains = map[models.ChainType]map[string]string{
+	models.ChainTypePolygon: {"mainnet": "bor-mainnet", "amoy": "amoy"},
+	models.ChainTypeBSC:     {"mainnet": "bsc", "testnet": "chapel"},
+}
+
+func (s *spec) erigonArgs(args *argList) error {
+	c := s.settings
+	switch {
+	case s.namedNetwork():
+		args.set("chain", c.str("network"))
+	case erigonChains[s.node.ChainType] != nil:
+		args.set("chain", erigonChains[s.node.ChainType][c.str("network")])
+	default:
+		id, err := s.chainID()
+		if err != nil {
+			return err
+		}
+		args.set("networkid", id)
+	}
+	args.setIf(c, "data_dir", "datadir")
+	args.setIf(c, "prune_mode", "prune.mode")
+	args.setIf(c, "p2p_port", "port")
+	args.setIf(c, "max_peers", "maxpeers")
+	args.setIf(c, "torrent_port", "torrent.port")
+	args.setIf(c, "private_api_addr", "private.api.addr")
+	s.httpArgs(args, true)
+	args.set("authrpc.addr", listenAddr)
+	args.setIf(c, "authrpc_port", "authrpc.port")
+	args.set("authrpc.vhosts", "*")
+	args.setIf(c, "jwt_secret_path", "authrpc.jwtsecret")
+	if c.has("internal_cl") && !c.boolean("internal_cl") {
+		args.add("--externalcl")
+	}
+	args.setIf(c, "log_level", "log.console.verbosity")
+	if c.has("metrics_port") {
+		args.add("--metrics")
+		args.set("metrics.addr", listenAddr)
+		args.set("metrics.port", c.integer("metrics_port"))
+	}
+	args.setList(c, "bootnodes", "bootnodes")
+	return nil
+}
+
+// nethermindArgs renders Nethermind's flags. Custom chains name their chain
+// spec in extra_flags.
+func (s *spec) nethermindArgs(args *argList) {
+	c := s.settings
+	if s.namedNetwork() {
+		args.set("config", c.str("network"))
+	}
+	args.setIf(c, "data_dir", "datadir")
+	pruning := c.str("pruning_mode")
+	switch c.str("sync_mode") {
+	case "snap":
+		args.set("Sync.FastSync", true)
+		args.set("Sync.SnapSync", true)
+	case "fast":
+		args.set("Sync.FastSync", true)
+	case "full":
+		args.set("Sync.FastSync", false)
+	case "archive":
+		args.set("Sync.FastSync", false)
+		pruning = "None"
+	}
+	if pruning != "" {
+		args.set("Pruning.Mode", pruning)
+	}
+	args.setIf(c, "p2p_port", "Network.P2PPort")
+	args.setIf(c, "p2p_port", "Network.DiscoveryPort")
+	args.setIf(c, "max_peers", "Network.MaxActivePeers")
+	args.set("JsonRpc.Enabled", true)
+	args.set("JsonRpc.Host", listenAddr)
+	args.setIf(c, "http_port", "JsonRpc.Port")
+	args.setIf(c, "ws_port", "JsonRpc.WebSocketsPort")
+	args.setList(c, "http_api", "JsonRpc.EnabledModules")
+	args.set("JsonRpc.EngineHost", listenAddr)
+	args.setIf(c, "engine_port", "JsonRpc.EnginePort")
+	args.setIf(c, "jwt_secret_path", "JsonRpc.JwtSecretFile")
+	args.set("log", strings.ToUpper(c.str("log_level")))
+	if c.has("metrics_port") {
+		args.set("Metrics.Enabled", true)
+		args.set("Metrics.ExposePort", c.integer("metrics_port"))
+	}
+	args.setList(c, "bootnodes", "Discovery.Bootnodes")
+}
+
+// besuArgs renders Besu's flags. Custom chains name their genesis file in
+// extra_flags.
+func (s *spec) besuArgs(args *argList) {
+	c := s.settings
+	if s.namedNetwork() {
+		args.set("network", c.str("network"))
+	} else if id, ok := nodeconfig.ChainID(s.node.ChainType, c); ok {
+		args.set("network-id", id)
+	}
+	args.setIf(c, "data_dir", "data-path")
+	args.setIf(c, "sync_mode", "sync-mode")
+	args.setIf(c, "data_storage_format", "data-storage-format")
+	args.setIf(c, "p2p_port", "p2p-port")
+	args.setIf(c, "max_peers", "max-peers")
+	apis := strings.ToUpper(strings.Join(c.strings("http_api"), ","))
+	args.add("--rpc-http-enabled")
+	args.set("rpc-http-host", listenAddr)
+	args.setIf(c, "http_port", "rpc-http-port")
+	if apis != "" {
+		args.set("rpc-http-api", apis)
+	}
+	args.set("host-allowlist", "*")
+	args.add("--rpc-ws-enabled")
+	args.set("rpc-ws-host", listenAddr)
+	args.setIf(c, "ws_port", "rpc-ws-port")
+	if apis != "" {
+		args.set("rpc-ws-api", apis)
+	}
+	args.setIf(c, "engine_port", "engine-rpc-port")
+	args.set("engine-host-allowlist", "*")
+	args.setIf(c, "jwt_secret_path", "engine-jwt-secret")
+	args.set("logging", strings.ToUpper(c.str("log_level")))
+	if c.has("metrics_port") {
+		args.add("--metrics-enabled")
+		args.set("metrics-host", listenAddr)
+		args.set("metrics-port", c.integer("metrics_port"))
+	}
+	args.setList(c, "bootnodes", "bootnodes")
+}
+
+// rethArgs renders reth's node subcommand. Custom chains name their chain
+// spec in extra_flags.
+func (s *spec) rethArgs(args *argList) {
+	c := s.settings
+	args.add("node")
+	if s.namedNetwork() {
+		args.set("chain", c.str("network"))
+	}
+	args.setIf(c, "data_dir", "datadir")
+	if c.str("prune_mode") == "full" {
+		args.add("--full")
+	}
+	args.setIf(c, "p2p_port", "port")
+	args.setIf(c, "p2p_port", "discovery.port")
+	args.setIf(c, "max_peers", "max-outbound-peers")
+	s.httpArgs(args, false)
+	args.set("authrpc.addr", listenAddr)
+	args.setIf(c, "authrpc_port", "authrpc.port")
+	args.setIf(c, "jwt_secret_path", "authrpc.jwtsecret")
+	if c.has("metrics_port") {
+		args.set("metrics", fmt.Sprintf("%s:%d", listenAddr, c.integer("metrics_port")))
+	}
+	args.setList(c, "bootnodes", "bootnodes")
+	args.add(rethVerbosity[c.str("log_level")])
+}
+
+func (s *spec) borArgs(args *argList) {
+	c := s.settings
+	args.add("server")
+	args.set("chain", c.str("network"))
+	args.setIf(c, "data_dir", "datadir")
+	args.setIf(c, "sync_mode", "syncmode")
+	args.setIf(c, "gc_mode", "gcmode")
+	args.setIf(c, "heimdall_url", "bor.heimdall")
+	args.setIf(c, "cache_size", "cache")
+	args.setIf(c, "p2p_port", "port")
+	args.setIf(c, "max_peers", "maxpeers")
+	s.httpArgs(args, true)
+	args.set("verbosity", gethVerbosity[c.str("log_level")])
+	if c.has("metrics_port") {
+		args.add("--metrics")
+		args.set("metrics.prometheus-addr", fmt.Sprintf("%s:%d", listenAddr, c.integer("metrics_port")))
+	}
+	args.setList(c, "bootnodes", "bootnodes")
+}
+
+func (s *spec) nitroArgs(args *argList) error {
+	c := s.settings
+	id, err := s.chainID()
+	if err != nil {
+		return err
+	}
+	args.set("chain.id", id)
+	args.setIf(c, "parent_chain_url", "parent-chain.connection.url")
+	args.setIf(c, "parent_chain_beacon_url", "parent-chain.blob-client.beacon-url")
+	args.setIf(c, "data_dir", "persistent.global-config")
+	args.set("http.addr", listenAddr)
+	args.setIf(c, "http_port", "http.port")
+	args.setList(c, "http_api", "http.api")
+	args.set("http.vhosts", "*")
+	args.set("ws.addr", listenAddr)
+	args.setIf(c, "ws_port", "ws.port")
+	args.setList(c, "http_api", "ws.api")
+	if c.boolean("archive") {
+		args.add("--execution.caching.archive")
+	}
+	args.set("log-level", strings.ToUpper(c.str("log_level")))
+	if c.has("metrics_port") {
+		args.add("--metrics")
+		args.set("metrics-server.addr", listenAddr)
+		args.set("metrics-server.port", c.integer("metrics_port"))
+	}
+	return nil
+}
+
+// port is a port the node listens on
+type port struct {
+	Name     string
+	Port     int64
+	Protocol string
+}
+
+// ports lists the ports the node's config opens, in a fixed order
+func (s *spec) ports() []port {
+	c := s.settings
+	var ports []port
+	add := func(name, key string, udp bool) {
+		if !c.has(key) {
+			return
+		}
+		ports = append(ports, port{Name: name, Port: c.integer(key), Protocol: "TCP"})
+		if udp {
+			ports = append(ports, port{Name: name + "-udp", Port: c.integer(key), Protocol: "UDP"})
+		}
+	}
+	add("http", "http_port", false)
+	add("ws", "ws_port", false)
+	if s.client != nodeconfig.Nitro {
+		add("p2p", "p2p_port", true)
+	}
+	add("engine", "authrpc_port", false)
+	add("engine", "engine_port", false)
+	add("torrent", "torrent_port", true)
+	add("metrics", "metrics_port", false)
+	return ports
+}
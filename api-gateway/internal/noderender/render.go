@@ -0,0 +1,193 @@
+// Package noderender produces a node client's native configuration from the
+// node's normalized config, the chain catalog and the node's resource hints:
+// a geth TOML file, the client's command-line flags, a Docker Compose file or
+// Kubernetes manifests. Output depends only on the node, so rendering the
+// same node twice gives identical bytes.
+package noderender
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeconfig"
+)
+
+// ErrUnsupported is returned when a format can't be rendered for a node
+var ErrUnsupported = errors.New("format not supported for this node")
+
+// Format is a kind of rendered configuration
+type Format string
+
+const (
+	// GethTOML is a geth config file for --config
+	GethTOML Format = "geth-toml"
+	// CLIFlags is the client's command line, one argument per line
+	CLIFlags Format = "cli-flags"
+	// DockerCompose is a Compose file with a service running the node
+	DockerCompose Format = "docker-compose"
+	// K8sManifest is a Kubernetes StatefulSet and Service running the node
+	K8sManifest Format = "k8s-manifest"
+)
+
+// Formats lists every format
+var Formats = []Format{GethTOML, CLIFlags, DockerCompose, K8sManifest}
+
+// ParseFormat reads a format name
+func ParseFormat(value string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == value {
+			return format, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return "", fmt.Errorf("format must be one of %s", strings.Join(names, ", "))
+}
+
+// Output is a rendered configuration
+type Output struct {
+	ContentType string
+	Filename    string
+	Body        []byte
+}
+
+// Render produces node's configuration in format. Configs stored before
+// they were checked against profiles are normalized first; one that breaks
+// its profile is reported as a *nodeconfig.Error.
+func Render(node *models.BlockchainNode, format Format) (*Output, error) {
+	result, err := nodeconfig.Normalize(node.ChainType, node.Config)
+	if err != nil {
+		return nil, err
+	}
+	if result.Client == "" {
+		return nil, fmt.Errorf("%w: config.client must name the client running the node", ErrUnsupported)
+	}
+	s := &spec{
+		node:     node,
+		client:   result.Client,
+		settings: settings(result.Config),
+		name:     resourceName(node.Name),
+	}
+
+	switch format {
+	case GethTOML:
+		if s.client != nodeconfig.Geth {
+			return nil, fmt.Errorf("%w: geth-toml is only available for geth nodes", ErrUnsupported)
+		}
+		body, err := s.gethTOML()
+		if err != nil {
+			return nil, err
+		}
+		return &Output{ContentType: "application/toml", Filename: s.name + ".toml", Body: body}, nil
+	case CLIFlags:
+		args, err := s.args()
+		if err != nil {
+			return nil, err
+		}
+		return &Output{ContentType: "text/plain; charset=utf-8", Filename: s.name + ".args", Body: []byte(strings.Join(args, "\n") + "\n")}, nil
+	case DockerCompose:
+		body, err := s.compose()
+		if err != nil {
+			return nil, err
+		}
+		return &Output{ContentType: "application/yaml", Filename: "docker-compose.yml", Body: body}, nil
+	case K8sManifest:
+		body, err := s.kubernetes()
+		if err != nil {
+			return nil, err
+		}
+		return &Output{ContentType: "application/yaml", Filename: s.name + ".yaml", Body: body}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// spec is a node being rendered
+type spec struct {
+	node     *models.BlockchainNode
+	client   nodeconfig.Client
+	settings settings
+	// name is the node's name as a DNS label, for services and resources
+	name string
+}
+
+// chainID returns the node's chain ID from the catalog or its config
+func (s *spec) chainID() (int64, error) {
+	id, ok := nodeconfig.ChainID(s.node.ChainType, s.settings)
+	if !ok {
+		return 0, fmt.Errorf("%w: config.chain_id is needed for %s nodes on network %q", ErrUnsupported, s.node.ChainType, s.settings.str("network"))
+	}
+	return id, nil
+}
+
+// resourceName turns a node name into a DNS label
+func resourceName(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	label := strings.TrimRight(b.String(), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	if label == "" {
+		return "node"
+	}
+	return label
+}
+
+// settings reads a normalized config
+type settings map[string]interface{}
+
+func (s settings) has(key string) bool {
+	_, ok := s[key]
+	return ok
+}
+
+func (s settings) str(key string) string {
+	value, _ := s[key].(string)
+	return value
+}
+
+func (s settings) integer(key string) int64 {
+	value, _ := s[key].(float64)
+	return int64(value)
+}
+
+func (s settings) boolean(key string) bool {
+	value, _ := s[key].(bool)
+	return value
+}
+
+func (s settings) strings(key string) []string {
+	values, _ := s[key].([]interface{})
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out
+}
+
+// resources returns the node's resource hints
+func (s settings) resources() settings {
+	resources, _ := s["resources"].(map[string]interface{})
+	return settings(resources)
+}
+
+func (s settings) number(key string) (float64, bool) {
+	value, ok := s[key].(float64)
+	return value, ok
+}
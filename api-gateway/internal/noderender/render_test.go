@@ -0,0 +1,138 @@
+package noderender_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/noderender"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenNodes covers every client, with a custom chain exercising the
+// settings the others leave at their defaults
+var goldenNodes = []struct {
+	name      string
+	chainType models.ChainType
+	config    string
+	labels    map[string]string
+}{
+	{name: "geth-mainnet", chainType: models.ChainTypeEthereum,
+		config: `{"client": "geth", "jwt_secret_path": "/secrets/jwt.hex", "http_api": ["eth", "net", "web3", "txpool"], "resources": {"disk_gb": 2000}}`},
+	{name: "erigon-polygon", chainType: models.ChainTypePolygon,
+		config: `{"client": "erigon", "network": "amoy", "prune_mode": "archive", "resources": {"disk_gb": 4000}}`},
+	{name: "nethermind-sepolia", chainType: models.ChainTypeEthereum,
+		config: `{"client": "nethermind", "network": "sepolia", "pruning_mode": "Full", "jwt_secret_path": "/secrets/jwt.hex", "resources": {"disk_gb": 500}}`},
+	{name: "besu-holesky", chainType: models.ChainTypeEthereum,
+		config: `{"client": "besu", "network": "holesky", "sync_mode": "CHECKPOINT", "max_peers": 25, "resources": {"disk_gb": 500, "memory_gb": 8}}`},
+	{name: "reth-mainnet", chainType: models.ChainTypeEthereum,
+		config: `{"client": "reth", "prune_mode": "full", "log_level": "debug", "resources": {"disk_gb": 1500, "cpu": 2.5}}`},
+	{name: "bor-mainnet", chainType: models.ChainTypePolygon,
+		config: `{"client": "bor", "heimdall_url": "http://heimdall:1317", "gc_mode": "archive", "resources": {"disk_gb": 6000}}`},
+	{name: "nitro-one", chainType: models.ChainTypeArbitrum,
+		config: `{"client": "nitro", "parent_chain_url": "https://eth.example.com", "parent_chain_beacon_url": "https://beacon.example.com", "resources": {"disk_gb": 1000}}`},
+	{name: "geth-custom", chainType: models.ChainTypeCustom,
+		config: `{
+			"client": "geth",
+			"network": "devnet",
+			"chain_id": 1337,
+			"cache_size": 2048,
+			"metrics_port": 6061,
+			"txpool_price_limit": 1000000000,
+			"bootnodes": ["enode://abc@10.0.0.1:30303"],
+			"extra_flags": ["--nodiscover"],
+			"resources": {"cpu": 4, "memory_gb": 16, "disk_gb": 500, "storage_class": "fast-ssd"}
+		}`,
+		labels: map[string]string{"team": "platform", "env": "dev"}},
+}
+
+// goldenFiles names each format's golden file suffix
+var goldenFiles = map[noderender.Format]string{
+	noderender.GethTOML:      ".toml",
+	noderender.CLIFlags:      ".args",
+	noderender.DockerCompose: ".compose.yml",
+	noderender.K8sManifest:   ".k8s.yaml",
+}
+
+// goldenNode builds a test node the way it's read from the database
+func goldenNode(t *testing.T, i int) *models.BlockchainNode {
+	t.Helper()
+
+	n := goldenNodes[i]
+	node := &models.BlockchainNode{
+		ID:        uuid.MustParse("00000000-0000-4000-8000-000000000001"),
+		Name:      n.name,
+		ChainType: n.chainType,
+		Labels:    n.labels,
+	}
+	if err := json.Unmarshal([]byte(n.config), &node.Config); err != nil {
+		t.Fatalf("%s: %v", n.name, err)
+	}
+	return node
+}
+
+func TestRenderGolden(t *testing.T) {
+	for i, n := range goldenNodes {
+		for _, format := range noderender.Formats {
+			path := filepath.Join("testdata", n.name+goldenFiles[format])
+			t.Run(n.name+"/"+string(format), func(t *testing.T) {
+				output, err := noderender.Render(goldenNode(t, i), format)
+				if errors.Is(err, noderender.ErrUnsupported) {
+					if _, statErr := os.Stat(path); statErr == nil {
+						t.Fatalf("%s exists but rendering failed: %v", path, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Render failed: %v", err)
+				}
+
+				if *update {
+					if err := os.WriteFile(path, output.Body, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("%v; run `go test ./internal/noderender -update`", err)
+				}
+				if !bytes.Equal(output.Body, want) {
+					t.Errorf("output differs from %s; run `go test ./internal/noderender -update` if the change is intended\ngot:\n%s", path, output.Body)
+				}
+			})
+		}
+	}
+}
+
+// TestRenderIsDeterministic renders each node repeatedly, so output that
+// depends on map iteration order shows up as a difference
+func TestRenderIsDeterministic(t *testing.T) {
+	for i, n := range goldenNodes {
+		for _, format := range noderender.Formats {
+			first, err := noderender.Render(goldenNode(t, i), format)
+			if errors.Is(err, noderender.ErrUnsupported) {
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s/%s: %v", n.name, format, err)
+			}
+			for run := 0; run < 20; run++ {
+				again, err := noderender.Render(goldenNode(t, i), format)
+				if err != nil {
+					t.Fatalf("%s/%s: %v", n.name, format, err)
+				}
+				if !bytes.Equal(first.Body, again.Body) || first.Filename != again.Filename {
+					t.Fatalf("%s/%s: rendering twice gave different output:\n%s\n---\n%s", n.name, format, first.Body, again.Body)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package noderender
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tomlWriter writes TOML tables in the order they're given
+type tomlWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *tomlWriter) table(name string) {
+	if w.buf.Len() > 0 {
+		w.buf.WriteByte('\n')
+	}
+	fmt.Fprintf(&w.buf, "[%s]\n", name)
+}
+
+func (w *tomlWriter) str(key, value string) {
+	fmt.Fprintf(&w.buf, "%s = %s\n", key, strconv.Quote(value))
+}
+
+func (w *tomlWriter) integer(key string, value int64) {
+	fmt.Fprintf(&w.buf, "%s = %d\n", key, value)
+}
+
+func (w *tomlWriter) boolean(key string, value bool) {
+	fmt.Fprintf(&w.buf, "%s = %t\n", key, value)
+}
+
+func (w *tomlWriter) strings(key string, values []string) {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	fmt.Fprintf(&w.buf, "%s = [%s]\n", key, strings.Join(quoted, ", "))
+}
+
+// gethTOML returns a config file for geth --config, with the tables and
+// keys of geth dumpconfig. Settings geth only takes as flags, such as the
+// log verbosity, are left to cli-flags.
+func (s *spec) gethTOML() ([]byte, error) {
+	c := s.settings
+	id, err := s.chainID()
+	if err != nil {
+		return nil, err
+	}
+
+	var w tomlWriter
+	w.table("Eth")
+	w.integer("NetworkId", id)
+	w.str("SyncMode", c.str("sync_mode"))
+	w.boolean("NoPruning", c.str("gc_mode") == "archive")
+	if c.has("cache_size") {
+		w.integer("DatabaseCache", c.integer("cache_size"))
+	}
+	if c.has("txpool_price_limit") {
+		w.table("Eth.TxPool")
+		w.integer("PriceLimit", c.integer("txpool_price_limit"))
+	}
+
+	w.table("Node")
+	w.str("DataDir", c.str("data_dir"))
+	w.str("HTTPHost", listenAddr)
+	w.integer("HTTPPort", c.integer("http_port"))
+	w.strings("HTTPVirtualHosts", []string{"*"})
+	w.strings("HTTPModules", c.strings("http_api"))
+	w.str("WSHost", listenAddr)
+	w.integer("WSPort", c.integer("ws_port"))
+	w.strings("WSModules", c.strings("http_api"))
+	w.str("AuthAddr", listenAddr)
+	w.integer("AuthPort", c.integer("authrpc_port"))
+	w.strings("AuthVirtualHosts", []string{"*"})
+	if c.has("jwt_secret_path") {
+		w.str("JWTSecret", c.str("jwt_secret_path"))
+	}
+
+	w.table("Node.P2P")
+	w.integer("MaxPeers", c.integer("max_peers"))
+	w.str("ListenAddr", fmt.Sprintf(":%d", c.integer("p2p_port")))
+	if bootnodes := c.strings("bootnodes"); len(bootnodes) > 0 {
+		w.strings("BootstrapNodes", bootnodes)
+	}
+
+	if c.has("metrics_port") {
+		w.table("Metrics")
+		w.boolean("Enabled", true)
+		w.str("HTTP", listenAddr)
+		w.integer("Port", c.integer("metrics_port"))
+	}
+	return w.buf.Bytes(), nil
+}
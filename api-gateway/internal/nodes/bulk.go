@@ -0,0 +1,124 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// MaxBulkNodes is how many nodes one bulk operation may change
+const MaxBulkNodes = 1000
+
+// validateBulk checks that req describes a complete action
+func validateBulk(req *models.BulkNodeRequest) error {
+	switch req.Action {
+	case models.BulkNodeSetStatus:
+		if !req.Status.IsValid() {
+			return invalid("set_status needs a valid status")
+		}
+	case models.BulkNodeMaintenance:
+		req.Status = models.NodeStatusMaintenance
+	case models.BulkNodeLabel:
+		if len(req.Labels) == 0 && len(req.RemoveLabels) == 0 {
+			return invalid("label needs labels or remove_labels")
+		}
+		if err := labels.Validate(req.Labels); err != nil {
+			return invalid(err.Error())
+		}
+		for _, key := range req.RemoveLabels {
+			if err := labels.ValidateKey(key); err != nil {
+				return invalid(err.Error())
+			}
+		}
+	case models.BulkNodeDelete:
+	default:
+		return invalid("action must be set_status, maintenance, label or delete")
+	}
+	return nil
+}
+
+// Bulk applies req's action to every node its target selects in a single
+// transaction. A dry run only reports the nodes that would change.
+func (s *Store) Bulk(ctx context.Context, req models.BulkNodeRequest) (*models.BulkNodeResponse, error) {
+	if err := validateBulk(&req); err != nil {
+		return nil, err
+	}
+	filter, err := s.ResolveTarget(ctx, req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.BulkNodeResponse{Action: req.Action, DryRun: req.DryRun}
+	err = pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var args []interface{}
+		query := `SELECT id FROM nodes WHERE ` + strings.Join(filter.where(&args), " AND ") + ` ORDER BY id`
+		if !req.DryRun {
+			query += ` FOR UPDATE`
+		}
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil {
+			return err
+		}
+		if len(ids) > MaxBulkNodes {
+			return invalid(fmt.Sprintf("target selects %d nodes, more than the %d allowed at once", len(ids), MaxBulkNodes))
+		}
+		response.NodeIDs = ids
+		response.Count = len(ids)
+		if req.DryRun || len(ids) == 0 {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		switch req.Action {
+		case models.BulkNodeSetStatus, models.BulkNodeMaintenance:
+			_, err = tx.Exec(ctx,
+				`UPDATE nodes SET status = $1, updated_at = $2 WHERE id = ANY($3)`, req.Status, now, ids)
+		case models.BulkNodeLabel:
+			set := req.Labels
+			if set == nil {
+				set = map[string]string{}
+			}
+			remove := req.RemoveLabels
+			if remove == nil {
+				remove = []string{}
+			}
+			_, err = tx.Exec(ctx, `
+				UPDATE nodes SET labels = (labels - $1::text[]) || $2::jsonb, updated_at = $3
+				WHERE id = ANY($4)`,
+				remove, set, now, ids,
+			)
+			if err != nil {
+				return err
+			}
+			var over int
+			err = tx.QueryRow(ctx, `
+				SELECT COUNT(*) FROM nodes
+				WHERE id = ANY($1) AND (SELECT COUNT(*) FROM jsonb_object_keys(labels)) > $2`,
+				ids, labels.MaxLabels,
+			).Scan(&over)
+			if err == nil && over > 0 {
+				err = invalid(fmt.Sprintf("%d nodes would have more than %d labels", over, labels.MaxLabels))
+			}
+		case models.BulkNodeDelete:
+			_, err = tx.Exec(ctx, `DELETE FROM nodes WHERE id = ANY($1)`, ids)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if response.NodeIDs == nil {
+		response.NodeIDs = []uuid.UUID{}
+	}
+	return response, nil
+}
@@ -0,0 +1,290 @@
+package nodes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// ErrPlanStale is returned when applying a plan whose nodes changed since
+// it was computed
+var ErrPlanStale = errors.New("nodes changed since the plan was computed")
+
+// fleetNode pairs a manifest entry with the node it describes
+type fleetNode struct {
+	desired *models.CreateNodeRequest
+	current *models.BlockchainNode
+	fields  []models.FleetFieldChange
+}
+
+// fleetPlan is a computed plan with what is needed to apply it
+type fleetPlan struct {
+	models.FleetPlan
+	creates []*models.CreateNodeRequest
+	updates []fleetNode
+	deletes []uuid.UUID
+}
+
+// normalize round-trips a value through JSON so values decoded from YAML,
+// JSON requests and the database compare equal
+func normalize(value interface{}) interface{} {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var out interface{}
+	if err := json.Unmarshal(payload, &out); err != nil {
+		return value
+	}
+	return out
+}
+
+// diffNode lists the fields of current that differ from desired
+func diffNode(current *models.BlockchainNode, desired *models.CreateNodeRequest) []models.FleetFieldChange {
+	var fields []models.FleetFieldChange
+	add := func(field string, from, to interface{}) {
+		if !reflect.DeepEqual(normalize(from), normalize(to)) {
+			fields = append(fields, models.FleetFieldChange{Field: field, From: from, To: to})
+		}
+	}
+	emptyIfNil := func(m map[string]string) map[string]string {
+		if m == nil {
+			return map[string]string{}
+		}
+		return m
+	}
+	configOrNil := func(m map[string]interface{}) interface{} {
+		if len(m) == 0 {
+			return nil
+		}
+		return m
+	}
+
+	add("endpoint_url", current.EndpointURL, desired.EndpointURL)
+	add("region", current.Region, desired.Region)
+	add("provider", current.Provider, desired.Provider)
+	add("config", configOrNil(current.Config), configOrNil(desired.Config))
+	add("labels", emptyIfNil(current.Labels), emptyIfNil(desired.Labels))
+	add("consensus_endpoint_url", current.ConsensusEndpointURL, desired.ConsensusEndpointURL)
+	add("publish_onchain", current.PublishOnchain, desired.PublishOnchain)
+	return fields
+}
+
+// prefixFields returns fields with prefix added to each path
+func prefixFields(prefix string, fields []models.FieldError) []models.FieldError {
+	if fields == nil {
+		return nil
+	}
+	out := make([]models.FieldError, len(fields))
+	for i, field := range fields {
+		out[i] = models.FieldError{Field: prefix + field.Field, Message: field.Message}
+	}
+	return out
+}
+
+// validateManifest checks every desired node and returns the manifest's scope
+func validateManifest(manifest *models.FleetManifest) (labels.Selector, error) {
+	selector, err := labels.Parse(manifest.Selector)
+	if err != nil {
+		return nil, invalid("Invalid selector: " + err.Error())
+	}
+
+	names := make(map[string]bool, len(manifest.Nodes))
+	for i := range manifest.Nodes {
+		node := &manifest.Nodes[i]
+		if err := ValidateCreate(node); err != nil {
+			var problem *ValidationError
+			if errors.As(err, &problem) {
+				return nil, &ValidationError{Message: fmt.Sprintf("nodes[%d]: %s", i, problem.Message), Fields: prefixFields(fmt.Sprintf("nodes[%d].", i), problem.Fields)}
+			}
+			return nil, err
+		}
+		if names[node.Name] {
+			return nil, invalid(fmt.Sprintf("nodes[%d]: name %q is used more than once", i, node.Name))
+		}
+		names[node.Name] = true
+		if !selector.Matches(node.Labels) {
+			return nil, invalid(fmt.Sprintf("nodes[%d]: labels must match the manifest selector %q", i, selector.String()))
+		}
+	}
+	return selector, nil
+}
+
+// plan compares manifest with the gateway-managed nodes in its scope. When
+// lock is set the nodes are locked for the rest of the transaction.
+func plan(ctx context.Context, q querier, manifest models.FleetManifest, prune, lock bool) (*fleetPlan, error) {
+	selector, err := validateManifest(&manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{models.NodeSourceGateway}
+	conditions := append([]string{"source = $1"}, selectorConditions(selector, func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	})...)
+	query := `SELECT ` + Columns + ` FROM nodes WHERE ` + strings.Join(conditions, " AND ") + ` ORDER BY name, id`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	current := make(map[string]*models.BlockchainNode)
+	fingerprint := sha256.New()
+	for rows.Next() {
+		node, err := Scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := current[node.Name]; ok {
+			return nil, invalid(fmt.Sprintf("Several nodes are named %q; rename them before managing them with a manifest", node.Name))
+		}
+		current[node.Name] = node
+		fmt.Fprintf(fingerprint, "%s %s\n", node.ID, node.UpdatedAt.UTC().Format(time.RFC3339Nano))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(fingerprint, "prune=%t\n", prune)
+	fingerprint.Write(manifestJSON)
+
+	p := &fleetPlan{FleetPlan: models.FleetPlan{
+		Creates:     []models.FleetChange{},
+		Updates:     []models.FleetChange{},
+		Deletes:     []models.FleetChange{},
+		Prune:       prune,
+		Fingerprint: hex.EncodeToString(fingerprint.Sum(nil)),
+	}}
+	desired := make(map[string]bool, len(manifest.Nodes))
+	for i := range manifest.Nodes {
+		node := &manifest.Nodes[i]
+		desired[node.Name] = true
+
+		existing, ok := current[node.Name]
+		if !ok {
+			p.creates = append(p.creates, node)
+			p.Creates = append(p.Creates, models.FleetChange{Type: models.FleetCreate, Name: node.Name})
+			continue
+		}
+		if existing.ChainType != node.ChainType {
+			return nil, invalid(fmt.Sprintf("nodes[%d]: chain_type of %q can't change from %s; remove the node first", i, node.Name, existing.ChainType))
+		}
+		fields := diffNode(existing, node)
+		if len(fields) == 0 {
+			p.Unchanged++
+			continue
+		}
+		id := existing.ID
+		p.updates = append(p.updates, fleetNode{desired: node, current: existing, fields: fields})
+		p.Updates = append(p.Updates, models.FleetChange{Type: models.FleetUpdate, Name: node.Name, NodeID: &id, Fields: fields})
+	}
+
+	if prune {
+		names := make([]string, 0, len(current))
+		for name := range current {
+			if !desired[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			id := current[name].ID
+			p.deletes = append(p.deletes, id)
+			p.Deletes = append(p.Deletes, models.FleetChange{Type: models.FleetDelete, Name: name, NodeID: &id})
+		}
+	}
+	return p, nil
+}
+
+// Plan returns the changes applying manifest would make. With prune, nodes
+// in the manifest's scope that it doesn't list are deleted.
+func (s *Store) Plan(ctx context.Context, manifest models.FleetManifest, prune bool) (*models.FleetPlan, error) {
+	p, err := plan(ctx, s.db, manifest, prune, false)
+	if err != nil {
+		return nil, err
+	}
+	return &p.FleetPlan, nil
+}
+
+// Apply makes the nodes match manifest in a single transaction. A non-empty
+// fingerprint must match the one Plan returned or ErrPlanStale is returned.
+func (s *Store) Apply(ctx context.Context, manifest models.FleetManifest, prune bool, fingerprint string) (*models.FleetPlan, error) {
+	var applied *fleetPlan
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		p, err := plan(ctx, tx, manifest, prune, true)
+		if err != nil {
+			return err
+		}
+		if fingerprint != "" && fingerprint != p.Fingerprint {
+			return ErrPlanStale
+		}
+
+		now := time.Now().UTC()
+		for i, req := range p.creates {
+			node, err := insertNode(ctx, tx, *req, now)
+			if err != nil {
+				return err
+			}
+			id := node.ID
+			p.Creates[i].NodeID = &id
+		}
+		for _, update := range p.updates {
+			node, desired := update.current, update.desired
+			consensusStatus := node.ConsensusStatus
+			if desired.ConsensusEndpointURL != node.ConsensusEndpointURL {
+				// The stored state belongs to the old beacon node
+				consensusStatus = nil
+			}
+			nodeLabels := desired.Labels
+			if nodeLabels == nil {
+				nodeLabels = map[string]string{}
+			}
+			_, err := tx.Exec(ctx, `
+				UPDATE nodes
+				SET endpoint_url = $1, region = $2, provider = $3, config = $4, labels = $5,
+					consensus_endpoint_url = $6, consensus_status = $7, publish_onchain = $8, updated_at = $9
+				WHERE id = $10`,
+				desired.EndpointURL, desired.Region, desired.Provider, desired.Config, nodeLabels,
+				desired.ConsensusEndpointURL, consensusStatus, desired.PublishOnchain, now, node.ID,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		if len(p.deletes) > 0 {
+			if _, err := tx.Exec(ctx, `DELETE FROM nodes WHERE id = ANY($1)`, p.deletes); err != nil {
+				return err
+			}
+		}
+
+		p.Applied = true
+		applied = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &applied.FleetPlan, nil
+}
@@ -0,0 +1,366 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+var (
+	// ErrGroupNotFound is returned when a node group does not exist
+	ErrGroupNotFound = errors.New("node group not found")
+	// ErrGroupExists is returned when a node group's name is taken
+	ErrGroupExists = errors.New("node group already exists")
+)
+
+const groupColumns = `id, name, description, selector, created_by, created_at, updated_at`
+
+// querier is the part of pgxpool.Pool and pgx.Tx the group queries use
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func scanGroup(row pgx.Row) (*models.NodeGroup, error) {
+	var group models.NodeGroup
+	err := row.Scan(
+		&group.ID,
+		&group.Name,
+		&group.Description,
+		&group.Selector,
+		&group.CreatedBy,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// validateSelector checks a group selector and returns it in canonical form
+func validateSelector(selector string) (string, error) {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return "", invalid("Invalid selector: " + err.Error())
+	}
+	return parsed.String(), nil
+}
+
+// groupByRef loads the group whose ID or, failing that, name is ref
+func groupByRef(ctx context.Context, q querier, ref string, lock bool) (*models.NodeGroup, error) {
+	query := `SELECT ` + groupColumns + ` FROM node_groups WHERE lower(name) = lower($1)`
+	var arg interface{} = ref
+	if id, err := uuid.Parse(ref); err == nil {
+		query = `SELECT ` + groupColumns + ` FROM node_groups WHERE id = $1`
+		arg = id
+	}
+	if lock {
+		query += ` FOR UPDATE`
+	}
+
+	group, err := scanGroup(q.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGroupNotFound
+	}
+	return group, err
+}
+
+// loadMembers fills in the group's explicit members and its node count
+func (s *Store) loadMembers(ctx context.Context, q querier, group *models.NodeGroup) error {
+	rows, err := q.Query(ctx,
+		`SELECT node_id FROM node_group_members WHERE group_id = $1 ORDER BY added_at, node_id`, group.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	group.MemberIDs = []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		group.MemberIDs = append(group.MemberIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var args []interface{}
+	filter := Filter{Group: group}
+	return q.QueryRow(ctx,
+		`SELECT COUNT(*) FROM nodes WHERE `+strings.Join(filter.where(&args), " AND "), args...,
+	).Scan(&group.NodeCount)
+}
+
+// ListGroups returns every node group by name
+func (s *Store) ListGroups(ctx context.Context) ([]models.NodeGroup, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+groupColumns+` FROM node_groups ORDER BY lower(name)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.NodeGroup{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range groups {
+		if err := s.loadMembers(ctx, s.db, &groups[i]); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// GetGroup returns the node group whose ID or name is ref
+func (s *Store) GetGroup(ctx context.Context, ref string) (*models.NodeGroup, error) {
+	group, err := groupByRef(ctx, s.db, ref, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadMembers(ctx, s.db, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// addMembers makes nodeIDs explicit members of group, rejecting unknown nodes
+func addMembers(ctx context.Context, tx pgx.Tx, groupID uuid.UUID, nodeIDs []uuid.UUID, now time.Time) error {
+	var known int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM nodes WHERE id = ANY($1)`, nodeIDs).Scan(&known); err != nil {
+		return err
+	}
+	if known != len(uniqueIDs(nodeIDs)) {
+		return invalid("Unknown node in member_ids")
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO node_group_members (group_id, node_id, added_at)
+		SELECT $1, id, $3 FROM nodes WHERE id = ANY($2)
+		ON CONFLICT DO NOTHING`,
+		groupID, nodeIDs, now,
+	)
+	return err
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// groupError maps a unique violation on the group name to ErrGroupExists
+func groupError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrGroupExists
+	}
+	return err
+}
+
+// CreateGroup creates a node group owned by createdBy
+func (s *Store) CreateGroup(ctx context.Context, req models.CreateNodeGroupRequest, createdBy uuid.UUID) (*models.NodeGroup, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, invalid("name is required")
+	}
+	if _, err := uuid.Parse(name); err == nil {
+		return nil, invalid("name can't be a UUID")
+	}
+	selector, err := validateSelector(req.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	group := &models.NodeGroup{
+		ID:          uuid.New(),
+		Name:        name,
+		Description: req.Description,
+		Selector:    selector,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if createdBy != uuid.Nil {
+		group.CreatedBy = &createdBy
+	}
+
+	err = pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO node_groups (`+groupColumns+`)
+			VALUES ($1, $2, $3, $4, $5, $6, $6)`,
+			group.ID, group.Name, group.Description, group.Selector, group.CreatedBy, now,
+		)
+		if err != nil {
+			return groupError(err)
+		}
+		if len(req.MemberIDs) > 0 {
+			if err := addMembers(ctx, tx, group.ID, req.MemberIDs, now); err != nil {
+				return err
+			}
+		}
+		return s.loadMembers(ctx, tx, group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// UpdateGroup applies the fields set in req to the group whose ID or name is ref
+func (s *Store) UpdateGroup(ctx context.Context, ref string, req models.UpdateNodeGroupRequest) (*models.NodeGroup, error) {
+	var group *models.NodeGroup
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var err error
+		if group, err = groupByRef(ctx, tx, ref, true); err != nil {
+			return err
+		}
+
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				return invalid("name can't be empty")
+			}
+			if _, err := uuid.Parse(name); err == nil {
+				return invalid("name can't be a UUID")
+			}
+			group.Name = name
+		}
+		if req.Description != nil {
+			group.Description = *req.Description
+		}
+		if req.Selector != nil {
+			if group.Selector, err = validateSelector(*req.Selector); err != nil {
+				return err
+			}
+		}
+		group.UpdatedAt = time.Now().UTC()
+
+		_, err = tx.Exec(ctx, `
+			UPDATE node_groups SET name = $1, description = $2, selector = $3, updated_at = $4
+			WHERE id = $5`,
+			group.Name, group.Description, group.Selector, group.UpdatedAt, group.ID,
+		)
+		if err != nil {
+			return groupError(err)
+		}
+		return s.loadMembers(ctx, tx, group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// DeleteGroup removes the group whose ID or name is ref; its nodes are kept
+func (s *Store) DeleteGroup(ctx context.Context, ref string) (uuid.UUID, error) {
+	group, err := groupByRef(ctx, s.db, ref, false)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	tag, err := s.db.Exec(ctx, `DELETE FROM node_groups WHERE id = $1`, group.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return uuid.Nil, ErrGroupNotFound
+	}
+	return group.ID, nil
+}
+
+// AddGroupMembers makes nodeIDs explicit members of the group whose ID or
+// name is ref
+func (s *Store) AddGroupMembers(ctx context.Context, ref string, nodeIDs []uuid.UUID) (*models.NodeGroup, error) {
+	return s.changeMembers(ctx, ref, func(tx pgx.Tx, group *models.NodeGroup, now time.Time) error {
+		return addMembers(ctx, tx, group.ID, nodeIDs, now)
+	})
+}
+
+// RemoveGroupMembers removes nodeIDs from the explicit members of the group
+// whose ID or name is ref. Nodes matching the group's selector stay in it.
+func (s *Store) RemoveGroupMembers(ctx context.Context, ref string, nodeIDs []uuid.UUID) (*models.NodeGroup, error) {
+	return s.changeMembers(ctx, ref, func(tx pgx.Tx, group *models.NodeGroup, now time.Time) error {
+		_, err := tx.Exec(ctx,
+			`DELETE FROM node_group_members WHERE group_id = $1 AND node_id = ANY($2)`, group.ID, nodeIDs)
+		return err
+	})
+}
+
+func (s *Store) changeMembers(ctx context.Context, ref string, change func(pgx.Tx, *models.NodeGroup, time.Time) error) (*models.NodeGroup, error) {
+	var group *models.NodeGroup
+	err := pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var err error
+		if group, err = groupByRef(ctx, tx, ref, true); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if err := change(tx, group, now); err != nil {
+			return err
+		}
+		group.UpdatedAt = now
+		if _, err := tx.Exec(ctx, `UPDATE node_groups SET updated_at = $1 WHERE id = $2`, now, group.ID); err != nil {
+			return err
+		}
+		return s.loadMembers(ctx, tx, group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// ResolveTarget returns the filter selecting a bulk operation's nodes
+func (s *Store) ResolveTarget(ctx context.Context, target models.NodeTarget) (Filter, error) {
+	set := 0
+	for _, present := range []bool{target.Group != "", strings.TrimSpace(target.Selector) != "", len(target.NodeIDs) > 0} {
+		if present {
+			set++
+		}
+	}
+	if set != 1 {
+		return Filter{}, invalid("target needs exactly one of group, selector or node_ids")
+	}
+
+	switch {
+	case target.Group != "":
+		group, err := groupByRef(ctx, s.db, target.Group, false)
+		if err != nil {
+			return Filter{}, err
+		}
+		return Filter{Group: group}, nil
+	case len(target.NodeIDs) > 0:
+		return Filter{IDs: uniqueIDs(target.NodeIDs)}, nil
+	}
+
+	selector, err := labels.Parse(target.Selector)
+	if err != nil {
+		return Filter{}, invalid(fmt.Sprintf("Invalid selector: %v", err))
+	}
+	return Filter{Selector: selector}, nil
+}
@@ -0,0 +1,314 @@
+// Package nodes stores the blockchain nodes managed through the gateway. It
+// is shared by the REST and gRPC APIs and the node event watcher.
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeconfig"
+)
+
+// ErrNotFound is returned when a node does not exist
+var ErrNotFound = errors.New("node not found")
+
+// ValidationError reports a request that can't be applied as given. Fields
+// details the problems with individual fields, when known.
+type ValidationError struct {
+	Message string
+	Fields  []models.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func invalid(message string) error {
+	return &ValidationError{Message: message}
+}
+
+// Columns is the column list matching Scan
+const Columns = `id, name, chain_type, endpoint_url, status, version, sync_status,
+	created_at, updated_at, region, provider, performance_metrics, config,
+	consensus_endpoint_url, consensus_status, source, onchain_id, onchain_owner, publish_onchain, labels`
+
+// fields returns the scan destinations for Columns
+func fields(node *models.BlockchainNode) []interface{} {
+	return []interface{}{
+		&node.ID,
+		&node.Name,
+		&node.ChainType,
+		&node.EndpointURL,
+		&node.Status,
+		&node.Version,
+		&node.SyncStatus,
+		&node.CreatedAt,
+		&node.UpdatedAt,
+		&node.Region,
+		&node.Provider,
+		&node.PerformanceMetrics,
+		&node.Config,
+		&node.ConsensusEndpointURL,
+		&node.ConsensusStatus,
+		&node.Source,
+		&node.OnchainID,
+		&node.OnchainOwner,
+		&node.PublishOnchain,
+		&node.Labels,
+	}
+}
+
+// Scan scans a row selected with Columns
+func Scan(row pgx.Row) (*models.BlockchainNode, error) {
+	var node models.BlockchainNode
+	if err := row.Scan(fields(&node)...); err != nil {
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+// ValidateConsensusEndpoint checks that a beacon endpoint is only paired with
+// an Ethereum node and is a valid absolute URL
+func ValidateConsensusEndpoint(chainType models.ChainType, endpoint string) error {
+	if endpoint == "" {
+		return nil
+	}
+	if chainType != models.ChainTypeEthereum {
+		return invalid("consensus_endpoint_url is only supported for ethereum nodes")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return invalid("consensus_endpoint_url must be an absolute URL")
+	}
+	return nil
+}
+
+// Store reads and writes nodes
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a new Store
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// All returns every node with its consensus state applied
+func (s *Store) All(ctx context.Context) ([]models.BlockchainNode, error) {
+	rows, err := s.db.Query(ctx, `SELECT `+Columns+` FROM nodes ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.BlockchainNode
+	for rows.Next() {
+		node, err := Scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		node.ApplyConsensusStatus()
+		items = append(items, *node)
+	}
+	return items, rows.Err()
+}
+
+// Get returns the node with id
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (*models.BlockchainNode, error) {
+	node, err := Scan(s.db.QueryRow(ctx, `SELECT `+Columns+` FROM nodes WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	node.ApplyConsensusStatus()
+	return node, nil
+}
+
+// normalizeConfig checks config against the profile of its client on
+// chainType and returns it with deprecated keys migrated and defaults set
+func normalizeConfig(chainType models.ChainType, config map[string]interface{}) (map[string]interface{}, error) {
+	result, err := nodeconfig.Normalize(chainType, config)
+	var configErr *nodeconfig.Error
+	if errors.As(err, &configErr) {
+		return nil, &ValidationError{Message: configErr.Error(), Fields: configErr.Fields}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.Config, nil
+}
+
+// ValidateCreate checks the fields of a new node and normalizes its config
+func ValidateCreate(req *models.CreateNodeRequest) error {
+	switch {
+	case req.Name == "":
+		return invalid("name is required")
+	case req.ChainType == "":
+		return invalid("chain_type is required")
+	case !req.ChainType.IsValid():
+		return invalid(fmt.Sprintf("Unknown chain_type %q", req.ChainType))
+	case req.EndpointURL == "":
+		return invalid("endpoint_url is required")
+	case req.Region == "":
+		return invalid("region is required")
+	case req.Provider == "":
+		return invalid("provider is required")
+	}
+	if err := ValidateConsensusEndpoint(req.ChainType, req.ConsensusEndpointURL); err != nil {
+		return err
+	}
+	if err := labels.Validate(req.Labels); err != nil {
+		return invalid(err.Error())
+	}
+	config, err := normalizeConfig(req.ChainType, req.Config)
+	if err != nil {
+		return err
+	}
+	req.Config = config
+	return nil
+}
+
+// Create registers a new node, which starts out in the starting state
+func (s *Store) Create(ctx context.Context, req models.CreateNodeRequest) (*models.BlockchainNode, error) {
+	if err := ValidateCreate(&req); err != nil {
+		return nil, err
+	}
+	return insertNode(ctx, s.db, req, time.Now().UTC())
+}
+
+// Import creates nodes in a single transaction while fn reads them. Each
+// call to create validates and inserts one node; nothing is committed unless
+// fn returns nil.
+func (s *Store) Import(ctx context.Context, fn func(create func(models.CreateNodeRequest) (*models.BlockchainNode, error)) error) error {
+	return pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		now := time.Now().UTC()
+		return fn(func(req models.CreateNodeRequest) (*models.BlockchainNode, error) {
+			if err := ValidateCreate(&req); err != nil {
+				return nil, err
+			}
+			return insertNode(ctx, tx, req, now)
+		})
+	})
+}
+
+// insertNode stores a new gateway-managed node built from a validated request
+func insertNode(ctx context.Context, q querier, req models.CreateNodeRequest, now time.Time) (*models.BlockchainNode, error) {
+	if req.Labels == nil {
+		req.Labels = map[string]string{}
+	}
+	node := &models.BlockchainNode{
+		ID:                   uuid.New(),
+		Name:                 req.Name,
+		ChainType:            req.ChainType,
+		EndpointURL:          req.EndpointURL,
+		Status:               models.NodeStatusStarting,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+		Region:               req.Region,
+		Provider:             req.Provider,
+		Config:               req.Config,
+		Labels:               req.Labels,
+		ConsensusEndpointURL: req.ConsensusEndpointURL,
+		Source:               models.NodeSourceGateway,
+		PublishOnchain:       req.PublishOnchain,
+	}
+
+	_, err := q.Exec(ctx, `
+		INSERT INTO nodes (id, name, chain_type, endpoint_url, status, version, sync_status,
+			created_at, updated_at, region, provider, config, consensus_endpoint_url, publish_onchain, labels)
+		VALUES ($1, $2, $3, $4, $5, '', $6, $7, $7, $8, $9, $10, $11, $12, $13)`,
+		node.ID, node.Name, node.ChainType, node.EndpointURL, node.Status, node.SyncStatus,
+		now, node.Region, node.Provider, node.Config, node.ConsensusEndpointURL, node.PublishOnchain, node.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// Update applies the fields set in req to the node with id and returns it
+func (s *Store) Update(ctx context.Context, id uuid.UUID, req models.UpdateNodeRequest) (*models.BlockchainNode, error) {
+	node, err := Scan(s.db.QueryRow(ctx, `SELECT `+Columns+` FROM nodes WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		node.Name = *req.Name
+	}
+	if req.EndpointURL != nil {
+		node.EndpointURL = *req.EndpointURL
+	}
+	if req.Status != nil {
+		node.Status = *req.Status
+	}
+	if req.Config != nil {
+		if node.Config, err = normalizeConfig(node.ChainType, req.Config); err != nil {
+			return nil, err
+		}
+	}
+	if req.Labels != nil {
+		if err := labels.Validate(req.Labels); err != nil {
+			return nil, invalid(err.Error())
+		}
+		node.Labels = req.Labels
+	}
+	if req.ConsensusEndpointURL != nil {
+		if err := ValidateConsensusEndpoint(node.ChainType, *req.ConsensusEndpointURL); err != nil {
+			return nil, err
+		}
+		if *req.ConsensusEndpointURL != node.ConsensusEndpointURL {
+			// The stored state belongs to the old beacon node
+			node.ConsensusStatus = nil
+		}
+		node.ConsensusEndpointURL = *req.ConsensusEndpointURL
+	}
+	if req.PublishOnchain != nil {
+		if *req.PublishOnchain && node.Source != models.NodeSourceGateway {
+			return nil, invalid("Only gateway-managed nodes can be published on-chain")
+		}
+		node.PublishOnchain = *req.PublishOnchain
+	}
+	node.UpdatedAt = time.Now().UTC()
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE nodes
+		SET name = $1, endpoint_url = $2, status = $3, config = $4,
+			consensus_endpoint_url = $5, consensus_status = $6, publish_onchain = $7, updated_at = $8, labels = $9
+		WHERE id = $10`,
+		node.Name, node.EndpointURL, node.Status, node.Config,
+		node.ConsensusEndpointURL, node.ConsensusStatus, node.PublishOnchain, node.UpdatedAt, node.Labels, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	node.ApplyConsensusStatus()
+	return node, nil
+}
+
+// Delete removes the node with id
+func (s *Store) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM nodes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
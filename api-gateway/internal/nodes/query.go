@@ -0,0 +1,470 @@
+package nodes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/twist/api-gateway/internal/labels"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// likeEscaper escapes the LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Filter selects nodes. A node must match one of the values of each list
+// that is set, contain Name in its name, match Selector and be in Group.
+// IDs limits the filter to particular nodes.
+type Filter struct {
+	IDs        []uuid.UUID
+	ChainTypes []models.ChainType
+	Statuses   []models.NodeStatus
+	Regions    []string
+	Providers  []models.CloudProvider
+	Versions   []string
+	Name       string
+	Selector   labels.Selector
+
+	// Group limits the nodes to a node group's members and selector matches
+	Group *models.NodeGroup
+}
+
+// Validate checks that f only uses known chain types, statuses and providers
+func (f *Filter) Validate() error {
+	for _, chainType := range f.ChainTypes {
+		if !chainType.IsValid() {
+			return invalid(fmt.Sprintf("Invalid chain_type %q", chainType))
+		}
+	}
+	for _, status := range f.Statuses {
+		if !status.IsValid() {
+			return invalid(fmt.Sprintf("Invalid status %q", status))
+		}
+	}
+	for _, provider := range f.Providers {
+		if !provider.IsValid() {
+			return invalid(fmt.Sprintf("Invalid provider %q", provider))
+		}
+	}
+	return nil
+}
+
+// where returns the SQL conditions for f, appending their arguments to args
+func (f *Filter) where(args *[]interface{}) []string {
+	arg := func(value interface{}) string {
+		*args = append(*args, value)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+	anyOf := func(column string, values []string) []string {
+		if len(values) == 0 {
+			return nil
+		}
+		return []string{column + " = ANY(" + arg(values) + ")"}
+	}
+
+	var conditions []string
+	if len(f.IDs) > 0 {
+		conditions = append(conditions, "id = ANY("+arg(f.IDs)+")")
+	}
+	conditions = append(conditions, anyOf("chain_type", stringsOf(f.ChainTypes))...)
+	conditions = append(conditions, anyOf("effective_status", stringsOf(f.Statuses))...)
+	conditions = append(conditions, anyOf("region", f.Regions)...)
+	conditions = append(conditions, anyOf("provider", stringsOf(f.Providers))...)
+	conditions = append(conditions, anyOf("version", f.Versions)...)
+	if name := strings.TrimSpace(f.Name); name != "" {
+		conditions = append(conditions, "lower(name) LIKE "+arg("%"+strings.ToLower(likeEscaper.Replace(name))+"%"))
+	}
+
+	conditions = append(conditions, selectorConditions(f.Selector, arg)...)
+
+	if f.Group != nil {
+		membership := "id IN (SELECT node_id FROM node_group_members WHERE group_id = " + arg(f.Group.ID) + ")"
+		// The stored selector was validated when the group was saved
+		selector, _ := labels.Parse(f.Group.Selector)
+		if len(selector) > 0 {
+			membership = "(" + membership + " OR (" + strings.Join(selectorConditions(selector, arg), " AND ") + "))"
+		}
+		conditions = append(conditions, membership)
+	}
+	return conditions
+}
+
+// selectorConditions returns the SQL conditions matching selector. Containment
+// and key checks are served by the GIN index on labels.
+func selectorConditions(selector labels.Selector, arg func(interface{}) string) []string {
+	contains := func(key string, values []string) string {
+		parts := make([]string, len(values))
+		for i, value := range values {
+			parts[i] = "labels @> " + arg(map[string]string{key: value}) + "::jsonb"
+		}
+		return "(" + strings.Join(parts, " OR ") + ")"
+	}
+
+	var conditions []string
+	for _, r := range selector {
+		switch r.Operator {
+		case labels.Equals, labels.In:
+			conditions = append(conditions, contains(r.Key, r.Values))
+		case labels.NotEquals, labels.NotIn:
+			conditions = append(conditions, "NOT "+contains(r.Key, r.Values))
+		case labels.Exists:
+			conditions = append(conditions, "labels ? "+arg(r.Key))
+		case labels.DoesNotExist:
+			conditions = append(conditions, "NOT labels ? "+arg(r.Key))
+		}
+	}
+	return conditions
+}
+
+func stringsOf[T ~string](values []T) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = string(value)
+	}
+	return out
+}
+
+// keyKind is the Go type a sort key is scanned into
+type keyKind int
+
+const (
+	textKey keyKind = iota
+	timeKey
+	intKey
+)
+
+type sortKey struct {
+	column string
+	kind   keyKind
+}
+
+// sortKeys are the fields nodes can be sorted by. Status sorts by the status
+// reported to clients and sync_lag by the blocks behind the chain head.
+var sortKeys = map[string]sortKey{
+	"name":       {"name", textKey},
+	"chain_type": {"chain_type", textKey},
+	"status":     {"effective_status", textKey},
+	"region":     {"region", textKey},
+	"provider":   {"provider", textKey},
+	"version":    {"version", textKey},
+	"created_at": {"created_at", timeKey},
+	"updated_at": {"updated_at", timeKey},
+	"sync_lag":   {"sync_lag", intKey},
+}
+
+// maxSortFields limits how many fields a query can sort by
+const maxSortFields = 4
+
+// SortField orders nodes by one field
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultSort lists the newest nodes first
+var DefaultSort = []SortField{{Field: "created_at", Desc: true}}
+
+// ParseSort reads comma-separated fields, each prefixed with - to sort it in
+// descending order, such as -sync_lag,name
+func ParseSort(value string) ([]SortField, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := sortKeys[field.Field]; !ok {
+			return nil, invalid(fmt.Sprintf("Cannot sort by %q", field.Field))
+		}
+		if seen[field.Field] {
+			return nil, invalid(fmt.Sprintf("Duplicate sort field %q", field.Field))
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+	if len(fields) > maxSortFields {
+		return nil, invalid(fmt.Sprintf("At most %d sort fields are allowed", maxSortFields))
+	}
+	return fields, nil
+}
+
+func formatSort(fields []SortField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field.Field
+		if field.Desc {
+			parts[i] = "-" + field.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// cursor is the position after the last node of a page: the values of its
+// sort keys and its id, which breaks ties
+type cursor struct {
+	Sort   string            `json:"s"`
+	Values []json.RawMessage `json:"v"`
+	ID     uuid.UUID         `json:"id"`
+}
+
+func encodeCursor(c cursor) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+func decodeCursor(value string) (*cursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid("Invalid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, invalid("Invalid cursor")
+	}
+	return &c, nil
+}
+
+// keyValues decodes the cursor's sort key values into query arguments
+func (c *cursor) keyValues(fields []SortField) ([]interface{}, error) {
+	if len(c.Values) != len(fields) {
+		return nil, invalid("Invalid cursor")
+	}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		var err error
+		switch sortKeys[field.Field].kind {
+		case textKey:
+			var v string
+			err = json.Unmarshal(c.Values[i], &v)
+			values[i] = v
+		case timeKey:
+			var v time.Time
+			err = json.Unmarshal(c.Values[i], &v)
+			values[i] = v
+		case intKey:
+			var v int64
+			err = json.Unmarshal(c.Values[i], &v)
+			values[i] = v
+		}
+		if err != nil {
+			return nil, invalid("Invalid cursor")
+		}
+	}
+	return values, nil
+}
+
+// keyDest returns a scan destination for a sort key
+func keyDest(kind keyKind) interface{} {
+	switch kind {
+	case timeKey:
+		return new(time.Time)
+	case intKey:
+		return new(int64)
+	}
+	return new(string)
+}
+
+// Query selects a page of nodes
+type Query struct {
+	Filter
+	// Sort defaults to the cursor's sort, then to DefaultSort
+	Sort     []SortField
+	PageSize uint64
+	// Page pages by offset, starting at 1, when there is no Cursor
+	Page uint64
+	// Cursor continues after the last node of a previous page. It stays
+	// stable while nodes are added or removed.
+	Cursor string
+}
+
+// List returns the page of nodes selected by q. Statuses include the paired
+// consensus client's state.
+func (s *Store) List(ctx context.Context, q Query) (*models.ListNodesResponse, error) {
+	if err := q.Filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var after *cursor
+	if q.Cursor != "" {
+		if q.Page > 1 {
+			return nil, invalid("page can't be combined with cursor")
+		}
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if q.Sort == nil {
+			if q.Sort, err = ParseSort(c.Sort); err != nil {
+				return nil, invalid("Invalid cursor")
+			}
+		}
+		if formatSort(q.Sort) != c.Sort {
+			return nil, invalid("cursor was issued for a different sort")
+		}
+		after = c
+	}
+	if len(q.Sort) == 0 {
+		q.Sort = DefaultSort
+	}
+
+	var args []interface{}
+	conditions := q.Filter.where(&args)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total uint64
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM nodes`+where, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	// The id breaks ties in the direction of the last sort key, so a sort in
+	// a single direction can seek with one row comparison
+	columns := make([]string, 0, len(q.Sort)+1)
+	order := make([]string, 0, len(q.Sort)+1)
+	sameDirection := true
+	for _, field := range q.Sort {
+		column := sortKeys[field.Field].column
+		columns = append(columns, column)
+		order = append(order, column+direction(field.Desc))
+		sameDirection = sameDirection && field.Desc == q.Sort[0].Desc
+	}
+	idDesc := q.Sort[len(q.Sort)-1].Desc
+	order = append(order, "id"+direction(idDesc))
+
+	if after != nil {
+		values, err := after.keyValues(q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, after.ID)
+		keys := append(append([]string{}, columns...), "id")
+		descs := make([]bool, 0, len(keys))
+		for _, field := range q.Sort {
+			descs = append(descs, field.Desc)
+		}
+		descs = append(descs, idDesc)
+
+		placeholders := make([]string, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		seek := seekCondition(keys, placeholders, descs, sameDirection)
+		if where == "" {
+			where = " WHERE " + seek
+		} else {
+			where += " AND " + seek
+		}
+	}
+
+	offset := uint64(0)
+	if after == nil && q.Page > 1 {
+		offset = (q.Page - 1) * q.PageSize
+	}
+	args = append(args, q.PageSize+1, offset)
+	rows, err := s.db.Query(ctx, fmt.Sprintf(
+		`SELECT `+Columns+`, %s FROM nodes%s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		strings.Join(columns, ", "), where, strings.Join(order, ", "), len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.BlockchainNode, 0, q.PageSize)
+	var lastKeys []interface{}
+	more := false
+	for rows.Next() {
+		if uint64(len(items)) == q.PageSize {
+			// The extra row only shows there is another page
+			more = true
+			break
+		}
+		var node models.BlockchainNode
+		keys := make([]interface{}, len(q.Sort))
+		for i, field := range q.Sort {
+			keys[i] = keyDest(sortKeys[field.Field].kind)
+		}
+		if err := rows.Scan(append(fields(&node), keys...)...); err != nil {
+			return nil, err
+		}
+		node.ApplyConsensusStatus()
+		items = append(items, node)
+		lastKeys = keys
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	response := &models.ListNodesResponse{
+		Items:    items,
+		Total:    total,
+		PageSize: q.PageSize,
+	}
+	if after == nil {
+		response.Page = q.Page
+		if response.Page == 0 {
+			response.Page = 1
+		}
+		response.TotalPages = uint64(math.Ceil(float64(total) / float64(q.PageSize)))
+	}
+	if more {
+		next := cursor{Sort: formatSort(q.Sort), ID: items[len(items)-1].ID}
+		for _, key := range lastKeys {
+			value, err := json.Marshal(key)
+			if err != nil {
+				return nil, err
+			}
+			next.Values = append(next.Values, value)
+		}
+		if response.NextCursor, err = encodeCursor(next); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+func direction(desc bool) string {
+	if desc {
+		return " DESC"
+	}
+	return " ASC"
+}
+
+// seekCondition selects the rows after the given key values in sort order
+func seekCondition(keys, placeholders []string, descs []bool, sameDirection bool) string {
+	op := func(desc bool) string {
+		if desc {
+			return "<"
+		}
+		return ">"
+	}
+	if sameDirection {
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(keys, ", "), op(descs[0]), strings.Join(placeholders, ", "))
+	}
+
+	// Mixed directions expand to (a > $1) OR (a = $1 AND b < $2) OR ...
+	alternatives := make([]string, len(keys))
+	for i := range keys {
+		terms := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			terms = append(terms, keys[j]+" = "+placeholders[j])
+		}
+		terms = append(terms, keys[i]+" "+op(descs[i])+" "+placeholders[i])
+		alternatives[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
@@ -0,0 +1,79 @@
+package registry
+
+import "github.com/twist/api-gateway/internal/models"
+
+// The registry contract's enums are declared in the same order as the
+// corresponding models constants, so the on-chain value is the slice index.
+var (
+	chainTypes = []models.ChainType{
+		models.ChainTypeEthereum,
+		models.ChainTypePolygon,
+		models.ChainTypeArbitrum,
+		models.ChainTypeBSC,
+		models.ChainTypeCustom,
+	}
+
+	nodeStatuses = []models.NodeStatus{
+		models.NodeStatusRunning,
+		models.NodeStatusStopped,
+		models.NodeStatusStarting,
+		models.NodeStatusSyncing,
+		models.NodeStatusError,
+		models.NodeStatusMaintenance,
+	}
+
+	cloudProviders = []models.CloudProvider{
+		models.CloudProviderAWS,
+		models.CloudProviderGCP,
+		models.CloudProviderAzure,
+		models.CloudProviderDigitalOcean,
+		models.CloudProviderOnPremise,
+	}
+)
+
+// ChainTypeFromContract maps the contract's ChainType enum to models.ChainType
+func ChainTypeFromContract(v uint8) (models.ChainType, bool) {
+	return lookup(chainTypes, v)
+}
+
+// NodeStatusFromContract maps the contract's NodeStatus enum to models.NodeStatus
+func NodeStatusFromContract(v uint8) (models.NodeStatus, bool) {
+	return lookup(nodeStatuses, v)
+}
+
+// CloudProviderFromContract maps the contract's CloudProvider enum to models.CloudProvider
+func CloudProviderFromContract(v uint8) (models.CloudProvider, bool) {
+	return lookup(cloudProviders, v)
+}
+
+// ChainTypeToContract maps models.ChainType to the contract's ChainType enum
+func ChainTypeToContract(c models.ChainType) (uint8, bool) {
+	return index(chainTypes, c)
+}
+
+// NodeStatusToContract maps models.NodeStatus to the contract's NodeStatus enum
+func NodeStatusToContract(s models.NodeStatus) (uint8, bool) {
+	return index(nodeStatuses, s)
+}
+
+// CloudProviderToContract maps models.CloudProvider to the contract's CloudProvider enum
+func CloudProviderToContract(p models.CloudProvider) (uint8, bool) {
+	return index(cloudProviders, p)
+}
+
+func lookup[T any](values []T, v uint8) (T, bool) {
+	var zero T
+	if int(v) >= len(values) {
+		return zero, false
+	}
+	return values[v], true
+}
+
+func index[T comparable](values []T, v T) (uint8, bool) {
+	for i, candidate := range values {
+		if candidate == v {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,402 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/contracts"
+	"go.uber.org/zap"
+)
+
+const (
+	// logBatchSize is the widest block range requested from eth_getLogs at once
+	logBatchSize = 2000
+
+	// maxReorgDepth is how far the indexer rewinds when its checkpoint block
+	// is no longer canonical. Replaying is safe because every event handler is
+	// idempotent.
+	maxReorgDepth = 128
+)
+
+// Backend is the subset of an Ethereum client used by the Indexer. It is
+// satisfied by *ethclient.Client and by go-ethereum's simulated backend.
+type Backend interface {
+	bind.ContractCaller
+	ethereum.LogFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Indexer mirrors BlockchainNodeRegistry events into the nodes table. Only
+// blocks with enough confirmations are processed, and progress is stored as a
+// checkpoint so a restart resumes where it stopped.
+//
+// Anyone can register a node in the contract, so only nodes owned by a
+// trusted address are mirrored: mirrored nodes receive broadcast
+// transactions and count in the gas oracle and the tracker's majority.
+type Indexer struct {
+	db            *pgxpool.Pool
+	backend       Backend
+	logger        *zap.Logger
+	address       common.Address
+	trusted       map[common.Address]bool
+	abi           abi.ABI
+	startBlock    uint64
+	confirmations uint64
+	interval      time.Duration
+}
+
+// NewIndexer creates a new Indexer for the registry deployed at address,
+// mirroring the nodes registered by trustedOwners
+func NewIndexer(db *pgxpool.Pool, backend Backend, logger *zap.Logger, address common.Address, trustedOwners []common.Address, startBlock, confirmations uint64, interval time.Duration) (*Indexer, error) {
+	parsed, err := contracts.BlockchainNodeRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registry ABI: %w", err)
+	}
+
+	trusted := make(map[common.Address]bool, len(trustedOwners))
+	for _, owner := range trustedOwners {
+		trusted[owner] = true
+	}
+
+	return &Indexer{
+		db:            db,
+		backend:       backend,
+		logger:        logger,
+		address:       address,
+		trusted:       trusted,
+		abi:           *parsed,
+		startBlock:    startBlock,
+		confirmations: confirmations,
+		interval:      interval,
+	}, nil
+}
+
+// Run indexes new blocks until ctx is cancelled
+func (i *Indexer) Run(ctx context.Context) {
+	ticker := time.NewTicker(i.interval)
+	defer ticker.Stop()
+
+	if err := i.PruneUntrusted(ctx); err != nil && ctx.Err() == nil {
+		i.logger.Error("Failed to remove registry nodes of untrusted owners", zap.Error(err))
+	}
+
+	for {
+		if err := i.Sync(ctx); err != nil && ctx.Err() == nil {
+			i.logger.Error("Registry indexer sync failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PruneUntrusted removes mirrored nodes whose owners are no longer trusted
+func (i *Indexer) PruneUntrusted(ctx context.Context) error {
+	owners := make([]string, 0, len(i.trusted))
+	for owner := range i.trusted {
+		owners = append(owners, strings.ToLower(owner.Hex()))
+	}
+	tag, err := i.db.Exec(ctx,
+		`DELETE FROM nodes WHERE source = $1 AND NOT (onchain_owner = ANY($2))`,
+		models.NodeSourceOnchain, owners,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		i.logger.Info("Removed registry nodes of untrusted owners", zap.Int64("nodes", tag.RowsAffected()))
+	}
+	return nil
+}
+
+// checkpointName keys the indexer's cursor by contract address so pointing
+// the gateway at a new deployment starts from scratch
+func (i *Indexer) checkpointName() string {
+	return "registry:" + strings.ToLower(i.address.Hex())
+}
+
+// Sync processes all confirmed blocks since the last checkpoint
+func (i *Indexer) Sync(ctx context.Context) error {
+	head, err := i.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch head: %w", err)
+	}
+	if head.Number.Uint64() < i.confirmations {
+		return nil
+	}
+	safe := head.Number.Uint64() - i.confirmations
+
+	from, err := i.resumeBlock(ctx)
+	if err != nil {
+		return err
+	}
+
+	for from <= safe {
+		to := from + logBatchSize - 1
+		if to > safe {
+			to = safe
+		}
+
+		logs, err := i.backend.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{i.address},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to filter logs %d-%d: %w", from, to, err)
+		}
+
+		toHeader, err := i.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(to))
+		if err != nil {
+			return fmt.Errorf("failed to fetch header %d: %w", to, err)
+		}
+
+		err = pgx.BeginFunc(ctx, i.db, func(tx pgx.Tx) error {
+			for _, log := range logs {
+				if err := i.apply(ctx, tx, log); err != nil {
+					return fmt.Errorf("block %d log %d: %w", log.BlockNumber, log.Index, err)
+				}
+			}
+			return i.saveCheckpoint(ctx, tx, to, toHeader.Hash())
+		})
+		if err != nil {
+			return err
+		}
+
+		from = to + 1
+	}
+
+	return nil
+}
+
+// resumeBlock returns the first block to process. If the checkpointed block
+// was reorged out, it rewinds by maxReorgDepth.
+func (i *Indexer) resumeBlock(ctx context.Context) (uint64, error) {
+	var number int64
+	var hash string
+	err := i.db.QueryRow(ctx,
+		`SELECT block_number, block_hash FROM indexer_checkpoints WHERE name = $1`,
+		i.checkpointName(),
+	).Scan(&number, &hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return i.startBlock, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	header, err := i.backend.HeaderByNumber(ctx, big.NewInt(number))
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch checkpoint header: %w", err)
+	}
+	if header.Hash() == common.HexToHash(hash) {
+		return uint64(number) + 1, nil
+	}
+
+	rewind := i.startBlock
+	if uint64(number) > i.startBlock+maxReorgDepth {
+		rewind = uint64(number) - maxReorgDepth
+	}
+	i.logger.Warn("Registry checkpoint is no longer canonical, rewinding",
+		zap.Int64("checkpoint", number),
+		zap.Uint64("rewind_to", rewind),
+	)
+	return rewind, nil
+}
+
+func (i *Indexer) saveCheckpoint(ctx context.Context, tx pgx.Tx, number uint64, hash common.Hash) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO indexer_checkpoints (name, block_number, block_hash, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET
+			block_number = EXCLUDED.block_number,
+			block_hash = EXCLUDED.block_hash,
+			updated_at = EXCLUDED.updated_at`,
+		i.checkpointName(), int64(number), hash.Hex(), time.Now().UTC(),
+	)
+	return err
+}
+
+// apply dispatches a registry log to its event handler. Events for nodes the
+// gateway publishes itself are ignored: the gateway is authoritative for them.
+func (i *Indexer) apply(ctx context.Context, tx pgx.Tx, log types.Log) error {
+	if len(log.Topics) < 2 {
+		return nil
+	}
+	onchainID := strings.ToLower(log.Topics[1].Hex())
+
+	switch log.Topics[0] {
+	case i.abi.Events["NodeRegistered"].ID:
+		return i.applyRegistered(ctx, tx, log.Topics[1], onchainID)
+
+	case i.abi.Events["NodeUpdated"].ID:
+		var ev struct {
+			Status       uint8
+			CurrentBlock *big.Int
+			HighestBlock *big.Int
+		}
+		if err := i.abi.UnpackIntoInterface(&ev, "NodeUpdated", log.Data); err != nil {
+			return err
+		}
+		status, ok := NodeStatusFromContract(ev.Status)
+		if !ok {
+			return fmt.Errorf("unknown node status %d", ev.Status)
+		}
+		_, err := tx.Exec(ctx, `
+			UPDATE nodes SET status = $1, sync_status = $2, updated_at = $3
+			WHERE onchain_id = $4 AND source = $5`,
+			status, syncStatus(status, ev.CurrentBlock, ev.HighestBlock), time.Now().UTC(),
+			onchainID, models.NodeSourceOnchain,
+		)
+		return err
+
+	case i.abi.Events["NodeStatusChanged"].ID:
+		var ev struct {
+			OldStatus uint8
+			NewStatus uint8
+		}
+		if err := i.abi.UnpackIntoInterface(&ev, "NodeStatusChanged", log.Data); err != nil {
+			return err
+		}
+		status, ok := NodeStatusFromContract(ev.NewStatus)
+		if !ok {
+			return fmt.Errorf("unknown node status %d", ev.NewStatus)
+		}
+		_, err := tx.Exec(ctx, `
+			UPDATE nodes SET status = $1, updated_at = $2
+			WHERE onchain_id = $3 AND source = $4`,
+			status, time.Now().UTC(), onchainID, models.NodeSourceOnchain,
+		)
+		return err
+
+	case i.abi.Events["NodeDeregistered"].ID:
+		_, err := tx.Exec(ctx,
+			`DELETE FROM nodes WHERE onchain_id = $1 AND source = $2`,
+			onchainID, models.NodeSourceOnchain,
+		)
+		return err
+	}
+
+	return nil
+}
+
+// applyRegistered reads the node's full details from the contract, since the
+// NodeRegistered event only carries its name and chain type. Nodes of
+// untrusted owners are skipped.
+func (i *Indexer) applyRegistered(ctx context.Context, tx pgx.Tx, id common.Hash, onchainID string) error {
+	var source models.NodeSource
+	err := tx.QueryRow(ctx, `SELECT source FROM nodes WHERE onchain_id = $1`, onchainID).Scan(&source)
+	if err == nil && source != models.NodeSourceOnchain {
+		return nil
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	input, err := i.abi.Pack("getNodeDetails", id)
+	if err != nil {
+		return err
+	}
+	output, err := i.backend.CallContract(ctx, ethereum.CallMsg{To: &i.address, Data: input}, nil)
+	if err != nil {
+		return fmt.Errorf("getNodeDetails failed: %w", err)
+	}
+
+	var details struct {
+		Name         string
+		ChainType    uint8
+		EndpointUrl  string
+		Status       uint8
+		Version      string
+		CurrentBlock *big.Int
+		HighestBlock *big.Int
+		RegisteredAt *big.Int
+		UpdatedAt    *big.Int
+		Region       string
+		Provider     uint8
+		Owner        common.Address
+		IsActive     bool
+	}
+	if err := i.abi.UnpackIntoInterface(&details, "getNodeDetails", output); err != nil {
+		return fmt.Errorf("failed to decode getNodeDetails: %w", err)
+	}
+	if !i.trusted[details.Owner] {
+		i.logger.Debug("Skipping registry node of untrusted owner",
+			zap.String("onchain_id", onchainID), zap.String("owner", details.Owner.Hex()))
+		return nil
+	}
+
+	chainType, ok := ChainTypeFromContract(details.ChainType)
+	if !ok {
+		return fmt.Errorf("unknown chain type %d", details.ChainType)
+	}
+	status, ok := NodeStatusFromContract(details.Status)
+	if !ok {
+		return fmt.Errorf("unknown node status %d", details.Status)
+	}
+	provider, ok := CloudProviderFromContract(details.Provider)
+	if !ok {
+		return fmt.Errorf("unknown cloud provider %d", details.Provider)
+	}
+
+	registeredAt := time.Unix(details.RegisteredAt.Int64(), 0).UTC()
+	updatedAt := time.Unix(details.UpdatedAt.Int64(), 0).UTC()
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO nodes (id, name, chain_type, endpoint_url, status, version, sync_status,
+			created_at, updated_at, region, provider, source, onchain_id, onchain_owner)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			chain_type = EXCLUDED.chain_type,
+			endpoint_url = EXCLUDED.endpoint_url,
+			status = EXCLUDED.status,
+			version = EXCLUDED.version,
+			sync_status = EXCLUDED.sync_status,
+			updated_at = EXCLUDED.updated_at,
+			region = EXCLUDED.region,
+			provider = EXCLUDED.provider,
+			onchain_owner = EXCLUDED.onchain_owner`,
+		i.nodeUUID(id), details.Name, chainType, details.EndpointUrl, status, details.Version,
+		syncStatus(status, details.CurrentBlock, details.HighestBlock),
+		registeredAt, updatedAt, details.Region, provider,
+		models.NodeSourceOnchain, onchainID, strings.ToLower(details.Owner.Hex()),
+	)
+	return err
+}
+
+// nodeUUID derives a stable UUID from the registry address and on-chain ID,
+// so replaying events after a reorg or a checkpoint reset updates the same row
+func (i *Indexer) nodeUUID(id common.Hash) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, append(i.address.Bytes(), id.Bytes()...))
+}
+
+func syncStatus(status models.NodeStatus, current, highest *big.Int) models.SyncStatus {
+	s := models.SyncStatus{
+		IsSyncing:    status == models.NodeStatusSyncing,
+		CurrentBlock: current.Uint64(),
+		HighestBlock: highest.Uint64(),
+	}
+	if s.HighestBlock == 0 || s.CurrentBlock >= s.HighestBlock {
+		s.ProgressPercentage = 100
+	} else {
+		s.ProgressPercentage = float64(s.CurrentBlock) * 100 / float64(s.HighestBlock)
+	}
+	return s
+}
@@ -0,0 +1,466 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/migrations"
+	"github.com/twist/api-gateway/pkg/contracts"
+	"go.uber.org/zap"
+)
+
+// emitterCode deploys a contract that turns every call into a log: the first
+// two calldata words are the topics and the rest is the data. It stands in
+// for BlockchainNodeRegistry, whose bytecode isn't bound, so the tests can
+// emit registry events with their exact on-chain encoding.
+var emitterCode = common.FromHex("0x601680600b6000396000f33660409003806040600037602035600035826000a200")
+
+// testDB returns a pool on a fresh schema of TEST_DATABASE_URL holding the
+// nodes table and the indexer migration. Tests are skipped without it.
+func testDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	schema := fmt.Sprintf("registry_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE")
+		admin.Close()
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_DATABASE_URL: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	db, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	for _, name := range []string{"0000_nodes.sql", "0004_registry_indexer.sql"} {
+		migration, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			t.Fatalf("failed to read migration: %v", err)
+		}
+		if _, err := db.Exec(ctx, string(migration)); err != nil {
+			t.Fatalf("failed to apply %s: %v", name, err)
+		}
+	}
+	return db
+}
+
+// testNode is what the stand-in registry returns from getNodeDetails
+type testNode struct {
+	Name      string
+	ChainType uint8
+	Endpoint  string
+	Status    uint8
+	Version   string
+	Region    string
+	Provider  uint8
+
+	// Owner registered the node, the chain's trusted owner when zero
+	Owner common.Address
+}
+
+// testChain is a simulated chain with the emitter deployed in place of the
+// registry. getNodeDetails calls to the emitter are answered from nodes,
+// which isn't fork-aware: it holds the details of the latest registration.
+type testChain struct {
+	*backends.SimulatedBackend
+	t       *testing.T
+	auth    *bind.TransactOpts
+	owner   common.Address
+	address common.Address
+	emitter *bind.BoundContract
+	abi     abi.ABI
+	nodes   map[common.Hash]testNode
+}
+
+func newTestChain(t *testing.T) *testChain {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		owner: {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))},
+	}, 30_000_000)
+	t.Cleanup(func() { _ = backend.Close() })
+
+	parsed, err := contracts.BlockchainNodeRegistryMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &testChain{
+		SimulatedBackend: backend,
+		t:                t,
+		auth:             transactor(t, key),
+		owner:            owner,
+		abi:              *parsed,
+		nodes:            map[common.Hash]testNode{},
+	}
+	c.address, _, c.emitter, err = bind.DeployContract(c.auth, abi.ABI{}, emitterCode, backend)
+	if err != nil {
+		t.Fatalf("failed to deploy emitter: %v", err)
+	}
+	backend.Commit()
+	return c
+}
+
+func transactor(t *testing.T, key *ecdsa.PrivateKey) *bind.TransactOpts {
+	t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.GasLimit = 200_000
+	return auth
+}
+
+// emit queues a registry event for node id in the pending block
+func (c *testChain) emit(event string, id common.Hash, args ...interface{}) {
+	c.t.Helper()
+
+	ev := c.abi.Events[event]
+	data, err := ev.Inputs.NonIndexed().Pack(args...)
+	if err != nil {
+		c.t.Fatalf("failed to pack %s: %v", event, err)
+	}
+	calldata := append(append(ev.ID.Bytes(), id.Bytes()...), data...)
+	if _, err := c.emitter.RawTransact(c.auth, calldata); err != nil {
+		c.t.Fatalf("failed to emit %s: %v", event, err)
+	}
+}
+
+// register records node's details and queues its NodeRegistered event
+func (c *testChain) register(id common.Hash, node testNode) {
+	c.nodes[id] = node
+	c.emit("NodeRegistered", id, node.Name, node.ChainType)
+}
+
+// CallContract answers getNodeDetails for the emitter from c.nodes
+func (c *testChain) CallContract(ctx context.Context, call ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	method := c.abi.Methods["getNodeDetails"]
+	if call.To == nil || *call.To != c.address || !bytes.HasPrefix(call.Data, method.ID) {
+		return c.SimulatedBackend.CallContract(ctx, call, block)
+	}
+
+	args, err := method.Inputs.Unpack(call.Data[len(method.ID):])
+	if err != nil {
+		return nil, err
+	}
+	node, ok := c.nodes[common.Hash(args[0].([32]byte))]
+	if !ok {
+		return nil, errors.New("execution reverted: Node does not exist")
+	}
+	owner := node.Owner
+	if owner == (common.Address{}) {
+		owner = c.owner
+	}
+	now := big.NewInt(time.Now().Unix())
+	return method.Outputs.Pack(
+		node.Name, node.ChainType, node.Endpoint, node.Status, node.Version,
+		big.NewInt(100), big.NewInt(100), now, now,
+		node.Region, node.Provider, owner, true,
+	)
+}
+
+// indexer returns an Indexer trusting the chain's owner
+func (c *testChain) indexer(db *pgxpool.Pool, confirmations uint64) *Indexer {
+	c.t.Helper()
+	indexer, err := NewIndexer(db, c, zap.NewNop(), c.address, []common.Address{c.owner}, 0, confirmations, time.Second)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return indexer
+}
+
+// indexedNode is a nodes row written by the indexer
+type indexedNode struct {
+	Name       string
+	Status     models.NodeStatus
+	SyncStatus models.SyncStatus
+	Source     models.NodeSource
+}
+
+func loadNode(t *testing.T, db *pgxpool.Pool, id common.Hash) (*indexedNode, bool) {
+	t.Helper()
+
+	var n indexedNode
+	err := db.QueryRow(context.Background(),
+		`SELECT name, status, sync_status, source FROM nodes WHERE onchain_id = $1`,
+		strings.ToLower(id.Hex()),
+	).Scan(&n.Name, &n.Status, &n.SyncStatus, &n.Source)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false
+	}
+	if err != nil {
+		t.Fatalf("failed to load node: %v", err)
+	}
+	return &n, true
+}
+
+func loadCheckpoint(t *testing.T, db *pgxpool.Pool, indexer *Indexer) (uint64, common.Hash) {
+	t.Helper()
+
+	var number int64
+	var hash string
+	err := db.QueryRow(context.Background(),
+		`SELECT block_number, block_hash FROM indexer_checkpoints WHERE name = $1`,
+		indexer.checkpointName(),
+	).Scan(&number, &hash)
+	if err != nil {
+		t.Fatalf("failed to load checkpoint: %v", err)
+	}
+	return uint64(number), common.HexToHash(hash)
+}
+
+func syncIndexer(t *testing.T, indexer *Indexer) {
+	t.Helper()
+	if err := indexer.Sync(context.Background()); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+}
+
+var (
+	nodeA = common.HexToHash("0xa1")
+	nodeB = common.HexToHash("0xb2")
+	nodeC = common.HexToHash("0xc3")
+)
+
+func TestIndexerMirrorsRegistryEvents(t *testing.T) {
+	db := testDB(t)
+	chain := newTestChain(t)
+	ctx := context.Background()
+
+	// A gateway-published node is authoritative over its on-chain copy
+	_, err := db.Exec(ctx, `
+		INSERT INTO nodes (id, name, chain_type, endpoint_url, status, version, sync_status,
+			created_at, updated_at, region, provider, source, onchain_id)
+		VALUES (gen_random_uuid(), 'gateway-node', 'ethereum', 'http://gw:8545', 'running', '1.0', '{}',
+			NOW(), NOW(), 'eu', 'aws', $1, $2)`,
+		models.NodeSourceGateway, strings.ToLower(nodeC.Hex()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain.register(nodeA, testNode{Name: "geth-a", ChainType: 1, Endpoint: "http://a:8545", Version: "1.13", Region: "us", Provider: 1})
+	chain.register(nodeB, testNode{Name: "geth-b", Endpoint: "http://b:8545", Version: "1.13", Region: "eu"})
+	chain.register(nodeC, testNode{Name: "onchain-c", Endpoint: "http://c:8545", Version: "1.13", Region: "eu"})
+	chain.Commit()
+	chain.emit("NodeUpdated", nodeA, uint8(3), big.NewInt(50), big.NewInt(200))
+	chain.emit("NodeStatusChanged", nodeB, uint8(0), uint8(1))
+	chain.Commit()
+	chain.emit("NodeDeregistered", nodeB)
+	chain.Commit()
+
+	indexer := chain.indexer(db, 0)
+	syncIndexer(t, indexer)
+
+	a, ok := loadNode(t, db, nodeA)
+	if !ok {
+		t.Fatal("node A was not indexed")
+	}
+	if a.Name != "geth-a" || a.Source != models.NodeSourceOnchain {
+		t.Errorf("node A = %+v, want geth-a from onchain", a)
+	}
+	if a.Status != models.NodeStatusSyncing || !a.SyncStatus.IsSyncing || a.SyncStatus.CurrentBlock != 50 || a.SyncStatus.ProgressPercentage != 25 {
+		t.Errorf("node A status = %s %+v, want syncing at 50/200", a.Status, a.SyncStatus)
+	}
+
+	var chainType models.ChainType
+	var provider models.CloudProvider
+	err = db.QueryRow(ctx, `SELECT chain_type, provider FROM nodes WHERE id = $1`, indexer.nodeUUID(nodeA)).Scan(&chainType, &provider)
+	if err != nil {
+		t.Fatalf("node A is not keyed by its derived UUID: %v", err)
+	}
+	if chainType != models.ChainTypePolygon || provider != models.CloudProviderGCP {
+		t.Errorf("node A chain/provider = %s/%s, want polygon/gcp", chainType, provider)
+	}
+
+	if _, ok := loadNode(t, db, nodeB); ok {
+		t.Error("deregistered node B is still present")
+	}
+
+	c, _ := loadNode(t, db, nodeC)
+	if c == nil || c.Name != "gateway-node" || c.Source != models.NodeSourceGateway {
+		t.Errorf("gateway node C = %+v, want it untouched", c)
+	}
+
+	head, err := chain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	number, hash := loadCheckpoint(t, db, indexer)
+	if number != head.Number.Uint64() || hash != head.Hash() {
+		t.Errorf("checkpoint = %d %s, want head %d %s", number, hash, head.Number, head.Hash())
+	}
+}
+
+// Anyone can register a node, so only trusted owners' nodes are mirrored
+func TestIndexerIgnoresUntrustedOwners(t *testing.T) {
+	db := testDB(t)
+	chain := newTestChain(t)
+	ctx := context.Background()
+	stranger := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	chain.register(nodeA, testNode{Name: "geth-a", Endpoint: "http://a:8545", Status: 1, Version: "1.13", Region: "us"})
+	chain.register(nodeB, testNode{Name: "evil", Endpoint: "http://169.254.169.254/", Status: 1, Version: "1.13", Region: "us", Owner: stranger})
+	chain.Commit()
+	chain.emit("NodeStatusChanged", nodeB, uint8(0), uint8(1))
+	chain.Commit()
+
+	indexer := chain.indexer(db, 0)
+	syncIndexer(t, indexer)
+
+	if _, ok := loadNode(t, db, nodeA); !ok {
+		t.Fatal("the trusted owner's node was not indexed")
+	}
+	if b, ok := loadNode(t, db, nodeB); ok {
+		t.Fatalf("node of an untrusted owner was indexed: %+v", b)
+	}
+
+	// Owners dropped from the allowlist lose their mirrored nodes
+	distrusting, err := NewIndexer(db, chain, zap.NewNop(), chain.address, nil, 0, 0, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := distrusting.PruneUntrusted(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadNode(t, db, nodeA); ok {
+		t.Fatal("node of an owner no longer trusted was kept")
+	}
+}
+
+func TestIndexerResumesFromCheckpoint(t *testing.T) {
+	db := testDB(t)
+	chain := newTestChain(t)
+	ctx := context.Background()
+	indexer := chain.indexer(db, 1)
+
+	chain.register(nodeA, testNode{Name: "geth-a", Endpoint: "http://a:8545", Version: "1.13", Region: "us"})
+	chain.Commit()
+
+	// The registration has no confirmations yet
+	syncIndexer(t, indexer)
+	if _, ok := loadNode(t, db, nodeA); ok {
+		t.Fatal("node A was indexed before it was confirmed")
+	}
+
+	chain.Commit()
+	syncIndexer(t, indexer)
+	if _, ok := loadNode(t, db, nodeA); !ok {
+		t.Fatal("node A was not indexed once confirmed")
+	}
+	checkpoint, _ := loadCheckpoint(t, db, indexer)
+
+	// A renamed row shows whether the registration is replayed
+	if _, err := db.Exec(ctx, `UPDATE nodes SET name = 'renamed' WHERE onchain_id = $1`, strings.ToLower(nodeA.Hex())); err != nil {
+		t.Fatal(err)
+	}
+
+	chain.emit("NodeStatusChanged", nodeA, uint8(0), uint8(4))
+	chain.Commit()
+	chain.Commit()
+
+	// A new indexer picks up from the stored checkpoint
+	syncIndexer(t, chain.indexer(db, 1))
+
+	a, _ := loadNode(t, db, nodeA)
+	if a == nil || a.Status != models.NodeStatusError {
+		t.Fatalf("node A = %+v, want status error", a)
+	}
+	if a.Name != "renamed" {
+		t.Errorf("node A name = %q, blocks before the checkpoint were replayed", a.Name)
+	}
+	if resumed, _ := loadCheckpoint(t, db, indexer); resumed != checkpoint+2 {
+		t.Errorf("checkpoint = %d, want %d", resumed, checkpoint+2)
+	}
+}
+
+func TestIndexerRollsBackReorgedEvents(t *testing.T) {
+	db := testDB(t)
+	chain := newTestChain(t)
+	ctx := context.Background()
+	indexer := chain.indexer(db, 0)
+
+	chain.register(nodeA, testNode{Name: "geth-a", Endpoint: "http://a:8545", Version: "1.13", Region: "us"})
+	fork := chain.Commit()
+	syncIndexer(t, indexer)
+
+	chain.emit("NodeDeregistered", nodeA)
+	chain.Commit()
+	syncIndexer(t, indexer)
+	if _, ok := loadNode(t, db, nodeA); ok {
+		t.Fatal("node A was not removed")
+	}
+	_, orphaned := loadCheckpoint(t, db, indexer)
+
+	// Replace the deregistration block with a longer chain without it
+	if err := chain.Fork(ctx, fork); err != nil {
+		t.Fatal(err)
+	}
+	chain.emit("NodeUpdated", nodeA, uint8(3), big.NewInt(10), big.NewInt(100))
+	chain.Commit()
+	chain.Commit()
+
+	head, err := chain.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reorged, _ := chain.HeaderByNumber(ctx, big.NewInt(3)); reorged.Hash() == orphaned {
+		t.Fatal("simulated chain did not reorg")
+	}
+
+	syncIndexer(t, indexer)
+
+	a, ok := loadNode(t, db, nodeA)
+	if !ok {
+		t.Fatal("node A was not restored after its deregistration was reorged out")
+	}
+	if a.Status != models.NodeStatusSyncing || a.SyncStatus.CurrentBlock != 10 {
+		t.Errorf("node A = %+v, want the side chain's syncing update", a)
+	}
+	number, hash := loadCheckpoint(t, db, indexer)
+	if number != head.Number.Uint64() || hash != head.Hash() {
+		t.Errorf("checkpoint = %d %s, want new head %d %s", number, hash, head.Number, head.Hash())
+	}
+}
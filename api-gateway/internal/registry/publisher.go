@@ -0,0 +1,575 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/nodeio"
+	"github.com/twist/api-gateway/pkg/contracts"
+	"go.uber.org/zap"
+)
+
+// ErrReceiptTimeout is returned when a transaction isn't mined even after the
+// maximum number of fee bumps
+var ErrReceiptTimeout = errors.New("transaction not mined after fee bumps")
+
+// publisherLeaseKey names the replica that publishes. Registrations get a new
+// on-chain ID per block, so two publishers would register each node twice.
+const publisherLeaseKey = "registry:publisher"
+
+// renewLeaseScript extends the lease only if this replica still holds it
+var renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseLeaseScript gives up the lease only if this replica holds it
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// TransactorBackend is the subset of an Ethereum client used by the
+// Publisher. It is satisfied by *ethclient.Client and by go-ethereum's
+// simulated backend.
+type TransactorBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// PublisherOptions control how aggressively the Publisher spends gas
+type PublisherOptions struct {
+	// Interval between publishing cycles
+	Interval time.Duration
+	// MaxTxPerCycle caps the number of transactions sent per cycle
+	MaxTxPerCycle int
+	// MinUpdateInterval is the minimum time between block-height-only updates of a node
+	MinUpdateInterval time.Duration
+	// BlockDelta is how far a node's block height must move before it is republished
+	BlockDelta uint64
+	// ReceiptTimeout is how long to wait for a receipt before bumping fees
+	ReceiptTimeout time.Duration
+	// MaxFeeBumps is how many times a stuck transaction is replaced with higher fees
+	MaxFeeBumps int
+	// MaxFeePerGas caps the fee cap in wei; zero means no cap
+	MaxFeePerGas *big.Int
+}
+
+// Publisher writes the gateway's view of opted-in nodes to the
+// BlockchainNodeRegistry contract. Status changes are published promptly,
+// block height updates are batched per cycle and rate limited per node. Only
+// the replica holding a Redis lease publishes.
+type Publisher struct {
+	db        *pgxpool.Pool
+	redis     *redis.Client
+	replicaID string
+	backend   TransactorBackend
+	logger    *zap.Logger
+	address   common.Address
+	abi       abi.ABI
+	contract  *bind.BoundContract
+	key       *ecdsa.PrivateKey
+	from      common.Address
+	chainID   *big.Int
+	opts      PublisherOptions
+
+	nextNonce   uint64
+	nonceSynced bool
+}
+
+// LoadKeystoreKey decrypts a go-ethereum keystore (V3) JSON key file
+func LoadKeystoreKey(path, password string) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore file: %w", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore file: %w", err)
+	}
+	return key.PrivateKey, nil
+}
+
+// NewPublisher creates a new Publisher signing with key on the chain chainID
+func NewPublisher(db *pgxpool.Pool, redisClient *redis.Client, backend TransactorBackend, logger *zap.Logger, address common.Address, key *ecdsa.PrivateKey, chainID *big.Int, opts PublisherOptions) (*Publisher, error) {
+	parsed, err := contracts.BlockchainNodeRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registry ABI: %w", err)
+	}
+
+	return &Publisher{
+		db:        db,
+		redis:     redisClient,
+		replicaID: uuid.NewString(),
+		backend:   backend,
+		logger:    logger,
+		address:   address,
+		abi:       *parsed,
+		contract:  bind.NewBoundContract(address, *parsed, backend, backend, backend),
+		key:       key,
+		from:      crypto.PubkeyToAddress(key.PublicKey),
+		chainID:   chainID,
+		opts:      opts,
+	}, nil
+}
+
+// Run publishes until ctx is cancelled
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Publish(ctx); err != nil && ctx.Err() == nil {
+			p.logger.Error("Registry publish cycle failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			p.resign(context.Background())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaseTTL outlasts a cycle's interval plus the longest wait for one
+// transaction, so the lease is only lost when the publishing replica stops
+func (p *Publisher) leaseTTL() time.Duration {
+	return 3*p.opts.Interval + time.Duration(p.opts.MaxFeeBumps+1)*p.opts.ReceiptTimeout
+}
+
+// lead takes or renews the publisher lease and reports whether this replica
+// holds it
+func (p *Publisher) lead(ctx context.Context) (bool, error) {
+	ttl := p.leaseTTL()
+	taken, err := p.redis.SetNX(ctx, publisherLeaseKey, p.replicaID, ttl).Result()
+	if err != nil || taken {
+		return taken, err
+	}
+	renewed, err := renewLeaseScript.Run(ctx, p.redis, []string{publisherLeaseKey}, p.replicaID, ttl.Milliseconds()).Int()
+	return renewed == 1, err
+}
+
+// resign gives up the publisher lease so another replica takes over at once
+func (p *Publisher) resign(ctx context.Context) {
+	if err := releaseLeaseScript.Run(ctx, p.redis, []string{publisherLeaseKey}, p.replicaID).Err(); err != nil {
+		p.logger.Warn("Failed to release registry publisher lease", zap.Error(err))
+	}
+}
+
+type actionKind int
+
+// Actions are executed in this order, so that deregistrations and status
+// changes win over block height updates when the cycle cap is reached
+const (
+	actionDeregister actionKind = iota
+	actionStatus
+	actionRegister
+	actionBlocks
+)
+
+type publication struct {
+	onchainID    string
+	status       models.NodeStatus
+	currentBlock uint64
+	highestBlock uint64
+	publishedAt  time.Time
+}
+
+type publishAction struct {
+	kind   actionKind
+	nodeID uuid.UUID
+	node   *models.BlockchainNode
+	pub    *publication
+}
+
+// Publish runs a single publishing cycle if this replica holds the lease.
+// The lease is renewed before each transaction.
+func (p *Publisher) Publish(ctx context.Context) error {
+	leading, err := p.lead(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to take publisher lease: %w", err)
+	}
+	if !leading {
+		// Another replica's transactions move the account's nonce
+		p.nonceSynced = false
+		return nil
+	}
+
+	actions, err := p.plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].kind < actions[j].kind })
+	if p.opts.MaxTxPerCycle > 0 && len(actions) > p.opts.MaxTxPerCycle {
+		p.logger.Info("Deferring registry updates to the next cycle", zap.Int("deferred", len(actions)-p.opts.MaxTxPerCycle))
+		actions = actions[:p.opts.MaxTxPerCycle]
+	}
+
+	for i, action := range actions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if i > 0 {
+			leading, err := p.lead(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to renew publisher lease: %w", err)
+			}
+			if !leading {
+				p.logger.Warn("Lost registry publisher lease, stopping cycle", zap.Int("remaining", len(actions)-i))
+				p.nonceSynced = false
+				return nil
+			}
+		}
+		if err := p.execute(ctx, action); err != nil {
+			p.logger.Error("Failed to publish node to registry",
+				zap.String("node_id", action.nodeID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// plan compares opted-in nodes with what was last published on-chain
+func (p *Publisher) plan(ctx context.Context) ([]publishAction, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT n.id, n.name, n.chain_type, n.endpoint_url, n.status, n.version, n.sync_status,
+			n.region, n.provider, n.publish_onchain,
+			p.node_id IS NOT NULL, COALESCE(p.onchain_id, ''), COALESCE(p.status, ''),
+			COALESCE(p.current_block, 0), COALESCE(p.highest_block, 0), COALESCE(p.published_at, 'epoch')
+		FROM nodes n
+		LEFT JOIN registry_publications p ON p.node_id = n.id
+		WHERE n.source = $1 AND (n.publish_onchain OR p.node_id IS NOT NULL)`,
+		models.NodeSourceGateway,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load nodes to publish: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var actions []publishAction
+	for rows.Next() {
+		var node models.BlockchainNode
+		var pub publication
+		var published bool
+		var currentBlock, highestBlock int64
+		err := rows.Scan(&node.ID, &node.Name, &node.ChainType, &node.EndpointURL, &node.Status, &node.Version,
+			&node.SyncStatus, &node.Region, &node.Provider, &node.PublishOnchain,
+			&published, &pub.onchainID, &pub.status, &currentBlock, &highestBlock, &pub.publishedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan node to publish: %w", err)
+		}
+		pub.currentBlock = uint64(currentBlock)
+		pub.highestBlock = uint64(highestBlock)
+
+		action := publishAction{nodeID: node.ID, node: &node}
+		switch {
+		case !published:
+			action.kind = actionRegister
+		case !node.PublishOnchain:
+			action.kind = actionDeregister
+			action.pub = &pub
+		case node.Status != pub.status:
+			action.kind = actionStatus
+			action.pub = &pub
+		case blocksMoved(node.SyncStatus, pub, p.opts.BlockDelta) && now.Sub(pub.publishedAt) >= p.opts.MinUpdateInterval:
+			action.kind = actionBlocks
+			action.pub = &pub
+		default:
+			continue
+		}
+		actions = append(actions, action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Nodes deleted from the gateway while published
+	orphans, err := p.db.Query(ctx, `
+		SELECT p.node_id, p.onchain_id
+		FROM registry_publications p
+		LEFT JOIN nodes n ON n.id = p.node_id
+		WHERE n.id IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load deleted publications: %w", err)
+	}
+	defer orphans.Close()
+
+	for orphans.Next() {
+		var pub publication
+		var nodeID uuid.UUID
+		if err := orphans.Scan(&nodeID, &pub.onchainID); err != nil {
+			return nil, err
+		}
+		actions = append(actions, publishAction{kind: actionDeregister, nodeID: nodeID, pub: &pub})
+	}
+
+	return actions, orphans.Err()
+}
+
+func blocksMoved(sync models.SyncStatus, pub publication, delta uint64) bool {
+	return absDiff(sync.CurrentBlock, pub.currentBlock) >= delta || absDiff(sync.HighestBlock, pub.highestBlock) >= delta
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func (p *Publisher) execute(ctx context.Context, action publishAction) error {
+	switch action.kind {
+	case actionRegister:
+		return p.register(ctx, action.node)
+	case actionDeregister:
+		return p.deregister(ctx, action.nodeID, action.pub.onchainID)
+	default:
+		return p.update(ctx, action.node, action.pub.onchainID)
+	}
+}
+
+func (p *Publisher) register(ctx context.Context, node *models.BlockchainNode) error {
+	chainType, ok := ChainTypeToContract(node.ChainType)
+	if !ok {
+		return fmt.Errorf("chain type %q has no registry equivalent", node.ChainType)
+	}
+	provider, ok := CloudProviderToContract(node.Provider)
+	if !ok {
+		return fmt.Errorf("provider %q has no registry equivalent", node.Provider)
+	}
+
+	// The registry is public, so credentials in the endpoint stay off-chain
+	endpoint := nodeio.PublicURL(node.EndpointURL)
+	if endpoint == "" {
+		return fmt.Errorf("endpoint URL of node %s can't be published", node.ID)
+	}
+
+	receipt, err := p.transact(ctx, "registerNode", node.Name, chainType, endpoint, node.Version, node.Region, provider)
+	if err != nil {
+		return err
+	}
+
+	var onchainID string
+	registered := p.abi.Events["NodeRegistered"].ID
+	for _, log := range receipt.Logs {
+		if log.Address == p.address && len(log.Topics) > 1 && log.Topics[0] == registered {
+			onchainID = strings.ToLower(log.Topics[1].Hex())
+			break
+		}
+	}
+	if onchainID == "" {
+		return fmt.Errorf("registerNode receipt %s has no NodeRegistered event", receipt.TxHash.Hex())
+	}
+
+	return pgx.BeginFunc(ctx, p.db, func(tx pgx.Tx) error {
+		// The indexer may already have mirrored our own registration
+		if _, err := tx.Exec(ctx, `DELETE FROM nodes WHERE onchain_id = $1 AND source = $2`, onchainID, models.NodeSourceOnchain); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE nodes SET onchain_id = $1, onchain_owner = $2 WHERE id = $3`,
+			onchainID, strings.ToLower(p.from.Hex()), node.ID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO registry_publications (node_id, onchain_id, status, last_tx_hash, published_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			node.ID, onchainID, models.NodeStatusStarting, receipt.TxHash.Hex(), time.Now().UTC(),
+		)
+		return err
+	})
+}
+
+func (p *Publisher) update(ctx context.Context, node *models.BlockchainNode, onchainID string) error {
+	status, ok := NodeStatusToContract(node.Status)
+	if !ok {
+		return fmt.Errorf("status %q has no registry equivalent", node.Status)
+	}
+
+	current := new(big.Int).SetUint64(node.SyncStatus.CurrentBlock)
+	highest := new(big.Int).SetUint64(node.SyncStatus.HighestBlock)
+	receipt, err := p.transact(ctx, "updateNodeStatus", common.HexToHash(onchainID), status, current, highest)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.Exec(ctx, `
+		UPDATE registry_publications
+		SET status = $1, current_block = $2, highest_block = $3, last_tx_hash = $4, published_at = $5
+		WHERE node_id = $6`,
+		node.Status, int64(node.SyncStatus.CurrentBlock), int64(node.SyncStatus.HighestBlock),
+		receipt.TxHash.Hex(), time.Now().UTC(), node.ID,
+	)
+	return err
+}
+
+func (p *Publisher) deregister(ctx context.Context, nodeID uuid.UUID, onchainID string) error {
+	if _, err := p.transact(ctx, "deregisterNode", common.HexToHash(onchainID)); err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(ctx, p.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `UPDATE nodes SET onchain_id = '', onchain_owner = '' WHERE id = $1`, nodeID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM registry_publications WHERE node_id = $1`, nodeID)
+		return err
+	})
+}
+
+// transact sends a contract call with an explicitly managed nonce and EIP-1559
+// fees, waits for the receipt and replaces the transaction with bumped fees
+// when it isn't mined in time
+func (p *Publisher) transact(ctx context.Context, method string, params ...interface{}) (*types.Receipt, error) {
+	nonce, err := p.reserveNonce(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tipCap, err := p.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest tip: %w", err)
+	}
+	head, err := p.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch head: %w", err)
+	}
+	baseFee := head.BaseFee
+	if baseFee == nil {
+		baseFee = new(big.Int)
+	}
+	feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tipCap)
+
+	opts, err := bind.NewKeyedTransactorWithChainID(p.key, p.chainID)
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = ctx
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+
+	var sent []*types.Transaction
+	for attempt := 0; attempt <= p.opts.MaxFeeBumps; attempt++ {
+		if p.opts.MaxFeePerGas != nil && feeCap.Cmp(p.opts.MaxFeePerGas) > 0 {
+			feeCap = new(big.Int).Set(p.opts.MaxFeePerGas)
+			if tipCap.Cmp(feeCap) > 0 {
+				tipCap = new(big.Int).Set(feeCap)
+			}
+		}
+		opts.GasTipCap = tipCap
+		opts.GasFeeCap = feeCap
+
+		tx, err := p.contract.Transact(opts, method, params...)
+		switch {
+		case err == nil:
+			sent = append(sent, tx)
+		case len(sent) > 0 && isNonceTooLow(err):
+			// An earlier attempt with this nonce was mined in the meantime
+		default:
+			p.nonceSynced = false
+			return nil, fmt.Errorf("failed to send %s: %w", method, err)
+		}
+
+		receipt, err := p.waitMined(ctx, sent)
+		if err == nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return nil, fmt.Errorf("%s transaction %s reverted", method, receipt.TxHash.Hex())
+			}
+			return receipt, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		p.logger.Warn("Registry transaction not mined in time, bumping fees",
+			zap.String("method", method),
+			zap.Uint64("nonce", nonce),
+			zap.Int("attempt", attempt+1),
+		)
+		tipCap = bumpFee(tipCap)
+		feeCap = bumpFee(feeCap)
+	}
+
+	// The nonce may still be taken by a pending transaction, so resync
+	p.nonceSynced = false
+	return nil, ErrReceiptTimeout
+}
+
+// reserveNonce hands out sequential nonces, resyncing with the node's pending
+// nonce whenever a send failed or the local counter fell behind
+func (p *Publisher) reserveNonce(ctx context.Context) (uint64, error) {
+	pending, err := p.backend.PendingNonceAt(ctx, p.from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch pending nonce: %w", err)
+	}
+	if !p.nonceSynced || pending > p.nextNonce {
+		p.nextNonce = pending
+		p.nonceSynced = true
+	}
+
+	nonce := p.nextNonce
+	p.nextNonce++
+	return nonce, nil
+}
+
+// waitMined waits up to ReceiptTimeout for any of the transactions sharing a
+// nonce to be mined
+func (p *Publisher) waitMined(ctx context.Context, txs []*types.Transaction) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.ReceiptTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		for _, tx := range txs {
+			receipt, err := p.backend.TransactionReceipt(ctx, tx.Hash())
+			if err == nil && receipt != nil {
+				return receipt, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// bumpFee raises a fee by 12.5%, above the 10% geth requires for replacement
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(1125))
+	bumped.Div(bumped, big.NewInt(1000))
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, big.NewInt(1))
+	}
+	return bumped
+}
+
+func isNonceTooLow(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
@@ -0,0 +1,354 @@
+// Package sessions manages login sessions: rotating refresh tokens with
+// reuse detection, and a Redis denylist of revoked access token IDs.
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrRefreshTokenReused is returned when an already rotated refresh token
+	// is presented again; the session it belongs to has been revoked
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+
+	// ErrNotFound is returned when a session does not exist or belongs to another user
+	ErrNotFound = errors.New("session not found")
+
+	// ErrSessionRevoked is returned when a token is issued for a session that
+	// was revoked while the token was being signed
+	ErrSessionRevoked = errors.New("session has been revoked")
+)
+
+// Denylist records revoked access tokens by jti until they would have expired
+type Denylist struct {
+	redis *redis.Client
+}
+
+// NewDenylist creates a new Denylist
+func NewDenylist(redisClient *redis.Client) *Denylist {
+	return &Denylist{redis: redisClient}
+}
+
+func denylistKey(jti string) string {
+	return "jwt:deny:" + jti
+}
+
+// Revoke denies the access token jti until expiresAt
+func (d *Denylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return d.redis.Set(ctx, denylistKey(jti), "1", ttl).Err()
+}
+
+// IsRevoked reports whether the access token jti has been revoked
+func (d *Denylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := d.redis.Exists(ctx, denylistKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Store persists sessions and their refresh tokens
+type Store struct {
+	db         *pgxpool.Pool
+	denylist   *Denylist
+	refreshTTL time.Duration
+}
+
+// NewStore creates a new Store whose sessions last refreshTTL without being refreshed
+func NewStore(db *pgxpool.Pool, denylist *Denylist, refreshTTL time.Duration) *Store {
+	return &Store{db: db, denylist: denylist, refreshTTL: refreshTTL}
+}
+
+// newRefreshToken returns a random refresh token and the hash it is stored under
+func newRefreshToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create starts a session for userID and returns it with its first refresh
+// token. mfa records whether the login presented a second factor.
+func (s *Store) Create(ctx context.Context, userID uuid.UUID, userAgent, ipAddress string, mfa bool) (*models.Session, string, error) {
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserAgent:  userAgent,
+		Device:     DescribeDevice(userAgent),
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.refreshTTL),
+	}
+
+	err = pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_sessions (id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, mfa)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			session.ID, userID, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastUsedAt, session.ExpiresAt, mfa); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO refresh_tokens (token_hash, session_id, issued_at) VALUES ($1, $2, $3)`,
+			hash, session.ID, now)
+		return err
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, token, nil
+}
+
+// Impersonate starts a session in which impersonatorID acts as userID. It
+// has no refresh token and ends after ttl, with the access token issued for it.
+func (s *Store) Impersonate(ctx context.Context, userID, impersonatorID uuid.UUID, userAgent, ipAddress string, ttl time.Duration) (*models.Session, error) {
+	now := time.Now().UTC()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserAgent:  userAgent,
+		Device:     DescribeDevice(userAgent),
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+
+	// The admin already satisfied the MFA policy to get here
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO user_sessions (id, user_id, user_agent, ip_address, created_at, last_used_at, expires_at, mfa, impersonator_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE, $8)`,
+		session.ID, userID, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastUsedAt, session.ExpiresAt, impersonatorID)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Rotation is the result of exchanging a refresh token
+type Rotation struct {
+	UserID       uuid.UUID
+	SessionID    uuid.UUID
+	MFA          bool
+	RefreshToken string
+}
+
+// Rotate exchanges a refresh token for a new one, extending its session
+func (s *Store) Rotate(ctx context.Context, refreshToken, userAgent, ipAddress string) (*Rotation, error) {
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &Rotation{RefreshToken: token}
+	reused := false
+	now := time.Now().UTC()
+	err = pgx.BeginFunc(ctx, s.db, func(tx pgx.Tx) error {
+		var usedAt, revokedAt *time.Time
+		var expiresAt time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT s.id, s.user_id, s.mfa, s.expires_at, s.revoked_at, t.used_at
+			FROM refresh_tokens t
+			JOIN user_sessions s ON s.id = t.session_id
+			WHERE t.token_hash = $1
+			FOR UPDATE`, hashRefreshToken(refreshToken),
+		).Scan(&rotation.SessionID, &rotation.UserID, &rotation.MFA, &expiresAt, &revokedAt, &usedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidRefreshToken
+		}
+		if err != nil {
+			return err
+		}
+		if revokedAt != nil || !now.Before(expiresAt) {
+			return ErrInvalidRefreshToken
+		}
+		if usedAt != nil {
+			reused = true
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET used_at = $1 WHERE token_hash = $2`,
+			now, hashRefreshToken(refreshToken)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO refresh_tokens (token_hash, session_id, issued_at) VALUES ($1, $2, $3)`,
+			hash, rotation.SessionID, now); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE user_sessions
+			SET last_used_at = $1, expires_at = $2, user_agent = $3, ip_address = $4
+			WHERE id = $5`,
+			now, now.Add(s.refreshTTL), userAgent, ipAddress, rotation.SessionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		if err := s.revoke(ctx, `id = $1`, []interface{}{rotation.SessionID}, "refresh token reuse"); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	return rotation, nil
+}
+
+// SetAccessToken records the access token issued for a session and denies
+// the one it replaces. If the session has been revoked in the meantime, the
+// new token is denied too and ErrSessionRevoked is returned.
+func (s *Store) SetAccessToken(ctx context.Context, sessionID uuid.UUID, jti string, expiresAt time.Time) error {
+	var previous string
+	var previousExpiry *time.Time
+	err := s.db.QueryRow(ctx, `
+		UPDATE user_sessions s SET access_jti = $1, access_expires_at = $2
+		FROM user_sessions prev
+		WHERE s.id = $3 AND prev.id = s.id AND s.revoked_at IS NULL
+		RETURNING prev.access_jti, prev.access_expires_at`,
+		jti, expiresAt, sessionID,
+	).Scan(&previous, &previousExpiry)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if err := s.denylist.Revoke(ctx, jti, expiresAt); err != nil {
+			return err
+		}
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+
+	if previous != "" && previousExpiry != nil {
+		return s.denylist.Revoke(ctx, previous, *previousExpiry)
+	}
+	return nil
+}
+
+// Revoke ends one of userID's sessions
+func (s *Store) Revoke(ctx context.Context, userID, sessionID uuid.UUID, reason string) error {
+	return s.revoke(ctx, `id = $1 AND user_id = $2`, []interface{}{sessionID, userID}, reason)
+}
+
+// RevokeAll ends every session of userID and returns how many were active
+func (s *Store) RevokeAll(ctx context.Context, userID uuid.UUID, reason string) (int, error) {
+	var count int
+	err := s.revokeEach(ctx, `user_id = $1`, []interface{}{userID}, reason, func() { count++ })
+	return count, err
+}
+
+// RevokeOthers ends every session of userID except keep and returns how many were active
+func (s *Store) RevokeOthers(ctx context.Context, userID, keep uuid.UUID, reason string) (int, error) {
+	var count int
+	err := s.revokeEach(ctx, `user_id = $1 AND id <> $2`, []interface{}{userID, keep}, reason, func() { count++ })
+	return count, err
+}
+
+func (s *Store) revoke(ctx context.Context, where string, args []interface{}, reason string) error {
+	found := false
+	if err := s.revokeEach(ctx, where, args, reason, func() { found = true }); err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// revokeEach marks the active sessions matching where as revoked and denies
+// their current access tokens
+func (s *Store) revokeEach(ctx context.Context, where string, args []interface{}, reason string, each func()) error {
+	args = append(args, time.Now().UTC(), reason)
+	n := len(args)
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`
+		UPDATE user_sessions SET revoked_at = $%d, revoked_reason = $%d
+		WHERE %s AND revoked_at IS NULL
+		RETURNING access_jti, access_expires_at`, n-1, n, where), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type access struct {
+		jti       string
+		expiresAt *time.Time
+	}
+	var tokens []access
+	for rows.Next() {
+		var a access
+		if err := rows.Scan(&a.jti, &a.expiresAt); err != nil {
+			return err
+		}
+		tokens = append(tokens, a)
+		each()
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, a := range tokens {
+		if a.expiresAt == nil {
+			continue
+		}
+		if err := s.denylist.Revoke(ctx, a.jti, *a.expiresAt); err != nil {
+			return fmt.Errorf("failed to deny access token: %w", err)
+		}
+	}
+	return nil
+}
+
+// List returns the active sessions of userID, most recently used first
+func (s *Store) List(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, user_agent, ip_address, created_at, last_used_at, expires_at, impersonator_id
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.ImpersonatorID); err != nil {
+			return nil, err
+		}
+		session.Device = DescribeDevice(session.UserAgent)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
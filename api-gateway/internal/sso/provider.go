@@ -0,0 +1,205 @@
+// Package sso implements OpenID Connect single sign-on with the
+// authorization code flow and PKCE.
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/twist/api-gateway/internal/models"
+	"golang.org/x/oauth2"
+)
+
+// ErrNotAllowed is returned when an identity is not in any allowed group
+var ErrNotAllowed = errors.New("identity is not a member of an allowed group")
+
+// Config configures a Provider
+type Config struct {
+	IssuerURL     string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	Scopes        []string
+	UsernameClaim string
+	GroupsClaim   string
+	// AdminGroups are the groups whose members get models.RoleAdmin
+	AdminGroups []string
+	// AllowedGroups, when set, restricts login to members of these groups
+	AllowedGroups []string
+	// HTTPClient is used for discovery, token exchange and key fetches,
+	// which lets a mock provider be served in-process. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Identity is a verified identity from the provider's ID token
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Groups        []string
+	Role          models.UserRole
+}
+
+// Provider runs the authorization code flow against an OpenID Connect
+// provider. Discovery happens on first use so that the gateway starts even
+// while the provider is unreachable.
+type Provider struct {
+	cfg Config
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewProvider creates a new Provider
+func NewProvider(cfg Config) *Provider {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &Provider{cfg: cfg}
+}
+
+func (p *Provider) context(ctx context.Context) context.Context {
+	return oidc.ClientContext(ctx, p.cfg.HTTPClient)
+}
+
+// discover fetches the provider metadata once and caches the result
+func (p *Provider) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth != nil {
+		return p.oauth, p.verifier, nil
+	}
+
+	// The provider keeps using this context for background key refreshes,
+	// so it must not be the request context
+	provider, err := oidc.NewProvider(p.context(context.Background()), p.cfg.IssuerURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+
+	scopes := p.cfg.Scopes
+	if !contains(scopes, oidc.ScopeOpenID) {
+		scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+	}
+
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       scopes,
+	}
+	p.verifier = provider.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	return p.oauth, p.verifier, nil
+}
+
+// AuthCodeURL returns the provider URL to send the user to. The PKCE
+// verifier and nonce must be kept to complete the login.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauth, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+// Exchange redeems an authorization code, verifies the ID token and its
+// nonce, and returns the identity with its gateway role
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Identity, error) {
+	oauth, idVerifier, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = p.context(ctx)
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("token response has no id_token")
+	}
+	idToken, err := idVerifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode ID token claims: %w", err)
+	}
+
+	identity := &Identity{
+		Issuer:   idToken.Issuer,
+		Subject:  idToken.Subject,
+		Groups:   stringsClaim(claims[p.cfg.GroupsClaim]),
+		Username: stringClaim(claims[p.cfg.UsernameClaim]),
+	}
+	identity.Email = stringClaim(claims["email"])
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+
+	if len(p.cfg.AllowedGroups) > 0 && !intersects(identity.Groups, p.cfg.AllowedGroups) {
+		return nil, ErrNotAllowed
+	}
+	identity.Role = models.RoleUser
+	if intersects(identity.Groups, p.cfg.AdminGroups) {
+		identity.Role = models.RoleAdmin
+	}
+	return identity, nil
+}
+
+func stringClaim(value interface{}) string {
+	s, _ := value.(string)
+	return strings.TrimSpace(s)
+}
+
+// stringsClaim accepts a claim that is either a list of strings or a single
+// space- or comma-separated string, as providers differ
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	default:
+		return nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func intersects(a, b []string) bool {
+	for _, v := range a {
+		if contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
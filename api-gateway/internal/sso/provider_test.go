@@ -0,0 +1,185 @@
+package sso_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/internal/sso"
+	"github.com/twist/api-gateway/internal/sso/ssotest"
+	"golang.org/x/oauth2"
+)
+
+const redirectURL = "http://gateway.test/api/v1/auth/oidc/callback"
+
+func newProvider(t *testing.T, configure func(*sso.Config)) (*sso.Provider, *ssotest.Provider) {
+	t.Helper()
+
+	mock, err := ssotest.NewProvider("gateway", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(mock.Close)
+
+	cfg := sso.Config{
+		IssuerURL:     mock.Issuer(),
+		ClientID:      mock.ClientID,
+		ClientSecret:  mock.ClientSecret,
+		RedirectURL:   redirectURL,
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		AdminGroups:   []string{"platform-admins"},
+		HTTPClient:    mock.Client(),
+	}
+	if configure != nil {
+		configure(&cfg)
+	}
+	return sso.NewProvider(cfg), mock
+}
+
+// login runs the authorization step and returns the code the provider
+// redirected back with. edit may change the authorization URL first.
+func login(t *testing.T, provider *sso.Provider, mock *ssotest.Provider, nonce, verifier string, claims map[string]interface{}, edit func(url.Values)) string {
+	t.Helper()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+	if edit != nil {
+		parsed, _ := url.Parse(authURL)
+		query := parsed.Query()
+		edit(query)
+		parsed.RawQuery = query.Encode()
+		authURL = parsed.String()
+	}
+
+	callback, err := mock.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	parsed, _ := url.Parse(callback)
+	if got := parsed.Query().Get("state"); got != "state-1" {
+		t.Fatalf("callback state = %q, want state-1", got)
+	}
+	return parsed.Query().Get("code")
+}
+
+func TestExchangeMapsClaims(t *testing.T) {
+	provider, mock := newProvider(t, nil)
+	verifier := oauth2.GenerateVerifier()
+
+	code := login(t, provider, mock, "nonce-1", verifier, map[string]interface{}{
+		"sub":                "user-1",
+		"email":              "ada@example.com",
+		"email_verified":     true,
+		"preferred_username": " ada ",
+		"groups":             []string{"engineering", "platform-admins"},
+	}, nil)
+
+	identity, err := provider.Exchange(context.Background(), code, verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if identity.Issuer != mock.Issuer() || identity.Subject != "user-1" {
+		t.Errorf("identity = %s/%s", identity.Issuer, identity.Subject)
+	}
+	if identity.Username != "ada" || identity.Email != "ada@example.com" || !identity.EmailVerified {
+		t.Errorf("identity claims = %+v", identity)
+	}
+	if identity.Role != models.RoleAdmin {
+		t.Errorf("role = %s, want admin from platform-admins", identity.Role)
+	}
+}
+
+func TestExchangeGroupRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups interface{}
+		role   models.UserRole
+		err    error
+	}{
+		{name: "admin group", groups: []string{"platform-admins"}, role: models.RoleAdmin},
+		{name: "space separated", groups: "staff platform-admins", role: models.RoleAdmin},
+		{name: "other groups", groups: []string{"staff"}, role: models.RoleUser},
+		{name: "not allowed", groups: []string{"contractors"}, err: sso.ErrNotAllowed},
+		{name: "no groups", err: sso.ErrNotAllowed},
+	}
+
+	provider, mock := newProvider(t, func(cfg *sso.Config) {
+		cfg.AllowedGroups = []string{"staff", "platform-admins"}
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]interface{}{"sub": "user-1"}
+			if tt.groups != nil {
+				claims["groups"] = tt.groups
+			}
+			verifier := oauth2.GenerateVerifier()
+			code := login(t, provider, mock, "nonce-1", verifier, claims, nil)
+
+			identity, err := provider.Exchange(context.Background(), code, verifier, "nonce-1")
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange failed: %v", err)
+			}
+			if identity.Role != tt.role {
+				t.Errorf("role = %s, want %s", identity.Role, tt.role)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsNonceMismatch(t *testing.T) {
+	provider, mock := newProvider(t, nil)
+	verifier := oauth2.GenerateVerifier()
+	code := login(t, provider, mock, "nonce-1", verifier, map[string]interface{}{"sub": "user-1"}, nil)
+
+	_, err := provider.Exchange(context.Background(), code, verifier, "nonce-2")
+	if err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("err = %v, want nonce mismatch", err)
+	}
+}
+
+// The provider invalidates a code on its first redemption, so a failed PKCE
+// check surfaces as invalid_grant
+func TestExchangeRequiresPKCEVerifier(t *testing.T) {
+	provider, mock := newProvider(t, nil)
+	verifier := oauth2.GenerateVerifier()
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		code := login(t, provider, mock, "nonce-1", verifier, map[string]interface{}{"sub": "user-1"}, nil)
+		_, err := provider.Exchange(context.Background(), code, oauth2.GenerateVerifier(), "nonce-1")
+		if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+			t.Fatalf("err = %v, want invalid_grant", err)
+		}
+	})
+
+	t.Run("substituted challenge", func(t *testing.T) {
+		code := login(t, provider, mock, "nonce-1", verifier, map[string]interface{}{"sub": "user-1"}, func(query url.Values) {
+			query.Set("code_challenge", oauth2.S256ChallengeFromVerifier(oauth2.GenerateVerifier()))
+		})
+		_, err := provider.Exchange(context.Background(), code, verifier, "nonce-1")
+		if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+			t.Fatalf("err = %v, want invalid_grant", err)
+		}
+	})
+
+	t.Run("code is single use", func(t *testing.T) {
+		code := login(t, provider, mock, "nonce-1", verifier, map[string]interface{}{"sub": "user-1"}, nil)
+		if _, err := provider.Exchange(context.Background(), code, verifier, "nonce-1"); err != nil {
+			t.Fatalf("Exchange failed: %v", err)
+		}
+		if _, err := provider.Exchange(context.Background(), code, verifier, "nonce-1"); err == nil {
+			t.Fatal("a redeemed code was accepted again")
+		}
+	})
+}
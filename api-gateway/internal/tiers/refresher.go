@@ -0,0 +1,254 @@
+package tiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/contracts"
+	"go.uber.org/zap"
+)
+
+// Refresher periodically reads the TWIST holdings of every linked wallet and
+// moves users between tiers. Upgrades apply immediately; downgrades are
+// scheduled and only applied once holdings have stayed low for the grace period.
+type Refresher struct {
+	db         *pgxpool.Pool
+	redis      *redis.Client
+	token      *contracts.TokenService
+	backend    contracts.Backend
+	logger     *zap.Logger
+	thresholds Thresholds
+	interval   time.Duration
+	timeout    time.Duration
+	grace      time.Duration
+}
+
+// NewRefresher creates a new Refresher
+func NewRefresher(db *pgxpool.Pool, redisClient *redis.Client, token *contracts.TokenService, backend contracts.Backend, logger *zap.Logger, thresholds Thresholds, interval, timeout, grace time.Duration) *Refresher {
+	return &Refresher{
+		db:         db,
+		redis:      redisClient,
+		token:      token,
+		backend:    backend,
+		logger:     logger,
+		thresholds: thresholds,
+		interval:   interval,
+		timeout:    timeout,
+		grace:      grace,
+	}
+}
+
+// Run refreshes all users until ctx is cancelled
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.refreshAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Refresher) refreshAll(ctx context.Context) {
+	rows, err := r.db.Query(ctx, `
+		SELECT user_id FROM user_wallets
+		UNION
+		SELECT user_id FROM user_tiers`)
+	if err != nil {
+		r.logger.Error("Failed to load users with tiers", zap.Error(err))
+		return
+	}
+	userIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		r.logger.Error("Failed to load users with tiers", zap.Error(err))
+		return
+	}
+
+	block, err := r.latestBlock(ctx)
+	if err != nil {
+		r.logger.Error("Failed to get latest block for tier refresh", zap.Error(err))
+		return
+	}
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := r.refreshUser(ctx, userID, block, "holdings changed"); err != nil {
+			r.logger.Warn("Failed to refresh user tier", zap.String("user_id", userID.String()), zap.Error(err))
+		}
+	}
+}
+
+// RefreshUser immediately re-reads a user's holdings and updates their tier
+func (r *Refresher) RefreshUser(ctx context.Context, userID uuid.UUID, reason string) error {
+	block, err := r.latestBlock(ctx)
+	if err != nil {
+		return err
+	}
+	return r.refreshUser(ctx, userID, block, reason)
+}
+
+func (r *Refresher) latestBlock(ctx context.Context) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	head, err := r.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return head.Number, nil
+}
+
+// holdings returns the balance plus the unclaimed vested amount of address
+func (r *Refresher) holdings(ctx context.Context, address common.Address, block *big.Int) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: block}
+	contract := r.token.Contract()
+
+	balance, err := contract.BalanceOf(opts, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read balance: %w", err)
+	}
+	vested, err := contract.VestedAmount(opts, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vested amount: %w", err)
+	}
+	claimed, err := contract.ClaimedAmount(opts, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claimed amount: %w", err)
+	}
+
+	total := new(big.Int).Add(balance, vested)
+	if vested.Cmp(claimed) > 0 {
+		return total.Sub(total, claimed), nil
+	}
+	return balance, nil
+}
+
+// refreshUser reads every wallet of a user before touching the database, so
+// an RPC failure leaves the user's tier unchanged rather than downgrading it
+func (r *Refresher) refreshUser(ctx context.Context, userID uuid.UUID, block *big.Int, reason string) error {
+	rows, err := r.db.Query(ctx, `SELECT address FROM user_wallets WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+	addresses, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	total := new(big.Int)
+	perWallet := make(map[string]*big.Int, len(addresses))
+	for _, address := range addresses {
+		amount, err := r.holdings(ctx, common.HexToAddress(address), block)
+		if err != nil {
+			return fmt.Errorf("wallet %s: %w", address, err)
+		}
+		perWallet[address] = amount
+		total.Add(total, amount)
+	}
+
+	var from, to models.Tier
+	now := time.Now().UTC()
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		for address, amount := range perWallet {
+			if _, err := tx.Exec(ctx, `
+				UPDATE user_wallets SET holdings = $1, checked_at = $2 WHERE address = $3`,
+				amount.String(), now, address); err != nil {
+				return err
+			}
+		}
+
+		current := models.TierFree
+		var pending *models.Tier
+		var downgradeAt *time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT tier, pending_tier, downgrade_at FROM user_tiers
+			WHERE user_id = $1 FOR UPDATE`, userID,
+		).Scan(&current, &pending, &downgradeAt)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		computed := r.thresholds.TierFor(total)
+		next := current
+		switch {
+		case computed.Rank() >= current.Rank():
+			next, pending, downgradeAt = computed, nil, nil
+		case r.grace <= 0:
+			next, pending, downgradeAt = computed, nil, nil
+			reason = "holdings fell below tier threshold"
+		case downgradeAt != nil && !now.Before(*downgradeAt):
+			next, pending, downgradeAt = computed, nil, nil
+			reason = "downgrade grace period elapsed"
+		default:
+			if downgradeAt == nil {
+				at := now.Add(r.grace)
+				downgradeAt = &at
+			}
+			pending = &computed
+		}
+
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_tiers (user_id, tier, holdings, pending_tier, downgrade_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (user_id) DO UPDATE SET
+				tier = EXCLUDED.tier,
+				holdings = EXCLUDED.holdings,
+				pending_tier = EXCLUDED.pending_tier,
+				downgrade_at = EXCLUDED.downgrade_at,
+				updated_at = EXCLUDED.updated_at`,
+			userID, next, total.String(), pending, downgradeAt, now); err != nil {
+			return err
+		}
+
+		if next == current {
+			return nil
+		}
+		from, to = current, next
+		_, err = tx.Exec(ctx, `
+			INSERT INTO tier_changes (user_id, from_tier, to_tier, holdings, reason, changed_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			userID, current, next, total.String(), reason, now)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if from != to {
+		r.logger.Info("User tier changed",
+			zap.String("user_id", userID.String()),
+			zap.String("from", string(from)),
+			zap.String("to", string(to)),
+		)
+		if err := Invalidate(ctx, r.redis, userID); err != nil {
+			r.logger.Warn("Failed to invalidate cached tier", zap.String("user_id", userID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// NormalizeAddress returns the lower-case hex form addresses are stored in
+func NormalizeAddress(address common.Address) string {
+	return strings.ToLower(address.Hex())
+}
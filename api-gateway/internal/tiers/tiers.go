@@ -0,0 +1,139 @@
+// Package tiers maps the TWIST held by users' linked wallets to API tiers
+// and resolves the tier of a user for rate limiting.
+package tiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+)
+
+// cacheTTL bounds how long a tier is served from Redis after it changes
+// without an explicit invalidation
+const cacheTTL = time.Minute
+
+// Thresholds are the minimum holdings, in token base units, for each paid tier
+type Thresholds struct {
+	Basic      *big.Int
+	Pro        *big.Int
+	Enterprise *big.Int
+}
+
+// NewThresholds converts whole-token thresholds to base units
+func NewThresholds(basic, pro, enterprise uint64) Thresholds {
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(models.TokenDecimals), nil)
+	tokens := func(n uint64) *big.Int {
+		return new(big.Int).Mul(new(big.Int).SetUint64(n), unit)
+	}
+	return Thresholds{Basic: tokens(basic), Pro: tokens(pro), Enterprise: tokens(enterprise)}
+}
+
+// TierFor returns the highest tier whose threshold holdings meets
+func (t Thresholds) TierFor(holdings *big.Int) models.Tier {
+	switch {
+	case holdings.Cmp(t.Enterprise) >= 0:
+		return models.TierEnterprise
+	case holdings.Cmp(t.Pro) >= 0:
+		return models.TierPro
+	case holdings.Cmp(t.Basic) >= 0:
+		return models.TierBasic
+	default:
+		return models.TierFree
+	}
+}
+
+// Resolver looks up users' current tiers, caching them in Redis
+type Resolver struct {
+	db    *pgxpool.Pool
+	redis *redis.Client
+}
+
+// NewResolver creates a new Resolver
+func NewResolver(db *pgxpool.Pool, redisClient *redis.Client) *Resolver {
+	return &Resolver{db: db, redis: redisClient}
+}
+
+// Tier returns the current tier of a user. Users without a tier record are on the free tier.
+func (r *Resolver) Tier(ctx context.Context, userID uuid.UUID) (models.Tier, error) {
+	key := cacheKey(userID)
+	if cached, err := r.redis.Get(ctx, key).Result(); err == nil {
+		return models.Tier(cached), nil
+	} else if err != redis.Nil {
+		return "", fmt.Errorf("failed to read cached tier: %w", err)
+	}
+
+	tier := models.TierFree
+	err := r.db.QueryRow(ctx, `SELECT tier FROM user_tiers WHERE user_id = $1`, userID).Scan(&tier)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("failed to load tier: %w", err)
+	}
+
+	if err := r.redis.Set(ctx, key, string(tier), cacheTTL).Err(); err != nil {
+		return tier, fmt.Errorf("failed to cache tier: %w", err)
+	}
+	return tier, nil
+}
+
+// Invalidate drops the cached tier of a user
+func Invalidate(ctx context.Context, redisClient *redis.Client, userID uuid.UUID) error {
+	return redisClient.Del(ctx, cacheKey(userID)).Err()
+}
+
+func cacheKey(userID uuid.UUID) string {
+	return "tier:" + userID.String()
+}
+
+// LoadUserTier loads a user's tier record and its most recent changes
+func LoadUserTier(ctx context.Context, db *pgxpool.Pool, userID uuid.UUID, changeLimit int) (*models.UserTierResponse, error) {
+	response := &models.UserTierResponse{
+		Tier:     models.TierFree,
+		Holdings: "0",
+		Decimals: models.TokenDecimals,
+		Changes:  []models.TierChange{},
+	}
+
+	var updatedAt time.Time
+	err := db.QueryRow(ctx, `
+		SELECT tier, holdings::text, pending_tier, downgrade_at, updated_at
+		FROM user_tiers WHERE user_id = $1`, userID,
+	).Scan(&response.Tier, &response.Holdings, &response.PendingTier, &response.DowngradeAt, &updatedAt)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, err
+	default:
+		response.UpdatedAt = &updatedAt
+	}
+
+	response.Limits = models.TierLimits{
+		RequestsPerMinute: response.Tier.RequestsPerMinute(),
+		RPCMethods:        response.Tier.RPCMethods(),
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT from_tier, to_tier, holdings::text, reason, changed_at
+		FROM tier_changes WHERE user_id = $1
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $2`, userID, changeLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var change models.TierChange
+		if err := rows.Scan(&change.FromTier, &change.ToTier, &change.Holdings, &change.Reason, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		response.Changes = append(response.Changes, change)
+	}
+	return response, rows.Err()
+}
@@ -0,0 +1,205 @@
+// Package token serves TWIST token vesting and supply data read from the
+// TwistToken contract, cached in Redis per block.
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/redis/go-redis/v9"
+	"github.com/twist/api-gateway/internal/models"
+	"github.com/twist/api-gateway/pkg/contracts"
+	"go.uber.org/zap"
+)
+
+// Service reads vesting and supply data from the TwistToken contract. All
+// values in a response are read at the same block, and responses are cached
+// under a key that includes the block number.
+type Service struct {
+	token       *contracts.TokenService
+	backend     contracts.Backend
+	redis       *redis.Client
+	logger      *zap.Logger
+	timeout     time.Duration
+	cacheTTL    time.Duration
+	curvePoints int
+}
+
+// NewService creates a new Service for the token deployed at address
+func NewService(address common.Address, backend contracts.Backend, redisClient *redis.Client, logger *zap.Logger, timeout, cacheTTL time.Duration, curvePoints int) (*Service, error) {
+	token, err := contracts.NewTokenService(address, backend, timeout, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if curvePoints < 1 {
+		curvePoints = 1
+	}
+
+	return &Service{
+		token:       token,
+		backend:     backend,
+		redis:       redisClient,
+		logger:      logger,
+		timeout:     timeout,
+		cacheTTL:    cacheTTL,
+		curvePoints: curvePoints,
+	}, nil
+}
+
+// Vesting returns the vesting position of beneficiary at the latest block
+func (s *Service) Vesting(ctx context.Context, beneficiary common.Address) (*models.TokenVesting, error) {
+	head, err := s.head(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("token:vesting:%s:%d", strings.ToLower(beneficiary.Hex()), head.Number.Uint64())
+	var vesting models.TokenVesting
+	if s.cacheGet(ctx, key, &vesting) {
+		return &vesting, nil
+	}
+
+	position, err := s.token.Vesting(ctx, beneficiary, head.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vesting: %w", err)
+	}
+
+	blockTime := time.Unix(int64(head.Time), 0).UTC()
+	unlocked := unlockedAt(position.Vested, position.StartTime, position.EndTime, blockTime)
+
+	vesting = models.TokenVesting{
+		Address:      beneficiary.Hex(),
+		BlockNumber:  head.Number.Uint64(),
+		BlockTime:    blockTime,
+		Decimals:     models.TokenDecimals,
+		Vested:       position.Vested.String(),
+		Unlocked:     unlocked.String(),
+		Claimed:      position.Claimed.String(),
+		Claimable:    position.Claimable.String(),
+		Locked:       new(big.Int).Sub(position.Vested, unlocked).String(),
+		VestingStart: position.StartTime,
+		VestingEnd:   position.EndTime,
+		UnlockCurve:  s.unlockCurve(position.Vested, position.StartTime, position.EndTime),
+	}
+
+	s.cacheSet(ctx, key, vesting)
+	return &vesting, nil
+}
+
+// Supply returns the token supply at the latest block
+func (s *Service) Supply(ctx context.Context) (*models.TokenSupply, error) {
+	head, err := s.head(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("token:supply:%d", head.Number.Uint64())
+	var supply models.TokenSupply
+	if s.cacheGet(ctx, key, &supply) {
+		return &supply, nil
+	}
+
+	values, err := s.token.Supply(ctx, head.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read supply: %w", err)
+	}
+
+	var ratio float64
+	if values.MaxSupply.Sign() > 0 {
+		ratio, _ = new(big.Rat).SetFrac(values.TotalSupply, values.MaxSupply).Float64()
+	}
+
+	supply = models.TokenSupply{
+		BlockNumber:      head.Number.Uint64(),
+		BlockTime:        time.Unix(int64(head.Time), 0).UTC(),
+		Decimals:         models.TokenDecimals,
+		MaxSupply:        values.MaxSupply.String(),
+		Circulating:      values.TotalSupply.String(),
+		Unminted:         new(big.Int).Sub(values.MaxSupply, values.TotalSupply).String(),
+		TotalVested:      values.TotalVested.String(),
+		CirculatingRatio: ratio,
+	}
+
+	s.cacheSet(ctx, key, supply)
+	return &supply, nil
+}
+
+// head returns the latest block header, which pins the block all reads use
+func (s *Service) head(ctx context.Context) (*types.Header, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	head, err := s.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	return head, nil
+}
+
+// unlockCurve samples the linear unlock schedule at evenly spaced times from
+// the vesting start to the vesting end
+func (s *Service) unlockCurve(vested *big.Int, start, end time.Time) []models.UnlockPoint {
+	step := end.Sub(start) / time.Duration(s.curvePoints)
+	points := make([]models.UnlockPoint, 0, s.curvePoints+1)
+	for i := 0; i <= s.curvePoints; i++ {
+		at := start.Add(step * time.Duration(i))
+		if i == s.curvePoints {
+			at = end
+		}
+		points = append(points, models.UnlockPoint{
+			Timestamp: at,
+			Unlocked:  unlockedAt(vested, start, end, at).String(),
+		})
+	}
+	return points
+}
+
+// unlockedAt mirrors the contract's linear vesting formula
+func unlockedAt(vested *big.Int, start, end, at time.Time) *big.Int {
+	if !at.Before(end) {
+		return new(big.Int).Set(vested)
+	}
+	if !at.After(start) {
+		return new(big.Int)
+	}
+
+	elapsed := big.NewInt(at.Unix() - start.Unix())
+	period := big.NewInt(end.Unix() - start.Unix())
+	unlocked := new(big.Int).Mul(vested, elapsed)
+	return unlocked.Quo(unlocked, period)
+}
+
+// cacheGet loads a cached response. Cache failures are logged and treated as misses.
+func (s *Service) cacheGet(ctx context.Context, key string, dest interface{}) bool {
+	data, err := s.redis.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return false
+	}
+	if err != nil {
+		s.logger.Warn("Failed to read token cache", zap.String("key", key), zap.Error(err))
+		return false
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		s.logger.Warn("Failed to decode token cache entry", zap.String("key", key), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// cacheSet stores a response. Cache failures are logged and ignored.
+func (s *Service) cacheSet(ctx context.Context, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		s.logger.Warn("Failed to encode token cache entry", zap.String("key", key), zap.Error(err))
+		return
+	}
+	if err := s.redis.Set(ctx, key, data, s.cacheTTL).Err(); err != nil {
+		s.logger.Warn("Failed to write token cache", zap.String("key", key), zap.Error(err))
+	}
+}
@@ -0,0 +1,358 @@
+// Package tokens signs and verifies the gateway's access tokens. With RS256
+// or EdDSA, signing keys are shared through the database, rotated on a
+// schedule and published as a JWKS so other services can verify tokens
+// without the gateway's secret.
+package tokens
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/twist/api-gateway/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// leeway is the clock skew tolerated when validating time claims, and the
+// extra time a retired key stays verifiable after its last token expires
+const leeway = 30 * time.Second
+
+// Options configures a Manager
+type Options struct {
+	// Algorithm is HS256, RS256 or EdDSA. Tokens signed with any other
+	// algorithm are rejected.
+	Algorithm string
+	// Secret signs HS256 tokens and encrypts stored private keys
+	Secret []byte
+	// Issuer is set as iss and required when verifying
+	Issuer string
+	// Audience is the gateway's own audience, required when verifying
+	Audience string
+	// ExtraAudiences are the other services tokens are issued for
+	ExtraAudiences []string
+	// TokenTTL is the lifetime of issued tokens
+	TokenTTL time.Duration
+	// RotationInterval is how long a key signs before it is replaced
+	RotationInterval time.Duration
+	// ReloadInterval is how often keys are re-read and rotation is checked
+	ReloadInterval time.Duration
+}
+
+// Manager signs and verifies access tokens
+type Manager struct {
+	db     *pgxpool.Pool
+	logger *zap.Logger
+	opts   Options
+	cipher *keyCipher
+
+	mu   sync.RWMutex
+	keys map[string]*signingKey
+}
+
+// NewManager creates a Manager. For asymmetric algorithms it creates the
+// first signing key if needed and loads all verifiable keys.
+func NewManager(ctx context.Context, db *pgxpool.Pool, logger *zap.Logger, opts Options) (*Manager, error) {
+	switch opts.Algorithm {
+	case AlgorithmHS256, AlgorithmRS256, AlgorithmEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", opts.Algorithm)
+	}
+
+	cipher, err := newKeyCipher(opts.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		db:     db,
+		logger: logger,
+		opts:   opts,
+		cipher: cipher,
+		keys:   make(map[string]*signingKey),
+	}
+	if m.symmetric() {
+		return m, nil
+	}
+
+	if err := m.rotate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to rotate signing keys: %w", err)
+	}
+	if err := m.reload(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	return m, nil
+}
+
+func (m *Manager) symmetric() bool {
+	return m.opts.Algorithm == AlgorithmHS256
+}
+
+// TokenTTL returns the lifetime of issued tokens
+func (m *Manager) TokenTTL() time.Duration {
+	return m.opts.TokenTTL
+}
+
+// Run rotates and reloads keys until ctx is cancelled
+func (m *Manager) Run(ctx context.Context) {
+	if m.symmetric() {
+		return
+	}
+
+	ticker := time.NewTicker(m.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := m.rotate(ctx); err != nil {
+			m.logger.Error("Failed to rotate JWT signing keys", zap.Error(err))
+		}
+		if err := m.reload(ctx); err != nil {
+			m.logger.Error("Failed to reload JWT signing keys", zap.Error(err))
+		}
+	}
+}
+
+// rotate creates a new signing key when the current one is due for
+// replacement and deletes keys that can no longer verify any token. It runs
+// under an advisory lock so that only one gateway instance rotates at a time.
+func (m *Manager) rotate(ctx context.Context) error {
+	return pgx.BeginFunc(ctx, m.db, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('jwt_signing_keys'))`); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if _, err := tx.Exec(ctx, `DELETE FROM jwt_signing_keys WHERE expires_at < $1`, now); err != nil {
+			return err
+		}
+
+		// The newest key of the configured algorithm is the current or next signing key
+		var createdAt time.Time
+		err := tx.QueryRow(ctx, `
+			SELECT created_at FROM jwt_signing_keys
+			WHERE algorithm = $1 AND retires_at IS NULL
+			ORDER BY activates_at DESC LIMIT 1`, m.opts.Algorithm,
+		).Scan(&createdAt)
+		hasKey := err == nil
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		if hasKey && now.Before(createdAt.Add(m.opts.RotationInterval)) {
+			return nil
+		}
+
+		// A replacement is published for two reload intervals before it signs
+		// so every instance can verify its tokens. With no key of the
+		// configured algorithm (first start or an algorithm change) it signs
+		// immediately.
+		activates := now
+		if hasKey {
+			activates = now.Add(2 * m.opts.ReloadInterval)
+		}
+
+		private, err := generateKey(m.opts.Algorithm)
+		if err != nil {
+			return err
+		}
+		privateDER, publicDER, err := marshalKeyPair(private)
+		if err != nil {
+			return err
+		}
+		sealed, err := m.cipher.seal(privateDER)
+		if err != nil {
+			return err
+		}
+
+		// Keys being replaced stop signing when the new key activates and
+		// stay verifiable until the last token they signed has expired
+		expires := activates.Add(m.opts.TokenTTL + leeway)
+		if _, err := tx.Exec(ctx, `
+			UPDATE jwt_signing_keys SET retires_at = $1, expires_at = $2
+			WHERE retires_at IS NULL`, activates, expires); err != nil {
+			return err
+		}
+
+		kid := uuid.NewString()
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO jwt_signing_keys (kid, algorithm, private_key, public_key, created_at, activates_at)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			kid, m.opts.Algorithm, sealed, publicDER, now, activates); err != nil {
+			return err
+		}
+
+		m.logger.Info("Created JWT signing key",
+			zap.String("kid", kid),
+			zap.String("algorithm", m.opts.Algorithm),
+			zap.Time("activates_at", activates),
+		)
+		return nil
+	})
+}
+
+// reload replaces the in-memory key set with the verifiable keys in the database
+func (m *Manager) reload(ctx context.Context) error {
+	rows, err := m.db.Query(ctx, `
+		SELECT kid, algorithm, private_key, public_key, created_at, activates_at, retires_at, expires_at
+		FROM jwt_signing_keys
+		WHERE expires_at IS NULL OR expires_at > NOW()`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]*signingKey)
+	for rows.Next() {
+		var key signingKey
+		var sealed, publicDER []byte
+		if err := rows.Scan(&key.kid, &key.algorithm, &sealed, &publicDER,
+			&key.createdAt, &key.activatesAt, &key.retiresAt, &key.expiresAt); err != nil {
+			return err
+		}
+
+		privateDER, err := m.cipher.open(sealed)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt key %s: %w", key.kid, err)
+		}
+		if key.private, err = parsePrivateKey(privateDER); err != nil {
+			return fmt.Errorf("failed to parse key %s: %w", key.kid, err)
+		}
+		if key.public, err = x509.ParsePKIXPublicKey(publicDER); err != nil {
+			return fmt.Errorf("failed to parse public key %s: %w", key.kid, err)
+		}
+		keys[key.kid] = &key
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+// currentKey returns the key to sign with: the most recently activated key
+// of the configured algorithm that is within its signing window
+func (m *Manager) currentKey() (*signingKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	var current *signingKey
+	for _, key := range m.keys {
+		if key.algorithm != m.opts.Algorithm || !key.canSign(now) {
+			continue
+		}
+		if current == nil || key.activatesAt.After(current.activatesAt) {
+			current = key
+		}
+	}
+	if current == nil {
+		return nil, errors.New("no active signing key")
+	}
+	return current, nil
+}
+
+// Sign sets the issuer, audience and issue and expiry times on claims and
+// returns the signed token and its expiry
+func (m *Manager) Sign(claims *middleware.JWTClaims) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.opts.TokenTTL)
+	claims.Issuer = m.opts.Issuer
+	claims.Audience = append(jwt.ClaimStrings{m.opts.Audience}, m.opts.ExtraAudiences...)
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	if m.symmetric() {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.opts.Secret)
+		return token, expiresAt, err
+	}
+
+	key, err := m.currentKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.kid
+	signed, err := token.SignedString(key.private)
+	return signed, expiresAt, err
+}
+
+// Parse verifies a token's signature, algorithm, issuer, audience and expiry
+// and returns its claims
+func (m *Manager) Parse(tokenString string) (*middleware.JWTClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{m.opts.Algorithm}),
+		jwt.WithIssuer(m.opts.Issuer),
+		jwt.WithAudience(m.opts.Audience),
+		jwt.WithLeeway(leeway),
+	)
+
+	var claims middleware.JWTClaims
+	_, err := parser.ParseWithClaims(tokenString, &claims, m.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt == nil {
+		return nil, errors.New("token has no expiry")
+	}
+	return &claims, nil
+}
+
+func (m *Manager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if m.symmetric() {
+		return m.opts.Secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if key.algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// JWKS returns the public keys that verify current tokens, including keys
+// that will start signing soon
+func (m *Manager) JWKS() JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]*signingKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].activatesAt.After(keys[j].activatesAt)
+	})
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range keys {
+		jwk, err := key.jwk()
+		if err != nil {
+			m.logger.Warn("Failed to encode JWK", zap.String("kid", key.kid), zap.Error(err))
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
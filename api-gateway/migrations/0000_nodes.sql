@@ -0,0 +1,20 @@
+-- Blockchain nodes, as models.BlockchainNode holds them. Later migrations add
+-- columns; databases created before the gateway owned its schema already
+-- have this table.
+CREATE TABLE IF NOT EXISTS nodes (
+    id                  UUID PRIMARY KEY,
+    name                TEXT NOT NULL,
+    chain_type          TEXT NOT NULL,
+    endpoint_url        TEXT NOT NULL,
+    status              TEXT NOT NULL,
+    version             TEXT NOT NULL DEFAULT '',
+    sync_status         JSONB NOT NULL DEFAULT '{}',
+    created_at          TIMESTAMPTZ NOT NULL,
+    updated_at          TIMESTAMPTZ NOT NULL,
+    region              TEXT NOT NULL,
+    provider            TEXT NOT NULL,
+    performance_metrics JSONB,
+    config              JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_nodes_status ON nodes (status);
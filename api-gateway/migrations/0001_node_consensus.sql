@@ -0,0 +1,4 @@
+-- Beacon (consensus layer) endpoint paired with an Ethereum execution node,
+-- and the last state observed by the consensus monitor.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS consensus_endpoint_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS consensus_status JSONB;
@@ -0,0 +1,17 @@
+-- Latest fee market and txpool snapshot per node, used by the gas oracle.
+CREATE TABLE IF NOT EXISTS node_gas_samples (
+    node_id                UUID PRIMARY KEY REFERENCES nodes(id) ON DELETE CASCADE,
+    chain_type             TEXT NOT NULL,
+    gas_price_gwei         DOUBLE PRECISION NOT NULL,
+    max_priority_fee_gwei  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    base_fee_gwei          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    priority_fee_p10_gwei  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    priority_fee_p50_gwei  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    priority_fee_p90_gwei  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    txpool_pending         BIGINT NOT NULL DEFAULT 0,
+    txpool_queued          BIGINT NOT NULL DEFAULT 0,
+    sampled_at             TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_gas_samples_chain_sampled
+    ON node_gas_samples (chain_type, sampled_at);
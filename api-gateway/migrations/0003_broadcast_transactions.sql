@@ -0,0 +1,21 @@
+-- Signed transactions fanned out by the gateway and tracked to finality.
+CREATE TABLE IF NOT EXISTS broadcast_transactions (
+    tx_hash         TEXT PRIMARY KEY,
+    chain_type      TEXT NOT NULL,
+    user_id         UUID NOT NULL,
+    raw_transaction TEXT NOT NULL,
+    from_address    TEXT NOT NULL DEFAULT '',
+    nonce           BIGINT,
+    status          TEXT NOT NULL,
+    block_number    BIGINT,
+    block_hash      TEXT NOT NULL DEFAULT '',
+    receipt_status  BIGINT,
+    webhook_url     TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL,
+    last_seen_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcast_transactions_open
+    ON broadcast_transactions (chain_type)
+    WHERE status IN ('pending', 'included');
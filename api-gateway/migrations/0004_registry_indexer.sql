@@ -0,0 +1,14 @@
+-- Origin of each node and its identity in the BlockchainNodeRegistry contract.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'gateway';
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS onchain_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS onchain_owner TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nodes_onchain_id ON nodes (onchain_id) WHERE onchain_id <> '';
+
+-- Last block processed by each chain follower, with its hash for reorg detection.
+CREATE TABLE IF NOT EXISTS indexer_checkpoints (
+    name         TEXT PRIMARY KEY,
+    block_number BIGINT NOT NULL,
+    block_hash   TEXT NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,14 @@
+-- Nodes that opt in to having their gateway-observed status published to the
+-- BlockchainNodeRegistry contract, and the state last written on-chain.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS publish_onchain BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- No foreign key: a row outliving its node is how the publisher knows to deregister it.
+CREATE TABLE IF NOT EXISTS registry_publications (
+    node_id       UUID PRIMARY KEY,
+    onchain_id    TEXT NOT NULL,
+    status        TEXT NOT NULL,
+    current_block BIGINT NOT NULL DEFAULT 0,
+    highest_block BIGINT NOT NULL DEFAULT 0,
+    last_tx_hash  TEXT NOT NULL DEFAULT '',
+    published_at  TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,34 @@
+-- Ethereum addresses users have proven control of, with the TWIST holdings
+-- last read for each.
+CREATE TABLE IF NOT EXISTS user_wallets (
+    address    TEXT PRIMARY KEY,
+    user_id    UUID NOT NULL,
+    holdings   NUMERIC(78, 0) NOT NULL DEFAULT 0,
+    linked_at  TIMESTAMPTZ NOT NULL,
+    checked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_wallets_user_id ON user_wallets (user_id);
+
+-- The API tier each user is on. A lower computed tier is only applied once
+-- downgrade_at has passed, so pending_tier tells the user what is coming.
+CREATE TABLE IF NOT EXISTS user_tiers (
+    user_id      UUID PRIMARY KEY,
+    tier         TEXT NOT NULL,
+    holdings     NUMERIC(78, 0) NOT NULL DEFAULT 0,
+    pending_tier TEXT,
+    downgrade_at TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tier_changes (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    UUID NOT NULL,
+    from_tier  TEXT NOT NULL,
+    to_tier    TEXT NOT NULL,
+    holdings   NUMERIC(78, 0) NOT NULL,
+    reason     TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tier_changes_user_id ON tier_changes (user_id, changed_at DESC);
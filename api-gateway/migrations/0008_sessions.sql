@@ -0,0 +1,29 @@
+-- Login sessions. Each holds one chain of rotating refresh tokens and the
+-- jti of the latest access token issued for it, so revoking the session can
+-- deny that token too.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id                UUID PRIMARY KEY,
+    user_id           UUID NOT NULL,
+    user_agent        TEXT NOT NULL DEFAULT '',
+    ip_address        TEXT NOT NULL DEFAULT '',
+    access_jti        TEXT NOT NULL DEFAULT '',
+    access_expires_at TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL,
+    last_used_at      TIMESTAMPTZ NOT NULL,
+    expires_at        TIMESTAMPTZ NOT NULL,
+    revoked_at        TIMESTAMPTZ,
+    revoked_reason    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions (user_id) WHERE revoked_at IS NULL;
+
+-- Refresh tokens are stored hashed. A token presented after it has already
+-- been rotated (used_at set) is treated as stolen and revokes its session.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash TEXT PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+    issued_at  TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
@@ -0,0 +1,14 @@
+-- Asymmetric JWT signing keys. Private keys are PKCS#8 DER encrypted with
+-- AES-GCM under a key derived from the JWT secret; public keys are PKIX DER.
+-- A key is published in the JWKS from created_at, signs from activates_at
+-- until retires_at, and verifies until expires_at.
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    kid          TEXT PRIMARY KEY,
+    algorithm    TEXT NOT NULL,
+    private_key  BYTEA NOT NULL,
+    public_key   BYTEA NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL,
+    activates_at TIMESTAMPTZ NOT NULL,
+    retires_at   TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ
+);
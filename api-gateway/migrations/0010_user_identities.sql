@@ -0,0 +1,14 @@
+-- External identities that log in to gateway accounts through OpenID
+-- Connect, keyed by the provider's issuer and subject.
+CREATE TABLE IF NOT EXISTS user_identities (
+    issuer        TEXT NOT NULL,
+    subject       TEXT NOT NULL,
+    user_id       UUID NOT NULL,
+    email         TEXT NOT NULL DEFAULT '',
+    groups        TEXT[] NOT NULL DEFAULT '{}',
+    created_at    TIMESTAMPTZ NOT NULL,
+    last_login_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
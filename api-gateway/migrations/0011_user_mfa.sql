@@ -0,0 +1,30 @@
+-- TOTP second factors. The secret is AES-GCM encrypted with a key derived
+-- from the JWT secret. enabled_at stays NULL until the user proves they can
+-- generate codes; last_used_step rejects replay of an accepted code.
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id        UUID PRIMARY KEY,
+    secret         BYTEA NOT NULL,
+    enabled_at     TIMESTAMPTZ,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL
+);
+
+-- Single-use recovery codes, stored hashed
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    user_id    UUID NOT NULL,
+    code_hash  TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+-- Roles whose members must use a second factor to reach protected routes
+CREATE TABLE IF NOT EXISTS mfa_role_requirements (
+    role       TEXT PRIMARY KEY,
+    updated_by UUID,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Whether a session was established with a second factor, so refreshed
+-- access tokens keep the claim
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS mfa BOOLEAN NOT NULL DEFAULT FALSE;
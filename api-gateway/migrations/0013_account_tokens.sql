@@ -0,0 +1,18 @@
+-- When the user's current email address was verified. Changing the address
+-- clears it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+-- Single-use tokens mailed for email verification and password reset. The
+-- token itself is signed and carries its ID; this row makes it single use.
+-- email pins a verification token to the address it was sent to.
+CREATE TABLE IF NOT EXISTS account_tokens (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL,
+    purpose    TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_tokens_user_id ON account_tokens (user_id, purpose) WHERE used_at IS NULL;
@@ -0,0 +1,13 @@
+-- Disabled users can't log in and their sessions and API keys stop working.
+-- password_reset_required blocks password login until the user resets it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Admins search users by username or email prefix
+CREATE INDEX IF NOT EXISTS idx_users_username_lower ON users (lower(username) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_created_at ON users (created_at, id);
+
+-- Sessions an admin started as the user for support
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS impersonator_id UUID;
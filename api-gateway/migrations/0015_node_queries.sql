@@ -0,0 +1,45 @@
+-- Kubernetes-style labels, matched by label selectors.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+
+-- The status reported to clients, with the paired consensus client's state
+-- rolled in as models.BlockchainNode.ApplyConsensusStatus does, so nodes can
+-- be filtered and sorted by what users see.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS effective_status TEXT GENERATED ALWAYS AS (
+    CASE
+        WHEN consensus_endpoint_url = '' OR status <> 'running' THEN status
+        WHEN consensus_status IS NULL THEN 'starting'
+        WHEN consensus_status->>'health' = 'unavailable'
+            OR COALESCE((consensus_status->>'el_offline')::boolean, FALSE) THEN 'error'
+        WHEN consensus_status->>'health' = 'syncing'
+            OR COALESCE((consensus_status->>'is_syncing')::boolean, FALSE)
+            OR COALESCE((consensus_status->>'is_optimistic')::boolean, FALSE) THEN 'syncing'
+        ELSE status
+    END
+) STORED;
+
+-- Blocks behind the chain head, for sorting by lag.
+ALTER TABLE nodes ADD COLUMN IF NOT EXISTS sync_lag BIGINT GENERATED ALWAYS AS (
+    GREATEST(
+        COALESCE((sync_status->>'highest_block')::numeric, 0)
+            - COALESCE((sync_status->>'current_block')::numeric, 0),
+        0
+    )::bigint
+) STORED;
+
+-- Filters
+CREATE INDEX IF NOT EXISTS idx_nodes_chain_type_status ON nodes (chain_type, effective_status);
+CREATE INDEX IF NOT EXISTS idx_nodes_effective_status ON nodes (effective_status);
+CREATE INDEX IF NOT EXISTS idx_nodes_region ON nodes (region);
+CREATE INDEX IF NOT EXISTS idx_nodes_provider ON nodes (provider);
+CREATE INDEX IF NOT EXISTS idx_nodes_version ON nodes (version);
+CREATE INDEX IF NOT EXISTS idx_nodes_labels ON nodes USING GIN (labels);
+
+-- Name substring search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_nodes_name_trgm ON nodes USING GIN (lower(name) gin_trgm_ops);
+
+-- Keyset pagination, each sort key with the id tie-breaker
+CREATE INDEX IF NOT EXISTS idx_nodes_created_at ON nodes (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_nodes_updated_at ON nodes (updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_nodes_name ON nodes (name, id);
+CREATE INDEX IF NOT EXISTS idx_nodes_sync_lag ON nodes (sync_lag, id);
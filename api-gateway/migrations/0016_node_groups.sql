@@ -0,0 +1,22 @@
+-- Named sets of nodes. A group holds the nodes matching its label selector
+-- together with its explicit members; either may be empty.
+CREATE TABLE IF NOT EXISTS node_groups (
+    id          UUID PRIMARY KEY,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    selector    TEXT NOT NULL DEFAULT '',
+    created_by  UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL,
+    updated_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_node_groups_name ON node_groups (lower(name));
+
+CREATE TABLE IF NOT EXISTS node_group_members (
+    group_id UUID NOT NULL REFERENCES node_groups(id) ON DELETE CASCADE,
+    node_id  UUID NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (group_id, node_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_group_members_node ON node_group_members (node_id);
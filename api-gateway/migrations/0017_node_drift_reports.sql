@@ -0,0 +1,11 @@
+-- Latest comparison of each running node with its stored config and version,
+-- written by the drift monitor. severity is the last known severity, which a
+-- check that couldn't reach the node leaves as it was.
+CREATE TABLE IF NOT EXISTS node_drift_reports (
+    node_id    UUID PRIMARY KEY REFERENCES nodes(id) ON DELETE CASCADE,
+    severity   TEXT NOT NULL,
+    report     JSONB NOT NULL,
+    checked_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_drift_reports_severity ON node_drift_reports (severity);
@@ -0,0 +1,4 @@
+-- Whether each API key was created from a session that passed MFA. Keys
+-- created before this was recorded count as not, so members of roles that
+-- require MFA must replace them.
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS mfa BOOLEAN NOT NULL DEFAULT FALSE;
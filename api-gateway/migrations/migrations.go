@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL schema changes owned by the API gateway.
+// Files are applied in lexical order by database.Migrate, so new migrations
+// must use the next free numeric prefix.
+package migrations
+
+import "embed"
+
+// FS holds all embedded *.sql migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,5 @@
+// Package gatewayv1 contains the generated gRPC services of the API gateway.
+// The definitions live in proto/twist/gateway/v1; regenerate with go generate.
+package gatewayv1
+
+//go:generate protoc -I ../../../../proto --go_out=../../../.. --go_opt=module=github.com/twist/api-gateway --go-grpc_out=../../../.. --go-grpc_opt=module=github.com/twist/api-gateway twist/gateway/v1/nodes.proto twist/gateway/v1/users.proto twist/gateway/v1/api_keys.proto
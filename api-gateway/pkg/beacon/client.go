@@ -0,0 +1,149 @@
+package beacon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Health values reported by the /eth/v1/node/health endpoint
+const (
+	HealthReady       = "ready"
+	HealthSyncing     = "syncing"
+	HealthUnavailable = "unavailable"
+)
+
+// Client is a minimal client for the standard Ethereum beacon node API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// SyncingStatus is the payload of /eth/v1/node/syncing
+type SyncingStatus struct {
+	HeadSlot     uint64
+	SyncDistance uint64
+	IsSyncing    bool
+	IsOptimistic bool
+	ELOffline    bool
+}
+
+// NewClient creates a new beacon API client for the given base URL
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Syncing returns the node's sync state
+func (c *Client) Syncing(ctx context.Context) (*SyncingStatus, error) {
+	var resp struct {
+		Data struct {
+			HeadSlot     string `json:"head_slot"`
+			SyncDistance string `json:"sync_distance"`
+			IsSyncing    bool   `json:"is_syncing"`
+			IsOptimistic bool   `json:"is_optimistic"`
+			ELOffline    bool   `json:"el_offline"`
+		} `json:"data"`
+	}
+	if err := c.getJSON(ctx, "/eth/v1/node/syncing", &resp); err != nil {
+		return nil, err
+	}
+
+	headSlot, err := parseUint(resp.Data.HeadSlot)
+	if err != nil {
+		return nil, fmt.Errorf("invalid head_slot: %w", err)
+	}
+	syncDistance, err := parseUint(resp.Data.SyncDistance)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync_distance: %w", err)
+	}
+
+	return &SyncingStatus{
+		HeadSlot:     headSlot,
+		SyncDistance: syncDistance,
+		IsSyncing:    resp.Data.IsSyncing,
+		IsOptimistic: resp.Data.IsOptimistic,
+		ELOffline:    resp.Data.ELOffline,
+	}, nil
+}
+
+// PeerCount returns the number of connected peers
+func (c *Client) PeerCount(ctx context.Context) (uint64, error) {
+	var resp struct {
+		Data struct {
+			Connected string `json:"connected"`
+		} `json:"data"`
+	}
+	if err := c.getJSON(ctx, "/eth/v1/node/peer_count", &resp); err != nil {
+		return 0, err
+	}
+
+	connected, err := parseUint(resp.Data.Connected)
+	if err != nil {
+		return 0, fmt.Errorf("invalid connected peer count: %w", err)
+	}
+
+	return connected, nil
+}
+
+// Health returns the node health derived from the status code of
+// /eth/v1/node/health: 200 is ready, 206 is syncing, anything else is unavailable
+func (c *Client) Health(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/eth/v1/node/health", nil)
+	if err != nil {
+		return HealthUnavailable, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return HealthUnavailable, fmt.Errorf("health request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return HealthReady, nil
+	case http.StatusPartialContent:
+		return HealthSyncing, nil
+	default:
+		return HealthUnavailable, nil
+	}
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s returned status %d", path, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseUint parses the quoted decimal integers used throughout the beacon API
+func parseUint(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
@@ -0,0 +1,1238 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contracts
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = ethereum.NotFound
+	_ = bind.Bind
+	_ = common.Big1
+	_ = types.BloomLookup
+	_ = event.NewSubscription
+	_ = abi.ConvertType
+)
+
+// BlockchainNodeRegistryMetaData contains all meta data concerning the BlockchainNodeRegistry contract.
+var BlockchainNodeRegistryMetaData = &bind.MetaData{
+	ABI: "[{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"id\",\"type\":\"bytes32\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"NodeDeregistered\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"id\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"enumBlockchainNodeRegistry.ChainType\",\"name\":\"chainType\",\"type\":\"uint8\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"NodeRegistered\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"id\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"enumBlockchainNodeRegistry.NodeStatus\",\"name\":\"oldStatus\",\"type\":\"uint8\"},{\"indexed\":false,\"internalType\":\"enumBlockchainNodeRegistry.NodeStatus\",\"name\":\"newStatus\",\"type\":\"uint8\"}],\"name\":\"NodeStatusChanged\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"id\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"enumBlockchainNodeRegistry.NodeStatus\",\"name\":\"status\",\"type\":\"uint8\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"currentBlock\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"highestBlock\",\"type\":\"uint256\"}],\"name\":\"NodeUpdated\",\"type\":\"event\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"_nodeId\",\"type\":\"bytes32\"}],\"name\":\"deregisterNode\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"getNodeCount\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"_nodeId\",\"type\":\"bytes32\"}],\"name\":\"getNodeDetails\",\"outputs\":[{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.ChainType\",\"name\":\"chainType\",\"type\":\"uint8\"},{\"internalType\":\"string\",\"name\":\"endpointUrl\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.NodeStatus\",\"name\":\"status\",\"type\":\"uint8\"},{\"internalType\":\"string\",\"name\":\"version\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"currentBlock\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"highestBlock\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"registeredAt\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"updatedAt\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"region\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.CloudProvider\",\"name\":\"provider\",\"type\":\"uint8\"},{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"isActive\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"_nodeId\",\"type\":\"bytes32\"}],\"name\":\"getNodeSyncPercentage\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"_owner\",\"type\":\"address\"}],\"name\":\"getNodesByOwner\",\"outputs\":[{\"internalType\":\"bytes32[]\",\"name\":\"\",\"type\":\"bytes32[]\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"enumBlockchainNodeRegistry.ChainType\",\"name\":\"\",\"type\":\"uint8\"}],\"name\":\"nodeCountByChain\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"nodeIds\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"name\":\"nodes\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"id\",\"type\":\"bytes32\"},{\"internalType\":\"string\",\"name\":\"name\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.ChainType\",\"name\":\"chainType\",\"type\":\"uint8\"},{\"internalType\":\"string\",\"name\":\"endpointUrl\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.NodeStatus\",\"name\":\"status\",\"type\":\"uint8\"},{\"internalType\":\"string\",\"name\":\"version\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"currentBlock\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"highestBlock\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"registeredAt\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"updatedAt\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"region\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.CloudProvider\",\"name\":\"provider\",\"type\":\"uint8\"},{\"internalType\":\"address\",\"name\":\"owner\",\"type\":\"address\"},{\"internalType\":\"bool\",\"name\":\"isActive\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"name\":\"ownerNodes\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"_name\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.ChainType\",\"name\":\"_chainType\",\"type\":\"uint8\"},{\"internalType\":\"string\",\"name\":\"_endpointUrl\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_version\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"_region\",\"type\":\"string\"},{\"internalType\":\"enumBlockchainNodeRegistry.CloudProvider\",\"name\":\"_provider\",\"type\":\"uint8\"}],\"name\":\"registerNode\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"_nodeId\",\"type\":\"bytes32\"},{\"internalType\":\"enumBlockchainNodeRegistry.NodeStatus\",\"name\":\"_status\",\"type\":\"uint8\"},{\"internalType\":\"uint256\",\"name\":\"_currentBlock\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"_highestBlock\",\"type\":\"uint256\"}],\"name\":\"updateNodeStatus\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]",
+}
+
+// BlockchainNodeRegistryABI is the input ABI used to generate the binding from.
+// Deprecated: Use BlockchainNodeRegistryMetaData.ABI instead.
+var BlockchainNodeRegistryABI = BlockchainNodeRegistryMetaData.ABI
+
+// BlockchainNodeRegistry is an auto generated Go binding around an Ethereum contract.
+type BlockchainNodeRegistry struct {
+	BlockchainNodeRegistryCaller     // Read-only binding to the contract
+	BlockchainNodeRegistryTransactor // Write-only binding to the contract
+	BlockchainNodeRegistryFilterer   // Log filterer for contract events
+}
+
+// BlockchainNodeRegistryCaller is an auto generated read-only Go binding around an Ethereum contract.
+type BlockchainNodeRegistryCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// BlockchainNodeRegistryTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type BlockchainNodeRegistryTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// BlockchainNodeRegistryFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type BlockchainNodeRegistryFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// BlockchainNodeRegistrySession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type BlockchainNodeRegistrySession struct {
+	Contract     *BlockchainNodeRegistry // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts           // Call options to use throughout this session
+	TransactOpts bind.TransactOpts       // Transaction auth options to use throughout this session
+}
+
+// BlockchainNodeRegistryCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type BlockchainNodeRegistryCallerSession struct {
+	Contract *BlockchainNodeRegistryCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts                 // Call options to use throughout this session
+}
+
+// BlockchainNodeRegistryTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type BlockchainNodeRegistryTransactorSession struct {
+	Contract     *BlockchainNodeRegistryTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts                 // Transaction auth options to use throughout this session
+}
+
+// BlockchainNodeRegistryRaw is an auto generated low-level Go binding around an Ethereum contract.
+type BlockchainNodeRegistryRaw struct {
+	Contract *BlockchainNodeRegistry // Generic contract binding to access the raw methods on
+}
+
+// BlockchainNodeRegistryCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type BlockchainNodeRegistryCallerRaw struct {
+	Contract *BlockchainNodeRegistryCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// BlockchainNodeRegistryTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type BlockchainNodeRegistryTransactorRaw struct {
+	Contract *BlockchainNodeRegistryTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewBlockchainNodeRegistry creates a new instance of BlockchainNodeRegistry, bound to a specific deployed contract.
+func NewBlockchainNodeRegistry(address common.Address, backend bind.ContractBackend) (*BlockchainNodeRegistry, error) {
+	contract, err := bindBlockchainNodeRegistry(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistry{BlockchainNodeRegistryCaller: BlockchainNodeRegistryCaller{contract: contract}, BlockchainNodeRegistryTransactor: BlockchainNodeRegistryTransactor{contract: contract}, BlockchainNodeRegistryFilterer: BlockchainNodeRegistryFilterer{contract: contract}}, nil
+}
+
+// NewBlockchainNodeRegistryCaller creates a new read-only instance of BlockchainNodeRegistry, bound to a specific deployed contract.
+func NewBlockchainNodeRegistryCaller(address common.Address, caller bind.ContractCaller) (*BlockchainNodeRegistryCaller, error) {
+	contract, err := bindBlockchainNodeRegistry(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistryCaller{contract: contract}, nil
+}
+
+// NewBlockchainNodeRegistryTransactor creates a new write-only instance of BlockchainNodeRegistry, bound to a specific deployed contract.
+func NewBlockchainNodeRegistryTransactor(address common.Address, transactor bind.ContractTransactor) (*BlockchainNodeRegistryTransactor, error) {
+	contract, err := bindBlockchainNodeRegistry(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistryTransactor{contract: contract}, nil
+}
+
+// NewBlockchainNodeRegistryFilterer creates a new log filterer instance of BlockchainNodeRegistry, bound to a specific deployed contract.
+func NewBlockchainNodeRegistryFilterer(address common.Address, filterer bind.ContractFilterer) (*BlockchainNodeRegistryFilterer, error) {
+	contract, err := bindBlockchainNodeRegistry(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistryFilterer{contract: contract}, nil
+}
+
+// bindBlockchainNodeRegistry binds a generic wrapper to an already deployed contract.
+func bindBlockchainNodeRegistry(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := BlockchainNodeRegistryMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _BlockchainNodeRegistry.Contract.BlockchainNodeRegistryCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.BlockchainNodeRegistryTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.BlockchainNodeRegistryTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerRaw) Call(opts *bind.CallOpts, result *[]interface{}, method string, params ...interface{}) error {
+	return _BlockchainNodeRegistry.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.contract.Transact(opts, method, params...)
+}
+
+// GetNodeCount is a free data retrieval call binding the contract method 0x39bf397e.
+//
+// Solidity: function getNodeCount() view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) GetNodeCount(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "getNodeCount")
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetNodeCount is a free data retrieval call binding the contract method 0x39bf397e.
+//
+// Solidity: function getNodeCount() view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) GetNodeCount() (*big.Int, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodeCount(&_BlockchainNodeRegistry.CallOpts)
+}
+
+// GetNodeCount is a free data retrieval call binding the contract method 0x39bf397e.
+//
+// Solidity: function getNodeCount() view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) GetNodeCount() (*big.Int, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodeCount(&_BlockchainNodeRegistry.CallOpts)
+}
+
+// GetNodeDetails is a free data retrieval call binding the contract method 0x51a89e00.
+//
+// Solidity: function getNodeDetails(bytes32 _nodeId) view returns(string name, uint8 chainType, string endpointUrl, uint8 status, string version, uint256 currentBlock, uint256 highestBlock, uint256 registeredAt, uint256 updatedAt, string region, uint8 provider, address owner, bool isActive)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) GetNodeDetails(opts *bind.CallOpts, _nodeId [32]byte) (struct {
+	Name         string
+	ChainType    uint8
+	EndpointUrl  string
+	Status       uint8
+	Version      string
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	RegisteredAt *big.Int
+	UpdatedAt    *big.Int
+	Region       string
+	Provider     uint8
+	Owner        common.Address
+	IsActive     bool
+}, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "getNodeDetails", _nodeId)
+
+	outstruct := new(struct {
+		Name         string
+		ChainType    uint8
+		EndpointUrl  string
+		Status       uint8
+		Version      string
+		CurrentBlock *big.Int
+		HighestBlock *big.Int
+		RegisteredAt *big.Int
+		UpdatedAt    *big.Int
+		Region       string
+		Provider     uint8
+		Owner        common.Address
+		IsActive     bool
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Name = *abi.ConvertType(out[0], new(string)).(*string)
+	outstruct.ChainType = *abi.ConvertType(out[1], new(uint8)).(*uint8)
+	outstruct.EndpointUrl = *abi.ConvertType(out[2], new(string)).(*string)
+	outstruct.Status = *abi.ConvertType(out[3], new(uint8)).(*uint8)
+	outstruct.Version = *abi.ConvertType(out[4], new(string)).(*string)
+	outstruct.CurrentBlock = *abi.ConvertType(out[5], new(*big.Int)).(**big.Int)
+	outstruct.HighestBlock = *abi.ConvertType(out[6], new(*big.Int)).(**big.Int)
+	outstruct.RegisteredAt = *abi.ConvertType(out[7], new(*big.Int)).(**big.Int)
+	outstruct.UpdatedAt = *abi.ConvertType(out[8], new(*big.Int)).(**big.Int)
+	outstruct.Region = *abi.ConvertType(out[9], new(string)).(*string)
+	outstruct.Provider = *abi.ConvertType(out[10], new(uint8)).(*uint8)
+	outstruct.Owner = *abi.ConvertType(out[11], new(common.Address)).(*common.Address)
+	outstruct.IsActive = *abi.ConvertType(out[12], new(bool)).(*bool)
+
+	return *outstruct, err
+
+}
+
+// GetNodeDetails is a free data retrieval call binding the contract method 0x51a89e00.
+//
+// Solidity: function getNodeDetails(bytes32 _nodeId) view returns(string name, uint8 chainType, string endpointUrl, uint8 status, string version, uint256 currentBlock, uint256 highestBlock, uint256 registeredAt, uint256 updatedAt, string region, uint8 provider, address owner, bool isActive)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) GetNodeDetails(_nodeId [32]byte) (struct {
+	Name         string
+	ChainType    uint8
+	EndpointUrl  string
+	Status       uint8
+	Version      string
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	RegisteredAt *big.Int
+	UpdatedAt    *big.Int
+	Region       string
+	Provider     uint8
+	Owner        common.Address
+	IsActive     bool
+}, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodeDetails(&_BlockchainNodeRegistry.CallOpts, _nodeId)
+}
+
+// GetNodeDetails is a free data retrieval call binding the contract method 0x51a89e00.
+//
+// Solidity: function getNodeDetails(bytes32 _nodeId) view returns(string name, uint8 chainType, string endpointUrl, uint8 status, string version, uint256 currentBlock, uint256 highestBlock, uint256 registeredAt, uint256 updatedAt, string region, uint8 provider, address owner, bool isActive)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) GetNodeDetails(_nodeId [32]byte) (struct {
+	Name         string
+	ChainType    uint8
+	EndpointUrl  string
+	Status       uint8
+	Version      string
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	RegisteredAt *big.Int
+	UpdatedAt    *big.Int
+	Region       string
+	Provider     uint8
+	Owner        common.Address
+	IsActive     bool
+}, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodeDetails(&_BlockchainNodeRegistry.CallOpts, _nodeId)
+}
+
+// GetNodeSyncPercentage is a free data retrieval call binding the contract method 0x81bb4bc0.
+//
+// Solidity: function getNodeSyncPercentage(bytes32 _nodeId) view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) GetNodeSyncPercentage(opts *bind.CallOpts, _nodeId [32]byte) (*big.Int, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "getNodeSyncPercentage", _nodeId)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// GetNodeSyncPercentage is a free data retrieval call binding the contract method 0x81bb4bc0.
+//
+// Solidity: function getNodeSyncPercentage(bytes32 _nodeId) view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) GetNodeSyncPercentage(_nodeId [32]byte) (*big.Int, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodeSyncPercentage(&_BlockchainNodeRegistry.CallOpts, _nodeId)
+}
+
+// GetNodeSyncPercentage is a free data retrieval call binding the contract method 0x81bb4bc0.
+//
+// Solidity: function getNodeSyncPercentage(bytes32 _nodeId) view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) GetNodeSyncPercentage(_nodeId [32]byte) (*big.Int, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodeSyncPercentage(&_BlockchainNodeRegistry.CallOpts, _nodeId)
+}
+
+// GetNodesByOwner is a free data retrieval call binding the contract method 0x42fcf250.
+//
+// Solidity: function getNodesByOwner(address _owner) view returns(bytes32[])
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) GetNodesByOwner(opts *bind.CallOpts, _owner common.Address) ([][32]byte, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "getNodesByOwner", _owner)
+
+	if err != nil {
+		return *new([][32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([][32]byte)).(*[][32]byte)
+
+	return out0, err
+
+}
+
+// GetNodesByOwner is a free data retrieval call binding the contract method 0x42fcf250.
+//
+// Solidity: function getNodesByOwner(address _owner) view returns(bytes32[])
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) GetNodesByOwner(_owner common.Address) ([][32]byte, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodesByOwner(&_BlockchainNodeRegistry.CallOpts, _owner)
+}
+
+// GetNodesByOwner is a free data retrieval call binding the contract method 0x42fcf250.
+//
+// Solidity: function getNodesByOwner(address _owner) view returns(bytes32[])
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) GetNodesByOwner(_owner common.Address) ([][32]byte, error) {
+	return _BlockchainNodeRegistry.Contract.GetNodesByOwner(&_BlockchainNodeRegistry.CallOpts, _owner)
+}
+
+// NodeCountByChain is a free data retrieval call binding the contract method 0xae38ed92.
+//
+// Solidity: function nodeCountByChain(uint8 ) view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) NodeCountByChain(opts *bind.CallOpts, arg0 uint8) (*big.Int, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "nodeCountByChain", arg0)
+
+	if err != nil {
+		return *new(*big.Int), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new(*big.Int)).(**big.Int)
+
+	return out0, err
+
+}
+
+// NodeCountByChain is a free data retrieval call binding the contract method 0xae38ed92.
+//
+// Solidity: function nodeCountByChain(uint8 ) view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) NodeCountByChain(arg0 uint8) (*big.Int, error) {
+	return _BlockchainNodeRegistry.Contract.NodeCountByChain(&_BlockchainNodeRegistry.CallOpts, arg0)
+}
+
+// NodeCountByChain is a free data retrieval call binding the contract method 0xae38ed92.
+//
+// Solidity: function nodeCountByChain(uint8 ) view returns(uint256)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) NodeCountByChain(arg0 uint8) (*big.Int, error) {
+	return _BlockchainNodeRegistry.Contract.NodeCountByChain(&_BlockchainNodeRegistry.CallOpts, arg0)
+}
+
+// NodeIds is a free data retrieval call binding the contract method 0x98543166.
+//
+// Solidity: function nodeIds(uint256 ) view returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) NodeIds(opts *bind.CallOpts, arg0 *big.Int) ([32]byte, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "nodeIds", arg0)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// NodeIds is a free data retrieval call binding the contract method 0x98543166.
+//
+// Solidity: function nodeIds(uint256 ) view returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) NodeIds(arg0 *big.Int) ([32]byte, error) {
+	return _BlockchainNodeRegistry.Contract.NodeIds(&_BlockchainNodeRegistry.CallOpts, arg0)
+}
+
+// NodeIds is a free data retrieval call binding the contract method 0x98543166.
+//
+// Solidity: function nodeIds(uint256 ) view returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) NodeIds(arg0 *big.Int) ([32]byte, error) {
+	return _BlockchainNodeRegistry.Contract.NodeIds(&_BlockchainNodeRegistry.CallOpts, arg0)
+}
+
+// Nodes is a free data retrieval call binding the contract method 0xd86e697d.
+//
+// Solidity: function nodes(bytes32 ) view returns(bytes32 id, string name, uint8 chainType, string endpointUrl, uint8 status, string version, uint256 currentBlock, uint256 highestBlock, uint256 registeredAt, uint256 updatedAt, string region, uint8 provider, address owner, bool isActive)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) Nodes(opts *bind.CallOpts, arg0 [32]byte) (struct {
+	Id           [32]byte
+	Name         string
+	ChainType    uint8
+	EndpointUrl  string
+	Status       uint8
+	Version      string
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	RegisteredAt *big.Int
+	UpdatedAt    *big.Int
+	Region       string
+	Provider     uint8
+	Owner        common.Address
+	IsActive     bool
+}, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "nodes", arg0)
+
+	outstruct := new(struct {
+		Id           [32]byte
+		Name         string
+		ChainType    uint8
+		EndpointUrl  string
+		Status       uint8
+		Version      string
+		CurrentBlock *big.Int
+		HighestBlock *big.Int
+		RegisteredAt *big.Int
+		UpdatedAt    *big.Int
+		Region       string
+		Provider     uint8
+		Owner        common.Address
+		IsActive     bool
+	})
+	if err != nil {
+		return *outstruct, err
+	}
+
+	outstruct.Id = *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+	outstruct.Name = *abi.ConvertType(out[1], new(string)).(*string)
+	outstruct.ChainType = *abi.ConvertType(out[2], new(uint8)).(*uint8)
+	outstruct.EndpointUrl = *abi.ConvertType(out[3], new(string)).(*string)
+	outstruct.Status = *abi.ConvertType(out[4], new(uint8)).(*uint8)
+	outstruct.Version = *abi.ConvertType(out[5], new(string)).(*string)
+	outstruct.CurrentBlock = *abi.ConvertType(out[6], new(*big.Int)).(**big.Int)
+	outstruct.HighestBlock = *abi.ConvertType(out[7], new(*big.Int)).(**big.Int)
+	outstruct.RegisteredAt = *abi.ConvertType(out[8], new(*big.Int)).(**big.Int)
+	outstruct.UpdatedAt = *abi.ConvertType(out[9], new(*big.Int)).(**big.Int)
+	outstruct.Region = *abi.ConvertType(out[10], new(string)).(*string)
+	outstruct.Provider = *abi.ConvertType(out[11], new(uint8)).(*uint8)
+	outstruct.Owner = *abi.ConvertType(out[12], new(common.Address)).(*common.Address)
+	outstruct.IsActive = *abi.ConvertType(out[13], new(bool)).(*bool)
+
+	return *outstruct, err
+
+}
+
+// Nodes is a free data retrieval call binding the contract method 0xd86e697d.
+//
+// Solidity: function nodes(bytes32 ) view returns(bytes32 id, string name, uint8 chainType, string endpointUrl, uint8 status, string version, uint256 currentBlock, uint256 highestBlock, uint256 registeredAt, uint256 updatedAt, string region, uint8 provider, address owner, bool isActive)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) Nodes(arg0 [32]byte) (struct {
+	Id           [32]byte
+	Name         string
+	ChainType    uint8
+	EndpointUrl  string
+	Status       uint8
+	Version      string
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	RegisteredAt *big.Int
+	UpdatedAt    *big.Int
+	Region       string
+	Provider     uint8
+	Owner        common.Address
+	IsActive     bool
+}, error) {
+	return _BlockchainNodeRegistry.Contract.Nodes(&_BlockchainNodeRegistry.CallOpts, arg0)
+}
+
+// Nodes is a free data retrieval call binding the contract method 0xd86e697d.
+//
+// Solidity: function nodes(bytes32 ) view returns(bytes32 id, string name, uint8 chainType, string endpointUrl, uint8 status, string version, uint256 currentBlock, uint256 highestBlock, uint256 registeredAt, uint256 updatedAt, string region, uint8 provider, address owner, bool isActive)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) Nodes(arg0 [32]byte) (struct {
+	Id           [32]byte
+	Name         string
+	ChainType    uint8
+	EndpointUrl  string
+	Status       uint8
+	Version      string
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	RegisteredAt *big.Int
+	UpdatedAt    *big.Int
+	Region       string
+	Provider     uint8
+	Owner        common.Address
+	IsActive     bool
+}, error) {
+	return _BlockchainNodeRegistry.Contract.Nodes(&_BlockchainNodeRegistry.CallOpts, arg0)
+}
+
+// OwnerNodes is a free data retrieval call binding the contract method 0xa4b104b8.
+//
+// Solidity: function ownerNodes(address , uint256 ) view returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCaller) OwnerNodes(opts *bind.CallOpts, arg0 common.Address, arg1 *big.Int) ([32]byte, error) {
+	var out []interface{}
+	err := _BlockchainNodeRegistry.contract.Call(opts, &out, "ownerNodes", arg0, arg1)
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// OwnerNodes is a free data retrieval call binding the contract method 0xa4b104b8.
+//
+// Solidity: function ownerNodes(address , uint256 ) view returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) OwnerNodes(arg0 common.Address, arg1 *big.Int) ([32]byte, error) {
+	return _BlockchainNodeRegistry.Contract.OwnerNodes(&_BlockchainNodeRegistry.CallOpts, arg0, arg1)
+}
+
+// OwnerNodes is a free data retrieval call binding the contract method 0xa4b104b8.
+//
+// Solidity: function ownerNodes(address , uint256 ) view returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryCallerSession) OwnerNodes(arg0 common.Address, arg1 *big.Int) ([32]byte, error) {
+	return _BlockchainNodeRegistry.Contract.OwnerNodes(&_BlockchainNodeRegistry.CallOpts, arg0, arg1)
+}
+
+// DeregisterNode is a paid mutator transaction binding the contract method 0xbb74cae3.
+//
+// Solidity: function deregisterNode(bytes32 _nodeId) returns()
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactor) DeregisterNode(opts *bind.TransactOpts, _nodeId [32]byte) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.contract.Transact(opts, "deregisterNode", _nodeId)
+}
+
+// DeregisterNode is a paid mutator transaction binding the contract method 0xbb74cae3.
+//
+// Solidity: function deregisterNode(bytes32 _nodeId) returns()
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) DeregisterNode(_nodeId [32]byte) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.DeregisterNode(&_BlockchainNodeRegistry.TransactOpts, _nodeId)
+}
+
+// DeregisterNode is a paid mutator transaction binding the contract method 0xbb74cae3.
+//
+// Solidity: function deregisterNode(bytes32 _nodeId) returns()
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactorSession) DeregisterNode(_nodeId [32]byte) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.DeregisterNode(&_BlockchainNodeRegistry.TransactOpts, _nodeId)
+}
+
+// RegisterNode is a paid mutator transaction binding the contract method 0x066a847e.
+//
+// Solidity: function registerNode(string _name, uint8 _chainType, string _endpointUrl, string _version, string _region, uint8 _provider) returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactor) RegisterNode(opts *bind.TransactOpts, _name string, _chainType uint8, _endpointUrl string, _version string, _region string, _provider uint8) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.contract.Transact(opts, "registerNode", _name, _chainType, _endpointUrl, _version, _region, _provider)
+}
+
+// RegisterNode is a paid mutator transaction binding the contract method 0x066a847e.
+//
+// Solidity: function registerNode(string _name, uint8 _chainType, string _endpointUrl, string _version, string _region, uint8 _provider) returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) RegisterNode(_name string, _chainType uint8, _endpointUrl string, _version string, _region string, _provider uint8) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.RegisterNode(&_BlockchainNodeRegistry.TransactOpts, _name, _chainType, _endpointUrl, _version, _region, _provider)
+}
+
+// RegisterNode is a paid mutator transaction binding the contract method 0x066a847e.
+//
+// Solidity: function registerNode(string _name, uint8 _chainType, string _endpointUrl, string _version, string _region, uint8 _provider) returns(bytes32)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactorSession) RegisterNode(_name string, _chainType uint8, _endpointUrl string, _version string, _region string, _provider uint8) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.RegisterNode(&_BlockchainNodeRegistry.TransactOpts, _name, _chainType, _endpointUrl, _version, _region, _provider)
+}
+
+// UpdateNodeStatus is a paid mutator transaction binding the contract method 0xf76b14ac.
+//
+// Solidity: function updateNodeStatus(bytes32 _nodeId, uint8 _status, uint256 _currentBlock, uint256 _highestBlock) returns()
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactor) UpdateNodeStatus(opts *bind.TransactOpts, _nodeId [32]byte, _status uint8, _currentBlock *big.Int, _highestBlock *big.Int) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.contract.Transact(opts, "updateNodeStatus", _nodeId, _status, _currentBlock, _highestBlock)
+}
+
+// UpdateNodeStatus is a paid mutator transaction binding the contract method 0xf76b14ac.
+//
+// Solidity: function updateNodeStatus(bytes32 _nodeId, uint8 _status, uint256 _currentBlock, uint256 _highestBlock) returns()
+func (_BlockchainNodeRegistry *BlockchainNodeRegistrySession) UpdateNodeStatus(_nodeId [32]byte, _status uint8, _currentBlock *big.Int, _highestBlock *big.Int) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.UpdateNodeStatus(&_BlockchainNodeRegistry.TransactOpts, _nodeId, _status, _currentBlock, _highestBlock)
+}
+
+// UpdateNodeStatus is a paid mutator transaction binding the contract method 0xf76b14ac.
+//
+// Solidity: function updateNodeStatus(bytes32 _nodeId, uint8 _status, uint256 _currentBlock, uint256 _highestBlock) returns()
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryTransactorSession) UpdateNodeStatus(_nodeId [32]byte, _status uint8, _currentBlock *big.Int, _highestBlock *big.Int) (*types.Transaction, error) {
+	return _BlockchainNodeRegistry.Contract.UpdateNodeStatus(&_BlockchainNodeRegistry.TransactOpts, _nodeId, _status, _currentBlock, _highestBlock)
+}
+
+// BlockchainNodeRegistryNodeDeregisteredIterator is returned from FilterNodeDeregistered and is used to iterate over the raw logs and unpacked data for NodeDeregistered events raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeDeregisteredIterator struct {
+	Event *BlockchainNodeRegistryNodeDeregistered // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *BlockchainNodeRegistryNodeDeregisteredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(BlockchainNodeRegistryNodeDeregistered)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(BlockchainNodeRegistryNodeDeregistered)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *BlockchainNodeRegistryNodeDeregisteredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *BlockchainNodeRegistryNodeDeregisteredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// BlockchainNodeRegistryNodeDeregistered represents a NodeDeregistered event raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeDeregistered struct {
+	Id    [32]byte
+	Owner common.Address
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterNodeDeregistered is a free log retrieval operation binding the contract event 0x4fbca14b36bf88062db3956201b4356f36747c50bb571421cb4a745fee47aa51.
+//
+// Solidity: event NodeDeregistered(bytes32 indexed id, address indexed owner)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) FilterNodeDeregistered(opts *bind.FilterOpts, id [][32]byte, owner []common.Address) (*BlockchainNodeRegistryNodeDeregisteredIterator, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.FilterLogs(opts, "NodeDeregistered", idRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistryNodeDeregisteredIterator{contract: _BlockchainNodeRegistry.contract, event: "NodeDeregistered", logs: logs, sub: sub}, nil
+}
+
+// WatchNodeDeregistered is a free log subscription operation binding the contract event 0x4fbca14b36bf88062db3956201b4356f36747c50bb571421cb4a745fee47aa51.
+//
+// Solidity: event NodeDeregistered(bytes32 indexed id, address indexed owner)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) WatchNodeDeregistered(opts *bind.WatchOpts, sink chan<- *BlockchainNodeRegistryNodeDeregistered, id [][32]byte, owner []common.Address) (event.Subscription, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.WatchLogs(opts, "NodeDeregistered", idRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(BlockchainNodeRegistryNodeDeregistered)
+				if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeDeregistered", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNodeDeregistered is a log parse operation binding the contract event 0x4fbca14b36bf88062db3956201b4356f36747c50bb571421cb4a745fee47aa51.
+//
+// Solidity: event NodeDeregistered(bytes32 indexed id, address indexed owner)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) ParseNodeDeregistered(log types.Log) (*BlockchainNodeRegistryNodeDeregistered, error) {
+	event := new(BlockchainNodeRegistryNodeDeregistered)
+	if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeDeregistered", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// BlockchainNodeRegistryNodeRegisteredIterator is returned from FilterNodeRegistered and is used to iterate over the raw logs and unpacked data for NodeRegistered events raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeRegisteredIterator struct {
+	Event *BlockchainNodeRegistryNodeRegistered // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *BlockchainNodeRegistryNodeRegisteredIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(BlockchainNodeRegistryNodeRegistered)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(BlockchainNodeRegistryNodeRegistered)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *BlockchainNodeRegistryNodeRegisteredIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *BlockchainNodeRegistryNodeRegisteredIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// BlockchainNodeRegistryNodeRegistered represents a NodeRegistered event raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeRegistered struct {
+	Id        [32]byte
+	Name      string
+	ChainType uint8
+	Owner     common.Address
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNodeRegistered is a free log retrieval operation binding the contract event 0x4064330ee06763dadbe1fad5ba13b54c81a1d58da7c52114ed8d8a4be4e5c556.
+//
+// Solidity: event NodeRegistered(bytes32 indexed id, string name, uint8 chainType, address indexed owner)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) FilterNodeRegistered(opts *bind.FilterOpts, id [][32]byte, owner []common.Address) (*BlockchainNodeRegistryNodeRegisteredIterator, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.FilterLogs(opts, "NodeRegistered", idRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistryNodeRegisteredIterator{contract: _BlockchainNodeRegistry.contract, event: "NodeRegistered", logs: logs, sub: sub}, nil
+}
+
+// WatchNodeRegistered is a free log subscription operation binding the contract event 0x4064330ee06763dadbe1fad5ba13b54c81a1d58da7c52114ed8d8a4be4e5c556.
+//
+// Solidity: event NodeRegistered(bytes32 indexed id, string name, uint8 chainType, address indexed owner)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) WatchNodeRegistered(opts *bind.WatchOpts, sink chan<- *BlockchainNodeRegistryNodeRegistered, id [][32]byte, owner []common.Address) (event.Subscription, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.WatchLogs(opts, "NodeRegistered", idRule, ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(BlockchainNodeRegistryNodeRegistered)
+				if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeRegistered", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNodeRegistered is a log parse operation binding the contract event 0x4064330ee06763dadbe1fad5ba13b54c81a1d58da7c52114ed8d8a4be4e5c556.
+//
+// Solidity: event NodeRegistered(bytes32 indexed id, string name, uint8 chainType, address indexed owner)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) ParseNodeRegistered(log types.Log) (*BlockchainNodeRegistryNodeRegistered, error) {
+	event := new(BlockchainNodeRegistryNodeRegistered)
+	if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeRegistered", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// BlockchainNodeRegistryNodeStatusChangedIterator is returned from FilterNodeStatusChanged and is used to iterate over the raw logs and unpacked data for NodeStatusChanged events raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeStatusChangedIterator struct {
+	Event *BlockchainNodeRegistryNodeStatusChanged // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *BlockchainNodeRegistryNodeStatusChangedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(BlockchainNodeRegistryNodeStatusChanged)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(BlockchainNodeRegistryNodeStatusChanged)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *BlockchainNodeRegistryNodeStatusChangedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *BlockchainNodeRegistryNodeStatusChangedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// BlockchainNodeRegistryNodeStatusChanged represents a NodeStatusChanged event raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeStatusChanged struct {
+	Id        [32]byte
+	OldStatus uint8
+	NewStatus uint8
+	Raw       types.Log // Blockchain specific contextual infos
+}
+
+// FilterNodeStatusChanged is a free log retrieval operation binding the contract event 0x97ce26e802772169b8b5a9cbc8b509e63288002d529848122ddb9a2a5879acc0.
+//
+// Solidity: event NodeStatusChanged(bytes32 indexed id, uint8 oldStatus, uint8 newStatus)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) FilterNodeStatusChanged(opts *bind.FilterOpts, id [][32]byte) (*BlockchainNodeRegistryNodeStatusChangedIterator, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.FilterLogs(opts, "NodeStatusChanged", idRule)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistryNodeStatusChangedIterator{contract: _BlockchainNodeRegistry.contract, event: "NodeStatusChanged", logs: logs, sub: sub}, nil
+}
+
+// WatchNodeStatusChanged is a free log subscription operation binding the contract event 0x97ce26e802772169b8b5a9cbc8b509e63288002d529848122ddb9a2a5879acc0.
+//
+// Solidity: event NodeStatusChanged(bytes32 indexed id, uint8 oldStatus, uint8 newStatus)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) WatchNodeStatusChanged(opts *bind.WatchOpts, sink chan<- *BlockchainNodeRegistryNodeStatusChanged, id [][32]byte) (event.Subscription, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.WatchLogs(opts, "NodeStatusChanged", idRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(BlockchainNodeRegistryNodeStatusChanged)
+				if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeStatusChanged", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNodeStatusChanged is a log parse operation binding the contract event 0x97ce26e802772169b8b5a9cbc8b509e63288002d529848122ddb9a2a5879acc0.
+//
+// Solidity: event NodeStatusChanged(bytes32 indexed id, uint8 oldStatus, uint8 newStatus)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) ParseNodeStatusChanged(log types.Log) (*BlockchainNodeRegistryNodeStatusChanged, error) {
+	event := new(BlockchainNodeRegistryNodeStatusChanged)
+	if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeStatusChanged", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// BlockchainNodeRegistryNodeUpdatedIterator is returned from FilterNodeUpdated and is used to iterate over the raw logs and unpacked data for NodeUpdated events raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeUpdatedIterator struct {
+	Event *BlockchainNodeRegistryNodeUpdated // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *BlockchainNodeRegistryNodeUpdatedIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(BlockchainNodeRegistryNodeUpdated)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(BlockchainNodeRegistryNodeUpdated)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *BlockchainNodeRegistryNodeUpdatedIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *BlockchainNodeRegistryNodeUpdatedIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// BlockchainNodeRegistryNodeUpdated represents a NodeUpdated event raised by the BlockchainNodeRegistry contract.
+type BlockchainNodeRegistryNodeUpdated struct {
+	Id           [32]byte
+	Status       uint8
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	Raw          types.Log // Blockchain specific contextual infos
+}
+
+// FilterNodeUpdated is a free log retrieval operation binding the contract event 0xc9334cfb64b2f63d9b06a03e435ba225783fc1d26cbd9621670109fb8d5fcc00.
+//
+// Solidity: event NodeUpdated(bytes32 indexed id, uint8 status, uint256 currentBlock, uint256 highestBlock)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) FilterNodeUpdated(opts *bind.FilterOpts, id [][32]byte) (*BlockchainNodeRegistryNodeUpdatedIterator, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.FilterLogs(opts, "NodeUpdated", idRule)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockchainNodeRegistryNodeUpdatedIterator{contract: _BlockchainNodeRegistry.contract, event: "NodeUpdated", logs: logs, sub: sub}, nil
+}
+
+// WatchNodeUpdated is a free log subscription operation binding the contract event 0xc9334cfb64b2f63d9b06a03e435ba225783fc1d26cbd9621670109fb8d5fcc00.
+//
+// Solidity: event NodeUpdated(bytes32 indexed id, uint8 status, uint256 currentBlock, uint256 highestBlock)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) WatchNodeUpdated(opts *bind.WatchOpts, sink chan<- *BlockchainNodeRegistryNodeUpdated, id [][32]byte) (event.Subscription, error) {
+
+	var idRule []interface{}
+	for _, idItem := range id {
+		idRule = append(idRule, idItem)
+	}
+
+	logs, sub, err := _BlockchainNodeRegistry.contract.WatchLogs(opts, "NodeUpdated", idRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(BlockchainNodeRegistryNodeUpdated)
+				if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeUpdated", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseNodeUpdated is a log parse operation binding the contract event 0xc9334cfb64b2f63d9b06a03e435ba225783fc1d26cbd9621670109fb8d5fcc00.
+//
+// Solidity: event NodeUpdated(bytes32 indexed id, uint8 status, uint256 currentBlock, uint256 highestBlock)
+func (_BlockchainNodeRegistry *BlockchainNodeRegistryFilterer) ParseNodeUpdated(log types.Log) (*BlockchainNodeRegistryNodeUpdated, error) {
+	event := new(BlockchainNodeRegistryNodeUpdated)
+	if err := _BlockchainNodeRegistry.contract.UnpackLog(event, "NodeUpdated", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,342 @@
+package contracts
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The bindings don't carry bytecode, so the tests deploy small hand-assembled
+// contracts that answer the wrappers' calls with canned ABI-encoded output.
+
+func push2(n int) []byte {
+	return []byte{0x61, byte(n >> 8), byte(n)}
+}
+
+// deployable wraps runtime code in creation code that returns it
+func deployable(runtime []byte) []byte {
+	code := push2(len(runtime))
+	code = append(code, 0x80) // DUP1
+	code = append(code, push2(13)...)
+	code = append(code, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3) // CODECOPY, RETURN
+	return append(code, runtime...)
+}
+
+// cannedCode returns a contract that answers every call with output and, when
+// topics are given, first emits one log with those topics and data
+func cannedCode(output []byte, topics []common.Hash, data []byte) []byte {
+	// data and output follow the code, whose length only depends on the
+	// number of topics
+	codeLen := 15
+	if len(topics) > 0 {
+		codeLen += 15 + 33*len(topics)
+	}
+
+	var code []byte
+	if len(topics) > 0 {
+		code = append(code, push2(len(data))...)
+		code = append(code, push2(codeLen)...)
+		code = append(code, 0x60, 0x00, 0x39) // CODECOPY data to memory
+		for i := len(topics) - 1; i >= 0; i-- {
+			code = append(code, 0x7f) // PUSH32
+			code = append(code, topics[i].Bytes()...)
+		}
+		code = append(code, push2(len(data))...)
+		code = append(code, 0x60, 0x00, byte(0xa0+len(topics))) // LOGn
+	}
+	code = append(code, push2(len(output))...)
+	code = append(code, push2(codeLen+len(data))...)
+	code = append(code, 0x60, 0x00, 0x39) // CODECOPY output to memory
+	code = append(code, push2(len(output))...)
+	code = append(code, 0x60, 0x00, 0xf3) // RETURN
+
+	return deployable(append(append(code, data...), output...))
+}
+
+var (
+	// blockNumberCode returns the number of the block it runs in
+	blockNumberCode = deployable([]byte{0x43, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3})
+
+	// revertCode reverts every call
+	revertCode = deployable([]byte{0x60, 0x00, 0x60, 0x00, 0xfd})
+)
+
+// testBackend is a simulated chain that optionally mines every transaction as
+// soon as it's sent, and can stall calls until their context expires
+type testBackend struct {
+	*backends.SimulatedBackend
+	autoMine bool
+	stall    bool
+}
+
+func (b *testBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	if b.autoMine {
+		b.Commit()
+	}
+	return nil
+}
+
+func (b *testBackend) CallContract(ctx context.Context, call ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	if b.stall {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return b.SimulatedBackend.CallContract(ctx, call, block)
+}
+
+func newTestBackend(t *testing.T) (*testBackend, *bind.TransactOpts) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &testBackend{
+		SimulatedBackend: backends.NewSimulatedBackend(core.GenesisAlloc{
+			crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))},
+		}, 30_000_000),
+		autoMine: true,
+	}
+	t.Cleanup(func() { _ = backend.Close() })
+	return backend, transactor(t, key)
+}
+
+func transactor(t *testing.T, key *ecdsa.PrivateKey) *bind.TransactOpts {
+	t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth
+}
+
+func deploy(t *testing.T, backend *testBackend, auth *bind.TransactOpts, code []byte) common.Address {
+	t.Helper()
+	address, _, _, err := bind.DeployContract(auth, abi.ABI{}, code, backend)
+	if err != nil {
+		t.Fatalf("failed to deploy: %v", err)
+	}
+	backend.Commit()
+	return address
+}
+
+func registryABI(t *testing.T) *abi.ABI {
+	t.Helper()
+	parsed, err := BlockchainNodeRegistryMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func newRegistryService(t *testing.T, backend *testBackend, address common.Address, timeout, receiptTimeout time.Duration) *RegistryService {
+	t.Helper()
+	service, err := NewRegistryService(address, backend, timeout, receiptTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+func TestRegistryServiceRegisterNodeReturnsEventID(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	parsed := registryABI(t)
+
+	id := common.HexToHash("0x1d")
+	event := parsed.Events["NodeRegistered"]
+	data, err := event.Inputs.NonIndexed().Pack("geth-1", uint8(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	topics := []common.Hash{event.ID, id, common.BytesToHash(auth.From.Bytes())}
+	address := deploy(t, backend, auth, cannedCode(nil, topics, data))
+
+	service := newRegistryService(t, backend, address, time.Second, 5*time.Second)
+	got, receipt, err := service.RegisterNode(context.Background(), auth, "geth-1", 0, "http://geth:8545", "1.13", "eu", 0)
+	if err != nil {
+		t.Fatalf("RegisterNode failed: %v", err)
+	}
+	if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful || receipt.BlockNumber == nil {
+		t.Fatalf("receipt = %+v, want a mined successful receipt", receipt)
+	}
+	if got != id {
+		t.Errorf("node ID = %x, want %x", got, id)
+	}
+}
+
+func TestRegistryServiceRegisterNodeWithoutEvent(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	address := deploy(t, backend, auth, cannedCode(nil, nil, nil))
+
+	service := newRegistryService(t, backend, address, time.Second, 5*time.Second)
+	_, receipt, err := service.RegisterNode(context.Background(), auth, "geth-1", 0, "http://geth:8545", "1.13", "eu", 0)
+	if err == nil || !strings.Contains(err.Error(), "no NodeRegistered event") {
+		t.Fatalf("err = %v, want missing event error", err)
+	}
+	if receipt == nil {
+		t.Error("receipt was not returned with the error")
+	}
+}
+
+func TestRegistryServiceNodeDetails(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	parsed := registryABI(t)
+
+	registered := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	output, err := parsed.Methods["getNodeDetails"].Outputs.Pack(
+		"geth-1", uint8(1), "http://geth:8545", uint8(3), "1.13",
+		big.NewInt(90), big.NewInt(100), big.NewInt(registered.Unix()), big.NewInt(registered.Unix()+60),
+		"eu", uint8(2), auth.From, true,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := deploy(t, backend, auth, cannedCode(output, nil, nil))
+
+	service := newRegistryService(t, backend, address, time.Second, time.Second)
+	node, err := service.NodeDetails(context.Background(), common.HexToHash("0x1d"))
+	if err != nil {
+		t.Fatalf("NodeDetails failed: %v", err)
+	}
+	if node.Name != "geth-1" || node.ChainType != 1 || node.Status != 3 || node.Provider != 2 || !node.IsActive {
+		t.Errorf("node = %+v", node)
+	}
+	if node.CurrentBlock.Int64() != 90 || node.Owner != auth.From {
+		t.Errorf("node block/owner = %s/%s", node.CurrentBlock, node.Owner)
+	}
+	if !node.RegisteredAt.Equal(registered) || !node.UpdatedAt.Equal(registered.Add(time.Minute)) {
+		t.Errorf("node times = %s/%s, want %s and a minute later", node.RegisteredAt, node.UpdatedAt, registered)
+	}
+}
+
+func TestRegistryServiceCallTimeout(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	address := deploy(t, backend, auth, cannedCode(common.LeftPadBytes([]byte{7}, 32), nil, nil))
+	service := newRegistryService(t, backend, address, 50*time.Millisecond, time.Second)
+
+	count, err := service.NodeCount(context.Background())
+	if err != nil || count != 7 {
+		t.Fatalf("NodeCount = %d, %v, want 7", count, err)
+	}
+
+	backend.stall = true
+	start := time.Now()
+	_, err = service.NodeCount(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("call returned after %s, want the 50ms timeout", elapsed)
+	}
+}
+
+func TestRegistryServiceWaitsForLateReceipt(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	address := deploy(t, backend, auth, cannedCode(nil, nil, nil))
+	service := newRegistryService(t, backend, address, time.Second, 5*time.Second)
+
+	backend.autoMine = false
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		backend.Commit()
+	}()
+
+	receipt, err := service.UpdateNodeStatus(context.Background(), auth, [32]byte{1}, 0, big.NewInt(1), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("UpdateNodeStatus failed: %v", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Errorf("receipt status = %d", receipt.Status)
+	}
+}
+
+func TestRegistryServiceReceiptTimeout(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	address := deploy(t, backend, auth, cannedCode(nil, nil, nil))
+	service := newRegistryService(t, backend, address, time.Second, 100*time.Millisecond)
+
+	// Nothing mines the transaction
+	backend.autoMine = false
+	start := time.Now()
+	receipt, err := service.DeregisterNode(context.Background(), auth, [32]byte{1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if receipt != nil {
+		t.Errorf("receipt = %+v, want none", receipt)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %s, want the 100ms receipt timeout", elapsed)
+	}
+}
+
+func TestRegistryServiceReportsRevert(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	address := deploy(t, backend, auth, revertCode)
+	service := newRegistryService(t, backend, address, time.Second, 5*time.Second)
+
+	// A fixed gas limit skips estimation, which would reject the call before sending
+	opts := *auth
+	opts.GasLimit = 100_000
+	receipt, err := service.DeregisterNode(context.Background(), &opts, [32]byte{1})
+	if err == nil || !strings.Contains(err.Error(), "reverted") {
+		t.Fatalf("err = %v, want reverted", err)
+	}
+	if receipt == nil || receipt.Status != types.ReceiptStatusFailed {
+		t.Errorf("receipt = %+v, want the failed receipt", receipt)
+	}
+}
+
+func TestTokenServiceReadsAtBlock(t *testing.T) {
+	backend, auth := newTestBackend(t)
+	address := deploy(t, backend, auth, blockNumberCode)
+	backend.Commit()
+
+	service, err := NewTokenService(address, backend, time.Second, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	head, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := service.BalanceOf(ctx, auth.From, nil)
+	if err != nil || latest.Cmp(head.Number) != 0 {
+		t.Fatalf("BalanceOf(latest) = %v, %v, want %s", latest, err, head.Number)
+	}
+	pinned, err := service.BalanceOf(ctx, auth.From, head.Number)
+	if err != nil || pinned.Cmp(head.Number) != 0 {
+		t.Fatalf("BalanceOf(%s) = %v, %v", head.Number, pinned, err)
+	}
+	// The simulated backend only serves the head, so an older pin must fail
+	// rather than silently read the latest state
+	if _, err := service.BalanceOf(ctx, auth.From, big.NewInt(1)); err == nil {
+		t.Error("BalanceOf(1) ignored the block number")
+	}
+
+	supply, err := service.Supply(ctx, nil)
+	if err != nil {
+		t.Fatalf("Supply failed: %v", err)
+	}
+	if supply.TotalSupply.Cmp(head.Number) != 0 || supply.MaxSupply.Cmp(head.Number) != 0 || supply.TotalVested.Cmp(head.Number) != 0 {
+		t.Errorf("supply = %+v", supply)
+	}
+}
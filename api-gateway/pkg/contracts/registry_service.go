@@ -0,0 +1,153 @@
+package contracts
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// RegistryNode is a node as stored in the BlockchainNodeRegistry contract.
+// The enum fields hold the contract's raw enum values.
+type RegistryNode struct {
+	ID           [32]byte
+	Name         string
+	ChainType    uint8
+	EndpointURL  string
+	Status       uint8
+	Version      string
+	CurrentBlock *big.Int
+	HighestBlock *big.Int
+	RegisteredAt time.Time
+	UpdatedAt    time.Time
+	Region       string
+	Provider     uint8
+	Owner        common.Address
+	IsActive     bool
+}
+
+// RegistryService wraps the BlockchainNodeRegistry binding with per-call
+// timeouts and receipt waiting
+type RegistryService struct {
+	contract       *BlockchainNodeRegistry
+	backend        Backend
+	timeout        time.Duration
+	receiptTimeout time.Duration
+}
+
+// NewRegistryService creates a RegistryService for the registry deployed at address
+func NewRegistryService(address common.Address, backend Backend, timeout, receiptTimeout time.Duration) (*RegistryService, error) {
+	contract, err := NewBlockchainNodeRegistry(address, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryService{
+		contract:       contract,
+		backend:        backend,
+		timeout:        timeout,
+		receiptTimeout: receiptTimeout,
+	}, nil
+}
+
+// Contract returns the underlying generated binding
+func (s *RegistryService) Contract() *BlockchainNodeRegistry {
+	return s.contract
+}
+
+// NodeCount returns the number of nodes ever registered
+func (s *RegistryService) NodeCount(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	count, err := s.contract.GetNodeCount(callOpts(ctx, nil))
+	if err != nil {
+		return 0, err
+	}
+	return count.Uint64(), nil
+}
+
+// NodeDetails returns a registered node
+func (s *RegistryService) NodeDetails(ctx context.Context, id [32]byte) (*RegistryNode, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	details, err := s.contract.GetNodeDetails(callOpts(ctx, nil), id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryNode{
+		ID:           id,
+		Name:         details.Name,
+		ChainType:    details.ChainType,
+		EndpointURL:  details.EndpointUrl,
+		Status:       details.Status,
+		Version:      details.Version,
+		CurrentBlock: details.CurrentBlock,
+		HighestBlock: details.HighestBlock,
+		RegisteredAt: time.Unix(details.RegisteredAt.Int64(), 0).UTC(),
+		UpdatedAt:    time.Unix(details.UpdatedAt.Int64(), 0).UTC(),
+		Region:       details.Region,
+		Provider:     details.Provider,
+		Owner:        details.Owner,
+		IsActive:     details.IsActive,
+	}, nil
+}
+
+// NodesByOwner returns the IDs of the nodes registered by owner
+func (s *RegistryService) NodesByOwner(ctx context.Context, owner common.Address) ([][32]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.contract.GetNodesByOwner(callOpts(ctx, nil), owner)
+}
+
+// RegisterNode registers a node, waits for the receipt and returns the
+// contract-assigned node ID
+func (s *RegistryService) RegisterNode(ctx context.Context, opts *bind.TransactOpts, name string, chainType uint8, endpointURL, version, region string, provider uint8) ([32]byte, *types.Receipt, error) {
+	tx, err := s.contract.RegisterNode(transactOpts(ctx, opts), name, chainType, endpointURL, version, region, provider)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+
+	receipt, err := waitMined(ctx, s.backend, tx, s.receiptTimeout)
+	if err != nil {
+		return [32]byte{}, receipt, err
+	}
+
+	id, err := s.RegisteredNodeID(receipt)
+	return id, receipt, err
+}
+
+// RegisteredNodeID extracts the node ID from a registerNode receipt
+func (s *RegistryService) RegisteredNodeID(receipt *types.Receipt) ([32]byte, error) {
+	for _, log := range receipt.Logs {
+		if event, err := s.contract.ParseNodeRegistered(*log); err == nil {
+			return event.Id, nil
+		}
+	}
+	return [32]byte{}, errors.New("receipt has no NodeRegistered event")
+}
+
+// UpdateNodeStatus updates a node's status and block heights and waits for the receipt
+func (s *RegistryService) UpdateNodeStatus(ctx context.Context, opts *bind.TransactOpts, id [32]byte, status uint8, currentBlock, highestBlock *big.Int) (*types.Receipt, error) {
+	tx, err := s.contract.UpdateNodeStatus(transactOpts(ctx, opts), id, status, currentBlock, highestBlock)
+	if err != nil {
+		return nil, err
+	}
+	return waitMined(ctx, s.backend, tx, s.receiptTimeout)
+}
+
+// DeregisterNode deregisters a node and waits for the receipt
+func (s *RegistryService) DeregisterNode(ctx context.Context, opts *bind.TransactOpts, id [32]byte) (*types.Receipt, error) {
+	tx, err := s.contract.DeregisterNode(transactOpts(ctx, opts), id)
+	if err != nil {
+		return nil, err
+	}
+	return waitMined(ctx, s.backend, tx, s.receiptTimeout)
+}
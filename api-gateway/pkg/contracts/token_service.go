@@ -0,0 +1,145 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Vesting is a beneficiary's TwistToken vesting position
+type Vesting struct {
+	Vested    *big.Int
+	Claimed   *big.Int
+	Claimable *big.Int
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// Supply is the TwistToken supply
+type Supply struct {
+	TotalSupply *big.Int
+	MaxSupply   *big.Int
+	TotalVested *big.Int
+}
+
+// TokenService wraps the TwistToken binding with per-call timeouts and
+// receipt waiting. Reads accept an optional block number so that related
+// values can be read from the same block.
+type TokenService struct {
+	contract       *TwistToken
+	backend        Backend
+	timeout        time.Duration
+	receiptTimeout time.Duration
+}
+
+// NewTokenService creates a TokenService for the token deployed at address
+func NewTokenService(address common.Address, backend Backend, timeout, receiptTimeout time.Duration) (*TokenService, error) {
+	contract, err := NewTwistToken(address, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenService{
+		contract:       contract,
+		backend:        backend,
+		timeout:        timeout,
+		receiptTimeout: receiptTimeout,
+	}, nil
+}
+
+// Contract returns the underlying generated binding
+func (s *TokenService) Contract() *TwistToken {
+	return s.contract
+}
+
+// BalanceOf returns the token balance of account at block, or at the latest block when block is nil
+func (s *TokenService) BalanceOf(ctx context.Context, account common.Address, block *big.Int) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	return s.contract.BalanceOf(callOpts(ctx, block), account)
+}
+
+// Vesting returns the vesting position of beneficiary at block, or at the latest block when block is nil
+func (s *TokenService) Vesting(ctx context.Context, beneficiary common.Address, block *big.Int) (*Vesting, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	opts := callOpts(ctx, block)
+	vested, err := s.contract.VestedAmount(opts, beneficiary)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.contract.ClaimedAmount(opts, beneficiary)
+	if err != nil {
+		return nil, err
+	}
+	claimable, err := s.contract.GetClaimableAmount(opts, beneficiary)
+	if err != nil {
+		return nil, err
+	}
+	start, err := s.contract.VestingStartTime(opts)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.contract.VestingEndTime(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Vesting{
+		Vested:    vested,
+		Claimed:   claimed,
+		Claimable: claimable,
+		StartTime: time.Unix(start.Int64(), 0).UTC(),
+		EndTime:   time.Unix(end.Int64(), 0).UTC(),
+	}, nil
+}
+
+// Supply returns the token supply at block, or at the latest block when block is nil
+func (s *TokenService) Supply(ctx context.Context, block *big.Int) (*Supply, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	opts := callOpts(ctx, block)
+	total, err := s.contract.TotalSupply(opts)
+	if err != nil {
+		return nil, err
+	}
+	max, err := s.contract.MAXSUPPLY(opts)
+	if err != nil {
+		return nil, err
+	}
+	vested, err := s.contract.TotalVested(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Supply{
+		TotalSupply: total,
+		MaxSupply:   max,
+		TotalVested: vested,
+	}, nil
+}
+
+// ClaimVestedTokens claims the sender's vested tokens and waits for the receipt
+func (s *TokenService) ClaimVestedTokens(ctx context.Context, opts *bind.TransactOpts) (*types.Receipt, error) {
+	tx, err := s.contract.ClaimVestedTokens(transactOpts(ctx, opts))
+	if err != nil {
+		return nil, err
+	}
+	return waitMined(ctx, s.backend, tx, s.receiptTimeout)
+}
+
+// AddVesting adds a vesting allocation for beneficiary and waits for the receipt
+func (s *TokenService) AddVesting(ctx context.Context, opts *bind.TransactOpts, beneficiary common.Address, amount *big.Int) (*types.Receipt, error) {
+	tx, err := s.contract.AddVesting(transactOpts(ctx, opts), beneficiary, amount)
+	if err != nil {
+		return nil, err
+	}
+	return waitMined(ctx, s.backend, tx, s.receiptTimeout)
+}
@@ -0,0 +1,163 @@
+package ethrpc
+
+import (
+	"context"
+	"math/big"
+)
+
+// FeeHistory is the result of eth_feeHistory
+type FeeHistory struct {
+	OldestBlock   Quantity     `json:"oldestBlock"`
+	BaseFeePerGas []Quantity   `json:"baseFeePerGas"`
+	GasUsedRatio  []float64    `json:"gasUsedRatio"`
+	Reward        [][]Quantity `json:"reward"`
+}
+
+// TxpoolStatus is the result of txpool_status
+type TxpoolStatus struct {
+	Pending Quantity `json:"pending"`
+	Queued  Quantity `json:"queued"`
+}
+
+// GasPrice calls eth_gasPrice
+func (c *Client) GasPrice(ctx context.Context) (*big.Int, error) {
+	var q Quantity
+	if err := c.Call(ctx, &q, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+	return q.Big(), nil
+}
+
+// MaxPriorityFeePerGas calls eth_maxPriorityFeePerGas
+func (c *Client) MaxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
+	var q Quantity
+	if err := c.Call(ctx, &q, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return q.Big(), nil
+}
+
+// FeeHistory calls eth_feeHistory for the last blockCount blocks ending at
+// the latest block, sampling the given reward percentiles
+func (c *Client) FeeHistory(ctx context.Context, blockCount uint64, percentiles []float64) (*FeeHistory, error) {
+	var history FeeHistory
+	if err := c.Call(ctx, &history, "eth_feeHistory", EncodeQuantity(new(big.Int).SetUint64(blockCount)), "latest", percentiles); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// TxpoolStatus calls txpool_status
+func (c *Client) TxpoolStatus(ctx context.Context) (*TxpoolStatus, error) {
+	var status TxpoolStatus
+	if err := c.Call(ctx, &status, "txpool_status"); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// BlockNumber calls eth_blockNumber
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	var q Quantity
+	if err := c.Call(ctx, &q, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return q.Uint64(), nil
+}
+
+// Transaction is the subset of eth_getTransactionByHash used by the gateway
+type Transaction struct {
+	Hash        string    `json:"hash"`
+	From        string    `json:"from"`
+	Nonce       Quantity  `json:"nonce"`
+	BlockHash   *string   `json:"blockHash"`
+	BlockNumber *Quantity `json:"blockNumber"`
+}
+
+// Receipt is the subset of eth_getTransactionReceipt used by the gateway
+type Receipt struct {
+	TransactionHash string   `json:"transactionHash"`
+	BlockHash       string   `json:"blockHash"`
+	BlockNumber     Quantity `json:"blockNumber"`
+	Status          Quantity `json:"status"`
+	GasUsed         Quantity `json:"gasUsed"`
+}
+
+// BlockHeader is the subset of eth_getBlockByNumber used by the gateway
+type BlockHeader struct {
+	Number     Quantity `json:"number"`
+	Hash       string   `json:"hash"`
+	ParentHash string   `json:"parentHash"`
+	Timestamp  Quantity `json:"timestamp"`
+}
+
+// SendRawTransaction calls eth_sendRawTransaction and returns the transaction hash
+func (c *Client) SendRawTransaction(ctx context.Context, rawTx string) (string, error) {
+	var hash string
+	if err := c.Call(ctx, &hash, "eth_sendRawTransaction", rawTx); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// TransactionByHash calls eth_getTransactionByHash. It returns nil without an
+// error when the node doesn't know the transaction.
+func (c *Client) TransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
+	var tx *Transaction
+	if err := c.Call(ctx, &tx, "eth_getTransactionByHash", hash); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// TransactionReceipt calls eth_getTransactionReceipt. It returns nil without
+// an error when the transaction has not been included yet.
+func (c *Client) TransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	var receipt *Receipt
+	if err := c.Call(ctx, &receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// BlockHeaderByTag calls eth_getBlockByNumber without transaction bodies.
+// tag is a hex block number or one of "latest", "safe" and "finalized".
+func (c *Client) BlockHeaderByTag(ctx context.Context, tag string) (*BlockHeader, error) {
+	var header *BlockHeader
+	if err := c.Call(ctx, &header, "eth_getBlockByNumber", tag, false); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// BlockHeaderByNumber calls eth_getBlockByNumber for a specific height
+func (c *Client) BlockHeaderByNumber(ctx context.Context, number uint64) (*BlockHeader, error) {
+	return c.BlockHeaderByTag(ctx, EncodeQuantity(new(big.Int).SetUint64(number)))
+}
+
+// TransactionCount calls eth_getTransactionCount for address at the given block tag
+func (c *Client) TransactionCount(ctx context.Context, address, tag string) (uint64, error) {
+	var q Quantity
+	if err := c.Call(ctx, &q, "eth_getTransactionCount", address, tag); err != nil {
+		return 0, err
+	}
+	return q.Uint64(), nil
+}
+
+// ChainID calls eth_chainId
+func (c *Client) ChainID(ctx context.Context) (uint64, error) {
+	var q Quantity
+	if err := c.Call(ctx, &q, "eth_chainId"); err != nil {
+		return 0, err
+	}
+	return q.Uint64(), nil
+}
+
+// Balance calls eth_getBalance for address at the given block tag
+func (c *Client) Balance(ctx context.Context, address, tag string) (*big.Int, error) {
+	var q Quantity
+	if err := c.Call(ctx, &q, "eth_getBalance", address, tag); err != nil {
+		return nil, err
+	}
+	return q.Big(), nil
+}
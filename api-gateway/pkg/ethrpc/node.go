@@ -0,0 +1,43 @@
+package ethrpc
+
+import "context"
+
+// NodeInfo is the subset of admin_nodeInfo used by the gateway
+type NodeInfo struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Enode      string `json:"enode"`
+	ListenAddr string `json:"listenAddr"`
+	Ports      struct {
+		Discovery int64 `json:"discovery"`
+		Listener  int64 `json:"listener"`
+	} `json:"ports"`
+}
+
+// ClientVersion calls web3_clientVersion
+func (c *Client) ClientVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := c.Call(ctx, &version, "web3_clientVersion"); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// RPCModules calls rpc_modules, which maps each namespace the endpoint
+// serves to its version
+func (c *Client) RPCModules(ctx context.Context) (map[string]string, error) {
+	var modules map[string]string
+	if err := c.Call(ctx, &modules, "rpc_modules"); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// NodeInfo calls admin_nodeInfo
+func (c *Client) NodeInfo(ctx context.Context) (*NodeInfo, error) {
+	var info NodeInfo
+	if err := c.Call(ctx, &info, "admin_nodeInfo"); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
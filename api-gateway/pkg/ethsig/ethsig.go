@@ -0,0 +1,48 @@
+// Package ethsig verifies Ethereum personal_sign (EIP-191) signatures.
+package ethsig
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrSignatureMismatch is returned when a signature was made by a different address
+var ErrSignatureMismatch = errors.New("signature does not match address")
+
+// RecoverPersonal returns the address that signed message with personal_sign.
+// The signature is 65 hex-encoded bytes with a recovery id of 0/1 or 27/28.
+func RecoverPersonal(message []byte, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(accounts.TextHash(message), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// VerifyPersonal checks that address signed message with personal_sign
+func VerifyPersonal(address common.Address, message []byte, signature string) error {
+	signer, err := RecoverPersonal(message, signature)
+	if err != nil {
+		return err
+	}
+	if signer != address {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
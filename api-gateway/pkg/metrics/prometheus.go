@@ -16,6 +16,13 @@ type PrometheusClient struct {
 	databaseConnections *prometheus.GaugeVec
 	nodesTotal          prometheus.Gauge
 	nodesActive         prometheus.Gauge
+	txpoolPending       *prometheus.GaugeVec
+	txpoolQueued        *prometheus.GaugeVec
+	gasRecommendation   *prometheus.GaugeVec
+	authFailures        *prometheus.CounterVec
+	authLockouts        *prometheus.CounterVec
+	credentialStuffing  *prometheus.CounterVec
+	configDrift         *prometheus.GaugeVec
 }
 
 // NewPrometheusClient creates a new Prometheus metrics client
@@ -67,6 +74,62 @@ func NewPrometheusClient() *PrometheusClient {
 		},
 	)
 
+	txpoolPending := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blockchain_node_txpool_pending",
+			Help: "Number of pending transactions in a node's txpool",
+		},
+		[]string{"chain", "node_id"},
+	)
+
+	txpoolQueued := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blockchain_node_txpool_queued",
+			Help: "Number of queued transactions in a node's txpool",
+		},
+		[]string{"chain", "node_id"},
+	)
+
+	gasRecommendation := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "chain_gas_recommendation_gwei",
+			Help: "Recommended max fee per gas in gwei by chain and inclusion speed",
+		},
+		[]string{"chain", "speed"},
+	)
+
+	authFailures := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_failures_total",
+			Help: "Failed authentication attempts by method and reason",
+		},
+		[]string{"method", "reason"},
+	)
+
+	authLockouts := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_lockouts_total",
+			Help: "Temporary authentication lockouts by method and scope (user or ip)",
+		},
+		[]string{"method", "scope"},
+	)
+
+	credentialStuffing := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_credential_stuffing_suspected_total",
+			Help: "Client IPs blocked for failing logins against many distinct accounts",
+		},
+		[]string{"method"},
+	)
+
+	configDrift := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blockchain_node_config_drift_findings",
+			Help: "Differences between a running node and its stored config by severity",
+		},
+		[]string{"chain", "node_id", "severity"},
+	)
+
 	// Register metrics
 	prometheus.MustRegister(requestsTotal)
 	prometheus.MustRegister(requestDuration)
@@ -74,6 +137,13 @@ func NewPrometheusClient() *PrometheusClient {
 	prometheus.MustRegister(databaseConnections)
 	prometheus.MustRegister(nodesTotal)
 	prometheus.MustRegister(nodesActive)
+	prometheus.MustRegister(txpoolPending)
+	prometheus.MustRegister(txpoolQueued)
+	prometheus.MustRegister(gasRecommendation)
+	prometheus.MustRegister(authFailures)
+	prometheus.MustRegister(authLockouts)
+	prometheus.MustRegister(credentialStuffing)
+	prometheus.MustRegister(configDrift)
 
 	return &PrometheusClient{
 		requestsTotal:       requestsTotal,
@@ -82,6 +152,13 @@ func NewPrometheusClient() *PrometheusClient {
 		databaseConnections: databaseConnections,
 		nodesTotal:          nodesTotal,
 		nodesActive:         nodesActive,
+		txpoolPending:       txpoolPending,
+		txpoolQueued:        txpoolQueued,
+		gasRecommendation:   gasRecommendation,
+		authFailures:        authFailures,
+		authLockouts:        authLockouts,
+		credentialStuffing:  credentialStuffing,
+		configDrift:         configDrift,
 	}
 }
 
@@ -115,6 +192,39 @@ func (p *PrometheusClient) SetNodesActive(count int) {
 	p.nodesActive.Set(float64(count))
 }
 
+// SetTxpoolSize sets the pending and queued txpool sizes of a node
+func (p *PrometheusClient) SetTxpoolSize(chain, nodeID string, pending, queued uint64) {
+	p.txpoolPending.WithLabelValues(chain, nodeID).Set(float64(pending))
+	p.txpoolQueued.WithLabelValues(chain, nodeID).Set(float64(queued))
+}
+
+// SetGasRecommendation sets the recommended max fee for a chain and speed
+func (p *PrometheusClient) SetGasRecommendation(chain, speed string, gwei float64) {
+	p.gasRecommendation.WithLabelValues(chain, speed).Set(gwei)
+}
+
+// RecordAuthFailure records a failed authentication attempt
+func (p *PrometheusClient) RecordAuthFailure(method, reason string) {
+	p.authFailures.WithLabelValues(method, reason).Inc()
+}
+
+// RecordAuthLockout records a temporary lockout of a user or client IP
+func (p *PrometheusClient) RecordAuthLockout(method, scope string) {
+	p.authLockouts.WithLabelValues(method, scope).Inc()
+}
+
+// RecordCredentialStuffing records a client IP blocked for suspected credential stuffing
+func (p *PrometheusClient) RecordCredentialStuffing(method string) {
+	p.credentialStuffing.WithLabelValues(method).Inc()
+}
+
+// SetConfigDrift sets how many drift findings of each severity a node has
+func (p *PrometheusClient) SetConfigDrift(chain, nodeID string, findings map[string]int) {
+	for severity, count := range findings {
+		p.configDrift.WithLabelValues(chain, nodeID, severity).Set(float64(count))
+	}
+}
+
 // Handler returns the HTTP handler for Prometheus metrics
 func (p *PrometheusClient) Handler() http.Handler {
 	return promhttp.Handler()
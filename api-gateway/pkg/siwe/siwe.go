@@ -0,0 +1,169 @@
+// Package siwe parses and validates Sign-In with Ethereum (EIP-4361) messages.
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const preambleSuffix = " wants you to sign in with your Ethereum account:"
+
+// Message is a parsed EIP-4361 message
+type Message struct {
+	Scheme         string
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        int64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// Parse parses an EIP-4361 message
+func Parse(text string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, errors.New("message is too short")
+	}
+
+	var msg Message
+	origin, ok := strings.CutSuffix(lines[0], preambleSuffix)
+	if !ok || origin == "" {
+		return nil, errors.New("invalid preamble")
+	}
+	if scheme, domain, ok := strings.Cut(origin, "://"); ok {
+		msg.Scheme, msg.Domain = scheme, domain
+	} else {
+		msg.Domain = origin
+	}
+
+	if !common.IsHexAddress(lines[1]) || !strings.HasPrefix(lines[1], "0x") {
+		return nil, errors.New("invalid address")
+	}
+	msg.Address = common.HexToAddress(lines[1])
+	if msg.Address.Hex() != lines[1] {
+		return nil, errors.New("address must be EIP-55 checksummed")
+	}
+
+	// The address is followed by a blank line, an optional statement line and another blank line
+	i := 2
+	if i >= len(lines) || lines[i] != "" {
+		return nil, errors.New("expected blank line after address")
+	}
+	i++
+	if i < len(lines) && lines[i] != "" && !strings.HasPrefix(lines[i], "URI: ") {
+		msg.Statement = lines[i]
+		i++
+		if i >= len(lines) || lines[i] != "" {
+			return nil, errors.New("expected blank line after statement")
+		}
+		i++
+	} else if i < len(lines) && lines[i] == "" {
+		i++
+	}
+
+	fields := make(map[string]string)
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "Resources:" {
+			for i++; i < len(lines); i++ {
+				resource, ok := strings.CutPrefix(lines[i], "- ")
+				if !ok {
+					return nil, fmt.Errorf("invalid resource line %q", lines[i])
+				}
+				msg.Resources = append(msg.Resources, resource)
+			}
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("invalid field line %q", line)
+		}
+		if _, dup := fields[key]; dup {
+			return nil, fmt.Errorf("duplicate field %q", key)
+		}
+		fields[key] = value
+	}
+
+	for _, required := range []string{"URI", "Version", "Chain ID", "Nonce", "Issued At"} {
+		if fields[required] == "" {
+			return nil, fmt.Errorf("missing field %q", required)
+		}
+	}
+
+	msg.URI = fields["URI"]
+	msg.Version = fields["Version"]
+	msg.Nonce = fields["Nonce"]
+	msg.RequestID = fields["Request ID"]
+
+	chainID, err := strconv.ParseInt(fields["Chain ID"], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid chain id")
+	}
+	msg.ChainID = chainID
+
+	if msg.IssuedAt, err = time.Parse(time.RFC3339, fields["Issued At"]); err != nil {
+		return nil, errors.New("invalid issued at time")
+	}
+	if value, ok := fields["Expiration Time"]; ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.New("invalid expiration time")
+		}
+		msg.ExpirationTime = &t
+	}
+	if value, ok := fields["Not Before"]; ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, errors.New("invalid not before time")
+		}
+		msg.NotBefore = &t
+	}
+
+	return &msg, nil
+}
+
+// Validate checks the message was made for domain and one of chainIDs and
+// is valid at now. clockSkew is tolerated on all time checks.
+func (m *Message) Validate(domain string, chainIDs []int64, now time.Time, clockSkew time.Duration) error {
+	if m.Version != "1" {
+		return fmt.Errorf("unsupported version %q", m.Version)
+	}
+	if !strings.EqualFold(m.Domain, domain) {
+		return fmt.Errorf("domain %q does not match %q", m.Domain, domain)
+	}
+
+	allowed := false
+	for _, id := range chainIDs {
+		if m.ChainID == id {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("chain id %d is not accepted", m.ChainID)
+	}
+
+	if m.IssuedAt.After(now.Add(clockSkew)) {
+		return errors.New("message is issued in the future")
+	}
+	if m.ExpirationTime != nil && !now.Before(m.ExpirationTime.Add(clockSkew)) {
+		return errors.New("message has expired")
+	}
+	if m.NotBefore != nil && now.Add(clockSkew).Before(*m.NotBefore) {
+		return errors.New("message is not yet valid")
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package siwe_test
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/twist/api-gateway/pkg/ethsig"
+	"github.com/twist/api-gateway/pkg/siwe"
+)
+
+// address is the EIP-55 example address
+const address = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+var issuedAt = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// baseFields are the fields every message needs
+var baseFields = []string{
+	"URI: https://gateway.example.com/login",
+	"Version: 1",
+	"Chain ID: 1",
+	"Nonce: k3Jd8aQ2vX",
+	"Issued At: 2026-01-01T12:00:00Z",
+}
+
+// message builds the text of a message for address with fields
+func message(address string, fields ...string) string {
+	lines := append([]string{
+		"gateway.example.com wants you to sign in with your Ethereum account:",
+		address,
+		"",
+		"Sign in to the gateway",
+		"",
+	}, fields...)
+	return strings.Join(lines, "\n")
+}
+
+// without returns fields without the one named key
+func without(fields []string, key string) []string {
+	var kept []string
+	for _, field := range fields {
+		if !strings.HasPrefix(field, key+": ") {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+func TestParse(t *testing.T) {
+	msg, err := siwe.Parse(message(address, append(baseFields,
+		"Expiration Time: 2026-01-01T12:10:00Z",
+		"Resources:",
+		"- https://gateway.example.com/terms",
+	)...))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if msg.Domain != "gateway.example.com" || msg.Address.Hex() != address || msg.Statement != "Sign in to the gateway" ||
+		msg.ChainID != 1 || msg.Nonce != "k3Jd8aQ2vX" || !msg.IssuedAt.Equal(issuedAt) {
+		t.Errorf("Parse = %+v", msg)
+	}
+	if msg.ExpirationTime == nil || !msg.ExpirationTime.Equal(issuedAt.Add(10*time.Minute)) {
+		t.Errorf("ExpirationTime = %v, want %v", msg.ExpirationTime, issuedAt.Add(10*time.Minute))
+	}
+	if len(msg.Resources) != 1 || msg.Resources[0] != "https://gateway.example.com/terms" {
+		t.Errorf("Resources = %v", msg.Resources)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		err  string
+	}{
+		{name: "missing nonce", text: message(address, without(baseFields, "Nonce")...), err: `missing field "Nonce"`},
+		{name: "empty nonce", text: message(address, append(without(baseFields, "Nonce"), "Nonce: ")...), err: `missing field "Nonce"`},
+		{name: "lowercase address", text: message(strings.ToLower(address), baseFields...), err: "address must be EIP-55 checksummed"},
+		{name: "wrong checksum", text: message("0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", baseFields...), err: "address must be EIP-55 checksummed"},
+		{name: "short address", text: message(address[:40], baseFields...), err: "invalid address"},
+		{name: "duplicate field", text: message(address, append(baseFields, "Nonce: again")...), err: `duplicate field "Nonce"`},
+		{name: "invalid expiration time", text: message(address, append(baseFields, "Expiration Time: tomorrow")...), err: "invalid expiration time"},
+		{name: "invalid preamble", text: strings.Replace(message(address, baseFields...), "wants you", "asks you", 1), err: "invalid preamble"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := siwe.Parse(tt.text)
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("Parse error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	const skew = 30 * time.Second
+
+	tests := []struct {
+		name   string
+		fields []string
+		domain string
+		now    time.Time
+		err    string
+	}{
+		{name: "valid", fields: baseFields, now: issuedAt.Add(time.Minute)},
+		{name: "wrong domain", fields: baseFields, domain: "evil.example.com", now: issuedAt,
+			err: `domain "gateway.example.com" does not match "evil.example.com"`},
+		{name: "chain not accepted", fields: append(without(baseFields, "Chain ID"), "Chain ID: 5"), now: issuedAt,
+			err: "chain id 5 is not accepted"},
+		{name: "issued in the future", fields: baseFields, now: issuedAt.Add(-time.Minute),
+			err: "message is issued in the future"},
+		{name: "issued within the clock skew", fields: baseFields, now: issuedAt.Add(-skew / 2)},
+		{name: "expired", fields: append(baseFields, "Expiration Time: 2026-01-01T12:10:00Z"), now: issuedAt.Add(11 * time.Minute),
+			err: "message has expired"},
+		{name: "expired within the clock skew", fields: append(baseFields, "Expiration Time: 2026-01-01T12:10:00Z"), now: issuedAt.Add(10*time.Minute + skew/2)},
+		{name: "not yet valid", fields: append(baseFields, "Not Before: 2026-01-01T12:05:00Z"), now: issuedAt.Add(time.Minute),
+			err: "message is not yet valid"},
+		{name: "not before reached", fields: append(baseFields, "Not Before: 2026-01-01T12:05:00Z"), now: issuedAt.Add(5 * time.Minute)},
+		{name: "unsupported version", fields: append(without(baseFields, "Version"), "Version: 2"), now: issuedAt,
+			err: `unsupported version "2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := siwe.Parse(message(address, tt.fields...))
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			domain := tt.domain
+			if domain == "" {
+				domain = "gateway.example.com"
+			}
+
+			err = msg.Validate(domain, []int64{1, 137}, tt.now, skew)
+			if tt.err == "" && err != nil {
+				t.Errorf("Validate failed: %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("Validate error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestSignature checks a parsed message against its personal_sign signature
+// the way SIWEVerify does
+func TestSignature(t *testing.T) {
+	signer, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := message(crypto.PubkeyToAddress(signer.PublicKey).Hex(), baseFields...)
+	msg, err := siwe.Parse(text)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  *ecdsa.PrivateKey
+		err  error
+	}{
+		{name: "signed by the address", key: signer},
+		{name: "signed by a different key", key: other, err: ethsig.ErrSignatureMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := crypto.Sign(accounts.TextHash([]byte(text)), tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Wallets return the legacy 27/28 recovery id
+			sig[crypto.RecoveryIDOffset] += 27
+
+			err = ethsig.VerifyPersonal(msg.Address, []byte(text), hexutil.Encode(sig))
+			if !errors.Is(err, tt.err) {
+				t.Errorf("VerifyPersonal = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}